@@ -16,7 +16,7 @@
 
 PROTOCOL_BUF_VERSION=3.15.1
 PROTOC_GEN_GO_GRPC_VERSION=v1.1.0
-PROTOC_GEN_GO_VERSION=v1.25.0
+PROTOC_GEN_GO_VERSION=v1.36.11
 wget https://github.com/protocolbuffers/protobuf/releases/download/v$PROTOCOL_BUF_VERSION/protoc-$PROTOCOL_BUF_VERSION-linux-x86_64.zip
 wget https://github.com/grpc/grpc-go/releases/download/cmd/protoc-gen-go-grpc/$PROTOC_GEN_GO_GRPC_VERSION/protoc-gen-go-grpc.$PROTOC_GEN_GO_GRPC_VERSION.linux.amd64.tar.gz
 wget wget https://github.com/protocolbuffers/protobuf-go/releases/download/$PROTOC_GEN_GO_VERSION/protoc-gen-go.$PROTOC_GEN_GO_VERSION.linux.amd64.tar.gz
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit writes an append-only record of mutating CSI operations as JSON lines.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Stdout is the path to write audit records to standard output
+const Stdout = "stdout"
+
+// Record is an audit record of a mutating operation, secrets must be redacted before writing.
+type Record struct {
+	Time          time.Time         `json:"time"`
+	Operation     string            `json:"operation"`
+	VolumeID      string            `json:"volumeID,omitempty"`
+	SnapshotID    string            `json:"snapshotID,omitempty"`
+	PVCName       string            `json:"pvcName,omitempty"`
+	PVCNamespace  string            `json:"pvcNamespace,omitempty"`
+	PVName        string            `json:"pvName,omitempty"`
+	ResourceGroup string            `json:"resourceGroup,omitempty"`
+	Account       string            `json:"account,omitempty"`
+	Share         string            `json:"share,omitempty"`
+	Snapshot      string            `json:"snapshot,omitempty"`
+	Path          string            `json:"path,omitempty"`
+	Parameters    map[string]string `json:"parameters,omitempty"`
+	Code          string            `json:"code"`
+	Error         string            `json:"error,omitempty"`
+	DurationMs    int64             `json:"durationMs"`
+}
+
+// Sink writes audit records as JSON lines into a file with size based rotation, or into standard output.
+type Sink struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	out        io.Writer
+	file       *os.File
+	size       int64
+}
+
+// NewSink creates a sink writing to path, which is Stdout or a file path. The file is rotated when it's over maxSizeMB,
+// and at most maxBackups rotated files are kept as <path>.1 ... <path>.<maxBackups>, maxSizeMB <= 0 disables rotation.
+func NewSink(path string, maxSizeMB, maxBackups int) (*Sink, error) {
+	s := &Sink{path: path, maxSize: int64(maxSizeMB) * 1024 * 1024, maxBackups: maxBackups}
+	if path == Stdout {
+		s.out = os.Stdout
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", s.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit log %s: %w", s.path, err)
+	}
+	s.file, s.out, s.size = f, f, info.Size()
+	return nil
+}
+
+// rotate renames <path>.N to <path>.N+1, and the current file to <path>.1.
+// The renames are done before the current file is closed, so it's still written to if any of them fails.
+func (s *Sink) rotate() error {
+	if s.maxBackups <= 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		_ = os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxBackups))
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			if err := os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	}
+	current := s.file
+	if err := s.open(); err != nil {
+		return err
+	}
+	return current.Close()
+}
+
+// Write appends the record as one JSON line
+func (s *Sink) Write(record *Record) error {
+	if s == nil || record == nil {
+		return nil
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+	var rotateErr error
+	if s.file != nil && s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			// the record is still written to the active file
+			rotateErr = fmt.Errorf("failed to rotate audit log %s: %w", s.path, err)
+		}
+	}
+	n, err := s.out.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// Close closes the audit log file
+func (s *Sink) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestSinkWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	sink, err := NewSink(path, 100, 3)
+	if err != nil {
+		t.Fatalf("NewSink failed: %v", err)
+	}
+	records := []*Record{
+		{Time: time.Now(), Operation: "CreateVolume", PVCName: "pvc", PVCNamespace: "default", Account: "account", Share: "share", Code: "OK", DurationMs: 10},
+		{Time: time.Now(), Operation: "DeleteVolume", VolumeID: "rg#account#share", Code: "Internal", Error: "failed"},
+	}
+	for _, r := range records {
+		if err := sink.Write(r); err != nil {
+			t.Errorf("Write failed: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	// records are appended after reopen
+	sink, err = NewSink(path, 100, 3)
+	if err != nil {
+		t.Fatalf("NewSink failed: %v", err)
+	}
+	if err := sink.Write(&Record{Operation: "NodeStageVolume", Code: "OK"}); err != nil {
+		t.Errorf("Write failed: %v", err)
+	}
+	sink.Close()
+
+	got := readRecords(t, path)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(got))
+	}
+	if got[0].Operation != "CreateVolume" || got[0].PVCName != "pvc" || got[0].Share != "share" {
+		t.Errorf("unexpected record: %+v", got[0])
+	}
+	if got[1].Error != "failed" || got[1].Code != "Internal" {
+		t.Errorf("unexpected record: %+v", got[1])
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSinkRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewSink(path, 1, 2)
+	if err != nil {
+		t.Fatalf("NewSink failed: %v", err)
+	}
+	defer sink.Close()
+	// about 5KB per record, the file is rotated every ~200 records
+	record := &Record{Operation: "CreateVolume", Parameters: map[string]string{"padding": strings.Repeat("x", 5*1024)}}
+	for i := 0; i < 700; i++ {
+		if err := sink.Write(record); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 1024*1024 {
+			t.Errorf("%s is over the max size: %d", p, info.Size())
+		}
+		readRecords(t, p)
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.3", path)); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, got error: %v", err)
+	}
+}
+
+func TestSinkRotateFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// a non-empty directory at <path>.1 fails the rename of the current file
+	if err := os.MkdirAll(filepath.Join(path+".1", "dir"), 0750); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	sink, err := NewSink(path, 1, 1)
+	if err != nil {
+		t.Fatalf("NewSink failed: %v", err)
+	}
+	defer sink.Close()
+	// about 50KB per record, the file is over the max size after ~20 records
+	record := &Record{Operation: "CreateVolume", Parameters: map[string]string{"padding": strings.Repeat("x", 50*1024)}}
+	const records = 25
+	var rotateErrors int
+	for i := 0; i < records; i++ {
+		if err := sink.Write(record); err != nil {
+			rotateErrors++
+		}
+	}
+	if rotateErrors == 0 {
+		t.Errorf("expected rotation errors")
+	}
+	// the active file is still written to after the rotation failure
+	if n := len(readRecords(t, path)); n != records {
+		t.Errorf("expected %d records in %s, got %d", records, path, n)
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+	if err := sink.Write(record); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if n := len(readRecords(t, path+".1")); n != records {
+		t.Errorf("expected %d records in %s.1, got %d", records, path, n)
+	}
+	if n := len(readRecords(t, path)); n != 1 {
+		t.Errorf("expected 1 record in %s, got %d", path, n)
+	}
+}
+
+func TestSinkNil(t *testing.T) {
+	var sink *Sink
+	if err := sink.Write(&Record{}); err != nil {
+		t.Errorf("Write on nil sink should be no-op, got %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close on nil sink should be no-op, got %v", err)
+	}
+	stdout, err := NewSink(Stdout, 0, 0)
+	if err != nil {
+		t.Fatalf("NewSink failed: %v", err)
+	}
+	if err := stdout.Close(); err != nil {
+		t.Errorf("Close on stdout sink should be no-op, got %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azerrors classifies errors returned by Azure data plane and ARM calls into typed categories.
+//
+// Errors are classified by the status code and error code of *azcore.ResponseError in the error chain,
+// the error message is only inspected when the type has been lost, e.g. the error was formatted with %v
+// by cloud provider or it's in the format of legacy autorest clients.
+package azerrors
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// Category is the category of an Azure error
+type Category string
+
+const (
+	// None means there is no error
+	None Category = ""
+	// Throttled means the request is throttled by Azure or by the client side rate limiter
+	Throttled Category = "throttled"
+	// NotFound means the resource, e.g. storage account, file share or directory, does not exist
+	NotFound Category = "not_found"
+	// Conflict means the resource is in a conflicting state, e.g. already exists or is being deleted
+	Conflict Category = "conflict"
+	// Quota means an account or subscription limit is exceeded
+	Quota Category = "quota"
+	// Auth means the request is not authenticated or not authorized
+	Auth Category = "auth"
+	// ServerError means Azure returned a 5xx error
+	ServerError Category = "server_error"
+	// Unknown means the error could not be classified
+	Unknown Category = "unknown"
+)
+
+// Error codes returned by Azure Files data plane and storage resource provider
+const (
+	CodeTooManyRequests                 = "TooManyRequests"
+	CodeServerBusy                      = "ServerBusy"
+	CodeSubscriptionRequestsThrottled   = "SubscriptionRequestsThrottled"
+	CodeShareNotFound                   = "ShareNotFound"
+	CodeParentNotFound                  = "ParentNotFound"
+	CodeResourceNotFound                = "ResourceNotFound"
+	CodeResourceGroupNotFound           = "ResourceGroupNotFound"
+	CodeStorageAccountNotFound          = "StorageAccountNotFound"
+	CodeShareAlreadyExists              = "ShareAlreadyExists"
+	CodeResourceAlreadyExists           = "ResourceAlreadyExists"
+	CodeShareBeingDeleted               = "ShareBeingDeleted"
+	CodeShareHasSnapshots               = "ShareHasSnapshots"
+	CodeStorageAccountIsNotProvisioned  = "StorageAccountIsNotProvisioned"
+	CodeAuthenticationFailed            = "AuthenticationFailed"
+	CodeAuthorizationFailure            = "AuthorizationFailure"
+	CodeAuthorizationFailed             = "AuthorizationFailed"
+	CodeAuthorizationPermissionMismatch = "AuthorizationPermissionMismatch"
+	CodeInvalidAuthenticationInfo       = "InvalidAuthenticationInfo"
+	CodeKeyBasedAuthNotPermitted        = "KeyBasedAuthenticationNotPermitted"
+	CodeQuotaExceeded                   = "QuotaExceeded"
+	// account limit error codes end with this suffix, e.g. TotalSharesProvisionedCapacityExceedsAccountLimit
+	accountLimitCodeSuffix = "ExceedsAccountLimit"
+)
+
+var (
+	throttledCodes = []string{CodeTooManyRequests, CodeServerBusy, CodeSubscriptionRequestsThrottled}
+	notFoundCodes  = []string{CodeShareNotFound, CodeParentNotFound, CodeResourceNotFound, CodeResourceGroupNotFound, CodeStorageAccountNotFound}
+	conflictCodes  = []string{CodeShareAlreadyExists, CodeResourceAlreadyExists, CodeShareBeingDeleted, CodeShareHasSnapshots, CodeStorageAccountIsNotProvisioned}
+	authCodes      = []string{CodeAuthenticationFailed, CodeAuthorizationFailure, CodeAuthorizationFailed, CodeAuthorizationPermissionMismatch,
+		CodeInvalidAuthenticationInfo, CodeKeyBasedAuthNotPermitted}
+	// retriableCodes are the non throttling errors which are expected to disappear after a while
+	retriableCodes = []string{CodeShareBeingDeleted, CodeStorageAccountIsNotProvisioned}
+
+	// message patterns of errors whose type has been lost
+	throttledMessages = []string{strings.ToLower(CodeTooManyRequests), "client throttled", "httpstatuscode: 429", "statuscode=429"}
+	notFoundMessages  = []string{strings.ToLower(CodeShareNotFound), "httpstatuscode: 404", "statuscode=404"}
+	conflictMessages  = []string{strings.ToLower(CodeShareAlreadyExists), strings.ToLower(CodeStorageAccountIsNotProvisioned), "the specified share is being deleted"}
+	retriableMessages = []string{strings.ToLower(CodeStorageAccountIsNotProvisioned), "the specified share is being deleted"}
+	authMessages      = []string{strings.ToLower(CodeAuthorizationFailed), strings.ToLower(CodeAuthenticationFailed)}
+
+	retryAfterRegexp = regexp.MustCompile(`RetryAfter: (\d+)s`)
+)
+
+// Classify returns the category of the error
+func Classify(err error) Category {
+	if err == nil {
+		return None
+	}
+	if respErr := responseError(err); respErr != nil {
+		return classifyResponseError(respErr)
+	}
+	return classifyMessage(err.Error())
+}
+
+// HasResponseError returns true if there is an *azcore.ResponseError in the error chain,
+// which is classified by its status code and error code instead of the error message
+func HasResponseError(err error) bool {
+	return responseError(err) != nil
+}
+
+func classifyResponseError(respErr *azcore.ResponseError) Category {
+	code := respErr.ErrorCode
+	switch {
+	case respErr.StatusCode == http.StatusTooManyRequests || containsCode(throttledCodes, code):
+		return Throttled
+	case strings.HasSuffix(code, accountLimitCodeSuffix) || strings.EqualFold(code, CodeQuotaExceeded):
+		return Quota
+	case respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden || containsCode(authCodes, code):
+		return Auth
+	case respErr.StatusCode == http.StatusNotFound || containsCode(notFoundCodes, code):
+		return NotFound
+	case respErr.StatusCode == http.StatusConflict || containsCode(conflictCodes, code):
+		return Conflict
+	case respErr.StatusCode >= http.StatusInternalServerError:
+		return ServerError
+	default:
+		return Unknown
+	}
+}
+
+func classifyMessage(msg string) Category {
+	msg = strings.ToLower(msg)
+	switch {
+	case containsMessage(throttledMessages, msg):
+		return Throttled
+	case strings.Contains(msg, strings.ToLower(accountLimitCodeSuffix)):
+		return Quota
+	case containsMessage(authMessages, msg):
+		return Auth
+	case containsMessage(notFoundMessages, msg):
+		return NotFound
+	case containsMessage(conflictMessages, msg):
+		return Conflict
+	default:
+		return Unknown
+	}
+}
+
+// IsThrottled returns true if the request is throttled
+func IsThrottled(err error) bool {
+	return Classify(err) == Throttled
+}
+
+// IsNotFound returns true if the resource does not exist
+func IsNotFound(err error) bool {
+	return Classify(err) == NotFound
+}
+
+// IsConflict returns true if the resource is in a conflicting state
+func IsConflict(err error) bool {
+	return Classify(err) == Conflict
+}
+
+// IsQuota returns true if an account or subscription limit is exceeded
+func IsQuota(err error) bool {
+	return Classify(err) == Quota
+}
+
+// IsAuth returns true if the request is not authenticated or not authorized
+func IsAuth(err error) bool {
+	return Classify(err) == Auth
+}
+
+// IsRetriable returns true if the same request is expected to succeed after a while,
+// i.e. throttling errors and transient conflicts such as share being deleted
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if respErr := responseError(err); respErr != nil {
+		return classifyResponseError(respErr) == Throttled || containsCode(retriableCodes, respErr.ErrorCode)
+	}
+	msg := strings.ToLower(err.Error())
+	return classifyMessage(msg) == Throttled || containsMessage(retriableMessages, msg)
+}
+
+// HasErrorCode returns true if the error has the error code,
+// the error message is checked if there is no *azcore.ResponseError in the error chain
+func HasErrorCode(err error, code string) bool {
+	if err == nil {
+		return false
+	}
+	if respErr := responseError(err); respErr != nil {
+		return strings.EqualFold(respErr.ErrorCode, code)
+	}
+	return strings.Contains(strings.ToLower(err.Error()), strings.ToLower(code))
+}
+
+// ErrorCode returns the error code of *azcore.ResponseError in the error chain, or empty string if not found
+func ErrorCode(err error) string {
+	if respErr := responseError(err); respErr != nil {
+		return respErr.ErrorCode
+	}
+	return ""
+}
+
+// StatusCode returns the HTTP status code of *azcore.ResponseError in the error chain, or 0 if not found
+func StatusCode(err error) int {
+	if respErr := responseError(err); respErr != nil {
+		return respErr.StatusCode
+	}
+	return 0
+}
+
+// RetryAfter returns the duration to wait before retrying, it's read from the retry-after-ms, x-ms-retry-after-ms
+// and Retry-After response headers, or from the "RetryAfter: <N>s" message of cloud provider errors.
+// 0 is returned if there is no such hint.
+func RetryAfter(err error) time.Duration {
+	if err == nil {
+		return 0
+	}
+	if respErr := responseError(err); respErr != nil && respErr.RawResponse != nil {
+		header := respErr.RawResponse.Header
+		for _, h := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+			if v := header.Get(h); v != "" {
+				if ms, err := strconv.Atoi(v); err == nil && ms > 0 {
+					return time.Duration(ms) * time.Millisecond
+				}
+			}
+		}
+		if v := header.Get("Retry-After"); v != "" {
+			if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+				return time.Duration(sec) * time.Second
+			}
+			if t, err := http.ParseTime(v); err == nil {
+				if d := time.Until(t); d > 0 {
+					return d
+				}
+			}
+		}
+	}
+	if match := retryAfterRegexp.FindStringSubmatch(err.Error()); len(match) > 1 {
+		if sec, err := strconv.Atoi(match[1]); err == nil {
+			return time.Duration(sec) * time.Second
+		}
+	}
+	return 0
+}
+
+func responseError(err error) *azcore.ResponseError {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr != nil {
+		return respErr
+	}
+	return nil
+}
+
+func containsCode(codes []string, code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMessage(patterns []string, msg string) bool {
+	for _, p := range patterns {
+		if strings.Contains(msg, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+)
+
+func newResponseError(statusCode int, errorCode string, header http.Header) error {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &azcore.ResponseError{
+		StatusCode:  statusCode,
+		ErrorCode:   errorCode,
+		RawResponse: &http.Response{StatusCode: statusCode, Header: header},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		desc      string
+		err       error
+		expected  Category
+		retriable bool
+	}{
+		{
+			desc:     "nil error",
+			err:      nil,
+			expected: None,
+		},
+		{
+			desc:      "429 from data plane",
+			err:       newResponseError(http.StatusTooManyRequests, "", nil),
+			expected:  Throttled,
+			retriable: true,
+		},
+		{
+			desc:      "ServerBusy from data plane",
+			err:       newResponseError(http.StatusServiceUnavailable, CodeServerBusy, nil),
+			expected:  Throttled,
+			retriable: true,
+		},
+		{
+			desc:      "wrapped throttling error",
+			err:       fmt.Errorf("failed to create file share: %w", newResponseError(http.StatusTooManyRequests, CodeTooManyRequests, nil)),
+			expected:  Throttled,
+			retriable: true,
+		},
+		{
+			desc:     "share not found",
+			err:      newResponseError(http.StatusNotFound, CodeShareNotFound, nil),
+			expected: NotFound,
+		},
+		{
+			desc:     "ARM resource not found",
+			err:      newResponseError(http.StatusNotFound, CodeResourceNotFound, nil),
+			expected: NotFound,
+		},
+		{
+			desc:      "share being deleted",
+			err:       newResponseError(http.StatusConflict, CodeShareBeingDeleted, nil),
+			expected:  Conflict,
+			retriable: true,
+		},
+		{
+			desc:     "share already exists",
+			err:      newResponseError(http.StatusConflict, CodeShareAlreadyExists, nil),
+			expected: Conflict,
+		},
+		{
+			desc:     "account limit",
+			err:      newResponseError(http.StatusBadRequest, "TotalSharesProvisionedCapacityExceedsAccountLimit", nil),
+			expected: Quota,
+		},
+		{
+			desc:     "authorization failed",
+			err:      newResponseError(http.StatusForbidden, CodeAuthorizationPermissionMismatch, nil),
+			expected: Auth,
+		},
+		{
+			desc:     "internal server error",
+			err:      newResponseError(http.StatusInternalServerError, "InternalError", nil),
+			expected: ServerError,
+		},
+		{
+			desc:     "bad request",
+			err:      newResponseError(http.StatusBadRequest, "InvalidHeaderValue", nil),
+			expected: Unknown,
+		},
+		{
+			desc:      "typed error takes precedence over message",
+			err:       fmt.Errorf("TooManyRequests: %w", newResponseError(http.StatusNotFound, CodeShareNotFound, nil)),
+			expected:  NotFound,
+			retriable: false,
+		},
+		{
+			desc:      "client throttled message",
+			err:       errors.New("Retriable: true, RetryAfter: 16s, HTTPStatusCode: 0, RawError: azure cloud provider throttled for operation StorageAccountListByResourceGroup with reason \"client throttled\""),
+			expected:  Throttled,
+			retriable: true,
+		},
+		{
+			desc:      "legacy share being deleted message",
+			err:       errors.New("storage.FileSharesClient#Create: Failure sending request: StatusCode=409 -- Original Error: autorest/azure: Service returned an error. Status=<nil> Code=\"ShareBeingDeleted\" Message=\"The specified share is being deleted. Try operation later.\""),
+			expected:  Conflict,
+			retriable: true,
+		},
+		{
+			desc:      "legacy account not provisioned message",
+			err:       errors.New("Retriable: true, HTTPStatusCode: 409, RawError: Code=\"StorageAccountIsNotProvisioned\""),
+			expected:  Conflict,
+			retriable: true,
+		},
+		{
+			desc:     "legacy not found message",
+			err:      errors.New("Retriable: false, RetryAfter: 0s, HTTPStatusCode: 404, RawError: not found"),
+			expected: NotFound,
+		},
+		{
+			desc:     "legacy account limit message",
+			err:      errors.New("Code=\"TotalSharesProvisionedCapacityExceedsAccountLimit\""),
+			expected: Quota,
+		},
+		{
+			desc:     "unknown message",
+			err:      errors.New("no match"),
+			expected: Unknown,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Classify(test.err), test.desc)
+		assert.Equal(t, test.retriable, IsRetriable(test.err), test.desc)
+	}
+}
+
+func TestIsCategory(t *testing.T) {
+	assert.True(t, IsThrottled(newResponseError(http.StatusTooManyRequests, "", nil)))
+	assert.True(t, IsNotFound(newResponseError(http.StatusNotFound, "", nil)))
+	assert.True(t, IsConflict(newResponseError(http.StatusConflict, "", nil)))
+	assert.True(t, IsQuota(newResponseError(http.StatusBadRequest, CodeQuotaExceeded, nil)))
+	assert.True(t, IsAuth(newResponseError(http.StatusUnauthorized, "", nil)))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestErrorCode(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", newResponseError(http.StatusConflict, CodeShareAlreadyExists, nil))
+	assert.Equal(t, CodeShareAlreadyExists, ErrorCode(err))
+	assert.Equal(t, http.StatusConflict, StatusCode(err))
+	assert.True(t, HasErrorCode(err, CodeShareAlreadyExists))
+	assert.False(t, HasErrorCode(err, CodeShareBeingDeleted))
+	assert.True(t, HasErrorCode(errors.New("Code=\"ShareAlreadyExists\""), CodeShareAlreadyExists))
+	assert.False(t, HasErrorCode(nil, CodeShareAlreadyExists))
+	assert.Empty(t, ErrorCode(errors.New("no match")))
+	assert.Zero(t, StatusCode(errors.New("no match")))
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		desc     string
+		err      error
+		expected time.Duration
+	}{
+		{
+			desc:     "nil error",
+			expected: 0,
+		},
+		{
+			desc:     "Retry-After header in seconds",
+			err:      newResponseError(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"5"}}),
+			expected: 5 * time.Second,
+		},
+		{
+			desc:     "x-ms-retry-after-ms header",
+			err:      newResponseError(http.StatusTooManyRequests, "", http.Header{"X-Ms-Retry-After-Ms": []string{"1500"}}),
+			expected: 1500 * time.Millisecond,
+		},
+		{
+			desc:     "invalid Retry-After header",
+			err:      newResponseError(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"invalid"}}),
+			expected: 0,
+		},
+		{
+			desc:     "cloud provider error message",
+			err:      errors.New("Retriable: true, RetryAfter: 217s, HTTPStatusCode: 0, RawError: client throttled"),
+			expected: 217 * time.Second,
+		},
+		{
+			desc:     "no hint",
+			err:      errors.New("no match"),
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, RetryAfter(test.err), test.desc)
+	}
+}
@@ -24,6 +24,7 @@ if [ "$KUBELET_PATH" != "/var/lib/kubelet" ];then
   echo "kubelet path is $KUBELET_PATH, update azurefile-proxy.service...."
   sed -i "s#--azurefile-proxy-endpoint[^ ]*#--azurefile-proxy-endpoint=unix:/${KUBELET_PATH}/plugins/file.csi.azure.com/azurefile-proxy.sock#" /azurefile-proxy/azurefile-proxy.service
   echo "azurefile-proxy endpoint is updated to unix:/$KUBELET_PATH/plugins/file.csi.azure.com/azurefile-proxy.sock"
+  sed -i "s#--kubelet-root-dir[^ ]*#--kubelet-root-dir=${KUBELET_PATH}#" /azurefile-proxy/azurefile-proxy.service
 fi
 
 HOST_CMD="nsenter --mount=/proc/1/ns/mnt"
@@ -17,18 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/server"
 	csicommon "sigs.k8s.io/azurefile-csi-driver/pkg/csi-common"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 )
 
 var (
 	azurefileProxyEndpoint = flag.String("azurefile-proxy-endpoint", "unix://tmp/azurefile-proxy.sock", "azurefile-proxy endpoint")
+	otlpEndpoint           = flag.String("otlp-endpoint", "", "OTLP gRPC endpoint to export OpenTelemetry traces to, empty string disables tracing")
+	tracingSamplingRatio   = flag.Float64("tracing-sampling-ratio", 0.1, "ratio of mount calls sampled for tracing, calls with a sampled parent span are always sampled")
+	loggingFormat          = flag.String("logging-format", csicommon.LogFormatText, "log output format, \"text\" or \"json\"")
+	kubeletRootDir         = flag.String("kubelet-root-dir", "/var/lib/kubelet", "kubelet root directory, only targets under it are mounted or unmounted")
+	allowedUIDs            = flag.String("allowed-uids", "", "comma separated UIDs of the callers allowed on unix socket, e.g. 0 for the node driver running as root, empty string disables the UID check")
+	allowedCgroups         = flag.String("allowed-cgroups", "", "comma separated cgroup path prefixes of the callers allowed on unix socket, e.g. /kubepods.slice, empty string disables the cgroup check")
+	tlsCertFile            = flag.String("tls-cert-file", "", "server certificate file of mTLS, required on TCP endpoint")
+	tlsKeyFile             = flag.String("tls-key-file", "", "server private key file of mTLS, required on TCP endpoint")
+	tlsClientCAFile        = flag.String("tls-client-ca-file", "", "CA file to verify client certificates of mTLS, required on TCP endpoint")
 	grpcServerRunner       = server.RunGRPCServer
 )
 
@@ -36,10 +50,25 @@ func main() {
 	klog.InitFlags(nil)
 	_ = flag.Set("logtostderr", "true")
 	flag.Parse()
+	if err := csicommon.SetLogFormat(*loggingFormat); err != nil {
+		klog.Fatalln(err)
+	}
 	proto, addr, err := csicommon.ParseEndpoint(*azurefileProxyEndpoint)
 	if err != nil {
 		klog.Fatalf("failed to parse endpoint %v", err.Error())
 	}
+	authOptions, err := parseAuthOptions(*allowedUIDs, *allowedCgroups)
+	if err != nil {
+		klog.Fatalf("failed to parse allowed callers: %v", err)
+	}
+	var tlsConfig *tls.Config
+	if proto == "tcp" {
+		if tlsConfig, err = server.NewServerTLSConfig(*tlsCertFile, *tlsKeyFile, *tlsClientCAFile); err != nil {
+			klog.Fatalf("failed to set up mTLS on %s: %v", *azurefileProxyEndpoint, err)
+		}
+	} else if len(authOptions.AllowedUIDs) == 0 && len(authOptions.AllowedCgroups) == 0 {
+		klog.Fatalf("no caller is allowed on %s, set --allowed-uids or --allowed-cgroups explicitly", *azurefileProxyEndpoint)
+	}
 
 	if proto == "unix" {
 		addr = "/" + addr
@@ -53,10 +82,41 @@ func main() {
 		klog.Fatal("cannot start server:", err)
 	}
 
-	mountServer := server.NewMountServiceServer()
+	shutdownTracing, err := tracing.Init(context.Background(), *otlpEndpoint, "azurefile-proxy", *tracingSamplingRatio)
+	if err != nil {
+		klog.Fatalf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("failed to shutdown tracing: %v", err)
+		}
+	}()
+
+	mountServer := server.NewMountServiceServer(*kubeletRootDir)
 
 	klog.V(2).Infof("Listening for connections on address: %v\n", listener.Addr())
-	if err = grpcServerRunner(mountServer, false, listener); err != nil {
+	if err = grpcServerRunner(mountServer, tlsConfig, authOptions, listener); err != nil {
 		klog.Fatalf("Error running grpc server %v. Error: %v", listener.Addr(), err)
 	}
 }
+
+// parseAuthOptions parses the comma separated allowed UIDs and cgroups
+func parseAuthOptions(uids, cgroups string) (server.AuthOptions, error) {
+	var authOptions server.AuthOptions
+	for _, v := range strings.Split(uids, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		uid, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return authOptions, err
+		}
+		authOptions.AllowedUIDs = append(authOptions.AllowedUIDs, uint32(uid))
+	}
+	for _, v := range strings.Split(cgroups, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			authOptions.AllowedCgroups = append(authOptions.AllowedCgroups, v)
+		}
+	}
+	return authOptions, nil
+}
@@ -17,15 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"crypto/tls"
 	"net"
 	"os"
 	"runtime"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/server"
 )
 
-func mockRunGRPCServer(_ pb.MountServiceServer, _ bool, _ net.Listener) error {
+func mockRunGRPCServer(_ pb.MountServiceServer, _ *tls.Config, _ server.AuthOptions, _ net.Listener) error {
 	return nil
 }
 
@@ -41,8 +46,23 @@ func TestMain(t *testing.T) {
 	defer func() { grpcServerRunner = originalGRPCServerRunner }()
 
 	// Set the azurefile-proxy-endpoint
-	os.Args = []string{"cmd", "-azurefile-proxy-endpoint=unix://tmp/test.sock"}
+	os.Args = []string{"cmd", "-azurefile-proxy-endpoint=unix://tmp/test.sock", "-allowed-uids=0"}
 
 	// Run main
 	main()
 }
+
+func TestParseAuthOptions(t *testing.T) {
+	authOptions, err := parseAuthOptions("0, 1000,", "/kubepods.slice,,/system.slice/kubelet.service")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 1000}, authOptions.AllowedUIDs)
+	assert.Equal(t, []string{"/kubepods.slice", "/system.slice/kubelet.service"}, authOptions.AllowedCgroups)
+
+	authOptions, err = parseAuthOptions("", "")
+	require.NoError(t, err)
+	assert.Empty(t, authOptions.AllowedUIDs)
+	assert.Empty(t, authOptions.AllowedCgroups)
+
+	_, err = parseAuthOptions("root", "")
+	assert.Error(t, err)
+}
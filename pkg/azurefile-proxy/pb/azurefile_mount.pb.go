@@ -1,17 +1,17 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.25.0
-// 	protoc        v3.6.1
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.15.1
 // source: proto/azurefile_mount.proto
 
 package pb
 
 import (
-	proto "github.com/golang/protobuf/proto"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -21,29 +21,22 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// This is a compile-time assertion that a sufficiently up-to-date version
-// of the legacy proto package is being used.
-const _ = proto.ProtoPackageIsVersion4
-
 type MountAzureFileRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Source           string   `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
-	Target           string   `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
-	Fstype           string   `protobuf:"bytes,3,opt,name=fstype,proto3" json:"fstype,omitempty"`
-	MountOptions     []string `protobuf:"bytes,4,rep,name=mountOptions,proto3" json:"mountOptions,omitempty"`
-	SensitiveOptions []string `protobuf:"bytes,5,rep,name=sensitiveOptions,proto3" json:"sensitiveOptions,omitempty"`
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Source           string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Target           string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Fstype           string                 `protobuf:"bytes,3,opt,name=fstype,proto3" json:"fstype,omitempty"`
+	MountOptions     []string               `protobuf:"bytes,4,rep,name=mountOptions,proto3" json:"mountOptions,omitempty"`
+	SensitiveOptions []string               `protobuf:"bytes,5,rep,name=sensitiveOptions,proto3" json:"sensitiveOptions,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *MountAzureFileRequest) Reset() {
 	*x = MountAzureFileRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_azurefile_mount_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *MountAzureFileRequest) String() string {
@@ -54,7 +47,7 @@ func (*MountAzureFileRequest) ProtoMessage() {}
 
 func (x *MountAzureFileRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_azurefile_mount_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -105,18 +98,16 @@ func (x *MountAzureFileRequest) GetSensitiveOptions() []string {
 }
 
 type MountAzureFileResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MountAzureFileResponse) Reset() {
 	*x = MountAzureFileResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_azurefile_mount_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *MountAzureFileResponse) String() string {
@@ -127,7 +118,7 @@ func (*MountAzureFileResponse) ProtoMessage() {}
 
 func (x *MountAzureFileResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_azurefile_mount_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -142,57 +133,507 @@ func (*MountAzureFileResponse) Descriptor() ([]byte, []int) {
 	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{1}
 }
 
-var File_proto_azurefile_mount_proto protoreflect.FileDescriptor
+type UnmountAzureFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-var file_proto_azurefile_mount_proto_rawDesc = []byte{
-	0x0a, 0x1b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x7a, 0x75, 0x72, 0x65, 0x66, 0x69, 0x6c,
-	0x65, 0x5f, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xaf, 0x01,
-	0x0a, 0x15, 0x4d, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x7a, 0x75, 0x72, 0x65, 0x46, 0x69, 0x6c, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
-	0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x73, 0x74, 0x79, 0x70,
-	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x73, 0x74, 0x79, 0x70, 0x65, 0x12,
-	0x22, 0x0a, 0x0c, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
-	0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65,
-	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x73,
-	0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22,
-	0x18, 0x0a, 0x16, 0x4d, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x7a, 0x75, 0x72, 0x65, 0x46, 0x69, 0x6c,
-	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x53, 0x0a, 0x0c, 0x4d, 0x6f, 0x75,
-	0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x4d, 0x6f, 0x75,
-	0x6e, 0x74, 0x41, 0x7a, 0x75, 0x72, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x16, 0x2e, 0x4d, 0x6f,
-	0x75, 0x6e, 0x74, 0x41, 0x7a, 0x75, 0x72, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x4d, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x7a, 0x75, 0x72, 0x65,
-	0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x06,
-	0x5a, 0x04, 0x2e, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *UnmountAzureFileRequest) Reset() {
+	*x = UnmountAzureFileRequest{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
+func (x *UnmountAzureFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnmountAzureFileRequest) ProtoMessage() {}
+
+func (x *UnmountAzureFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnmountAzureFileRequest.ProtoReflect.Descriptor instead.
+func (*UnmountAzureFileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UnmountAzureFileRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type UnmountAzureFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnmountAzureFileResponse) Reset() {
+	*x = UnmountAzureFileResponse{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnmountAzureFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnmountAzureFileResponse) ProtoMessage() {}
+
+func (x *UnmountAzureFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnmountAzureFileResponse.ProtoReflect.Descriptor instead.
+func (*UnmountAzureFileResponse) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{3}
+}
+
+type ListMountsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// only mounts under targetPrefix are listed if it's set
+	TargetPrefix  string `protobuf:"bytes,1,opt,name=targetPrefix,proto3" json:"targetPrefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsRequest) Reset() {
+	*x = ListMountsRequest{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsRequest) ProtoMessage() {}
+
+func (x *ListMountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsRequest.ProtoReflect.Descriptor instead.
+func (*ListMountsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListMountsRequest) GetTargetPrefix() string {
+	if x != nil {
+		return x.TargetPrefix
+	}
+	return ""
+}
+
+type MountPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Fstype        string                 `protobuf:"bytes,3,opt,name=fstype,proto3" json:"fstype,omitempty"`
+	Options       []string               `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MountPoint) Reset() {
+	*x = MountPoint{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MountPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MountPoint) ProtoMessage() {}
+
+func (x *MountPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MountPoint.ProtoReflect.Descriptor instead.
+func (*MountPoint) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MountPoint) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *MountPoint) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *MountPoint) GetFstype() string {
+	if x != nil {
+		return x.Fstype
+	}
+	return ""
+}
+
+func (x *MountPoint) GetOptions() []string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type ListMountsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mounts        []*MountPoint          `protobuf:"bytes,1,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsResponse) Reset() {
+	*x = ListMountsResponse{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsResponse) ProtoMessage() {}
+
+func (x *ListMountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsResponse.ProtoReflect.Descriptor instead.
+func (*ListMountsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListMountsResponse) GetMounts() []*MountPoint {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{7}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HealthResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *HealthResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVersionRequest) Reset() {
+	*x = GetVersionRequest{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionRequest) ProtoMessage() {}
+
+func (x *GetVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetVersionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{9}
+}
+
+type GetVersionResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Version string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// apiVersion is increased when RPCs are added, a proxy without GetVersion is of apiVersion 1
+	ApiVersion    uint32 `protobuf:"varint,2,opt,name=apiVersion,proto3" json:"apiVersion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVersionResponse) Reset() {
+	*x = GetVersionResponse{}
+	mi := &file_proto_azurefile_mount_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionResponse) ProtoMessage() {}
+
+func (x *GetVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_azurefile_mount_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetVersionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_azurefile_mount_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetVersionResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *GetVersionResponse) GetApiVersion() uint32 {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return 0
+}
+
+var File_proto_azurefile_mount_proto protoreflect.FileDescriptor
+
+const file_proto_azurefile_mount_proto_rawDesc = "" +
+	"\n" +
+	"\x1bproto/azurefile_mount.proto\"\xaf\x01\n" +
+	"\x15MountAzureFileRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x16\n" +
+	"\x06fstype\x18\x03 \x01(\tR\x06fstype\x12\"\n" +
+	"\fmountOptions\x18\x04 \x03(\tR\fmountOptions\x12*\n" +
+	"\x10sensitiveOptions\x18\x05 \x03(\tR\x10sensitiveOptions\"\x18\n" +
+	"\x16MountAzureFileResponse\"1\n" +
+	"\x17UnmountAzureFileRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\"\x1a\n" +
+	"\x18UnmountAzureFileResponse\"7\n" +
+	"\x11ListMountsRequest\x12\"\n" +
+	"\ftargetPrefix\x18\x01 \x01(\tR\ftargetPrefix\"n\n" +
+	"\n" +
+	"MountPoint\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x16\n" +
+	"\x06fstype\x18\x03 \x01(\tR\x06fstype\x12\x18\n" +
+	"\aoptions\x18\x04 \x03(\tR\aoptions\"9\n" +
+	"\x12ListMountsResponse\x12#\n" +
+	"\x06mounts\x18\x01 \x03(\v2\v.MountPointR\x06mounts\"\x0f\n" +
+	"\rHealthRequest\"D\n" +
+	"\x0eHealthResponse\x12\x18\n" +
+	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x13\n" +
+	"\x11GetVersionRequest\"N\n" +
+	"\x12GetVersionResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1e\n" +
+	"\n" +
+	"apiVersion\x18\x02 \x01(\rR\n" +
+	"apiVersion2\xbd\x02\n" +
+	"\fMountService\x12C\n" +
+	"\x0eMountAzureFile\x12\x16.MountAzureFileRequest\x1a\x17.MountAzureFileResponse\"\x00\x12I\n" +
+	"\x10UnmountAzureFile\x12\x18.UnmountAzureFileRequest\x1a\x19.UnmountAzureFileResponse\"\x00\x127\n" +
+	"\n" +
+	"ListMounts\x12\x12.ListMountsRequest\x1a\x13.ListMountsResponse\"\x00\x12+\n" +
+	"\x06Health\x12\x0e.HealthRequest\x1a\x0f.HealthResponse\"\x00\x127\n" +
+	"\n" +
+	"GetVersion\x12\x12.GetVersionRequest\x1a\x13.GetVersionResponse\"\x00B\x06Z\x04.;pbb\x06proto3"
+
 var (
 	file_proto_azurefile_mount_proto_rawDescOnce sync.Once
-	file_proto_azurefile_mount_proto_rawDescData = file_proto_azurefile_mount_proto_rawDesc
+	file_proto_azurefile_mount_proto_rawDescData []byte
 )
 
 func file_proto_azurefile_mount_proto_rawDescGZIP() []byte {
 	file_proto_azurefile_mount_proto_rawDescOnce.Do(func() {
-		file_proto_azurefile_mount_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_azurefile_mount_proto_rawDescData)
+		file_proto_azurefile_mount_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_azurefile_mount_proto_rawDesc), len(file_proto_azurefile_mount_proto_rawDesc)))
 	})
 	return file_proto_azurefile_mount_proto_rawDescData
 }
 
-var file_proto_azurefile_mount_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_proto_azurefile_mount_proto_goTypes = []interface{}{
-	(*MountAzureFileRequest)(nil),  // 0: MountAzureFileRequest
-	(*MountAzureFileResponse)(nil), // 1: MountAzureFileResponse
+var file_proto_azurefile_mount_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_proto_azurefile_mount_proto_goTypes = []any{
+	(*MountAzureFileRequest)(nil),    // 0: MountAzureFileRequest
+	(*MountAzureFileResponse)(nil),   // 1: MountAzureFileResponse
+	(*UnmountAzureFileRequest)(nil),  // 2: UnmountAzureFileRequest
+	(*UnmountAzureFileResponse)(nil), // 3: UnmountAzureFileResponse
+	(*ListMountsRequest)(nil),        // 4: ListMountsRequest
+	(*MountPoint)(nil),               // 5: MountPoint
+	(*ListMountsResponse)(nil),       // 6: ListMountsResponse
+	(*HealthRequest)(nil),            // 7: HealthRequest
+	(*HealthResponse)(nil),           // 8: HealthResponse
+	(*GetVersionRequest)(nil),        // 9: GetVersionRequest
+	(*GetVersionResponse)(nil),       // 10: GetVersionResponse
 }
 var file_proto_azurefile_mount_proto_depIdxs = []int32{
-	0, // 0: MountService.MountAzureFile:input_type -> MountAzureFileRequest
-	1, // 1: MountService.MountAzureFile:output_type -> MountAzureFileResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: ListMountsResponse.mounts:type_name -> MountPoint
+	0,  // 1: MountService.MountAzureFile:input_type -> MountAzureFileRequest
+	2,  // 2: MountService.UnmountAzureFile:input_type -> UnmountAzureFileRequest
+	4,  // 3: MountService.ListMounts:input_type -> ListMountsRequest
+	7,  // 4: MountService.Health:input_type -> HealthRequest
+	9,  // 5: MountService.GetVersion:input_type -> GetVersionRequest
+	1,  // 6: MountService.MountAzureFile:output_type -> MountAzureFileResponse
+	3,  // 7: MountService.UnmountAzureFile:output_type -> UnmountAzureFileResponse
+	6,  // 8: MountService.ListMounts:output_type -> ListMountsResponse
+	8,  // 9: MountService.Health:output_type -> HealthResponse
+	10, // 10: MountService.GetVersion:output_type -> GetVersionResponse
+	6,  // [6:11] is the sub-list for method output_type
+	1,  // [1:6] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_azurefile_mount_proto_init() }
@@ -200,39 +641,13 @@ func file_proto_azurefile_mount_proto_init() {
 	if File_proto_azurefile_mount_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_proto_azurefile_mount_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MountAzureFileRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_proto_azurefile_mount_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MountAzureFileResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_proto_azurefile_mount_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_azurefile_mount_proto_rawDesc), len(file_proto_azurefile_mount_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -241,7 +656,6 @@ func file_proto_azurefile_mount_proto_init() {
 		MessageInfos:      file_proto_azurefile_mount_proto_msgTypes,
 	}.Build()
 	File_proto_azurefile_mount_proto = out.File
-	file_proto_azurefile_mount_proto_rawDesc = nil
 	file_proto_azurefile_mount_proto_goTypes = nil
 	file_proto_azurefile_mount_proto_depIdxs = nil
 }
@@ -19,6 +19,10 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MountServiceClient interface {
 	MountAzureFile(ctx context.Context, in *MountAzureFileRequest, opts ...grpc.CallOption) (*MountAzureFileResponse, error)
+	UnmountAzureFile(ctx context.Context, in *UnmountAzureFileRequest, opts ...grpc.CallOption) (*UnmountAzureFileResponse, error)
+	ListMounts(ctx context.Context, in *ListMountsRequest, opts ...grpc.CallOption) (*ListMountsResponse, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
 }
 
 type mountServiceClient struct {
@@ -38,11 +42,51 @@ func (c *mountServiceClient) MountAzureFile(ctx context.Context, in *MountAzureF
 	return out, nil
 }
 
+func (c *mountServiceClient) UnmountAzureFile(ctx context.Context, in *UnmountAzureFileRequest, opts ...grpc.CallOption) (*UnmountAzureFileResponse, error) {
+	out := new(UnmountAzureFileResponse)
+	err := c.cc.Invoke(ctx, "/MountService/UnmountAzureFile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mountServiceClient) ListMounts(ctx context.Context, in *ListMountsRequest, opts ...grpc.CallOption) (*ListMountsResponse, error) {
+	out := new(ListMountsResponse)
+	err := c.cc.Invoke(ctx, "/MountService/ListMounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mountServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/MountService/Health", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mountServiceClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	out := new(GetVersionResponse)
+	err := c.cc.Invoke(ctx, "/MountService/GetVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MountServiceServer is the server API for MountService service.
 // All implementations must embed UnimplementedMountServiceServer
 // for forward compatibility
 type MountServiceServer interface {
 	MountAzureFile(context.Context, *MountAzureFileRequest) (*MountAzureFileResponse, error)
+	UnmountAzureFile(context.Context, *UnmountAzureFileRequest) (*UnmountAzureFileResponse, error)
+	ListMounts(context.Context, *ListMountsRequest) (*ListMountsResponse, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
 	mustEmbedUnimplementedMountServiceServer()
 }
 
@@ -53,6 +97,18 @@ type UnimplementedMountServiceServer struct {
 func (UnimplementedMountServiceServer) MountAzureFile(context.Context, *MountAzureFileRequest) (*MountAzureFileResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MountAzureFile not implemented")
 }
+func (UnimplementedMountServiceServer) UnmountAzureFile(context.Context, *UnmountAzureFileRequest) (*UnmountAzureFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnmountAzureFile not implemented")
+}
+func (UnimplementedMountServiceServer) ListMounts(context.Context, *ListMountsRequest) (*ListMountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMounts not implemented")
+}
+func (UnimplementedMountServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedMountServiceServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
 func (UnimplementedMountServiceServer) mustEmbedUnimplementedMountServiceServer() {}
 
 // UnsafeMountServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +140,78 @@ func _MountService_MountAzureFile_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MountService_UnmountAzureFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmountAzureFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MountServiceServer).UnmountAzureFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/MountService/UnmountAzureFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MountServiceServer).UnmountAzureFile(ctx, req.(*UnmountAzureFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MountService_ListMounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MountServiceServer).ListMounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/MountService/ListMounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MountServiceServer).ListMounts(ctx, req.(*ListMountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MountService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MountServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/MountService/Health",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MountServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MountService_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MountServiceServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/MountService/GetVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MountServiceServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MountService_ServiceDesc is the grpc.ServiceDesc for MountService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +223,22 @@ var MountService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "MountAzureFile",
 			Handler:    _MountService_MountAzureFile_Handler,
 		},
+		{
+			MethodName: "UnmountAzureFile",
+			Handler:    _MountService_UnmountAzureFile_Handler,
+		},
+		{
+			MethodName: "ListMounts",
+			Handler:    _MountService_ListMounts_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _MountService_Health_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _MountService_GetVersion_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/azurefile_mount.proto",
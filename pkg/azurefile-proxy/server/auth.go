@@ -0,0 +1,195 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// AuthOptions limits the callers of the proxy on unix socket, a caller must match all configured lists,
+// an empty list is not checked
+type AuthOptions struct {
+	// AllowedUIDs are the UIDs of the allowed callers
+	AllowedUIDs []uint32
+	// AllowedCgroups are the cgroup path prefixes of the allowed callers, e.g. /kubepods.slice
+	AllowedCgroups []string
+}
+
+// peerAuthInfo is the credential of the caller on unix socket read by SO_PEERCRED
+type peerAuthInfo struct {
+	credentials.CommonAuthInfo
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+func (peerAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentials is the transport credentials of unix socket, it reads the credential of the caller
+// in the handshake, the caller is authorized per request by callerAuthorizer
+type peerCredentials struct{}
+
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, nil, errors.New("peercred is only supported by server")
+}
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	authInfo, err := getPeerAuthInfo(conn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get peer credential: %v", err)
+	}
+	return conn, authInfo, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (c peerCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// callerAuthorizer authorizes the callers on unix socket with AuthOptions, callers over TLS are authorized by
+// the client certificate in the handshake
+type callerAuthorizer struct {
+	AuthOptions
+	// readCgroups is replaced in unit tests
+	readCgroups func(pid int32) ([]string, error)
+}
+
+func newCallerAuthorizer(options AuthOptions) *callerAuthorizer {
+	return &callerAuthorizer{
+		AuthOptions: options,
+		readCgroups: readProcCgroups,
+	}
+}
+
+func (a *callerAuthorizer) unaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "caller is unknown")
+	}
+	switch authInfo := p.AuthInfo.(type) {
+	case credentials.TLSInfo:
+	case peerAuthInfo:
+		if err := a.authorize(authInfo); err != nil {
+			klog.Warningf("%s is denied: %v", info.FullMethod, err)
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "caller is not authenticated")
+	}
+	return handler(ctx, req)
+}
+
+func (a *callerAuthorizer) authorize(authInfo peerAuthInfo) error {
+	if len(a.AllowedUIDs) > 0 && !slices.Contains(a.AllowedUIDs, authInfo.UID) {
+		return fmt.Errorf("uid %d of caller(pid %d) is not allowed", authInfo.UID, authInfo.PID)
+	}
+	if len(a.AllowedCgroups) > 0 {
+		cgroups, err := a.readCgroups(authInfo.PID)
+		if err != nil {
+			return fmt.Errorf("failed to read cgroups of caller(pid %d): %v", authInfo.PID, err)
+		}
+		if !matchCgroups(cgroups, a.AllowedCgroups) {
+			return fmt.Errorf("cgroups %v of caller(pid %d) are not allowed", cgroups, authInfo.PID)
+		}
+	}
+	return nil
+}
+
+// matchCgroups returns true if any cgroup is under any allowed cgroup
+func matchCgroups(cgroups, allowedCgroups []string) bool {
+	for _, cgroup := range cgroups {
+		for _, allowed := range allowedCgroups {
+			allowed = strings.TrimSuffix(allowed, "/")
+			if allowed == "" || cgroup == allowed || strings.HasPrefix(cgroup, allowed+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseProcCgroups returns the cgroup paths in the content of /proc/<pid>/cgroup, e.g. "0::/kubepods.slice/..."
+func parseProcCgroups(content string) []string {
+	var cgroups []string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 || fields[2] == "" {
+			continue
+		}
+		if !slices.Contains(cgroups, fields[2]) {
+			cgroups = append(cgroups, fields[2])
+		}
+	}
+	return cgroups
+}
+
+func readProcCgroups(pid int32) ([]string, error) {
+	content, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseProcCgroups(string(content)), nil
+}
+
+// NewServerTLSConfig returns the mTLS config of the proxy on TCP endpoint, the client certificate must be signed by clientCAFile
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || clientCAFile == "" {
+		return nil, errors.New("certificate, key and client CA files are all required for mTLS")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate is found in %s", clientCAFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
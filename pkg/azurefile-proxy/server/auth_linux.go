@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/credentials"
+)
+
+// getPeerAuthInfo reads the credential of the caller on unix socket by SO_PEERCRED
+func getPeerAuthInfo(conn net.Conn) (peerAuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return peerAuthInfo{}, fmt.Errorf("%T is not a unix socket connection", conn)
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return peerAuthInfo{}, err
+	}
+	var ucred *unix.Ucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return peerAuthInfo{}, err
+	}
+	if credErr != nil {
+		return peerAuthInfo{}, credErr
+	}
+	return peerAuthInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		PID:            ucred.Pid,
+		UID:            ucred.Uid,
+		GID:            ucred.Gid,
+	}, nil
+}
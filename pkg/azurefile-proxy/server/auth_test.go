@@ -0,0 +1,269 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
+)
+
+// writeTestCert writes a certificate signed by parent, or a self-signed CA if parent is nil, and returns its files
+func writeTestCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, ips ...net.IP) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  ips,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return cert, key, certFile, keyFile
+}
+
+func TestParseProcCgroups(t *testing.T) {
+	content := `12:memory:/kubepods.slice/kubepods-burstable.slice/cri-containerd-1.scope
+11:cpu,cpuacct:/kubepods.slice/kubepods-burstable.slice/cri-containerd-1.scope
+1:name=systemd:/system.slice/containerd.service
+0::/
+`
+	assert.Equal(t, []string{
+		"/kubepods.slice/kubepods-burstable.slice/cri-containerd-1.scope",
+		"/system.slice/containerd.service",
+		"/",
+	}, parseProcCgroups(content))
+	assert.Empty(t, parseProcCgroups(""))
+}
+
+func TestMatchCgroups(t *testing.T) {
+	cgroups := []string{"/kubepods.slice/kubepods-burstable.slice/cri-containerd-1.scope"}
+	assert.True(t, matchCgroups(cgroups, []string{"/kubepods.slice"}))
+	assert.True(t, matchCgroups(cgroups, []string{"/system.slice", "/kubepods.slice/"}))
+	assert.True(t, matchCgroups(cgroups, []string{"/kubepods.slice/kubepods-burstable.slice/cri-containerd-1.scope"}))
+	assert.False(t, matchCgroups(cgroups, []string{"/kubepods"}))
+	assert.False(t, matchCgroups(cgroups, []string{"/system.slice"}))
+	assert.False(t, matchCgroups(nil, []string{"/kubepods.slice"}))
+}
+
+func TestCallerAuthorizerAuthorize(t *testing.T) {
+	readCgroups := func(pid int32) ([]string, error) {
+		if pid == 0 {
+			return nil, errors.New("no such process")
+		}
+		return []string{"/kubepods.slice/pod1"}, nil
+	}
+	tests := []struct {
+		desc          string
+		options       AuthOptions
+		authInfo      peerAuthInfo
+		expectedError string
+	}{
+		{
+			desc:     "no check",
+			authInfo: peerAuthInfo{PID: 1, UID: 1000},
+		},
+		{
+			desc:     "allowed uid",
+			options:  AuthOptions{AllowedUIDs: []uint32{0}},
+			authInfo: peerAuthInfo{PID: 1, UID: 0},
+		},
+		{
+			desc:          "uid not allowed",
+			options:       AuthOptions{AllowedUIDs: []uint32{0}},
+			authInfo:      peerAuthInfo{PID: 1, UID: 1000},
+			expectedError: "uid 1000 of caller(pid 1) is not allowed",
+		},
+		{
+			desc:     "allowed uid and cgroup",
+			options:  AuthOptions{AllowedUIDs: []uint32{0}, AllowedCgroups: []string{"/kubepods.slice"}},
+			authInfo: peerAuthInfo{PID: 1, UID: 0},
+		},
+		{
+			desc:          "cgroup not allowed",
+			options:       AuthOptions{AllowedUIDs: []uint32{0}, AllowedCgroups: []string{"/system.slice"}},
+			authInfo:      peerAuthInfo{PID: 1, UID: 0},
+			expectedError: "are not allowed",
+		},
+		{
+			desc:          "cgroups not readable",
+			options:       AuthOptions{AllowedCgroups: []string{"/kubepods.slice"}},
+			authInfo:      peerAuthInfo{PID: 0, UID: 0},
+			expectedError: "no such process",
+		},
+	}
+	for _, test := range tests {
+		authorizer := newCallerAuthorizer(test.options)
+		authorizer.readCgroups = readCgroups
+		err := authorizer.authorize(test.authInfo)
+		if test.expectedError == "" {
+			assert.NoError(t, err, test.desc)
+			continue
+		}
+		require.Error(t, err, test.desc)
+		assert.Contains(t, err.Error(), test.expectedError, test.desc)
+	}
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	ca, caKey, caFile, _ := writeTestCert(t, "ca", nil, nil)
+	_, _, certFile, keyFile := writeTestCert(t, "server", ca, caKey)
+
+	_, err := NewServerTLSConfig(certFile, keyFile, "")
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, certFile, caFile)
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, keyFile, keyFile)
+	assert.Error(t, err)
+
+	tlsConfig, err := NewServerTLSConfig(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+}
+
+// serveTestProxy runs RunGRPCServer on listener until the test ends
+func serveTestProxy(t *testing.T, tlsConfig *tls.Config, authOptions AuthOptions, listener net.Listener) {
+	t.Helper()
+	go func() {
+		_ = RunGRPCServer(NewMountServiceServer(t.TempDir()), tlsConfig, authOptions, listener)
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+}
+
+func getTestProxyVersion(t *testing.T, target string, creds credentials.TransportCredentials) error {
+	t.Helper()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = mount_azurefile.NewMountServiceClient(conn).GetVersion(ctx, &mount_azurefile.GetVersionRequest{})
+	return err
+}
+
+func TestRunGRPCServerOnUnixSocket(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_PEERCRED is only supported on Linux")
+	}
+	uid := uint32(os.Getuid())
+	tests := []struct {
+		desc         string
+		authOptions  AuthOptions
+		expectedCode codes.Code
+	}{
+		{
+			desc:         "caller uid is allowed",
+			authOptions:  AuthOptions{AllowedUIDs: []uint32{uid}},
+			expectedCode: codes.OK,
+		},
+		{
+			desc:         "caller uid is not allowed",
+			authOptions:  AuthOptions{AllowedUIDs: []uint32{uid + 1}},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+	for _, test := range tests {
+		socket := filepath.Join(t.TempDir(), "azurefile-proxy.sock")
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		serveTestProxy(t, nil, test.authOptions, listener)
+
+		err = getTestProxyVersion(t, "unix://"+socket, insecure.NewCredentials())
+		assert.Equal(t, test.expectedCode, status.Code(err), test.desc)
+	}
+}
+
+func TestRunGRPCServerOnTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	err = RunGRPCServer(NewMountServiceServer(t.TempDir()), nil, AuthOptions{}, listener)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mTLS is required")
+
+	ca, caKey, caFile, _ := writeTestCert(t, "ca", nil, nil)
+	_, _, serverCertFile, serverKeyFile := writeTestCert(t, "server", ca, caKey, net.ParseIP("127.0.0.1"))
+	_, _, clientCertFile, clientKeyFile := writeTestCert(t, "client", ca, caKey)
+	otherCA, otherCAKey, _, _ := writeTestCert(t, "other-ca", nil, nil)
+	_, _, otherCertFile, otherKeyFile := writeTestCert(t, "other", otherCA, otherCAKey)
+
+	tlsConfig, err := NewServerTLSConfig(serverCertFile, serverKeyFile, caFile)
+	require.NoError(t, err)
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveTestProxy(t, tlsConfig, AuthOptions{}, listener)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca)
+	clientCreds := func(certFile, keyFile string) credentials.TransportCredentials {
+		clientTLSConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+		if certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			require.NoError(t, err)
+			clientTLSConfig.Certificates = []tls.Certificate{cert}
+		}
+		return credentials.NewTLS(clientTLSConfig)
+	}
+	target := listener.Addr().String()
+	assert.NoError(t, getTestProxyVersion(t, target, clientCreds(clientCertFile, clientKeyFile)), "client certificate signed by CA")
+	assert.Error(t, getTestProxyVersion(t, target, clientCreds("", "")), "no client certificate")
+	assert.Error(t, getTestProxyVersion(t, target, clientCreds(otherCertFile, otherKeyFile)), "client certificate signed by other CA")
+	assert.Error(t, getTestProxyVersion(t, target, insecure.NewCredentials()), "plaintext")
+}
@@ -18,36 +18,150 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 	mount_utils "k8s.io/mount-utils"
 	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile"
 	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	volumehelper "sigs.k8s.io/azurefile-csi-driver/pkg/util"
 )
 
+// targetResolveTimeout is the timeout of resolving and stat-ing a target in validateTarget
+const targetResolveTimeout = 5 * time.Second
+
+var (
+	// listedFsTypes are the file system types of the mounts returned by ListMounts, aznfs mounts show up as nfs4
+	listedFsTypes = []string{"nfs", "nfs4", "cifs", "smb3"}
+	// allowedFsTypes are the file system types allowed in MountAzureFile
+	allowedFsTypes = []string{"aznfs", "nfs", "cifs"}
+	// disallowedMountOptions are the mount options rejected in MountAzureFile, which would bind, move or remount
+	// an existing mount on the host, or change its propagation, instead of mounting a file share
+	disallowedMountOptions = []string{
+		"bind", "rbind", "remount", "move",
+		"shared", "rshared", "slave", "rslave", "private", "rprivate", "unbindable", "runbindable",
+	}
+)
+
 type MountServer struct {
 	mount_azurefile.UnimplementedMountServiceServer
 
 	mounter mount_utils.Interface
+	// kubeletRootDir is the only directory under which targets are mounted or unmounted
+	kubeletRootDir string
+	// lookPath is replaced in unit tests
+	lookPath func(file string) (string, error)
 }
 
 // NewMountServer returns a new Mountserver
-func NewMountServiceServer() *MountServer {
+func NewMountServiceServer(kubeletRootDir string) *MountServer {
 	mountServer := &MountServer{
-		mounter: mount_utils.New(""),
+		mounter:        mount_utils.New(""),
+		kubeletRootDir: kubeletRootDir,
+		lookPath:       exec.LookPath,
 	}
 	return mountServer
 }
 
+// validateTarget returns the target with symlinks in its parent directory resolved, or an error if it escapes the kubelet root
+// directory or it's a symlink, which mount and umount would follow. The file system is accessed under targetResolveTimeout since
+// a dead mount on the path would block the call, a target which could not be stat-ed in time is regarded as a dead mount
+// rather than a symlink since a symlink is read from its parent directory.
+func (server *MountServer) validateTarget(target string) (string, error) {
+	if target == "" {
+		return "", status.Error(codes.InvalidArgument, "target is not provided")
+	}
+	if !filepath.IsAbs(target) {
+		return "", status.Errorf(codes.InvalidArgument, "target %s is not an absolute path", target)
+	}
+	cleaned := filepath.Clean(target)
+	if !isUnder(cleaned, filepath.Clean(server.kubeletRootDir)) {
+		return "", status.Errorf(codes.PermissionDenied, "target %s is not under kubelet root directory %s", target, server.kubeletRootDir)
+	}
+
+	var root, parent string
+	resolveFunc := func() error {
+		var err error
+		if root, err = filepath.EvalSymlinks(server.kubeletRootDir); err != nil {
+			return status.Errorf(codes.Internal, "failed to resolve kubelet root directory %s: %v", server.kubeletRootDir, err)
+		}
+		if parent, err = filepath.EvalSymlinks(filepath.Dir(cleaned)); err != nil {
+			if os.IsNotExist(err) {
+				return status.Errorf(codes.NotFound, "parent directory of target %s does not exist", target)
+			}
+			return status.Errorf(codes.InvalidArgument, "failed to resolve target %s: %v", target, err)
+		}
+		return nil
+	}
+	timeoutFunc := func() error {
+		return status.Errorf(codes.DeadlineExceeded, "resolving target %s timed out after %v", target, targetResolveTimeout)
+	}
+	if err := volumehelper.WaitUntilTimeout(targetResolveTimeout, resolveFunc, timeoutFunc); err != nil {
+		return "", err
+	}
+	resolved := filepath.Join(parent, filepath.Base(cleaned))
+	if !isUnder(resolved, root) {
+		return "", status.Errorf(codes.PermissionDenied, "target %s is resolved to %s, which is not under kubelet root directory %s", target, resolved, server.kubeletRootDir)
+	}
+
+	var info os.FileInfo
+	lstatFunc := func() error {
+		var err error
+		info, err = os.Lstat(resolved)
+		return err
+	}
+	errLstatTimeout := errors.New("lstat timed out")
+	if err := volumehelper.WaitUntilTimeout(targetResolveTimeout, lstatFunc, func() error { return errLstatTimeout }); err != nil {
+		switch {
+		case errors.Is(err, errLstatTimeout):
+			klog.Warningf("stat on target %s timed out after %v, it's regarded as a dead mount", resolved, targetResolveTimeout)
+		case !os.IsNotExist(err):
+			return "", status.Errorf(codes.InvalidArgument, "failed to stat target %s: %v", resolved, err)
+		}
+		return resolved, nil
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return "", status.Errorf(codes.PermissionDenied, "target %s is a symlink", target)
+	}
+	return resolved, nil
+}
+
+// validateMountOptions returns an error if any of the options, each of which may be comma separated, is disallowed,
+// the disallowed option is not returned in the error since the options may be sensitive
+func validateMountOptions(options []string) error {
+	for _, option := range options {
+		for _, o := range strings.Split(option, ",") {
+			if slices.Contains(disallowedMountOptions, strings.ToLower(strings.TrimSpace(o))) {
+				return status.Errorf(codes.InvalidArgument, "mount options %v are not allowed", disallowedMountOptions)
+			}
+		}
+	}
+	return nil
+}
+
+// isUnder returns true if path is under dir, both of which are cleaned absolute paths
+func isUnder(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
 // MountAzureFile mounts an AzureFile share to given location
-func (server *MountServer) MountAzureFile(_ context.Context,
+func (server *MountServer) MountAzureFile(ctx context.Context,
 	req *mount_azurefile.MountAzureFileRequest,
 ) (resp *mount_azurefile.MountAzureFileResponse, err error) {
 
@@ -57,6 +171,19 @@ func (server *MountServer) MountAzureFile(_ context.Context,
 	options := req.GetMountOptions()
 	sensitiveOptions := req.GetSensitiveOptions()
 	klog.V(2).Infof("received mount request: source: %s, target: %s, fstype: %s, options: %s", source, target, fstype, strings.Join(options, ","))
+	if !slices.Contains(allowedFsTypes, fstype) {
+		return nil, status.Errorf(codes.InvalidArgument, "fstype %q is not allowed, supported fstypes: %v", fstype, allowedFsTypes)
+	}
+	if err := validateMountOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateMountOptions(sensitiveOptions); err != nil {
+		return nil, err
+	}
+	target, err = server.validateTarget(target)
+	if err != nil {
+		return nil, err
+	}
 
 	execFunc := func() error {
 		return server.mounter.MountSensitive(source, target, fstype, options, sensitiveOptions)
@@ -67,7 +194,10 @@ func (server *MountServer) MountAzureFile(_ context.Context,
 		return fmt.Errorf("mount operation timed out after %d seconds: source=%s, target=%s", mountTimeoutInSec, source, target)
 	}
 
-	if err = volumehelper.WaitUntilTimeout(time.Duration(mountTimeoutInSec)*time.Second, execFunc, timeoutFunc); err != nil {
+	_, span := tracing.StartSpan(ctx, "MountSensitive", attribute.String("source", source), attribute.String("fsType", fstype))
+	err = volumehelper.WaitUntilTimeout(time.Duration(mountTimeoutInSec)*time.Second, execFunc, timeoutFunc)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		klog.Error("azurefile mount failed: with error:", err.Error())
 		return nil, fmt.Errorf("azurefile mount failed: %v", err)
 	}
@@ -76,14 +206,135 @@ func (server *MountServer) MountAzureFile(_ context.Context,
 	return &mount_azurefile.MountAzureFileResponse{}, nil
 }
 
+// UnmountAzureFile unmounts an AzureFile share from given location, it's a no-op if the location is not mounted
+func (server *MountServer) UnmountAzureFile(ctx context.Context,
+	req *mount_azurefile.UnmountAzureFileRequest,
+) (resp *mount_azurefile.UnmountAzureFileResponse, err error) {
+	target := req.GetTarget()
+	klog.V(2).Infof("received unmount request: target: %s", target)
+	target, err = server.validateTarget(target)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			klog.V(2).Infof("parent directory of %s does not exist, skip unmount", target)
+			return &mount_azurefile.UnmountAzureFileResponse{}, nil
+		}
+		return nil, err
+	}
+
+	// the mount point check runs under the timeout as well since it blocks on a dead mount
+	skipped := false
+	execFunc := func() error {
+		notMnt, err := server.mounter.IsLikelyNotMountPoint(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				klog.V(2).Infof("%s does not exist, skip unmount", target)
+				skipped = true
+				return nil
+			}
+			if !mount_utils.IsCorruptedMnt(err) {
+				return fmt.Errorf("failed to check mount point %s: %v", target, err)
+			}
+			notMnt = false
+		}
+		if notMnt {
+			klog.V(2).Infof("%s is not mounted, skip unmount", target)
+			skipped = true
+			return nil
+		}
+		return server.mounter.Unmount(target)
+	}
+	mountTimeoutInSec := azurefile.MountTimeoutInSec - 2
+	timeoutFunc := func() error {
+		return fmt.Errorf("unmount operation timed out after %d seconds: target=%s", mountTimeoutInSec, target)
+	}
+	_, span := tracing.StartSpan(ctx, "Unmount", attribute.String("target", target))
+	err = volumehelper.WaitUntilTimeout(time.Duration(mountTimeoutInSec)*time.Second, execFunc, timeoutFunc)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		klog.Error("azurefile unmount failed: with error:", err.Error())
+		return nil, fmt.Errorf("azurefile unmount failed: %v", err)
+	}
+
+	if skipped {
+		return &mount_azurefile.UnmountAzureFileResponse{}, nil
+	}
+	klog.V(2).Infof("azurefile successfully unmounted from %s", target)
+	return &mount_azurefile.UnmountAzureFileResponse{}, nil
+}
+
+// ListMounts lists the nfs and smb mounts on the host
+func (server *MountServer) ListMounts(_ context.Context,
+	req *mount_azurefile.ListMountsRequest,
+) (*mount_azurefile.ListMountsResponse, error) {
+	mountPoints, err := server.mounter.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mounts: %v", err)
+	}
+	resp := &mount_azurefile.ListMountsResponse{}
+	for _, mp := range mountPoints {
+		if !slices.Contains(listedFsTypes, mp.Type) {
+			continue
+		}
+		if prefix := req.GetTargetPrefix(); prefix != "" && !strings.HasPrefix(mp.Path, prefix) {
+			continue
+		}
+		resp.Mounts = append(resp.Mounts, &mount_azurefile.MountPoint{
+			Source:  mp.Device,
+			Target:  mp.Path,
+			Fstype:  mp.Type,
+			Options: mp.Opts,
+		})
+	}
+	return resp, nil
+}
+
+// Health checks whether the proxy could mount on the host, a missing aznfs mount helper is reported in the message
+// but does not make the proxy unhealthy since it's only needed by aznfs volumes
+func (server *MountServer) Health(_ context.Context,
+	_ *mount_azurefile.HealthRequest,
+) (*mount_azurefile.HealthResponse, error) {
+	if _, err := server.mounter.List(); err != nil {
+		return &mount_azurefile.HealthResponse{Healthy: false, Message: fmt.Sprintf("failed to list mounts: %v", err)}, nil
+	}
+	if _, err := server.lookPath("mount.aznfs"); err != nil {
+		return &mount_azurefile.HealthResponse{Healthy: true, Message: fmt.Sprintf("aznfs volumes could not be mounted: %v", err)}, nil
+	}
+	return &mount_azurefile.HealthResponse{Healthy: true}, nil
+}
+
+// GetVersion returns the version of the proxy and the version of its API
+func (server *MountServer) GetVersion(_ context.Context,
+	_ *mount_azurefile.GetVersionRequest,
+) (*mount_azurefile.GetVersionResponse, error) {
+	return &mount_azurefile.GetVersionResponse{
+		Version:    azurefile.GetVersion("azurefile-proxy").DriverVersion,
+		ApiVersion: azurefile.AzurefileProxyAPIVersion,
+	}, nil
+}
+
+// RunGRPCServer serves mountServer on listener, mTLS with tlsConfig is required on TCP endpoint,
+// the callers on unix socket are authorized with authOptions
 func RunGRPCServer(
 	mountServer mount_azurefile.MountServiceServer,
-	enableTLS bool,
+	tlsConfig *tls.Config,
+	authOptions AuthOptions,
 	listener net.Listener,
 ) error {
+	var creds credentials.TransportCredentials
+	switch network := listener.Addr().Network(); {
+	case tlsConfig != nil:
+		creds = credentials.NewTLS(tlsConfig)
+	case network == "unix":
+		creds = peerCredentials{}
+	default:
+		return fmt.Errorf("mTLS is required on %s endpoint %s", network, listener.Addr().String())
+	}
 	serverOptions := []grpc.ServerOption{
+		grpc.Creds(creds),
 		grpc.ChainUnaryInterceptor(
 			grpcprom.NewServerMetrics().UnaryServerInterceptor(),
+			tracing.UnaryServerInterceptor,
+			newCallerAuthorizer(authOptions).unaryServerInterceptor,
 		),
 	}
 
@@ -91,6 +342,6 @@ func RunGRPCServer(
 
 	mount_azurefile.RegisterMountServiceServer(grpcServer, mountServer)
 
-	klog.V(2).Infof("Start GRPC server at %s, TLS = %t", listener.Addr().String(), enableTLS)
+	klog.V(2).Infof("Start GRPC server at %s, TLS = %t", listener.Addr().String(), tlsConfig != nil)
 	return grpcServer.Serve(listener)
 }
@@ -18,11 +18,17 @@ package server
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mount_utils "k8s.io/mount-utils"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile"
 	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
 )
 
@@ -45,6 +51,44 @@ func TestServerMountAzureFile(t *testing.T) {
 			},
 			code: codes.InvalidArgument,
 		},
+		{
+			name: "fstype_not_allowed",
+			args: mount_azurefile.MountAzureFileRequest{
+				Source: "/dev/sdb",
+				Target: "/var/lib/kubelet/plugins/kubernetes.io/csi/file.csi.azure.com/vol/globalmount",
+				Fstype: "ext4",
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "bind_mount_option_not_allowed",
+			args: mount_azurefile.MountAzureFileRequest{
+				Source:       "/var/lib/kubelet/pods",
+				Target:       "/var/lib/kubelet/plugins/kubernetes.io/csi/file.csi.azure.com/vol/globalmount",
+				Fstype:       "cifs",
+				MountOptions: []string{"vers=3.0,bind"},
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "remount_sensitive_option_not_allowed",
+			args: mount_azurefile.MountAzureFileRequest{
+				Source:           "//account.file.core.windows.net/share",
+				Target:           "/var/lib/kubelet/plugins/kubernetes.io/csi/file.csi.azure.com/vol/globalmount",
+				Fstype:           "cifs",
+				SensitiveOptions: []string{"password=secret", " Remount"},
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "target_not_under_kubelet_root",
+			args: mount_azurefile.MountAzureFileRequest{
+				Source: "account.file.core.windows.net:/account/share",
+				Target: "/etc",
+				Fstype: "aznfs",
+			},
+			code: codes.PermissionDenied,
+		},
 	}
 
 	for i := range testCases {
@@ -52,7 +96,7 @@ func TestServerMountAzureFile(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			mountServer := NewMountServiceServer()
+			mountServer := NewMountServiceServer("/var/lib/kubelet")
 			req := mount_azurefile.MountAzureFileRequest{
 				Source:           tc.args.Source,
 				Target:           tc.args.Target,
@@ -65,9 +109,176 @@ func TestServerMountAzureFile(t *testing.T) {
 				require.NoError(t, err)
 				require.NotNil(t, res)
 			} else {
-				require.Error(t, err)
+				require.Equal(t, tc.code, status.Code(err), "%v", err)
 				require.Nil(t, res)
 			}
 		})
 	}
 }
+
+type listErrorMounter struct {
+	*mount_utils.FakeMounter
+}
+
+func (m *listErrorMounter) List() ([]mount_utils.MountPoint, error) {
+	return nil, errors.New("failed to read mountinfo")
+}
+
+func TestServerUnmountAzureFile(t *testing.T) {
+	root := t.TempDir()
+	mountedTarget := filepath.Join(root, "mounted")
+	notMountedTarget := filepath.Join(root, "notmounted")
+	require.NoError(t, os.Mkdir(mountedTarget, 0750))
+	require.NoError(t, os.Mkdir(notMountedTarget, 0750))
+	mounter := mount_utils.NewFakeMounter([]mount_utils.MountPoint{
+		{Device: "account.file.core.windows.net:/account/share", Path: mountedTarget, Type: "nfs4"},
+	})
+	mountServer := &MountServer{mounter: mounter, kubeletRootDir: root}
+
+	_, err := mountServer.UnmountAzureFile(context.Background(), &mount_azurefile.UnmountAzureFileRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = mountServer.UnmountAzureFile(context.Background(), &mount_azurefile.UnmountAzureFileRequest{Target: t.TempDir()})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(root, "escape")))
+	_, err = mountServer.UnmountAzureFile(context.Background(), &mount_azurefile.UnmountAzureFileRequest{Target: filepath.Join(root, "escape", "mounted")})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	for _, target := range []string{filepath.Join(notMountedTarget, "notexist", "target"), filepath.Join(notMountedTarget, "notexist"), notMountedTarget, mountedTarget} {
+		res, err := mountServer.UnmountAzureFile(context.Background(), &mount_azurefile.UnmountAzureFileRequest{Target: target})
+		require.NoError(t, err, target)
+		require.NotNil(t, res, target)
+	}
+	mountPoints, err := mounter.List()
+	require.NoError(t, err)
+	require.Empty(t, mountPoints)
+}
+
+func TestServerListMounts(t *testing.T) {
+	mountServer := &MountServer{mounter: mount_utils.NewFakeMounter([]mount_utils.MountPoint{
+		{Device: "account.file.core.windows.net:/account/share", Path: "/var/lib/kubelet/plugins/a/globalmount", Type: "nfs4", Opts: []string{"vers=4.1"}},
+		{Device: "//account.file.core.windows.net/share", Path: "/var/lib/kubelet/plugins/b/globalmount", Type: "cifs"},
+		{Device: "/dev/sda1", Path: "/var/lib/kubelet", Type: "ext4"},
+		{Device: "account.file.core.windows.net:/account/share", Path: "/mnt/other", Type: "nfs"},
+	})}
+
+	res, err := mountServer.ListMounts(context.Background(), &mount_azurefile.ListMountsRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.GetMounts(), 3)
+
+	res, err = mountServer.ListMounts(context.Background(), &mount_azurefile.ListMountsRequest{TargetPrefix: "/var/lib/kubelet/plugins/a"})
+	require.NoError(t, err)
+	require.Len(t, res.GetMounts(), 1)
+	require.Equal(t, "account.file.core.windows.net:/account/share", res.GetMounts()[0].GetSource())
+	require.Equal(t, "/var/lib/kubelet/plugins/a/globalmount", res.GetMounts()[0].GetTarget())
+	require.Equal(t, "nfs4", res.GetMounts()[0].GetFstype())
+	require.Equal(t, []string{"vers=4.1"}, res.GetMounts()[0].GetOptions())
+
+	mountServer.mounter = &listErrorMounter{mount_utils.NewFakeMounter(nil)}
+	_, err = mountServer.ListMounts(context.Background(), &mount_azurefile.ListMountsRequest{})
+	require.Error(t, err)
+}
+
+func TestServerHealth(t *testing.T) {
+	found := func(file string) (string, error) { return "/sbin/" + file, nil }
+	notFound := func(file string) (string, error) { return "", errors.New(file + " not found") }
+
+	testCases := []struct {
+		name            string
+		mounter         mount_utils.Interface
+		lookPath        func(string) (string, error)
+		expectedHealthy bool
+		expectedMessage string
+	}{
+		{
+			name:            "healthy",
+			mounter:         mount_utils.NewFakeMounter(nil),
+			lookPath:        found,
+			expectedHealthy: true,
+		},
+		{
+			name:            "aznfs mount helper not found",
+			mounter:         mount_utils.NewFakeMounter(nil),
+			lookPath:        notFound,
+			expectedHealthy: true,
+			expectedMessage: "mount.aznfs not found",
+		},
+		{
+			name:            "mount table not readable",
+			mounter:         &listErrorMounter{mount_utils.NewFakeMounter(nil)},
+			lookPath:        found,
+			expectedMessage: "failed to read mountinfo",
+		},
+	}
+	for _, tc := range testCases {
+		mountServer := &MountServer{mounter: tc.mounter, lookPath: tc.lookPath}
+		res, err := mountServer.Health(context.Background(), &mount_azurefile.HealthRequest{})
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expectedHealthy, res.GetHealthy(), tc.name)
+		require.Contains(t, res.GetMessage(), tc.expectedMessage, tc.name)
+	}
+}
+
+func TestServerGetVersion(t *testing.T) {
+	res, err := NewMountServiceServer("/var/lib/kubelet").GetVersion(context.Background(), &mount_azurefile.GetVersionRequest{})
+	require.NoError(t, err)
+	require.Equal(t, azurefile.AzurefileProxyAPIVersion, res.GetApiVersion())
+	require.NotEmpty(t, res.GetVersion())
+}
+
+func TestValidateMountOptions(t *testing.T) {
+	require.NoError(t, validateMountOptions(nil))
+	require.NoError(t, validateMountOptions([]string{"vers=4.1", "actimeo=30,nconnect=4", "dir_mode=0777"}))
+	for _, options := range [][]string{{"bind"}, {"vers=3.0,rbind"}, {"remount"}, {"move"}, {"rshared"}, {"ro", "Private"}} {
+		err := validateMountOptions(options)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "%v", options)
+		require.NotContains(t, err.Error(), "vers", "%v", options)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "kubelet")
+	plugins := filepath.Join(root, "plugins")
+	require.NoError(t, os.MkdirAll(plugins, 0750))
+	// root is a symlink to the real kubelet root directory
+	rootLink := filepath.Join(dir, "kubelet-link")
+	require.NoError(t, os.Symlink(root, rootLink))
+	// escape is a symlinked parent directory which escapes the root
+	outside := filepath.Join(dir, "outside")
+	require.NoError(t, os.Mkdir(outside, 0750))
+	require.NoError(t, os.Symlink(outside, filepath.Join(plugins, "escape")))
+	// inside is a symlinked parent directory which stays under the root
+	require.NoError(t, os.Symlink(plugins, filepath.Join(root, "inside")))
+	// link is a symlinked target which mount would follow
+	require.NoError(t, os.Symlink(outside, filepath.Join(plugins, "link")))
+	target := filepath.Join(plugins, "globalmount")
+
+	tests := []struct {
+		desc     string
+		root     string
+		target   string
+		expected string
+		code     codes.Code
+	}{
+		{desc: "target under root", root: root, target: target, expected: target, code: codes.OK},
+		{desc: "root with trailing slash", root: root + "/", target: target, expected: target, code: codes.OK},
+		{desc: "target not cleaned", root: root, target: root + "//plugins/./globalmount/", expected: target, code: codes.OK},
+		{desc: "root is a symlink", root: rootLink, target: filepath.Join(rootLink, "plugins", "globalmount"), expected: target, code: codes.OK},
+		{desc: "symlinked parent under root", root: root, target: filepath.Join(root, "inside", "globalmount"), expected: target, code: codes.OK},
+		{desc: "empty target", root: root, target: "", code: codes.InvalidArgument},
+		{desc: "relative target", root: root, target: "plugins/globalmount", code: codes.InvalidArgument},
+		{desc: "root itself", root: root, target: root + "/", code: codes.PermissionDenied},
+		{desc: "target outside root", root: root, target: "/etc", code: codes.PermissionDenied},
+		{desc: "sibling with root prefix", root: root, target: root + "2/plugins", code: codes.PermissionDenied},
+		{desc: "dot dot", root: root, target: root + "/plugins/../../etc", code: codes.PermissionDenied},
+		{desc: "symlinked parent escapes root", root: root, target: filepath.Join(plugins, "escape", "globalmount"), code: codes.PermissionDenied},
+		{desc: "target is a symlink", root: root, target: filepath.Join(plugins, "link"), code: codes.PermissionDenied},
+		{desc: "parent does not exist", root: root, target: filepath.Join(plugins, "notexist", "globalmount"), code: codes.NotFound},
+	}
+	for _, test := range tests {
+		mountServer := &MountServer{kubeletRootDir: test.root}
+		resolved, err := mountServer.validateTarget(test.target)
+		require.Equal(t, test.code, status.Code(err), "%s: %v", test.desc, err)
+		require.Equal(t, test.expected, resolved, test.desc)
+	}
+}
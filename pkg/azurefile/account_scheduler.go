@@ -0,0 +1,155 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+const (
+	accountOperationQueueFullFmt = "too many operations on storage account(%s): %d in flight, %d queued, %s would be retried later"
+)
+
+// accountScheduler limits in-flight mutating operations per storage account,
+// operations over the limit wait in a FIFO queue and are rejected when the queue is full.
+type accountScheduler struct {
+	sync.Mutex
+	// maxInFlight <= 0 means no limit
+	maxInFlight int
+	maxQueued   int
+	// accounts keyed by lower case account name, entry is removed when the account is idle
+	accounts map[string]*accountQueue
+}
+
+type accountQueue struct {
+	inFlight int
+	waiters  []*accountWaiter
+}
+
+type accountWaiter struct {
+	operation string
+	// ready is closed when the in-flight slot is handed over to the waiter
+	ready chan struct{}
+}
+
+func newAccountScheduler(maxInFlight, maxQueued int) *accountScheduler {
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &accountScheduler{
+		maxInFlight: maxInFlight,
+		maxQueued:   maxQueued,
+		accounts:    make(map[string]*accountQueue),
+	}
+}
+
+// acquire waits until the operation could run on the account, the returned release func must be called once the operation finishes.
+// Aborted is returned if the queue is full, and the context error if the context is done before the operation gets its turn.
+func (s *accountScheduler) acquire(ctx context.Context, accountName, operation string) (func(), error) {
+	if s == nil || s.maxInFlight <= 0 || accountName == "" {
+		return func() {}, nil
+	}
+	key := strings.ToLower(accountName)
+	start := time.Now()
+
+	s.Lock()
+	q, ok := s.accounts[key]
+	if !ok {
+		q = &accountQueue{}
+		s.accounts[key] = q
+	}
+	if q.inFlight < s.maxInFlight && len(q.waiters) == 0 {
+		q.inFlight++
+		s.Unlock()
+		csiMetrics.ObserveAccountOperationWait(operation, 0)
+		return s.releaseFunc(key), nil
+	}
+	if len(q.waiters) >= s.maxQueued {
+		inFlight, queued := q.inFlight, len(q.waiters)
+		s.Unlock()
+		csiMetrics.RecordAccountOperationRejected(operation)
+		return nil, status.Errorf(codes.Aborted, accountOperationQueueFullFmt, accountName, inFlight, queued, operation)
+	}
+	w := &accountWaiter{operation: operation, ready: make(chan struct{})}
+	q.waiters = append(q.waiters, w)
+	queued := len(q.waiters)
+	s.Unlock()
+	csiMetrics.AddAccountOperationQueueDepth(operation, 1)
+	klog.FromContext(ctx).V(4).Info("operation is queued on storage account", "operation", operation, "account", accountName, "queueLength", queued)
+
+	select {
+	case <-w.ready:
+		csiMetrics.ObserveAccountOperationWait(operation, time.Since(start))
+		return s.releaseFunc(key), nil
+	case <-ctx.Done():
+		s.Lock()
+		granted := true
+		for i, waiter := range q.waiters {
+			if waiter == w {
+				q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+				granted = false
+				break
+			}
+		}
+		s.Unlock()
+		if granted {
+			// the slot was handed over at the same time, pass it on
+			s.release(key)
+		} else {
+			csiMetrics.AddAccountOperationQueueDepth(operation, -1)
+		}
+		csiMetrics.ObserveAccountOperationWait(operation, time.Since(start))
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (s *accountScheduler) releaseFunc(key string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.release(key) })
+	}
+}
+
+// release hands over the in-flight slot to the first waiter, or frees it if there is no waiter
+func (s *accountScheduler) release(key string) {
+	s.Lock()
+	defer s.Unlock()
+	q, ok := s.accounts[key]
+	if !ok {
+		return
+	}
+	if len(q.waiters) > 0 {
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		csiMetrics.AddAccountOperationQueueDepth(w.operation, -1)
+		close(w.ready)
+		return
+	}
+	q.inFlight--
+	if q.inFlight <= 0 {
+		delete(s.accounts, key)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// queued returns the number of operations waiting on the account
+func (s *accountScheduler) queued(accountName string) int {
+	s.Lock()
+	defer s.Unlock()
+	if q, ok := s.accounts[accountName]; ok {
+		return len(q.waiters)
+	}
+	return 0
+}
+
+func TestAccountSchedulerDisabled(t *testing.T) {
+	ctx := context.Background()
+	for _, s := range []*accountScheduler{nil, newAccountScheduler(0, 0)} {
+		for i := 0; i < 10; i++ {
+			release, err := s.acquire(ctx, "account", "create_volume")
+			require.NoError(t, err)
+			defer release()
+		}
+	}
+	// empty account name is not limited
+	s := newAccountScheduler(1, 0)
+	for i := 0; i < 2; i++ {
+		_, err := s.acquire(ctx, "", "create_volume")
+		require.NoError(t, err)
+	}
+}
+
+func TestAccountSchedulerQueueFull(t *testing.T) {
+	ctx := context.Background()
+	s := newAccountScheduler(1, 1)
+
+	release, err := s.acquire(ctx, "account", "create_volume")
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		r, err := s.acquire(ctx, "account", "expand_volume")
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return s.queued("account") == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	_, err = s.acquire(ctx, "account", "delete_volume")
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.ErrorContains(t, err, "too many operations on storage account(account): 1 in flight, 1 queued, delete_volume would be retried later")
+
+	// other accounts are not affected, account name is case insensitive
+	r, err := s.acquire(ctx, "account2", "delete_volume")
+	require.NoError(t, err)
+	r()
+	_, err = s.acquire(ctx, "ACCOUNT", "delete_volume")
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	release()
+	// releasing twice should not free another slot
+	release()
+	require.NoError(t, <-done)
+	assert.Empty(t, s.accounts)
+}
+
+func TestAccountSchedulerFIFO(t *testing.T) {
+	ctx := context.Background()
+	s := newAccountScheduler(1, 10)
+	release, err := s.acquire(ctx, "account", "create_volume")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := s.acquire(ctx, "account", "create_volume")
+			assert.NoError(t, err)
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			r()
+		}(i)
+		// make sure waiters are queued in order
+		require.Eventually(t, func() bool { return s.queued("account") == i+1 }, 5*time.Second, 10*time.Millisecond)
+	}
+	release()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+	assert.Empty(t, s.accounts)
+}
+
+func TestAccountSchedulerContextCanceled(t *testing.T) {
+	s := newAccountScheduler(1, 10)
+	release, err := s.acquire(context.Background(), "account", "create_volume")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx, "account", "create_volume")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, 0, s.queued("account"))
+
+	release()
+	assert.Empty(t, s.accounts)
+}
+
+func TestControllerOperationsAbortedWhenAccountQueueFull(t *testing.T) {
+	d := NewFakeDriver()
+	d.accountScheduler = newAccountScheduler(1, 0)
+	// occupy the only in-flight slot of the account
+	release, err := d.accountScheduler.acquire(context.Background(), "f5713de20cde511e8ba4900", "create_volume")
+	require.NoError(t, err)
+	defer release()
+
+	_, err = d.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{
+		VolumeId: "rg#f5713de20cde511e8ba4900#pvc-file-dynamic-17e43f84-f474-11e8-acd0-000d3a00df41",
+		Secrets:  createStorageAccountSecret("f5713de20cde511e8ba4900", "key"),
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = d.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{
+		VolumeId:      "rg#f5713de20cde511e8ba4900#pvc-file-dynamic-17e43f84-f474-11e8-acd0-000d3a00df41",
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 10 * 1024 * 1024 * 1024},
+		Secrets:       createStorageAccountSecret("f5713de20cde511e8ba4900", "key"),
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
@@ -0,0 +1,615 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/accountclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/blobservicepropertiesclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileservicepropertiesclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/privatednszonegroupclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/privateendpointclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/privatezoneclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/subnetclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/virtualnetworklinkclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
+)
+
+// armRequestLimiter is the client side ARM request budget shared by all controller operations:
+// requests of the storage, network and private DNS clients wait for a token from the per subscription token bucket,
+// and identical concurrent read calls (list accounts, list keys, get properties) are coalesced into one request.
+type armRequestLimiter struct {
+	sync.Mutex
+	// qps <= 0 means no rate limit, requests are only coalesced
+	qps   float64
+	burst int
+	// limiters keyed by lower case subscription ID
+	limiters map[string]*rate.Limiter
+	group    singleflight.Group
+}
+
+// armReadTimeout bounds a coalesced read call, which does not follow the cancellation of any single caller
+const armReadTimeout = 2 * time.Minute
+
+func newARMRequestLimiter(qps float64, burst int) *armRequestLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &armRequestLimiter{
+		qps:      qps,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// wait blocks until the request is allowed by the budget of the subscription
+func (l *armRequestLimiter) wait(ctx context.Context, subsID, operation string) error {
+	if l.qps <= 0 {
+		return nil
+	}
+	l.Lock()
+	limiter, ok := l.limiters[strings.ToLower(subsID)]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.qps), l.burst)
+		l.limiters[strings.ToLower(subsID)] = limiter
+	}
+	l.Unlock()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	waitTime := time.Since(start)
+	csiMetrics.ObserveARMRequestWait(operation, waitTime)
+	if err != nil {
+		return fmt.Errorf("client side ARM request budget of subscription(%s) exceeded for %s: %w", subsID, operation, err)
+	}
+	if waitTime > time.Second {
+		klog.FromContext(ctx).V(4).Info("waited for client side ARM request budget of subscription", "operation", operation, "waitTime", waitTime, "subsID", subsID)
+	}
+	return nil
+}
+
+// read coalesces identical concurrent read calls, only one of them waits for the budget and calls ARM.
+// The shared call runs on a context which is not canceled with the caller that started it, bounded by armReadTimeout,
+// so that the other callers are not failed by its cancellation, each caller still returns once its own context is done.
+// The result is shared by all callers, callers copy it before returning it to their own callers.
+func (l *armRequestLimiter) read(ctx context.Context, subsID, operation, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, span := startARMSpan(ctx, subsID, operation, key)
+	sharedCtx := context.WithoutCancel(ctx)
+	// leader is set when fn runs for this caller, res.Shared is also true for the leader of a coalesced group
+	var leader bool
+	ch := l.group.DoChan(fmt.Sprintf("%s/%s/%s", operation, strings.ToLower(subsID), strings.ToLower(key)), func() (interface{}, error) {
+		leader = true
+		sharedCtx, cancel := context.WithTimeout(sharedCtx, armReadTimeout)
+		defer cancel()
+		if err := l.wait(sharedCtx, subsID, operation); err != nil {
+			return nil, err
+		}
+		return fn(sharedCtx)
+	})
+
+	var result interface{}
+	var err error
+	var coalesced bool
+	select {
+	case res := <-ch:
+		result, err, coalesced = res.Val, res.Err, res.Shared && !leader
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if coalesced {
+		csiMetrics.RecordARMRequestCoalesced(operation)
+	}
+	span.SetAttributes(attribute.Bool("coalesced", coalesced))
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+// startARMSpan starts the span of an ARM request, key identifies the resource, e.g. <resourceGroup>/<account>
+func startARMSpan(ctx context.Context, subsID, operation, key string) (context.Context, trace.Span) {
+	return tracing.StartSpan(ctx, "ARM "+operation,
+		attribute.String("subscription", subsID),
+		attribute.String("resource", key))
+}
+
+// rateLimitedClientFactory puts the ARM request budget in front of the storage account, file share, service properties,
+// subnet, private endpoint and private DNS clients used by the driver and by the storage account repository
+type rateLimitedClientFactory struct {
+	azclient.ClientFactory
+	// defaultSubsID is the subscription of the clients which are not created for a specific subscription
+	defaultSubsID string
+	limiter       *armRequestLimiter
+}
+
+func newRateLimitedClientFactory(factory azclient.ClientFactory, defaultSubsID string, limiter *armRequestLimiter) azclient.ClientFactory {
+	if factory == nil || limiter == nil {
+		return factory
+	}
+	if _, ok := factory.(*rateLimitedClientFactory); ok {
+		return factory
+	}
+	return &rateLimitedClientFactory{ClientFactory: factory, defaultSubsID: defaultSubsID, limiter: limiter}
+}
+
+func (f *rateLimitedClientFactory) GetAccountClient() accountclient.Interface {
+	client := f.ClientFactory.GetAccountClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedAccountClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetAccountClientForSub(subscriptionID string) (accountclient.Interface, error) {
+	client, err := f.ClientFactory.GetAccountClientForSub(subscriptionID)
+	if err != nil || client == nil {
+		return client, err
+	}
+	return &rateLimitedAccountClient{Interface: client, subsID: subscriptionID, limiter: f.limiter}, nil
+}
+
+func (f *rateLimitedClientFactory) GetFileShareClient() fileshareclient.Interface {
+	client := f.ClientFactory.GetFileShareClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedFileShareClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetFileShareClientForSub(subscriptionID string) (fileshareclient.Interface, error) {
+	client, err := f.ClientFactory.GetFileShareClientForSub(subscriptionID)
+	if err != nil || client == nil {
+		return client, err
+	}
+	return &rateLimitedFileShareClient{Interface: client, subsID: subscriptionID, limiter: f.limiter}, nil
+}
+
+// newRateLimitedRepository returns the storage account repository whose compute and network clients share the ARM request budget,
+// the repository is rebuilt since it keeps the subnet and file service properties clients of the factories it is created with
+func newRateLimitedRepository(cloud *storage.AccountRepo, limiter *armRequestLimiter) (*storage.AccountRepo, error) {
+	computeClientFactory := newRateLimitedClientFactory(cloud.ComputeClientFactory, cloud.SubscriptionID, limiter)
+	if cloud.NetworkClientFactory == nil {
+		cloud.ComputeClientFactory = computeClientFactory
+		return cloud, nil
+	}
+	networkSubsID := cloud.NetworkResourceSubscriptionID
+	if networkSubsID == "" {
+		networkSubsID = cloud.SubscriptionID
+	}
+	networkClientFactory := newRateLimitedClientFactory(cloud.NetworkClientFactory, networkSubsID, limiter)
+	return storage.NewRepository(cloud.Config, cloud.Environment, cloud.AuthProvider, computeClientFactory, networkClientFactory)
+}
+
+type rateLimitedAccountClient struct {
+	accountclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedAccountClient) List(ctx context.Context, resourceGroupName string) ([]*armstorage.Account, error) {
+	result, err := c.limiter.read(ctx, c.subsID, "storage_account_list", resourceGroupName, func(ctx context.Context) (interface{}, error) {
+		return c.Interface.List(ctx, resourceGroupName)
+	})
+	accounts, _ := result.([]*armstorage.Account)
+	return copyEach(accounts), err
+}
+
+func (c *rateLimitedAccountClient) ListKeys(ctx context.Context, resourceGroupName string, accountName string) ([]*armstorage.AccountKey, error) {
+	result, err := c.limiter.read(ctx, c.subsID, "storage_account_list_keys", resourceGroupName+"/"+accountName, func(ctx context.Context) (interface{}, error) {
+		return c.Interface.ListKeys(ctx, resourceGroupName, accountName)
+	})
+	keys, _ := result.([]*armstorage.AccountKey)
+	return copyEach(keys), err
+}
+
+func (c *rateLimitedAccountClient) GetProperties(ctx context.Context, resourceGroupName string, accountName string, options *armstorage.AccountsClientGetPropertiesOptions) (*armstorage.Account, error) {
+	key := resourceGroupName + "/" + accountName
+	if options != nil && options.Expand != nil {
+		key += "/" + string(*options.Expand)
+	}
+	result, err := c.limiter.read(ctx, c.subsID, "storage_account_get_properties", key, func(ctx context.Context) (interface{}, error) {
+		return c.Interface.GetProperties(ctx, resourceGroupName, accountName, options)
+	})
+	account, _ := result.(*armstorage.Account)
+	if account != nil {
+		accountCopy := *account
+		account = &accountCopy
+	}
+	return account, err
+}
+
+// copyEach returns a copy of items in which each element is a shallow copy, so that a caller of a coalesced read
+// could set the top level fields of the returned items without affecting the other callers,
+// nested fields are still shared and must not be modified
+func copyEach[T any](items []*T) []*T {
+	if items == nil {
+		return nil
+	}
+	copied := slices.Clone(items)
+	for i, item := range copied {
+		if item != nil {
+			itemCopy := *item
+			copied[i] = &itemCopy
+		}
+	}
+	return copied
+}
+
+func (c *rateLimitedAccountClient) Create(ctx context.Context, resourceGroupName string, accountName string, resource *armstorage.AccountCreateParameters) (result *armstorage.Account, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "storage_account_create", resourceGroupName+"/"+accountName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "storage_account_create"); err != nil {
+		return nil, err
+	}
+	return c.Interface.Create(ctx, resourceGroupName, accountName, resource)
+}
+
+func (c *rateLimitedAccountClient) Update(ctx context.Context, resourceGroupName string, accountName string, parameters *armstorage.AccountUpdateParameters) (result *armstorage.Account, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "storage_account_update", resourceGroupName+"/"+accountName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "storage_account_update"); err != nil {
+		return nil, err
+	}
+	return c.Interface.Update(ctx, resourceGroupName, accountName, parameters)
+}
+
+func (c *rateLimitedAccountClient) Delete(ctx context.Context, resourceGroupName string, accountName string) (err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "storage_account_delete", resourceGroupName+"/"+accountName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "storage_account_delete"); err != nil {
+		return err
+	}
+	return c.Interface.Delete(ctx, resourceGroupName, accountName)
+}
+
+type rateLimitedFileShareClient struct {
+	fileshareclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedFileShareClient) Get(ctx context.Context, resourceGroupName string, accountName string, resourceName string, option *armstorage.FileSharesClientGetOptions) (result *armstorage.FileShare, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "file_share_get", resourceGroupName+"/"+accountName+"/"+resourceName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "file_share_get"); err != nil {
+		return nil, err
+	}
+	return c.Interface.Get(ctx, resourceGroupName, accountName, resourceName, option)
+}
+
+func (c *rateLimitedFileShareClient) List(ctx context.Context, resourceGroupName string, accountName string, option *armstorage.FileSharesClientListOptions) (result []*armstorage.FileShareItem, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "file_share_list", resourceGroupName+"/"+accountName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "file_share_list"); err != nil {
+		return nil, err
+	}
+	return c.Interface.List(ctx, resourceGroupName, accountName, option)
+}
+
+func (c *rateLimitedFileShareClient) Create(ctx context.Context, resourceGroupName string, resourceName string, parentResourceName string, resource armstorage.FileShare, expand *string) (result *armstorage.FileShare, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "file_share_create", resourceGroupName+"/"+resourceName+"/"+parentResourceName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "file_share_create"); err != nil {
+		return nil, err
+	}
+	return c.Interface.Create(ctx, resourceGroupName, resourceName, parentResourceName, resource, expand)
+}
+
+func (c *rateLimitedFileShareClient) Update(ctx context.Context, resourceGroupName string, resourceName string, parentResourceName string, resource armstorage.FileShare) (result *armstorage.FileShare, err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "file_share_update", resourceGroupName+"/"+resourceName+"/"+parentResourceName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "file_share_update"); err != nil {
+		return nil, err
+	}
+	return c.Interface.Update(ctx, resourceGroupName, resourceName, parentResourceName, resource)
+}
+
+func (c *rateLimitedFileShareClient) Delete(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string, option *armstorage.FileSharesClientDeleteOptions) (err error) {
+	ctx, span := startARMSpan(ctx, c.subsID, "file_share_delete", resourceGroupName+"/"+parentResourceName+"/"+resourceName)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := c.limiter.wait(ctx, c.subsID, "file_share_delete"); err != nil {
+		return err
+	}
+	return c.Interface.Delete(ctx, resourceGroupName, parentResourceName, resourceName, option)
+}
+
+// call waits for the budget of the subscription and calls fn in the span of the ARM request
+func (l *armRequestLimiter) call(ctx context.Context, subsID, operation, key string, fn func(context.Context) error) (err error) {
+	ctx, span := startARMSpan(ctx, subsID, operation, key)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err := l.wait(ctx, subsID, operation); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+func (f *rateLimitedClientFactory) GetFileServicePropertiesClient() fileservicepropertiesclient.Interface {
+	client := f.ClientFactory.GetFileServicePropertiesClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedFileServicePropertiesClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetFileServicePropertiesClientForSub(subscriptionID string) (fileservicepropertiesclient.Interface, error) {
+	client, err := f.ClientFactory.GetFileServicePropertiesClientForSub(subscriptionID)
+	if err != nil || client == nil {
+		return client, err
+	}
+	return &rateLimitedFileServicePropertiesClient{Interface: client, subsID: subscriptionID, limiter: f.limiter}, nil
+}
+
+func (f *rateLimitedClientFactory) GetBlobServicePropertiesClient() blobservicepropertiesclient.Interface {
+	client := f.ClientFactory.GetBlobServicePropertiesClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedBlobServicePropertiesClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetBlobServicePropertiesClientForSub(subscriptionID string) (blobservicepropertiesclient.Interface, error) {
+	client, err := f.ClientFactory.GetBlobServicePropertiesClientForSub(subscriptionID)
+	if err != nil || client == nil {
+		return client, err
+	}
+	return &rateLimitedBlobServicePropertiesClient{Interface: client, subsID: subscriptionID, limiter: f.limiter}, nil
+}
+
+func (f *rateLimitedClientFactory) GetSubnetClient() subnetclient.Interface {
+	client := f.ClientFactory.GetSubnetClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedSubnetClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetPrivateEndpointClient() privateendpointclient.Interface {
+	client := f.ClientFactory.GetPrivateEndpointClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedPrivateEndpointClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetPrivateDNSZoneGroupClient() privatednszonegroupclient.Interface {
+	client := f.ClientFactory.GetPrivateDNSZoneGroupClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedPrivateDNSZoneGroupClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetPrivateZoneClient() privatezoneclient.Interface {
+	client := f.ClientFactory.GetPrivateZoneClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedPrivateZoneClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+func (f *rateLimitedClientFactory) GetVirtualNetworkLinkClient() virtualnetworklinkclient.Interface {
+	client := f.ClientFactory.GetVirtualNetworkLinkClient()
+	if client == nil {
+		return nil
+	}
+	return &rateLimitedVirtualNetworkLinkClient{Interface: client, subsID: f.defaultSubsID, limiter: f.limiter}
+}
+
+type rateLimitedFileServicePropertiesClient struct {
+	fileservicepropertiesclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedFileServicePropertiesClient) Get(ctx context.Context, resourceGroupName string, resourceName string) (result *armstorage.FileServiceProperties, err error) {
+	err = c.limiter.call(ctx, c.subsID, "file_service_properties_get", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, resourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedFileServicePropertiesClient) Set(ctx context.Context, resourceGroupName string, resourceName string, parameters armstorage.FileServiceProperties) (result *armstorage.FileServiceProperties, err error) {
+	err = c.limiter.call(ctx, c.subsID, "file_service_properties_set", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Set(ctx, resourceGroupName, resourceName, parameters)
+		return err
+	})
+	return result, err
+}
+
+type rateLimitedBlobServicePropertiesClient struct {
+	blobservicepropertiesclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedBlobServicePropertiesClient) Get(ctx context.Context, resourceGroupName string, resourceName string) (result *armstorage.BlobServiceProperties, err error) {
+	err = c.limiter.call(ctx, c.subsID, "blob_service_properties_get", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, resourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedBlobServicePropertiesClient) Set(ctx context.Context, resourceGroupName string, resourceName string, parameters armstorage.BlobServiceProperties) (result *armstorage.BlobServiceProperties, err error) {
+	err = c.limiter.call(ctx, c.subsID, "blob_service_properties_set", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Set(ctx, resourceGroupName, resourceName, parameters)
+		return err
+	})
+	return result, err
+}
+
+type rateLimitedSubnetClient struct {
+	subnetclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedSubnetClient) Get(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string, expand *string) (result *armnetwork.Subnet, err error) {
+	err = c.limiter.call(ctx, c.subsID, "subnet_get", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, parentResourceName, resourceName, expand)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedSubnetClient) List(ctx context.Context, resourceGroupName string, parentResourceName string) (result []*armnetwork.Subnet, err error) {
+	err = c.limiter.call(ctx, c.subsID, "subnet_list", resourceGroupName+"/"+parentResourceName, func(ctx context.Context) error {
+		result, err = c.Interface.List(ctx, resourceGroupName, parentResourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedSubnetClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string, resourceParam armnetwork.Subnet) (result *armnetwork.Subnet, err error) {
+	err = c.limiter.call(ctx, c.subsID, "subnet_create_or_update", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.CreateOrUpdate(ctx, resourceGroupName, parentResourceName, resourceName, resourceParam)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedSubnetClient) Delete(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string) error {
+	return c.limiter.call(ctx, c.subsID, "subnet_delete", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		return c.Interface.Delete(ctx, resourceGroupName, parentResourceName, resourceName)
+	})
+}
+
+type rateLimitedPrivateEndpointClient struct {
+	privateendpointclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedPrivateEndpointClient) Get(ctx context.Context, resourceGroupName string, resourceName string, expand *string) (result *armnetwork.PrivateEndpoint, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_endpoint_get", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, resourceName, expand)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedPrivateEndpointClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, resourceName string, resourceParam armnetwork.PrivateEndpoint) (result *armnetwork.PrivateEndpoint, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_endpoint_create_or_update", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.CreateOrUpdate(ctx, resourceGroupName, resourceName, resourceParam)
+		return err
+	})
+	return result, err
+}
+
+type rateLimitedPrivateDNSZoneGroupClient struct {
+	privatednszonegroupclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedPrivateDNSZoneGroupClient) Get(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string) (result *armnetwork.PrivateDNSZoneGroup, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_dns_zone_group_get", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, parentResourceName, resourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedPrivateDNSZoneGroupClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string, resourceParam armnetwork.PrivateDNSZoneGroup) (result *armnetwork.PrivateDNSZoneGroup, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_dns_zone_group_create_or_update", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.CreateOrUpdate(ctx, resourceGroupName, parentResourceName, resourceName, resourceParam)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedPrivateDNSZoneGroupClient) Delete(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string) error {
+	return c.limiter.call(ctx, c.subsID, "private_dns_zone_group_delete", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		return c.Interface.Delete(ctx, resourceGroupName, parentResourceName, resourceName)
+	})
+}
+
+type rateLimitedPrivateZoneClient struct {
+	privatezoneclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedPrivateZoneClient) Get(ctx context.Context, resourceGroupName string, resourceName string) (result *armprivatedns.PrivateZone, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_zone_get", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, resourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedPrivateZoneClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, resourceName string, resourceParam armprivatedns.PrivateZone) (result *armprivatedns.PrivateZone, err error) {
+	err = c.limiter.call(ctx, c.subsID, "private_zone_create_or_update", resourceGroupName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.CreateOrUpdate(ctx, resourceGroupName, resourceName, resourceParam)
+		return err
+	})
+	return result, err
+}
+
+type rateLimitedVirtualNetworkLinkClient struct {
+	virtualnetworklinkclient.Interface
+	subsID  string
+	limiter *armRequestLimiter
+}
+
+func (c *rateLimitedVirtualNetworkLinkClient) Get(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string) (result *armprivatedns.VirtualNetworkLink, err error) {
+	err = c.limiter.call(ctx, c.subsID, "virtual_network_link_get", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.Get(ctx, resourceGroupName, parentResourceName, resourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedVirtualNetworkLinkClient) List(ctx context.Context, resourceGroupName string, parentResourceName string) (result []*armprivatedns.VirtualNetworkLink, err error) {
+	err = c.limiter.call(ctx, c.subsID, "virtual_network_link_list", resourceGroupName+"/"+parentResourceName, func(ctx context.Context) error {
+		result, err = c.Interface.List(ctx, resourceGroupName, parentResourceName)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedVirtualNetworkLinkClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string, resourceParam armprivatedns.VirtualNetworkLink) (result *armprivatedns.VirtualNetworkLink, err error) {
+	err = c.limiter.call(ctx, c.subsID, "virtual_network_link_create_or_update", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		result, err = c.Interface.CreateOrUpdate(ctx, resourceGroupName, parentResourceName, resourceName, resourceParam)
+		return err
+	})
+	return result, err
+}
+
+func (c *rateLimitedVirtualNetworkLinkClient) Delete(ctx context.Context, resourceGroupName string, parentResourceName string, resourceName string) error {
+	return c.limiter.call(ctx, c.subsID, "virtual_network_link_delete", resourceGroupName+"/"+parentResourceName+"/"+resourceName, func(ctx context.Context) error {
+		return c.Interface.Delete(ctx, resourceGroupName, parentResourceName, resourceName)
+	})
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/accountclient/mock_accountclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileservicepropertiesclient/mock_fileservicepropertiesclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient/mock_fileshareclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/mock_azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/subnetclient/mock_subnetclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
+)
+
+func TestNewRateLimitedClientFactory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	limiter := newARMRequestLimiter(10, 10)
+
+	assert.Nil(t, newRateLimitedClientFactory(nil, "subsID", limiter))
+	factory := mock_azclient.NewMockClientFactory(ctrl)
+	assert.Equal(t, factory, newRateLimitedClientFactory(factory, "subsID", nil))
+
+	wrapped := newRateLimitedClientFactory(factory, "subsID", limiter)
+	_, ok := wrapped.(*rateLimitedClientFactory)
+	require.True(t, ok)
+	// wrapping twice should not add another limiter
+	assert.Equal(t, wrapped, newRateLimitedClientFactory(wrapped, "subsID", limiter))
+
+	factory.EXPECT().GetAccountClient().Return(mock_accountclient.NewMockInterface(ctrl)).Times(1)
+	factory.EXPECT().GetAccountClientForSub("otherSubsID").Return(mock_accountclient.NewMockInterface(ctrl), nil).Times(1)
+	factory.EXPECT().GetFileShareClient().Return(mock_fileshareclient.NewMockInterface(ctrl)).Times(1)
+	factory.EXPECT().GetFileShareClientForSub("otherSubsID").Return(mock_fileshareclient.NewMockInterface(ctrl), nil).Times(1)
+
+	accountClient, ok := wrapped.GetAccountClient().(*rateLimitedAccountClient)
+	require.True(t, ok)
+	assert.Equal(t, "subsID", accountClient.subsID)
+	client, err := wrapped.GetAccountClientForSub("otherSubsID")
+	require.NoError(t, err)
+	assert.Equal(t, "otherSubsID", client.(*rateLimitedAccountClient).subsID)
+	fileShareClient, ok := wrapped.GetFileShareClient().(*rateLimitedFileShareClient)
+	require.True(t, ok)
+	assert.Equal(t, "subsID", fileShareClient.subsID)
+	shareClient, err := wrapped.GetFileShareClientForSub("otherSubsID")
+	require.NoError(t, err)
+	assert.Equal(t, "otherSubsID", shareClient.(*rateLimitedFileShareClient).subsID)
+}
+
+func TestARMRequestLimiterCoalescesReads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	release := make(chan struct{})
+	var calls int32
+	mockAccountClient := mock_accountclient.NewMockInterface(ctrl)
+	mockAccountClient.EXPECT().ListKeys(gomock.Any(), "rg", "account").DoAndReturn(
+		func(_ context.Context, _, _ string) ([]*armstorage.AccountKey, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return []*armstorage.AccountKey{{KeyName: ptr.To("key1"), Value: ptr.To("value")}}, nil
+		}).Times(1)
+	client := &rateLimitedAccountClient{Interface: mockAccountClient, subsID: "subsID", limiter: newARMRequestLimiter(0, 0)}
+
+	coalescedBefore := armRequestCoalescedCount(t, "storage_account_list_keys")
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([][]*armstorage.AccountKey, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys, err := client.ListKeys(ctx, "rg", "account")
+			assert.NoError(t, err)
+			results[i] = keys
+		}(i)
+	}
+	// let all callers join the in-flight request before it returns
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, keys := range results {
+		require.Len(t, keys, 1)
+		assert.Equal(t, "value", *keys[0].Value)
+	}
+	// the caller which made the request is not counted as coalesced
+	assert.Equal(t, float64(callers-1), armRequestCoalescedCount(t, "storage_account_list_keys")-coalescedBefore)
+}
+
+// armRequestCoalescedCount returns the coalesced ARM request count of the operation
+func armRequestCoalescedCount(t *testing.T, operation string) float64 {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var count float64
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_arm_request_coalesced_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "operation" && label.GetValue() == operation {
+					count += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestARMRequestLimiterCallerCancellation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	release := make(chan struct{})
+	mockAccountClient := mock_accountclient.NewMockInterface(ctrl)
+	mockAccountClient.EXPECT().ListKeys(gomock.Any(), "rg", "account").DoAndReturn(
+		func(ctx context.Context, _, _ string) ([]*armstorage.AccountKey, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return []*armstorage.AccountKey{{KeyName: ptr.To("key1"), Value: ptr.To("value")}}, nil
+		}).Times(1)
+	client := &rateLimitedAccountClient{Interface: mockAccountClient, subsID: "subsID", limiter: newARMRequestLimiter(0, 0)}
+
+	// the caller which starts the request is canceled, the caller joining it still gets the result
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.ListKeys(firstCtx, "rg", "account")
+		firstErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	secondResult := make(chan []*armstorage.AccountKey, 1)
+	go func() {
+		keys, err := client.ListKeys(context.Background(), "rg", "account")
+		assert.NoError(t, err)
+		secondResult <- keys
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+	keys := <-secondResult
+	require.Len(t, keys, 1)
+	assert.Equal(t, "value", *keys[0].Value)
+}
+
+func TestCopyEach(t *testing.T) {
+	assert.Nil(t, copyEach[armstorage.AccountKey](nil))
+	keys := []*armstorage.AccountKey{{KeyName: ptr.To("key1")}, nil}
+	copied := copyEach(keys)
+	require.Len(t, copied, 2)
+	assert.Nil(t, copied[1])
+	copied[0].KeyName = ptr.To("key2")
+	copied[1] = &armstorage.AccountKey{}
+	assert.Equal(t, "key1", *keys[0].KeyName)
+	assert.Nil(t, keys[1])
+}
+
+func TestARMRequestLimiterDoesNotCoalesceDifferentReads(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	server.AddAccount("subsID", "rg", "account1", "eastus")
+	server.AddAccount("subsID", "rg", "account2", "eastus")
+	accountClient, err := server.NewAccountClient("subsID")
+	require.NoError(t, err)
+	client := &rateLimitedAccountClient{Interface: accountClient, subsID: "subsID", limiter: newARMRequestLimiter(0, 0)}
+
+	account, err := client.GetProperties(ctx, "rg", "account1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "account1", *account.Name)
+	account, err = client.GetProperties(ctx, "rg", "account2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "account2", *account.Name)
+	accounts, err := client.List(ctx, "rg")
+	require.NoError(t, err)
+	assert.Len(t, accounts, 2)
+
+	_, err = client.GetProperties(ctx, "rg", "notexist", nil)
+	assert.Error(t, err)
+}
+
+func TestARMRequestLimiterWait(t *testing.T) {
+	ctx := context.Background()
+	limiter := newARMRequestLimiter(10, 1)
+
+	// burst of subsID1 is used up by the first request, the second request waits for about 1/qps
+	require.NoError(t, limiter.wait(ctx, "subsID1", "file_share_create"))
+	start := time.Now()
+	require.NoError(t, limiter.wait(ctx, "subsID1", "file_share_create"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// budget is per subscription, subscription ID is case insensitive
+	start = time.Now()
+	require.NoError(t, limiter.wait(ctx, "subsID2", "file_share_create"))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.Len(t, limiter.limiters, 2)
+	require.NoError(t, limiter.wait(ctx, "SUBSID2", "file_share_create"))
+	assert.Len(t, limiter.limiters, 2)
+
+	// request is failed if the context deadline is earlier than the next token
+	slowLimiter := newARMRequestLimiter(0.001, 1)
+	require.NoError(t, slowLimiter.wait(ctx, "subsID", "storage_account_create"))
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorContains(t, slowLimiter.wait(timeoutCtx, "subsID", "storage_account_create"), "client side ARM request budget of subscription(subsID) exceeded")
+
+	// qps 0 disables the rate limit
+	noLimit := newARMRequestLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, noLimit.wait(ctx, "subsID", "file_share_get"))
+	}
+	assert.Empty(t, noLimit.limiters)
+}
+
+func TestRateLimitedFileShareClient(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	server.AddAccount("subsID", "rg", "account", "eastus")
+	fileShareClient, err := server.NewFileShareClient("subsID")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	factory := mock_azclient.NewMockClientFactory(ctrl)
+	factory.EXPECT().GetFileShareClientForSub("subsID").Return(fileShareClient, nil).AnyTimes()
+	cloud := &storage.AccountRepo{
+		ComputeClientFactory: newRateLimitedClientFactory(factory, "subsID", newARMRequestLimiter(100, 10)),
+	}
+	mgmtClient, err := newAzureFileMgmtClient(cloud, &storage.AccountOptions{SubscriptionID: "subsID", ResourceGroup: "rg", Name: "account"})
+	require.NoError(t, err)
+
+	require.NoError(t, mgmtClient.CreateFileShare(ctx, &ShareOptions{Name: "share", RequestGiB: 10}))
+	quota, err := mgmtClient.GetFileShareQuota(ctx, "share")
+	require.NoError(t, err)
+	assert.Equal(t, 10, quota)
+	require.NoError(t, mgmtClient.ResizeFileShare(ctx, "share", 20))
+	quota, err = mgmtClient.GetFileShareQuota(ctx, "share")
+	require.NoError(t, err)
+	assert.Equal(t, 20, quota)
+	require.NoError(t, mgmtClient.DeleteFileShare(ctx, "share"))
+	assert.Empty(t, server.Shares("account"))
+}
+
+func TestNewRateLimitedRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	limiter := newARMRequestLimiter(0.001, 1)
+
+	computeClientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	networkClientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	subnetClient := mock_subnetclient.NewMockInterface(ctrl)
+	networkClientFactory.EXPECT().GetSubnetClient().Return(subnetClient).AnyTimes()
+	subnetClient.EXPECT().Get(gomock.Any(), "rg", "vnet", "subnet", nil).Return(&armnetwork.Subnet{}, nil).Times(1)
+	computeClientFactory.EXPECT().GetFileServicePropertiesClientForSub("subsID").Return(mock_fileservicepropertiesclient.NewMockInterface(ctrl), nil).Times(1)
+
+	cloud := &storage.AccountRepo{ComputeClientFactory: computeClientFactory, NetworkClientFactory: networkClientFactory}
+	cloud.SubscriptionID = "subsID"
+	cloud.NetworkResourceSubscriptionID = "networkSubsID"
+	repo, err := newRateLimitedRepository(cloud, limiter)
+	require.NoError(t, err)
+	assert.Equal(t, "subsID", repo.SubscriptionID)
+
+	fileServicePropertiesClient, err := repo.ComputeClientFactory.GetFileServicePropertiesClientForSub("subsID")
+	require.NoError(t, err)
+	assert.Equal(t, "subsID", fileServicePropertiesClient.(*rateLimitedFileServicePropertiesClient).subsID)
+
+	// subnet requests use the budget of the network subscription
+	subnetClientWrapped, ok := repo.NetworkClientFactory.GetSubnetClient().(*rateLimitedSubnetClient)
+	require.True(t, ok)
+	assert.Equal(t, "networkSubsID", subnetClientWrapped.subsID)
+	_, err = subnetClientWrapped.Get(ctx, "rg", "vnet", "subnet", nil)
+	require.NoError(t, err)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = subnetClientWrapped.Get(timeoutCtx, "rg", "vnet", "subnet", nil)
+	assert.ErrorContains(t, err, "client side ARM request budget of subscription(networkSubsID) exceeded for subnet_get")
+
+	// only the compute client factory is wrapped when there is no network client factory
+	cloud = &storage.AccountRepo{ComputeClientFactory: computeClientFactory}
+	repo, err = newRateLimitedRepository(cloud, limiter)
+	require.NoError(t, err)
+	assert.Equal(t, cloud, repo)
+	_, ok = repo.ComputeClientFactory.(*rateLimitedClientFactory)
+	assert.True(t, ok)
+}
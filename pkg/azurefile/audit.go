@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/audit"
+)
+
+const redactedValue = "<redacted>"
+
+// sensitiveParameterPatterns are the lower case substrings of parameter keys whose values are redacted in audit records
+var sensitiveParameterPatterns = []string{"key", "sas", "token", "password", "credential"}
+
+// auditedOperations are the mutating operations written into the audit log
+var auditedOperations = map[string]bool{
+	"CreateVolume":           true,
+	"DeleteVolume":           true,
+	"CreateSnapshot":         true,
+	"DeleteSnapshot":         true,
+	"ControllerExpandVolume": true,
+	"NodeStageVolume":        true,
+	"NodeUnstageVolume":      true,
+}
+
+// auditInterceptor writes an audit record of each mutating operation once it finishes
+func (d *Driver) auditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	operation := path.Base(info.FullMethod)
+	if d.auditSink == nil || !auditedOperations[operation] {
+		return handler(ctx, req)
+	}
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	record := newAuditRecord(operation, req, resp, err)
+	record.Time = start.UTC()
+	record.DurationMs = time.Since(start).Milliseconds()
+	if werr := d.auditSink.Write(record); werr != nil {
+		klog.FromContext(ctx).Error(werr, "failed to write audit record", "operation", operation)
+	}
+	return resp, err
+}
+
+// newAuditRecord creates the audit record of the operation, secrets in the request are never included
+func newAuditRecord(operation string, req, resp interface{}, err error) *audit.Record {
+	record := &audit.Record{Operation: operation, Code: status.Code(err).String()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	switch r := req.(type) {
+	case *csi.CreateVolumeRequest:
+		record.Parameters = redactParameters(r.GetParameters())
+		record.PVCName, record.PVCNamespace, record.PVName = getPVCInfo(r.GetParameters())
+		if r.GetCapacityRange() != nil {
+			record.Parameters = setAuditParameter(record.Parameters, "requiredBytes", strconv.FormatInt(r.GetCapacityRange().GetRequiredBytes(), 10))
+		}
+		if v, ok := resp.(*csi.CreateVolumeResponse); ok && v.GetVolume() != nil {
+			record.VolumeID = v.GetVolume().GetVolumeId()
+		}
+	case *csi.DeleteVolumeRequest:
+		record.VolumeID = r.GetVolumeId()
+	case *csi.ControllerExpandVolumeRequest:
+		record.VolumeID = r.GetVolumeId()
+		if r.GetCapacityRange() != nil {
+			record.Parameters = setAuditParameter(record.Parameters, "requiredBytes", strconv.FormatInt(r.GetCapacityRange().GetRequiredBytes(), 10))
+		}
+	case *csi.CreateSnapshotRequest:
+		record.VolumeID = r.GetSourceVolumeId()
+		record.Parameters = redactParameters(r.GetParameters())
+		record.Parameters = setAuditParameter(record.Parameters, "name", r.GetName())
+		if v, ok := resp.(*csi.CreateSnapshotResponse); ok && v.GetSnapshot() != nil {
+			record.SnapshotID = v.GetSnapshot().GetSnapshotId()
+		}
+	case *csi.DeleteSnapshotRequest:
+		record.SnapshotID = r.GetSnapshotId()
+	case *csi.NodeStageVolumeRequest:
+		record.VolumeID = r.GetVolumeId()
+		record.Path = r.GetStagingTargetPath()
+		record.Parameters = redactParameters(r.GetVolumeContext())
+		record.PVCName, record.PVCNamespace, record.PVName = getPVCInfo(r.GetVolumeContext())
+	case *csi.NodeUnstageVolumeRequest:
+		record.VolumeID = r.GetVolumeId()
+		record.Path = r.GetStagingTargetPath()
+	}
+
+	if record.SnapshotID != "" {
+		if rg, account, share, snapshot, _, perr := GetInfoFromSnapshotID(record.SnapshotID); perr == nil {
+			record.ResourceGroup, record.Account, record.Share, record.Snapshot = rg, account, share, snapshot
+		}
+	}
+	if record.Account == "" && record.VolumeID != "" {
+		if rg, account, share, _, _, _, perr := GetFileShareInfo(record.VolumeID); perr == nil {
+			record.ResourceGroup, record.Account, record.Share = rg, account, share
+		}
+	}
+	return record
+}
+
+func getPVCInfo(parameters map[string]string) (pvcName, pvcNamespace, pvName string) {
+	for k, v := range parameters {
+		switch strings.ToLower(k) {
+		case pvcNameKey:
+			pvcName = v
+		case pvcNamespaceKey:
+			pvcNamespace = v
+		case pvNameKey:
+			pvName = v
+		}
+	}
+	return pvcName, pvcNamespace, pvName
+}
+
+// redactParameters returns a copy of parameters with the values of sensitive keys redacted
+func redactParameters(parameters map[string]string) map[string]string {
+	if len(parameters) == 0 {
+		return nil
+	}
+	redacted := make(map[string]string, len(parameters))
+	for k, v := range parameters {
+		redacted[k] = v
+		lowerKey := strings.ToLower(k)
+		for _, pattern := range sensitiveParameterPatterns {
+			if strings.Contains(lowerKey, pattern) {
+				redacted[k] = redactedValue
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+func setAuditParameter(parameters map[string]string, key, value string) map[string]string {
+	if parameters == nil {
+		parameters = map[string]string{}
+	}
+	parameters[key] = value
+	return parameters
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/audit"
+)
+
+func TestNewAuditRecord(t *testing.T) {
+	tests := []struct {
+		desc     string
+		req      interface{}
+		resp     interface{}
+		err      error
+		expected audit.Record
+	}{
+		{
+			desc: "CreateVolume",
+			req: &csi.CreateVolumeRequest{
+				Name:          "pvc-123",
+				CapacityRange: &csi.CapacityRange{RequiredBytes: 1024},
+				Parameters: map[string]string{
+					"skuName":           "Premium_LRS",
+					pvcNameKey:          "pvc",
+					pvcNamespaceKey:     "default",
+					pvNameKey:           "pvc-123",
+					"storageAccountKey": "secret",
+					"csi.storage.k8s.io/serviceAccount.tokens": "token",
+				},
+				Secrets: map[string]string{"azurestorageaccountkey": "secret"},
+			},
+			resp: &csi.CreateVolumeResponse{Volume: &csi.Volume{VolumeId: "rg#account#pvc-123###default"}},
+			expected: audit.Record{
+				Operation: "CreateVolume", VolumeID: "rg#account#pvc-123###default",
+				PVCName: "pvc", PVCNamespace: "default", PVName: "pvc-123",
+				ResourceGroup: "rg", Account: "account", Share: "pvc-123",
+				Parameters: map[string]string{
+					"skuName":           "Premium_LRS",
+					pvcNameKey:          "pvc",
+					pvcNamespaceKey:     "default",
+					pvNameKey:           "pvc-123",
+					"storageAccountKey": redactedValue,
+					"csi.storage.k8s.io/serviceAccount.tokens": redactedValue,
+					"requiredBytes": "1024",
+				},
+				Code: "OK",
+			},
+		},
+		{
+			desc: "DeleteVolume failure",
+			req:  &csi.DeleteVolumeRequest{VolumeId: "rg#account#share", Secrets: map[string]string{"azurestorageaccountkey": "secret"}},
+			err:  status.Error(codes.Internal, "delete failed"),
+			expected: audit.Record{
+				Operation: "DeleteVolume", VolumeID: "rg#account#share",
+				ResourceGroup: "rg", Account: "account", Share: "share",
+				Code: "Internal", Error: "rpc error: code = Internal desc = delete failed",
+			},
+		},
+		{
+			desc: "ControllerExpandVolume",
+			req:  &csi.ControllerExpandVolumeRequest{VolumeId: "rg#account#share", CapacityRange: &csi.CapacityRange{RequiredBytes: 2048}},
+			expected: audit.Record{
+				Operation: "ControllerExpandVolume", VolumeID: "rg#account#share",
+				ResourceGroup: "rg", Account: "account", Share: "share",
+				Parameters: map[string]string{"requiredBytes": "2048"},
+				Code:       "OK",
+			},
+		},
+		{
+			desc: "CreateSnapshot",
+			req:  &csi.CreateSnapshotRequest{SourceVolumeId: "rg#account#share", Name: "snapshot-1"},
+			resp: &csi.CreateSnapshotResponse{Snapshot: &csi.Snapshot{SnapshotId: "rg#account#share####2025-09-05T07:51:41.0000000Z"}},
+			expected: audit.Record{
+				Operation: "CreateSnapshot", VolumeID: "rg#account#share", SnapshotID: "rg#account#share####2025-09-05T07:51:41.0000000Z",
+				ResourceGroup: "rg", Account: "account", Share: "share", Snapshot: "2025-09-05T07:51:41.0000000Z",
+				Parameters: map[string]string{"name": "snapshot-1"},
+				Code:       "OK",
+			},
+		},
+		{
+			desc: "DeleteSnapshot with invalid snapshot ID",
+			req:  &csi.DeleteSnapshotRequest{SnapshotId: "invalid"},
+			expected: audit.Record{
+				Operation: "DeleteSnapshot", SnapshotID: "invalid", Code: "OK",
+			},
+		},
+		{
+			desc: "NodeStageVolume",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId: "rg#account#share", StagingTargetPath: "/staging",
+				VolumeContext: map[string]string{pvcNameKey: "pvc", pvcNamespaceKey: "default", "sasToken": "token"},
+			},
+			expected: audit.Record{
+				Operation: "NodeStageVolume", VolumeID: "rg#account#share", Path: "/staging",
+				PVCName: "pvc", PVCNamespace: "default",
+				ResourceGroup: "rg", Account: "account", Share: "share",
+				Parameters: map[string]string{pvcNameKey: "pvc", pvcNamespaceKey: "default", "sasToken": redactedValue},
+				Code:       "OK",
+			},
+		},
+		{
+			desc: "NodeUnstageVolume",
+			req:  &csi.NodeUnstageVolumeRequest{VolumeId: "rg#account#share", StagingTargetPath: "/staging"},
+			expected: audit.Record{
+				Operation: "NodeUnstageVolume", VolumeID: "rg#account#share", Path: "/staging",
+				ResourceGroup: "rg", Account: "account", Share: "share", Code: "OK",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		record := newAuditRecord(test.expected.Operation, test.req, test.resp, test.err)
+		assert.Equal(t, test.expected, *record, test.desc)
+	}
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	d := NewFakeDriver()
+	handler := func(_ context.Context, _ interface{}) (interface{}, error) {
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+	req := &csi.DeleteVolumeRequest{VolumeId: "rg#account#share", Secrets: map[string]string{"azurestorageaccountkey": "secretvalue"}}
+
+	// audit log is disabled
+	resp, err := d.auditInterceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/DeleteVolume"}, handler)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	d.auditSink, err = audit.NewSink(logPath, 10, 1)
+	require.NoError(t, err)
+	defer d.auditSink.Close()
+
+	_, err = d.auditInterceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/DeleteVolume"}, handler)
+	assert.NoError(t, err)
+	// read only operations are not audited
+	_, err = d.auditInterceptor(context.Background(), &csi.NodeGetVolumeStatsRequest{VolumeId: "rg#account#share"},
+		&grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodeGetVolumeStats"}, handler)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "secretvalue")
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1)
+	var record audit.Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "DeleteVolume", record.Operation)
+	assert.Equal(t, "account", record.Account)
+	assert.Equal(t, "share", record.Share)
+	assert.Equal(t, "OK", record.Code)
+	assert.False(t, record.Time.IsZero())
+}
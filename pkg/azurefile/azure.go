@@ -37,6 +37,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/azurefile-csi-driver/pkg/filewatcher"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/configloader"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
@@ -87,6 +88,9 @@ func getCloudProvider(ctx context.Context, kubeconfig, nodeID, secretName, secre
 			klog.V(2).Infof("set QPS(%f) and QPS Burst(%d) for driver kubeClient", float32(kubeAPIQPS), kubeAPIBurst)
 			kubeCfg.QPS = float32(kubeAPIQPS)
 			kubeCfg.Burst = kubeAPIBurst
+			if tracing.Enabled() {
+				kubeCfg.Wrap(tracing.WrapTransport)
+			}
 			kubeClient, err = clientset.NewForConfig(kubeCfg)
 			if err != nil {
 				klog.Warningf("NewForConfig failed with error: %v", err)
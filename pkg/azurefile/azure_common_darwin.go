@@ -51,3 +51,17 @@ func prepareStagePath(path string, m *mount.SafeFormatAndMount) error {
 func GetVolumeStats(path string, enableWindowsHostProcess bool) (*csi.NodeGetVolumeStatsResponse, error) {
 	return nil, status.Errorf(codes.Internal, "GetVolumeStats is not supported on darwin")
 }
+
+// isReadOnlyFilesystem is only implemented on Linux
+func isReadOnlyFilesystem(_ string) (bool, error) {
+	return false, nil
+}
+
+// lazyUnmount is only implemented on Linux
+func lazyUnmount(_ *mount.SafeFormatAndMount, _ string) error {
+	return status.Error(codes.Unimplemented, "lazy unmount is only supported on Linux")
+}
+
+func listLoopDeviceTargets(_ string) ([]string, error) {
+	return nil, nil
+}
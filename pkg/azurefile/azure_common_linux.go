@@ -20,7 +20,11 @@ limitations under the License.
 package azurefile
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"golang.org/x/sys/unix"
 
 	"k8s.io/kubernetes/pkg/volume"
 	mount "k8s.io/mount-utils"
@@ -99,3 +103,36 @@ func GetVolumeStats(path string, _ bool) (*csi.NodeGetVolumeStatsResponse, error
 		},
 	}, nil
 }
+
+// isReadOnlyFilesystem returns true if the file system of path is mounted read-only
+func isReadOnlyFilesystem(path string) (bool, error) {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(path, &statfs); err != nil {
+		return false, err
+	}
+	return statfs.Flags&unix.ST_RDONLY != 0, nil
+}
+
+// lazyUnmount detaches the mount on target even if it's busy or the server is unreachable
+func lazyUnmount(m *mount.SafeFormatAndMount, target string) error {
+	if output, err := m.Exec.Command("umount", "-l", target).CombinedOutput(); err != nil {
+		return fmt.Errorf("umount -l %s failed with %v, output: %s", target, err, string(output))
+	}
+	return nil
+}
+
+// listLoopDeviceTargets returns the mount points in mountInfoPath on which a loop device is bind mounted,
+// e.g. the targets of published raw block volumes
+func listLoopDeviceTargets(mountInfoPath string) ([]string, error) {
+	mountInfos, err := mount.ParseMountInfo(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, mi := range mountInfos {
+		if mi.FsType == "devtmpfs" && strings.HasPrefix(mi.Root, "/loop") {
+			targets = append(targets, mi.MountPoint)
+		}
+	}
+	return targets, nil
+}
@@ -143,3 +143,18 @@ func GetVolumeStats(path string, enableWindowsHostProcess bool) (*csi.NodeGetVol
 		},
 	}, nil
 }
+
+// isReadOnlyFilesystem is only implemented on Linux
+func isReadOnlyFilesystem(_ string) (bool, error) {
+	return false, nil
+}
+
+// lazyUnmount is only implemented on Linux
+func lazyUnmount(_ *mount.SafeFormatAndMount, _ string) error {
+	return status.Error(codes.Unimplemented, "lazy unmount is only supported on Linux")
+}
+
+// listLoopDeviceTargets is only implemented on Linux
+func listLoopDeviceTargets(_ string) ([]string, error) {
+	return nil, nil
+}
@@ -19,20 +19,21 @@ package azurefile
 import (
 	"bytes"
 	"context"
+	"crypto/rsa"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
@@ -49,14 +50,18 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/volume/util/volumepathhandler"
 	mount "k8s.io/mount-utils"
 	"k8s.io/utils/ptr"
 
+	"sigs.k8s.io/azurefile-csi-driver/pkg/audit"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
 	csicommon "sigs.k8s.io/azurefile-csi-driver/pkg/csi-common"
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
 	"sigs.k8s.io/azurefile-csi-driver/pkg/mounter"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	fileutil "sigs.k8s.io/azurefile-csi-driver/pkg/util"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
@@ -173,25 +178,22 @@ const (
 	defaultRuntimeClassHandler        = "kata-cc"
 	mountWithManagedIdentityField     = "mountwithmanagedidentity"
 	mountWithWITokenField             = "mountwithworkloadidentitytoken"
+	provisioningModeField             = "provisioningmode"
+	onDeleteField                     = "ondelete"
+	folderQuotaField                  = "folderquota"
 
-	accountNotProvisioned = "StorageAccountIsNotProvisioned"
-	// this is a workaround fix for 429 throttling issue, will update cloud provider for better fix later
-	tooManyRequests   = "TooManyRequests"
-	shareBeingDeleted = "The specified share is being deleted"
-	clientThrottled   = "client throttled"
 	// accountLimitExceed returned by different API
 	accountLimitExceedManagementAPI = "TotalSharesProvisionedCapacityExceedsAccountLimit"
 	accountLimitExceedDataPlaneAPI  = "specified share does not exist"
 
-	fileShareNotFound  = "ShareNotFound"
-	statusCodeNotFound = "StatusCode=404"
-	httpCodeNotFound   = "HTTPStatusCode: 404"
-
 	// define different sleep time when hit throttling
 	accountOpThrottlingSleepSec = 16
 	fileOpThrottlingSleepSec    = 180
 	maxThrottlingSleepSec       = 1200
 
+	accountSearchCacheTTL       = time.Minute
+	dataPlaneAPIAccountCacheTTL = 10 * time.Minute
+
 	defaultAccountNamePrefix = "f"
 
 	defaultNamespace = "default"
@@ -228,8 +230,6 @@ var (
 	supportedDiskFsTypeList          = []string{ext4, ext3, ext2, xfs}
 	supportedFSGroupChangePolicyList = []string{FSGroupChangeNone, string(v1.FSGroupChangeAlways), string(v1.FSGroupChangeOnRootMismatch)}
 
-	retriableErrors = []string{accountNotProvisioned, tooManyRequests, shareBeingDeleted, clientThrottled}
-
 	// azcopyCloneVolumeOptions used in volume cloning and set --check-length to false because volume data may be in changing state, copy volume is not same as current source volume
 	azcopyCloneVolumeOptions = []string{"--recursive", "--check-length=false", "--log-level=ERROR"}
 	// azcopySnapshotRestoreOptions used in smb snapshot restore and set --check-length to true because snapshot data is changeless
@@ -251,6 +251,9 @@ type Driver struct {
 	kubeClient                             clientset.Interface
 	enableAzurefileProxy                   bool
 	azurefileProxyEndpoint                 string
+	azurefileProxyTLSCertFile              string
+	azurefileProxyTLSKeyFile               string
+	azurefileProxyTLSCAFile                string
 	cloudConfigSecretName                  string
 	cloudConfigSecretNamespace             string
 	customUserAgent                        string
@@ -265,6 +268,18 @@ type Driver struct {
 	mountPermissions                       uint64
 	kubeAPIQPS                             float64
 	kubeAPIBurst                           int
+	armRequestQPS                          float64
+	armRequestBurst                        int
+	skipMatchingTagCacheExpireInMinutes    int
+	cachePersistenceConfigMap              string
+	cachePersistenceInterval               time.Duration
+	mountOptionPolicyFile                  string
+	auditLogPath                           string
+	auditLogMaxSizeMB                      int
+	auditLogMaxBackups                     int
+	otlpEndpoint                           string
+	tracingSamplingRatio                   float64
+	mountHealthCheckInterval               time.Duration
 	enableWindowsHostProcess               bool
 	removeSMBMountOnWindows                bool
 	appendClosetimeoOption                 bool
@@ -273,6 +288,7 @@ type Driver struct {
 	appendActimeoOption                    bool
 	printVolumeStatsCallLogs               bool
 	enableKataCCMount                      bool
+	kataCCMountInfoPublicKeyFile           string
 	useWinCIMAPI                           bool
 	mounter                                *mount.SafeFormatAndMount
 	server                                 *grpc.Server
@@ -283,6 +299,38 @@ type Driver struct {
 	// a map storing all volumes with ongoing operations so that additional operations
 	// for that same volume (as defined by VolumeID) return an Aborted error
 	volumeLocks *volumeLocks
+	// limits in-flight mutating operations per storage account
+	accountScheduler *accountScheduler
+	// audit log of mutating operations, nil if disabled
+	auditSink *audit.Sink
+	// probes the health of staged mounts on the node, nil if disabled
+	mountHealthMonitor *mountHealthMonitor
+	// remounts stale staged mounts found by mountHealthMonitor, nil if disabled
+	mountRecovery *mountRecovery
+	// refreshes stats of staged volumes in background for NodeGetVolumeStats, nil if disabled
+	volumeStatsCollector *volumeStatsCollector
+	// collects I/O counters of staged volumes on the node, nil if disabled
+	volumeIOStats *volumeIOStatsCollector
+	// scans the usage of folder-based volumes against their soft quota, nil if disabled
+	folderUsageTracker  *folderUsageTracker
+	folderUsageInterval time.Duration
+	// fences VHD disk volumes to a single node with file leases, nil if disabled
+	vhdLeaseKeeper        *vhdLeaseKeeper
+	vhdLeaseRenewInterval time.Duration
+	// discard mode of VHD disk volumes, vhdTrimmer is only set in fstrim mode
+	vhdDiscardMode  string
+	vhdTrimmer      *vhdTrimmer
+	vhdTrimInterval time.Duration
+	// public key of the confidential guest which sensitive mount options in direct volume mount info are encrypted to, nil if not configured
+	kataCCMountInfoPublicKey *rsa.PublicKey
+	// attaches VHD disks of raw block volumes to loop devices
+	blockVolumePathHandler volumepathhandler.BlockVolumePathHandler
+	// target paths on which raw block volumes are published, only these paths are looked up as block devices in NodeGetVolumeStats
+	blockVolumeTargets sync.Map
+	// mount options allowed, denied, forced and defaulted by cluster admins, nil if no policy is configured
+	mountOptionPolicy atomic.Pointer[MountOptionPolicy]
+	// result of the last background health check of azurefile-proxy, nil if not checked yet
+	azurefileProxyHealth atomic.Pointer[azurefileProxyHealth]
 	// a map storing all volumes created by this driver <volumeName, accountName>
 	volMap sync.Map
 	// a timed cache storing all account name and keys retrieved by this driver <accountName, accountkey>
@@ -331,6 +379,9 @@ func NewDriver(options *DriverOptions) *Driver {
 	driver.NodeID = options.NodeID
 	driver.enableAzurefileProxy = options.EnableAzurefileProxy
 	driver.azurefileProxyEndpoint = options.AzureFileProxyEndpoint
+	driver.azurefileProxyTLSCertFile = options.AzureFileProxyTLSCertFile
+	driver.azurefileProxyTLSKeyFile = options.AzureFileProxyTLSKeyFile
+	driver.azurefileProxyTLSCAFile = options.AzureFileProxyTLSCAFile
 	driver.cloudConfigSecretName = options.CloudConfigSecretName
 	driver.cloudConfigSecretNamespace = options.CloudConfigSecretNamespace
 	driver.customUserAgent = options.CustomUserAgent
@@ -341,11 +392,102 @@ func NewDriver(options *DriverOptions) *Driver {
 	driver.enableVolumeMountGroup = options.EnableVolumeMountGroup
 	driver.enableGetVolumeStats = options.EnableGetVolumeStats
 	driver.enableKataCCMount = options.EnableKataCCMount
+	driver.kataCCMountInfoPublicKeyFile = options.KataCCMountInfoPublicKeyFile
 	driver.appendMountErrorHelpLink = options.AppendMountErrorHelpLink
 	driver.mountPermissions = options.MountPermissions
 	driver.fsGroupChangePolicy = options.FSGroupChangePolicy
 	driver.kubeAPIQPS = options.KubeAPIQPS
 	driver.kubeAPIBurst = options.KubeAPIBurst
+	driver.armRequestQPS = options.ARMRequestQPS
+	driver.armRequestBurst = options.ARMRequestBurst
+	driver.cachePersistenceConfigMap = options.CachePersistenceConfigMap
+	driver.cachePersistenceInterval = time.Duration(options.CachePersistenceIntervalSeconds) * time.Second
+	driver.mountOptionPolicyFile = options.MountOptionPolicyFile
+	driver.auditLogPath = options.AuditLogPath
+	driver.auditLogMaxSizeMB = options.AuditLogMaxSizeMB
+	driver.auditLogMaxBackups = options.AuditLogMaxBackups
+	driver.otlpEndpoint = options.OTLPEndpoint
+	driver.tracingSamplingRatio = options.TracingSamplingRatio
+	driver.mountHealthCheckInterval = time.Duration(options.MountHealthCheckIntervalSeconds) * time.Second
+	if driver.mountHealthCheckInterval > 0 {
+		driver.mountHealthMonitor = newMountHealthMonitor(time.Duration(options.MountHealthCheckTimeoutSeconds) * time.Second)
+	}
+	if options.EnableStaleMountRecovery {
+		if driver.mountHealthMonitor == nil || runtime.GOOS != "linux" {
+			klog.Warningf("stale mount recovery is disabled since it requires the mount health monitor on Linux")
+		} else {
+			driver.mountRecovery = newMountRecovery(time.Duration(options.StaleMountRecoveryMinIntervalSeconds)*time.Second,
+				options.MaxStaleMountRecoveriesPerMinute, options.StaleMountRecoveryKillSwitchFile)
+			driver.mountRecovery.lazyUnmount = func(target string) error {
+				return lazyUnmount(driver.mounter, target)
+			}
+			driver.mountRecovery.mount = func(source, target, fsType string, options, sensitiveOptions []string) error {
+				return fileutil.WaitUntilTimeout(MountTimeoutInSec*time.Second, func() error {
+					return SMBMount(driver.mounter, source, target, fsType, options, sensitiveOptions)
+				}, func() error {
+					return fmt.Errorf("mount operation timed out after %d seconds: source=%s, target=%s", MountTimeoutInSec, source, target)
+				})
+			}
+			driver.mountRecovery.bindMount = func(source, target string, options []string) error {
+				return fileutil.WaitUntilTimeout(MountTimeoutInSec*time.Second, func() error {
+					return driver.mounter.Mount(source, target, "", options)
+				}, func() error {
+					return fmt.Errorf("bind mount operation timed out after %d seconds: source=%s, target=%s", MountTimeoutInSec, source, target)
+				})
+			}
+			driver.mountHealthMonitor.onAbnormal = driver.recoverStaleMount
+		}
+	}
+	if options.EnableGetVolumeStats && options.VolumeStatsRefreshIntervalSeconds > 0 {
+		driver.volumeStatsCollector = newVolumeStatsCollector(time.Duration(options.VolumeStatsRefreshIntervalSeconds)*time.Second,
+			time.Duration(options.VolumeStatsTimeoutSeconds)*time.Second, func(path string) (*csi.NodeGetVolumeStatsResponse, error) {
+				return GetVolumeStats(path, driver.enableWindowsHostProcess)
+			})
+	}
+	if options.EnableVolumeIOStats {
+		if runtime.GOOS != "linux" {
+			klog.Warningf("volume I/O stats is disabled since it's only available on Linux")
+		} else {
+			driver.volumeIOStats = newVolumeIOStatsCollector(options.VolumeIOStatsMaxVolumes)
+		}
+	}
+	driver.folderUsageInterval = time.Duration(options.FolderUsageScanIntervalSeconds) * time.Second
+	if driver.folderUsageInterval > 0 {
+		action := strings.ToLower(options.FolderQuotaExceededAction)
+		if !isSupportedFolderQuotaExceededAction(action) {
+			klog.Warningf("folder quota exceeded action(%s) is not supported, supported list: %v, fall back to %s", options.FolderQuotaExceededAction, supportedFolderQuotaExceededActionList, folderQuotaExceededActionMetric)
+			action = folderQuotaExceededActionMetric
+		}
+		if action == folderQuotaExceededActionReadOnly && runtime.GOOS != "linux" {
+			klog.Warningf("folder quota exceeded action(%s) is only available on Linux, fall back to %s", action, folderQuotaExceededActionEvent)
+			action = folderQuotaExceededActionEvent
+		}
+		driver.folderUsageTracker = newFolderUsageTracker(options.FolderUsageMaxEntriesPerScan, action)
+		driver.folderUsageTracker.remountReadOnly = driver.remountFolderReadOnly
+	}
+	if options.EnableVHDLeaseFencing {
+		if !options.EnableVHDDiskFeature {
+			klog.Warningf("VHD lease fencing is disabled since VHD disk feature is disabled")
+		} else {
+			driver.vhdLeaseKeeper = newVHDLeaseKeeper(options.NodeID)
+			driver.vhdLeaseKeeper.isNodeGone = driver.isNodeGone
+			driver.vhdLeaseRenewInterval = time.Duration(options.VHDLeaseRenewIntervalSeconds) * time.Second
+		}
+	}
+	if options.VHDDiscardMode != "" {
+		mode := strings.ToLower(options.VHDDiscardMode)
+		if !isSupportedVHDDiscardMode(mode) {
+			klog.Warningf("VHD discard mode(%s) is not supported, supported list: %v, VHD discard is disabled", options.VHDDiscardMode, supportedVHDDiscardModeList)
+		} else if runtime.GOOS != "linux" {
+			klog.Warningf("VHD discard is disabled since it's only available on Linux")
+		} else {
+			driver.vhdDiscardMode = mode
+			if mode == vhdDiscardModeFstrim {
+				driver.vhdTrimmer = newVHDTrimmer()
+				driver.vhdTrimInterval = time.Duration(options.VHDTrimIntervalSeconds) * time.Second
+			}
+		}
+	}
 	driver.enableWindowsHostProcess = options.EnableWindowsHostProcess
 	driver.removeSMBMountOnWindows = options.RemoveSMBMountOnWindows
 	driver.appendClosetimeoOption = options.AppendClosetimeoOption
@@ -358,11 +500,13 @@ func NewDriver(options *DriverOptions) *Driver {
 	driver.volLockMap = newLockMap()
 	driver.subnetLockMap = newLockMap()
 	driver.volumeLocks = newVolumeLocks()
+	driver.accountScheduler = newAccountScheduler(options.MaxConcurrentOperationsPerAccount, options.MaxQueuedOperationsPerAccount)
 	driver.azcopy = &fileutil.Azcopy{ExecCmd: &fileutil.ExecCommand{}}
 	driver.kubeconfig = options.KubeConfig
 	driver.endpoint = options.Endpoint
 	driver.resolver = new(NetResolver)
 	driver.directVolume = new(directVolume)
+	driver.blockVolumePathHandler = volumepathhandler.NewBlockVolumePathHandler()
 	driver.isKataNode = false
 	driver.useWinCIMAPI = options.UseWinCIMAPI
 
@@ -373,13 +517,14 @@ func NewDriver(options *DriverOptions) *Driver {
 		klog.Fatalf("%v", err)
 	}
 
-	if driver.accountSearchCache, err = azcache.NewTimedCache(time.Minute, getter, false); err != nil {
+	if driver.accountSearchCache, err = azcache.NewTimedCache(accountSearchCacheTTL, getter, false); err != nil {
 		klog.Fatalf("%v", err)
 	}
 
 	if options.SkipMatchingTagCacheExpireInMinutes <= 0 {
 		options.SkipMatchingTagCacheExpireInMinutes = 30 // default expire in 30 minutes
 	}
+	driver.skipMatchingTagCacheExpireInMinutes = options.SkipMatchingTagCacheExpireInMinutes
 	if driver.skipMatchingTagCache, err = azcache.NewTimedCache(time.Duration(options.SkipMatchingTagCacheExpireInMinutes)*time.Minute, getter, false); err != nil {
 		klog.Fatalf("%v", err)
 	}
@@ -388,7 +533,7 @@ func NewDriver(options *DriverOptions) *Driver {
 		klog.Fatalf("%v", err)
 	}
 
-	if driver.dataPlaneAPIAccountCache, err = azcache.NewTimedCache(10*time.Minute, getter, false); err != nil {
+	if driver.dataPlaneAPIAccountCache, err = azcache.NewTimedCache(dataPlaneAPIAccountCacheTTL, getter, false); err != nil {
 		klog.Fatalf("%v", err)
 	}
 
@@ -418,6 +563,14 @@ func NewDriver(options *DriverOptions) *Driver {
 	return &driver
 }
 
+var (
+	// controllerOperations and nodeOperations are the operations recorded in the operation metrics,
+	// their series are exposed from the start of the controller and node driver
+	controllerOperations = []string{"controller_create_volume", "controller_create_volume_from_snapshot", "controller_create_volume_from_volume",
+		"controller_delete_volume", "controller_create_snapshot", "controller_delete_snapshot", "controller_expand_volume"}
+	nodeOperations = []string{"node_stage_volume", "node_unstage_volume", "node_publish_volume", "node_unpublish_volume"}
+)
+
 // Run driver initialization
 func (d *Driver) Run(ctx context.Context) error {
 	versionMeta, err := GetVersionYAML(d.Name)
@@ -429,7 +582,20 @@ func (d *Driver) Run(ctx context.Context) error {
 	if d.NodeID == "" {
 		// nodeid is not needed in controller component
 		klog.Warning("nodeid is empty")
+		csiMetrics.RegisterOperationMetrics(controllerOperations...)
+	} else {
+		csiMetrics.RegisterOperationMetrics(nodeOperations...)
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, d.otlpEndpoint, d.Name, d.tracingSamplingRatio)
+	if err != nil {
+		klog.Fatalf("failed to initialize tracing: %v", err)
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("failed to shutdown tracing: %v", err)
+		}
+	}()
 
 	userAgent := GetUserAgent(d.Name, d.customUserAgent, d.userAgentSuffix)
 	klog.V(2).Infof("driver userAgent: %s", userAgent)
@@ -437,6 +603,13 @@ func (d *Driver) Run(ctx context.Context) error {
 	if err != nil {
 		klog.Fatalf("failed to get Azure Cloud Provider, error: %v", err)
 	}
+	if d.cloud != nil && d.cloud.ComputeClientFactory != nil {
+		// ARM requests of all controller operations share the same budget
+		klog.V(2).Infof("ARM request budget per subscription: qps(%v), burst(%d)", d.armRequestQPS, d.armRequestBurst)
+		if d.cloud, err = newRateLimitedRepository(d.cloud, newARMRequestLimiter(d.armRequestQPS, d.armRequestBurst)); err != nil {
+			klog.Fatalf("failed to create rate limited storage repository, error: %v", err)
+		}
+	}
 	// pass if the storageEndpointSuffix must be trusted by azCopy by checking if it is not in azcopyTrustedSuffixesAAD
 	requiredAzCopyToTrust := d.getStorageEndPointSuffix() != "" && !strings.Contains(azcopyTrustedSuffixesAAD, d.getStorageEndPointSuffix())
 
@@ -479,15 +652,38 @@ func (d *Driver) Run(ctx context.Context) error {
 	}
 	if d.enableGetVolumeStats {
 		nodeCap = append(nodeCap, csi.NodeServiceCapability_RPC_GET_VOLUME_STATS)
+		if d.mountHealthMonitor != nil {
+			nodeCap = append(nodeCap, csi.NodeServiceCapability_RPC_VOLUME_CONDITION)
+		}
 	}
 	d.AddNodeServiceCapabilities(nodeCap)
 
+	if d.auditLogPath != "" {
+		if d.auditSink, err = audit.NewSink(d.auditLogPath, d.auditLogMaxSizeMB, d.auditLogMaxBackups); err != nil {
+			klog.Fatalf("failed to create audit log: %v", err)
+		}
+		klog.V(2).Infof("audit log of mutating operations is written to %s", d.auditLogPath)
+	}
+
+	if d.enableKataCCMount {
+		if d.kataCCMountInfoPublicKeyFile != "" {
+			if d.kataCCMountInfoPublicKey, err = loadKataMountInfoPublicKey(d.kataCCMountInfoPublicKeyFile); err != nil {
+				klog.Fatalf("failed to load public key of the confidential guest: %v", err)
+			}
+			klog.V(2).Infof("sensitive mount options in direct volume mount info are encrypted to public key(%s)", getKataMountInfoKeyID(d.kataCCMountInfoPublicKey))
+		} else {
+			klog.Warningf("sensitive mount options in direct volume mount info are saved in plaintext since kata-cc-mount-info-public-key-file is not set")
+		}
+	}
+
 	//setup grpc server
 	opts := []grpc.ServerOption{
 		// TODO: add more interceptors.
 		grpc.ChainUnaryInterceptor(
 			grpcprom.NewServerMetrics().UnaryServerInterceptor(),
+			tracing.UnaryServerInterceptor,
 			csicommon.LogGRPC,
+			d.auditInterceptor,
 		),
 	}
 	server := grpc.NewServer(opts...)
@@ -496,6 +692,43 @@ func (d *Driver) Run(ctx context.Context) error {
 	csi.RegisterNodeServer(server, d)
 	d.server = server
 	d.isKataNode = isKataNode(ctx, d.NodeID, defaultConfidentialContainerLabel, d.kubeClient)
+	if d.cachePersistenceConfigMap != "" && d.NodeID == "" {
+		d.startCachePersistence(ctx)
+	}
+	if d.mountOptionPolicyFile != "" {
+		if err := d.startMountOptionPolicyReload(ctx); err != nil {
+			klog.Fatalf("failed to load mount option policy: %v", err)
+		}
+	}
+	if d.enableAzurefileProxy && d.NodeID != "" {
+		go d.runAzurefileProxyHealthCheck(ctx, azurefileProxyHealthCheckInterval)
+	}
+	if d.NodeID != "" {
+		d.restoreBlockVolumeTargets(ctx, procMountInfoPath)
+	}
+	if d.mountHealthMonitor != nil && d.NodeID != "" {
+		go d.mountHealthMonitor.run(ctx, d.mountHealthCheckInterval)
+	}
+	if d.volumeStatsCollector != nil && d.NodeID != "" {
+		go d.volumeStatsCollector.run(ctx)
+	}
+	if d.volumeIOStats != nil && d.NodeID != "" {
+		csiMetrics.RegisterVolumeIOStatsCollector(func() []csiMetrics.VolumeIOStats {
+			return d.volumeIOStats.gather(ctx)
+		})
+	}
+	if d.vhdLeaseKeeper != nil && d.NodeID != "" && d.vhdLeaseRenewInterval > 0 {
+		go d.vhdLeaseKeeper.run(ctx, d.vhdLeaseRenewInterval)
+	}
+	if d.vhdTrimmer != nil && d.NodeID != "" && d.vhdTrimInterval > 0 {
+		go d.vhdTrimmer.run(ctx, d.vhdTrimInterval)
+	}
+	if d.folderUsageTracker != nil && d.NodeID != "" {
+		if d.kubeClient != nil {
+			d.folderUsageTracker.recordEvent = newPVCEventRecorder(d.kubeClient, d.NodeID)
+		}
+		go d.folderUsageTracker.run(ctx, d.folderUsageInterval)
+	}
 
 	listener, err := csicommon.ListenEndpoint(ctx, d.endpoint)
 	if err != nil {
@@ -505,6 +738,7 @@ func (d *Driver) Run(ctx context.Context) error {
 		<-ctx.Done()
 		d.server.GracefulStop()
 	}()
+	defer d.auditSink.Close()
 	if err = d.server.Serve(listener); errors.Is(err, grpc.ErrServerStopped) {
 		klog.Infof("gRPC server stopped serving")
 		return nil
@@ -537,8 +771,7 @@ func (d *Driver) getFileShareQuota(ctx context.Context, accountOptions *storage.
 	}
 	quota, err := fileClient.GetFileShareQuota(ctx, fileShareName)
 	if err != nil {
-		var respErr *azcore.ResponseError
-		if errors.As(err, &respErr) && respErr != nil && respErr.StatusCode == http.StatusNotFound {
+		if azerrors.IsNotFound(err) {
 			return -1, nil
 		}
 		return -1, err
@@ -771,7 +1004,9 @@ func getDirectoryClient(accountName, accountKey, storageEndpointSuffix, fileShar
 	}
 	serviceURL := getFileServiceURL(accountName, storageEndpointSuffix)
 
-	serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL, credential, nil)
+	clientOps := &service.ClientOptions{}
+	clientOps.PerRetryPolicies = append(tracing.AzurePolicies(), faultInjection.policies()...)
+	serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL, credential, clientOps)
 	if err != nil {
 		return nil, fmt.Errorf("NewClientWithSharedKeyCredential(%s) failed with error: %v", serviceURL, err)
 	}
@@ -813,10 +1048,11 @@ func IsCorruptedDir(dir string) bool {
 // GetAccountInfo get account info
 // return <rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, tokenFilePath, err>
 func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, reqContext map[string]string) (string, string, string, string, string, string, string, string, error) {
+	logger := klog.FromContext(ctx)
 	rgName, accountName, fileShareName, diskName, secretNamespace, subsID, err := GetFileShareInfo(volumeID)
 	if err != nil {
 		// ignore volumeID parsing error
-		klog.V(6).Infof("parsing volumeID(%s) return with error: %v", volumeID, err)
+		logger.V(6).Info("parsing volumeID returned with error", "volumeID", volumeID, "err", err)
 		err = nil
 	}
 
@@ -893,7 +1129,7 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 	}
 
 	if mountWithManagedIdentity {
-		klog.V(2).Infof("mountWithManagedIdentity is true, use managed identity auth")
+		logger.V(2).Info("mountWithManagedIdentity is true, use managed identity auth")
 		return rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, tokenFilePath, nil
 	}
 
@@ -904,7 +1140,7 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 				return rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, tokenFilePath, fmt.Errorf("clientID is empty for workload identity auth")
 			}
 		}
-		klog.V(2).Infof("mountWithWorkloadIdentityToken is specified, use workload identity auth for mount, clientID: %s, tenantID: %s", clientID, tenantID)
+		logger.V(2).Info("mountWithWorkloadIdentityToken is specified, use workload identity auth for mount", "clientID", clientID, "tenantID", tenantID)
 		token, err := parseServiceAccountToken(serviceAccountToken)
 		if err != nil {
 			return rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, tokenFilePath, fmt.Errorf("failed to parse service account token: %v", err)
@@ -917,7 +1153,7 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 		// check whether token value is the same as the one in the token file
 		existingToken, readErr := os.ReadFile(tokenFilePath)
 		if readErr == nil && string(existingToken) == token {
-			klog.V(4).Infof("the token file(%s) already exists and the token value is the same, no need to rewrite the token file", tokenFilePath)
+			logger.V(4).Info("the token file already exists and the token value is the same, no need to rewrite the token file", "tokenFile", tokenFilePath)
 			return rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, "", nil
 		}
 		// write token to a file
@@ -928,7 +1164,7 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 	}
 
 	if clientID != "" {
-		klog.V(2).Infof("clientID(%s) is specified, use service account token to get account key", clientID)
+		logger.V(2).Info("clientID is specified, use service account token to get account key", "clientID", clientID)
 		accountKey, err := d.cloud.GetStorageAccesskeyFromServiceAccountToken(ctx, subsID, accountName, rgName, clientID, tenantID, serviceAccountToken)
 		return rgName, accountName, accountKey, fileShareName, diskName, subsID, tenantID, "", err
 	}
@@ -955,12 +1191,12 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 				}
 				if err != nil {
 					// 3. if failed to get account key from kubernetes secret, use cluster identity to get account key
-					klog.Warningf("GetStorageAccountFromSecret(%s, %s) failed with error: %v", secretName, secretNamespace, err)
+					logger.Error(err, "GetStorageAccountFromSecret failed", "secret", secretName, "namespace", secretNamespace)
 					if !getAccountKeyFromSecret && accountName != "" {
-						klog.V(2).Infof("use cluster identity to get account key from (%s, %s, %s)", subsID, rgName, accountName)
+						logger.V(2).Info("use cluster identity to get account key", "subsID", subsID, "resourceGroup", rgName, "account", accountName)
 						accountKey, err = d.GetStorageAccesskeyWithSubsID(ctx, subsID, accountName, rgName, getLatestAccountKey)
 						if err != nil {
-							klog.Errorf("GetStorageAccesskey(%s, %s, %s) failed with error: %v", subsID, rgName, accountName, err)
+							logger.Error(err, "GetStorageAccesskey failed", "subsID", subsID, "resourceGroup", rgName, "account", accountName)
 						}
 					}
 				}
@@ -973,7 +1209,7 @@ func (d *Driver) GetAccountInfo(ctx context.Context, volumeID string, secrets, r
 			accountName = account
 		}
 		if err != nil {
-			klog.Errorf("getStorageAccount failed with error: %v", err)
+			logger.Error(err, "getStorageAccount failed")
 		}
 	}
 
@@ -1057,141 +1293,141 @@ func isSupportedFSGroupChangePolicy(policy string) bool {
 
 // CreateFileShare creates a file share
 func (d *Driver) CreateFileShare(ctx context.Context, accountOptions *storage.AccountOptions, shareOptions *ShareOptions, secrets map[string]string, useDataPlaneAPI string) error {
-	return wait.ExponentialBackoff(getBackOff(d.cloud.Config), func() (bool, error) {
+	logger := klog.FromContext(ctx)
+	return retryAzureOperation(getBackOff(d.cloud.Config), "create_file_share", fileOpThrottlingSleepSec, func() error {
 		var err error
 		var fileClient azureFileClient
 		if len(secrets) > 0 {
 			var accountName, accountKey string
 			accountName, accountKey, err = getStorageAccount(secrets)
 			if err != nil {
-				return true, err
+				return err
 			}
 			storageEndPointSuffix := d.getStorageEndPointSuffix()
 			if accountOptions != nil && accountOptions.StorageEndpointSuffix != "" {
 				storageEndPointSuffix = accountOptions.StorageEndpointSuffix
 			}
-			if fileClient, err = newAzureFileClient(accountName, accountKey, storageEndPointSuffix); err != nil {
-				return true, err
-			}
+			fileClient, err = newAzureFileClient(accountName, accountKey, storageEndPointSuffix)
 		} else if d.cloud != nil && d.cloud.AuthProvider != nil && strings.EqualFold(useDataPlaneAPI, oauth) {
 			fileClient, err = newAzureFileClientWithOAuth(d.cloud.AuthProvider.GetAzIdentity(), accountOptions.Name, d.getStorageEndPointSuffix())
 		} else {
 			fileClient, err = newAzureFileMgmtClient(d.cloud, accountOptions)
 		}
 		if err != nil {
-			return true, err
+			return err
 		}
-
-		if err = fileClient.CreateFileShare(ctx, shareOptions); err != nil {
-			if strings.Contains(err.Error(), "ShareAlreadyExists") {
-				klog.Warningf("CreateFileShare(%s) on account(%s) failed with error(%v), return as success", shareOptions.Name, accountOptions.Name, err)
-				return true, nil
-			}
-			if isRetriableError(err) {
-				klog.Warningf("CreateFileShare(%s) on account(%s) failed with error(%v), waiting for retrying", shareOptions.Name, accountOptions.Name, err)
-				sleepIfThrottled(err, fileOpThrottlingSleepSec)
-				return false, nil
-			}
-			klog.Errorf("CreateFileShare(%s) on account(%s) failed with error(%v)", shareOptions.Name, accountOptions.Name, err)
+		return fileClient.CreateFileShare(ctx, shareOptions)
+	}, func(err error, _ azerrors.Category) (bool, error) {
+		if azerrors.HasErrorCode(err, azerrors.CodeShareAlreadyExists) {
+			logger.Info("file share already exists, return as success", "share", shareOptions.Name, "account", accountOptions.Name, "err", err)
+			return true, nil
+		}
+		if !isRetriableError(err) {
+			logger.Error(err, "CreateFileShare failed", "share", shareOptions.Name, "account", accountOptions.Name)
 		}
-		return true, err
+		return false, nil
 	})
 }
 
 // DeleteFileShare deletes a file share using storage account name and key
 func (d *Driver) DeleteFileShare(ctx context.Context, subsID, resourceGroup, accountName, shareName string, secrets map[string]string, useDataPlaneAPI string) error {
-	return wait.ExponentialBackoff(getBackOff(d.cloud.Config), func() (bool, error) {
-		var err error
+	logger := klog.FromContext(ctx)
+	// shareDeleted is set once the share delete call is made in an attempt, only its NotFound error means the share is gone,
+	// a NotFound error from creating the client, e.g. of a missing account, is not
+	var shareDeleted bool
+	return retryAzureOperation(getBackOff(d.cloud.Config), "delete_file_share", 0, func() error {
+		shareDeleted = false
 		if len(secrets) > 0 {
-			accountName, accountKey, rerr := getStorageAccount(secrets)
-			if rerr != nil {
-				return true, rerr
+			accountName, accountKey, err := getStorageAccount(secrets)
+			if err != nil {
+				return err
 			}
-			fileClient, rerr := newAzureFileClient(accountName, accountKey, d.getStorageEndPointSuffix())
-			if rerr != nil {
-				return true, rerr
+			fileClient, err := newAzureFileClient(accountName, accountKey, d.getStorageEndPointSuffix())
+			if err != nil {
+				return err
 			}
-			err = fileClient.DeleteFileShare(ctx, shareName)
+			shareDeleted = true
+			return fileClient.DeleteFileShare(ctx, shareName)
 		} else if d.cloud != nil && d.cloud.AuthProvider != nil && strings.EqualFold(useDataPlaneAPI, oauth) {
-			fileClient, rerr := newAzureFileClientWithOAuth(d.cloud.AuthProvider.GetAzIdentity(), accountName, d.getStorageEndPointSuffix())
-			if rerr != nil {
-				return true, rerr
-			}
-			err = fileClient.DeleteFileShare(ctx, shareName)
-		} else {
-			fileClient, errGetClient := d.getFileShareClientForSub(subsID)
-			if errGetClient != nil {
-				return true, errGetClient
+			fileClient, err := newAzureFileClientWithOAuth(d.cloud.AuthProvider.GetAzIdentity(), accountName, d.getStorageEndPointSuffix())
+			if err != nil {
+				return err
 			}
-			err = fileClient.Delete(ctx, resourceGroup, accountName, shareName, nil)
+			shareDeleted = true
+			return fileClient.DeleteFileShare(ctx, shareName)
 		}
-
+		fileClient, err := d.getFileShareClientForSub(subsID)
 		if err != nil {
-			if strings.Contains(err.Error(), statusCodeNotFound) ||
-				strings.Contains(err.Error(), httpCodeNotFound) ||
-				strings.Contains(err.Error(), fileShareNotFound) {
-				klog.Warningf("DeleteFileShare(%s) on account(%s) failed with error(%v), return as success", shareName, accountName, err)
-				return true, nil
-			}
+			return err
 		}
-
-		if isRetriableError(err) {
-			klog.Warningf("DeleteFileShare(%s) on account(%s) failed with error(%v), waiting for retrying", shareName, accountName, err)
-			if isThrottlingError(err) {
-				klog.Warningf("switch to use data plane API instead for account %s since it's throttled", accountName)
-				d.dataPlaneAPIAccountCache.Set(accountName, "")
+		shareDeleted = true
+		return fileClient.Delete(ctx, resourceGroup, accountName, shareName, nil)
+	}, func(err error, category azerrors.Category) (bool, error) {
+		switch category {
+		case azerrors.NotFound:
+			if !shareDeleted {
 				return true, err
 			}
-			return false, nil
+			logger.Info("file share not found, return as success", "share", shareName, "account", accountName, "err", err)
+			return true, nil
+		case azerrors.Throttled:
+			logger.Info("switch to use data plane API instead since account is throttled", "account", accountName)
+			d.dataPlaneAPIAccountCache.Set(accountName, "")
+			return true, err
 		}
-
-		return true, err
+		return false, nil
 	})
 }
 
 // ResizeFileShare resizes a file share
 func (d *Driver) ResizeFileShare(ctx context.Context, subsID, resourceGroup, accountName, shareName string, sizeGiB int, secrets map[string]string, useDataPlaneAPI string) error {
-	return wait.ExponentialBackoff(getBackOff(d.cloud.Config), func() (bool, error) {
-		var err error
+	logger := klog.FromContext(ctx)
+	// shareResized is set once the resize call is made in an attempt, only its errors are retried,
+	// errors of creating the client or getting the file share are returned immediately
+	var shareResized bool
+	return retryAzureOperation(getBackOff(d.cloud.Config), "resize_file_share", fileOpThrottlingSleepSec, func() error {
+		shareResized = false
 		if len(secrets) > 0 {
-			accountName, accountKey, rerr := getStorageAccount(secrets)
-			if rerr != nil {
-				return true, rerr
+			accountName, accountKey, err := getStorageAccount(secrets)
+			if err != nil {
+				return err
 			}
-			fileClient, rerr := newAzureFileClient(accountName, accountKey, d.getStorageEndPointSuffix())
-			if rerr != nil {
-				return true, rerr
+			fileClient, err := newAzureFileClient(accountName, accountKey, d.getStorageEndPointSuffix())
+			if err != nil {
+				return err
 			}
-			err = fileClient.ResizeFileShare(ctx, shareName, sizeGiB)
+			shareResized = true
+			return fileClient.ResizeFileShare(ctx, shareName, sizeGiB)
 		} else if d.cloud != nil && d.cloud.AuthProvider != nil && strings.EqualFold(useDataPlaneAPI, oauth) {
-			fileClient, rerr := newAzureFileClientWithOAuth(d.cloud.AuthProvider.GetAzIdentity(), accountName, d.getStorageEndPointSuffix())
-			if rerr != nil {
-				return true, rerr
-			}
-			err = fileClient.ResizeFileShare(ctx, shareName, sizeGiB)
-		} else {
-			fileClient, rerr := d.getFileShareClientForSub(subsID)
-			if rerr != nil {
-				return true, rerr
-			}
-			fileShare, rerr := fileClient.Get(ctx, resourceGroup, accountName, shareName, nil)
-			if rerr != nil {
-				return true, rerr
-			}
-			if ptr.Deref(fileShare.FileShareProperties.ShareQuota, 0) >= int32(sizeGiB) {
-				klog.Warningf("file share size(%dGi) is already greater or equal than requested size(%dGi), accountName: %s, shareName: %s",
-					ptr.Deref(fileShare.FileShareProperties.ShareQuota, 0), sizeGiB, accountName, shareName)
-				return true, nil
+			fileClient, err := newAzureFileClientWithOAuth(d.cloud.AuthProvider.GetAzIdentity(), accountName, d.getStorageEndPointSuffix())
+			if err != nil {
+				return err
 			}
-			fileShare.FileShareProperties.ShareQuota = to.Ptr(int32(sizeGiB))
-			_, err = fileClient.Update(ctx, resourceGroup, accountName, shareName, *fileShare)
+			shareResized = true
+			return fileClient.ResizeFileShare(ctx, shareName, sizeGiB)
+		}
+		fileClient, err := d.getFileShareClientForSub(subsID)
+		if err != nil {
+			return err
 		}
-		if isRetriableError(err) {
-			klog.Warningf("ResizeFileShare(%s) on account(%s) with new size(%d) failed with error(%v), waiting for retrying", shareName, accountName, sizeGiB, err)
-			sleepIfThrottled(err, fileOpThrottlingSleepSec)
-			return false, nil
+		fileShare, err := fileClient.Get(ctx, resourceGroup, accountName, shareName, nil)
+		if err != nil {
+			return err
+		}
+		if ptr.Deref(fileShare.FileShareProperties.ShareQuota, 0) >= int32(sizeGiB) {
+			logger.Info("file share size is already greater or equal than requested size", "quotaGiB", ptr.Deref(fileShare.FileShareProperties.ShareQuota, 0),
+				"requestedGiB", sizeGiB, "account", accountName, "share", shareName)
+			return nil
+		}
+		fileShare.FileShareProperties.ShareQuota = to.Ptr(int32(sizeGiB))
+		shareResized = true
+		_, err = fileClient.Update(ctx, resourceGroup, accountName, shareName, *fileShare)
+		return err
+	}, func(err error, _ azerrors.Category) (bool, error) {
+		if !shareResized {
+			return true, err
 		}
-		return true, err
+		return false, nil
 	})
 }
 
@@ -1252,6 +1488,7 @@ func (d *Driver) GetTotalAccountQuota(ctx context.Context, subsID, resourceGroup
 
 // RemoveStorageAccountTag remove tag from storage account
 func (d *Driver) RemoveStorageAccountTag(ctx context.Context, subsID, resourceGroup, account, key string) error {
+	logger := klog.FromContext(ctx)
 	if d.cloud == nil {
 		return fmt.Errorf("cloud or StorageAccountClient is nil")
 	}
@@ -1261,11 +1498,11 @@ func (d *Driver) RemoveStorageAccountTag(ctx context.Context, subsID, resourceGr
 		return err
 	}
 	if cache != nil {
-		klog.V(6).Infof("skip remove tag(%s) on account(%s) subsID(%s) resourceGroup(%s) since tag is added or removed in a short time", key, account, subsID, resourceGroup)
+		logger.V(6).Info("skip remove tag since tag is added or removed in a short time", "tag", key, "account", account, "subsID", subsID, "resourceGroup", resourceGroup)
 		return nil
 	}
 
-	klog.V(2).Infof("remove tag(%s) on account(%s) subsID(%s), resourceGroup(%s)", key, account, subsID, resourceGroup)
+	logger.V(2).Info("remove tag on account", "tag", key, "account", account, "subsID", subsID, "resourceGroup", resourceGroup)
 	defer d.skipMatchingTagCache.Set(account, "")
 	if rerr := d.cloud.RemoveStorageAccountTag(ctx, subsID, resourceGroup, account, key); rerr != nil {
 		return rerr
@@ -1278,6 +1515,7 @@ func (d *Driver) RemoveStorageAccountTag(ctx context.Context, subsID, resourceGr
 //  2. use k8s client identity to read from k8s secret
 //  3. use cluster identity to get from storage account directly
 func (d *Driver) GetStorageAccesskey(ctx context.Context, accountOptions *storage.AccountOptions, secrets map[string]string, secretName, secretNamespace string) (string, error) {
+	logger := klog.FromContext(ctx)
 	if len(secrets) > 0 {
 		_, accountKey, err := getStorageAccount(secrets)
 		return accountKey, err
@@ -1299,7 +1537,7 @@ func (d *Driver) GetStorageAccesskey(ctx context.Context, accountOptions *storag
 	}
 	_, accountKey, err := d.GetStorageAccountFromSecret(ctx, secretName, secretNamespace)
 	if err != nil {
-		klog.V(2).Infof("could not get account(%s) key from secret(%s), error: %v, use cluster identity to get account key instead", accountOptions.Name, secretName, err)
+		logger.V(2).Info("could not get account key from secret, use cluster identity to get account key instead", "account", accountOptions.Name, "secret", secretName, "err", err)
 		accountKey, err = d.GetStorageAccesskeyWithSubsID(ctx, accountOptions.SubscriptionID, accountOptions.Name, accountOptions.ResourceGroup, accountOptions.GetLatestAccountKey)
 	}
 
@@ -1363,6 +1601,7 @@ func (d *Driver) getSubnetResourceID(vnetResourceGroup, vnetName, subnetName str
 }
 
 func (d *Driver) useDataPlaneAPI(ctx context.Context, volumeID, accountName string) string {
+	logger := klog.FromContext(ctx)
 	v, useDataPlaneAPI := d.dataPlaneAPIVolMap.Load(volumeID)
 	if useDataPlaneAPI {
 		return v.(string)
@@ -1370,7 +1609,7 @@ func (d *Driver) useDataPlaneAPI(ctx context.Context, volumeID, accountName stri
 
 	cache, err := d.dataPlaneAPIAccountCache.Get(ctx, accountName, azcache.CacheReadTypeDefault)
 	if err != nil {
-		klog.Errorf("get(%s) from dataPlaneAPIAccountCache failed with error: %v", accountName, err)
+		logger.Error(err, "get from dataPlaneAPIAccountCache failed", "account", accountName)
 		return ""
 	}
 	if cache != nil {
@@ -1380,8 +1619,9 @@ func (d *Driver) useDataPlaneAPI(ctx context.Context, volumeID, accountName stri
 }
 
 func (d *Driver) SetAzureCredentials(ctx context.Context, accountName, accountKey, secretName, secretNamespace string) (string, error) {
+	logger := klog.FromContext(ctx)
 	if d.kubeClient == nil {
-		klog.Warningf("could not create secret: kubeClient is nil")
+		logger.Info("could not create secret: kubeClient is nil")
 		return "", nil
 	}
 	if accountName == "" || accountKey == "" {
@@ -1456,12 +1696,13 @@ func isKataNode(ctx context.Context, nodeID, confidentialContainerLabel string,
 // createFolderIfNotExists creates a folder in Azure File Share if it doesn't already exist
 // This function handles nested paths by creating each directory level recursively
 func (d *Driver) createFolderIfNotExists(ctx context.Context, accountName, accountKey, fileShareName, folderName, storageEndpointSuffix string) error {
+	logger := klog.FromContext(ctx)
 	fileClient, err := newAzureFileClient(accountName, accountKey, storageEndpointSuffix)
-	if err != nil || fileClient.(*azureFileDataplaneClient).Client == nil {
+	if err != nil || getDataplaneServiceClient(fileClient) == nil {
 		return fmt.Errorf("create Azure File client(%s) failed: %v", accountName, err)
 	}
 
-	shareClient := fileClient.(*azureFileDataplaneClient).Client.NewShareClient(fileShareName)
+	shareClient := getDataplaneServiceClient(fileClient).NewShareClient(fileShareName)
 
 	// Performance optimization: First check if the complete directory structure already exists
 	// This is the most common case and avoids unnecessary recursive checking
@@ -1469,13 +1710,12 @@ func (d *Driver) createFolderIfNotExists(ctx context.Context, accountName, accou
 	_, err = fullPathClient.GetProperties(ctx, nil)
 	if err == nil {
 		// Complete directory structure already exists - fast path
-		klog.V(2).Infof("Folder path %s already exists in share %s", folderName, fileShareName)
+		logger.V(2).Info("folder path already exists in share", "folder", folderName, "share", fileShareName)
 		return nil
 	}
 
 	// Check if the error is something other than "not found"
-	var respErr *azcore.ResponseError
-	if !errors.As(err, &respErr) || respErr.StatusCode != 404 {
+	if !azerrors.IsNotFound(err) {
 		// Some other error occurred (permissions, network, etc.)
 		return fmt.Errorf("failed to check if folder path %s exists: %w", folderName, err)
 	}
@@ -1502,32 +1742,30 @@ func (d *Driver) createFolderIfNotExists(ctx context.Context, accountName, accou
 		// Check if directory already exists by trying to get its properties
 		if _, err = directoryClient.GetProperties(ctx, nil); err == nil {
 			// Directory already exists, continue to next level
-			klog.V(4).Infof("Directory %s already exists in share %s", currentPath, fileShareName)
+			logger.V(4).Info("directory already exists in share", "directory", currentPath, "share", fileShareName)
 			continue
 		}
 
 		// Check if the error is because directory doesn't exist
-		var respErr *azcore.ResponseError
-		if !errors.As(err, &respErr) || respErr.StatusCode != 404 {
+		if !azerrors.IsNotFound(err) {
 			// Some other error occurred
 			return fmt.Errorf("failed to check if directory %s exists: %w", currentPath, err)
 		}
 
 		// Create the directory at this level
 		if _, err = directoryClient.Create(ctx, nil); err != nil {
-			var respErr *azcore.ResponseError
-			if errors.As(err, &respErr) && respErr.StatusCode == 409 {
+			if azerrors.IsConflict(err) {
 				// Directory already exists (race condition), which is fine
-				klog.V(4).Infof("Directory %s already exists in share %s (detected during creation)", currentPath, fileShareName)
+				logger.V(4).Info("directory already exists in share (detected during creation)", "directory", currentPath, "share", fileShareName)
 				continue
 			}
 			return fmt.Errorf("failed to create directory %s: %w", currentPath, err)
 		}
 
-		klog.V(2).Infof("Successfully created directory %s in share %s", currentPath, fileShareName)
+		logger.V(2).Info("created directory in share successfully", "directory", currentPath, "share", fileShareName)
 	}
 
-	klog.V(2).Infof("Successfully ensured folder path %s exists in share %s", folderName, fileShareName)
+	logger.V(2).Info("ensured folder path exists in share", "folder", folderName, "share", fileShareName)
 	return nil
 }
 
@@ -28,6 +28,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/utils"
 )
 
@@ -55,6 +56,8 @@ func newAzureFileClient(accountName, accountKey, storageEndpointSuffix string) (
 	serviceURL := getFileServiceURL(accountName, storageEndpointSuffix)
 	clientOps := utils.GetDefaultAzCoreClientOption()
 	clientOps.Retry.StatusCodes = defaultValidStatusCodes
+	clientOps.PerRetryPolicies = append(clientOps.PerRetryPolicies, tracing.AzurePolicies()...)
+	clientOps.PerRetryPolicies = append(clientOps.PerRetryPolicies, faultInjection.policies()...)
 
 	fileClient, err := service.NewClientWithSharedKeyCredential(serviceURL, keyCred, &service.ClientOptions{
 		ClientOptions: clientOps,
@@ -63,25 +66,28 @@ func newAzureFileClient(accountName, accountKey, storageEndpointSuffix string) (
 		return nil, fmt.Errorf("error creating azure client: %v", err)
 	}
 
-	return &azureFileDataplaneClient{
+	return faultInjection.wrap(&azureFileDataplaneClient{
 		accountName: accountName,
 		Client:      fileClient,
-	}, nil
+	}), nil
 }
 
 func newAzureFileClientWithOAuth(cred azcore.TokenCredential, accountName, storageEndpointSuffix string) (azureFileClient, error) {
 	serviceURL := getFileServiceURL(accountName, storageEndpointSuffix)
+	clientOps := utils.GetDefaultAzCoreClientOption()
+	clientOps.PerRetryPolicies = append(clientOps.PerRetryPolicies, tracing.AzurePolicies()...)
+	clientOps.PerRetryPolicies = append(clientOps.PerRetryPolicies, faultInjection.policies()...)
 	fileClient, err := service.NewClient(serviceURL, cred, &service.ClientOptions{
-		ClientOptions: utils.GetDefaultAzCoreClientOption(),
+		ClientOptions: clientOps,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating azure client with oauth: %v", err)
 	}
 	klog.V(2).Infof("created azure file client with oauth, accountName: %s", accountName)
-	return &azureFileDataplaneClient{
+	return faultInjection.wrap(&azureFileDataplaneClient{
 		accountName: accountName,
 		Client:      fileClient,
-	}, nil
+	}), nil
 }
 
 func (f *azureFileDataplaneClient) CreateFileShare(ctx context.Context, shareOptions *ShareOptions) error {
@@ -101,14 +107,15 @@ func (f *azureFileDataplaneClient) DeleteFileShare(ctx context.Context, shareNam
 }
 
 func (f *azureFileDataplaneClient) ResizeFileShare(ctx context.Context, shareName string, sizeGiB int) error {
+	logger := klog.FromContext(ctx)
 	shareClient := f.Client.NewShareClient(shareName)
 	shareProps, err := shareClient.GetProperties(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to set quota on file share %s, err: %v", shareName, err)
 	}
 	if *shareProps.Quota >= int32(sizeGiB) {
-		klog.Warningf("file share size(%dGi) is already greater or equal than requested size(%dGi), accountName: %s, shareName: %s",
-			*shareProps.Quota, sizeGiB, f.accountName, shareName)
+		logger.Info("file share size is already greater or equal than requested size", "quotaGiB", *shareProps.Quota,
+			"requestedGiB", sizeGiB, "account", f.accountName, "share", shareName)
 		return nil
 	}
 	if _, err := shareClient.SetProperties(ctx, &share.SetPropertiesOptions{
@@ -116,7 +123,7 @@ func (f *azureFileDataplaneClient) ResizeFileShare(ctx context.Context, shareNam
 	}); err != nil {
 		return fmt.Errorf("failed to set quota on file share %s, err: %v", shareName, err)
 	}
-	klog.V(4).Infof("resize file share completed, accountName: %s, shareName: %s, sizeGiB: %d", f.accountName, shareName, sizeGiB)
+	logger.V(4).Info("resize file share completed", "account", f.accountName, "share", shareName, "sizeGiB", sizeGiB)
 	return nil
 }
 
@@ -22,8 +22,29 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
 )
 
+// fakeStorageEndpointSuffix is the storage endpoint suffix which is routed to the fake Azure Files backend by useFakeFileService
+const fakeStorageEndpointSuffix = "fake.core.windows.net"
+
+// useFakeFileService routes the data plane clients of fakeStorageEndpointSuffix to the fake Azure Files backend until the test ends
+func useFakeFileService(t *testing.T, server *fakeazure.Server) {
+	t.Helper()
+	original := getFileServiceURL
+	getFileServiceURL = func(accountName, storageEndpointSuffix string) string {
+		if storageEndpointSuffix == fakeStorageEndpointSuffix {
+			return server.ServiceURL(accountName)
+		}
+		return original(accountName, storageEndpointSuffix)
+	}
+	t.Cleanup(func() { getFileServiceURL = original })
+}
+
 func TestCreateFileShare(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode")
@@ -153,3 +174,31 @@ func TestGetFileShareQuotaDataPlane(t *testing.T) {
 		t.Run(tc.name, tc.testFunc)
 	}
 }
+
+func TestAzureFileDataplaneClientWithFakeBackend(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+
+	f, err := newAzureFileClient("testaccount", accountKey, fakeStorageEndpointSuffix)
+	require.NoError(t, err)
+
+	quota, err := f.GetFileShareQuota(ctx, "share")
+	assert.Error(t, err)
+	assert.Equal(t, -1, quota)
+
+	require.NoError(t, f.CreateFileShare(ctx, &ShareOptions{Name: "share", RequestGiB: 10}))
+	quota, err = f.GetFileShareQuota(ctx, "share")
+	require.NoError(t, err)
+	assert.Equal(t, 10, quota)
+
+	require.NoError(t, f.ResizeFileShare(ctx, "share", 20))
+	// resize to a smaller size is a no-op
+	require.NoError(t, f.ResizeFileShare(ctx, "share", 5))
+	assert.Equal(t, int32(20), server.ShareQuota("testaccount", "share"))
+
+	require.NoError(t, f.DeleteFileShare(ctx, "share"))
+	assert.Empty(t, server.Shares("testaccount"))
+}
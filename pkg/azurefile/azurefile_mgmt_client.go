@@ -44,10 +44,10 @@ func newAzureFileMgmtClient(cloud *storage.AccountRepo, accountOptions *storage.
 		return nil, fmt.Errorf("failed to get file share client for subscription %s: %w", accountOptions.SubscriptionID, err)
 	}
 
-	return &azureFileMgmtClient{
+	return faultInjection.wrap(&azureFileMgmtClient{
 		accountOptions:  accountOptions,
 		fileShareClient: fileShareClient,
-	}, nil
+	}), nil
 
 }
 
@@ -87,7 +87,7 @@ func (az *azureFileMgmtClient) CreateFileShare(ctx context.Context, shareOptions
 	if _, err := az.fileShareClient.Create(ctx, az.accountOptions.ResourceGroup, az.accountOptions.Name, shareOptions.Name, shareOps, nil); err != nil {
 		return fmt.Errorf("failed to create share %s in account %s: %w", shareOptions.Name, az.accountOptions.Name, err)
 	}
-	klog.V(4).Infof("created share %s in account %s", shareOptions.Name, az.accountOptions.Name)
+	klog.FromContext(ctx).V(4).Info("created share", "share", shareOptions.Name, "account", az.accountOptions.Name)
 	return nil
 }
 
@@ -96,7 +96,7 @@ func (az *azureFileMgmtClient) DeleteFileShare(ctx context.Context, shareName st
 	if err := az.fileShareClient.Delete(ctx, az.accountOptions.ResourceGroup, az.accountOptions.Name, shareName, nil); err != nil {
 		return err
 	}
-	klog.V(4).Infof("share %s deleted", shareName)
+	klog.FromContext(ctx).V(4).Info("deleted share", "share", shareName)
 	return nil
 }
 
@@ -107,8 +107,8 @@ func (az *azureFileMgmtClient) ResizeFileShare(ctx context.Context, name string,
 		return err
 	}
 	if int(*fileShare.FileShareProperties.ShareQuota) >= sizeGiB {
-		klog.Warningf("file share size(%dGi) is already greater or equal than requested size(%dGi), accountName: %s, shareName: %s",
-			*fileShare.FileShareProperties.ShareQuota, sizeGiB, az.accountOptions.Name, name)
+		klog.FromContext(ctx).Info("file share size is already greater or equal than requested size", "quotaGiB", *fileShare.FileShareProperties.ShareQuota,
+			"requestedGiB", sizeGiB, "account", az.accountOptions.Name, "share", name)
 		return nil
 	}
 
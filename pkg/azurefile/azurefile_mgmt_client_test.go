@@ -17,12 +17,16 @@ limitations under the License.
 package azurefile
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient/mock_fileshareclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/mock_azclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
@@ -67,3 +71,34 @@ func TestNewAzureMgmtFileClient(t *testing.T) {
 	})
 	assert.Equal(t, actualErr, nil, "newAzureFileMgmtClient should return success")
 }
+
+func TestAzureFileMgmtClientWithFakeBackend(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	server.AddAccount("testsub", "testrg", "testaccount", "eastus")
+	fileShareClient, err := server.NewFileShareClient("testsub")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	computeClientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	computeClientFactory.EXPECT().GetFileShareClientForSub("testsub").Return(fileShareClient, nil).AnyTimes()
+	f, err := newAzureFileMgmtClient(&storage.AccountRepo{ComputeClientFactory: computeClientFactory}, &storage.AccountOptions{
+		Name:           "testaccount",
+		SubscriptionID: "testsub",
+		ResourceGroup:  "testrg",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, f.CreateFileShare(ctx, &ShareOptions{Name: "share", RequestGiB: 10}))
+	quota, err := f.GetFileShareQuota(ctx, "share")
+	require.NoError(t, err)
+	assert.Equal(t, 10, quota)
+
+	require.NoError(t, f.ResizeFileShare(ctx, "share", 30))
+	assert.Equal(t, int32(30), server.ShareQuota("testaccount", "share"))
+
+	require.NoError(t, f.DeleteFileShare(ctx, "share"))
+	assert.Empty(t, server.Shares("testaccount"))
+}
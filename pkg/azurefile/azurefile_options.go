@@ -24,6 +24,9 @@ type DriverOptions struct {
 	DriverName                             string
 	EnableAzurefileProxy                   bool
 	AzureFileProxyEndpoint                 string
+	AzureFileProxyTLSCertFile              string
+	AzureFileProxyTLSKeyFile               string
+	AzureFileProxyTLSCAFile                string
 	CloudConfigSecretName                  string
 	CloudConfigSecretNamespace             string
 	CustomUserAgent                        string
@@ -34,11 +37,41 @@ type DriverOptions struct {
 	EnableVolumeMountGroup                 bool
 	EnableGetVolumeStats                   bool
 	EnableKataCCMount                      bool
+	KataCCMountInfoPublicKeyFile           string
 	AppendMountErrorHelpLink               bool
 	MountPermissions                       uint64
 	FSGroupChangePolicy                    string
 	KubeAPIQPS                             float64
 	KubeAPIBurst                           int
+	ARMRequestQPS                          float64
+	ARMRequestBurst                        int
+	MaxConcurrentOperationsPerAccount      int
+	MaxQueuedOperationsPerAccount          int
+	CachePersistenceConfigMap              string
+	CachePersistenceIntervalSeconds        int
+	AuditLogPath                           string
+	AuditLogMaxSizeMB                      int
+	AuditLogMaxBackups                     int
+	OTLPEndpoint                           string
+	TracingSamplingRatio                   float64
+	MountHealthCheckIntervalSeconds        int
+	MountHealthCheckTimeoutSeconds         int
+	EnableStaleMountRecovery               bool
+	StaleMountRecoveryMinIntervalSeconds   int
+	MaxStaleMountRecoveriesPerMinute       int
+	StaleMountRecoveryKillSwitchFile       string
+	MountOptionPolicyFile                  string
+	EnableVolumeIOStats                    bool
+	VolumeStatsRefreshIntervalSeconds      int
+	VolumeStatsTimeoutSeconds              int
+	VolumeIOStatsMaxVolumes                int
+	FolderUsageScanIntervalSeconds         int
+	FolderUsageMaxEntriesPerScan           int
+	FolderQuotaExceededAction              string
+	EnableVHDLeaseFencing                  bool
+	VHDLeaseRenewIntervalSeconds           int
+	VHDDiscardMode                         string
+	VHDTrimIntervalSeconds                 int
 	EnableWindowsHostProcess               bool
 	RemoveSMBMountOnWindows                bool
 	AppendClosetimeoOption                 bool
@@ -65,6 +98,9 @@ func (o *DriverOptions) AddFlags() *flag.FlagSet {
 	fs.StringVar(&o.DriverName, "drivername", DefaultDriverName, "name of the driver")
 	fs.BoolVar(&o.EnableAzurefileProxy, "enable-azurefile-proxy", false, "enable azurefile proxy")
 	fs.StringVar(&o.AzureFileProxyEndpoint, "azurefile-proxy-endpoint", "unix://tmp/azurefile-proxy.sock", "azurefile-proxy endpoint")
+	fs.StringVar(&o.AzureFileProxyTLSCertFile, "azurefile-proxy-tls-cert-file", "", "client certificate file of mTLS to azurefile-proxy on TCP endpoint")
+	fs.StringVar(&o.AzureFileProxyTLSKeyFile, "azurefile-proxy-tls-key-file", "", "client private key file of mTLS to azurefile-proxy on TCP endpoint")
+	fs.StringVar(&o.AzureFileProxyTLSCAFile, "azurefile-proxy-tls-ca-file", "", "CA file to verify the server certificate of azurefile-proxy on TCP endpoint")
 	fs.StringVar(&o.CloudConfigSecretName, "cloud-config-secret-name", "azure-cloud-provider", "secret name of cloud config. If set to an empty string, the driver will not read cloud config from a Kubernetes secret.")
 	fs.StringVar(&o.CloudConfigSecretNamespace, "cloud-config-secret-namespace", "kube-system", "secret namespace of cloud config. If set to an empty string, the driver will not read cloud config from a Kubernetes secret.")
 	fs.StringVar(&o.CustomUserAgent, "custom-user-agent", "", "custom userAgent")
@@ -75,11 +111,41 @@ func (o *DriverOptions) AddFlags() *flag.FlagSet {
 	fs.BoolVar(&o.EnableVolumeMountGroup, "enable-volume-mount-group", true, "indicates whether enabling VOLUME_MOUNT_GROUP")
 	fs.BoolVar(&o.EnableGetVolumeStats, "enable-get-volume-stats", true, "allow GET_VOLUME_STATS on agent node")
 	fs.BoolVar(&o.EnableKataCCMount, "enable-kata-cc-mount", false, "enable Kata Confidential Containers mount")
+	fs.StringVar(&o.KataCCMountInfoPublicKeyFile, "kata-cc-mount-info-public-key-file", "", "path of the PEM encoded RSA public key of the confidential guest, sensitive mount options in the direct volume mount info are encrypted to it so that the account key is not saved in plaintext on the host")
 	fs.BoolVar(&o.AppendMountErrorHelpLink, "append-mount-error-help-link", true, "Whether to include a link for help with mount errors when a mount error occurs.")
 	fs.Uint64Var(&o.MountPermissions, "mount-permissions", 0777, "mounted folder permissions")
 	fs.StringVar(&o.FSGroupChangePolicy, "fsgroup-change-policy", "", "indicates how the volume's ownership will be changed by the driver, OnRootMismatch is the default value")
 	fs.Float64Var(&o.KubeAPIQPS, "kube-api-qps", 25.0, "QPS to use while communicating with the kubernetes apiserver.")
 	fs.IntVar(&o.KubeAPIBurst, "kube-api-burst", 50, "Burst to use while communicating with the kubernetes apiserver.")
+	fs.Float64Var(&o.ARMRequestQPS, "arm-request-qps", 20.0, "client side QPS budget per subscription shared by the storage, network and private DNS ARM requests, 0 disables the rate limit")
+	fs.IntVar(&o.ARMRequestBurst, "arm-request-burst", 100, "client side burst budget per subscription shared by the storage, network and private DNS ARM requests")
+	fs.IntVar(&o.MaxConcurrentOperationsPerAccount, "max-concurrent-operations-per-account", 4, "maximum number of in-flight mutating operations (create, delete, expand, snapshot) per storage account in controller, 0 means no limit")
+	fs.IntVar(&o.MaxQueuedOperationsPerAccount, "max-queued-operations-per-account", 64, "maximum number of mutating operations waiting for their turn per storage account, operations over the limit are aborted")
+	fs.StringVar(&o.CachePersistenceConfigMap, "cache-persistence-configmap", "", "ConfigMap in the format of <namespace>/<name> to persist controller caches (storage account search, skip matching tag, data plane API account) across restarts, ignored on node, empty string disables cache persistence")
+	fs.IntVar(&o.CachePersistenceIntervalSeconds, "cache-persistence-interval-seconds", 60, "interval in seconds to save controller caches into the cache persistence ConfigMap")
+	fs.StringVar(&o.AuditLogPath, "audit-log-path", "", "file path to write the audit log of mutating operations as JSON lines, \"stdout\" writes to standard output, empty string disables the audit log")
+	fs.IntVar(&o.AuditLogMaxSizeMB, "audit-log-max-size-mb", 100, "maximum size in megabytes of the audit log file before it's rotated, 0 disables rotation")
+	fs.IntVar(&o.AuditLogMaxBackups, "audit-log-max-backups", 5, "maximum number of rotated audit log files to keep")
+	fs.StringVar(&o.OTLPEndpoint, "otlp-endpoint", "", "OTLP gRPC endpoint to export OpenTelemetry traces to, e.g. http://otel-collector:4317, empty string disables tracing")
+	fs.Float64Var(&o.TracingSamplingRatio, "tracing-sampling-ratio", 0.1, "ratio of CSI calls sampled for tracing, calls with a sampled parent span are always sampled")
+	fs.IntVar(&o.MountHealthCheckIntervalSeconds, "mount-health-check-interval-seconds", 60, "interval in seconds to probe the health of staged mounts on the node, the result is returned as VolumeCondition in NodeGetVolumeStats, 0 disables the mount health monitor")
+	fs.IntVar(&o.MountHealthCheckTimeoutSeconds, "mount-health-check-timeout-seconds", 10, "timeout in seconds of the stat probing a staged mount, a mount is unreachable if the stat does not return in time")
+	fs.BoolVar(&o.EnableStaleMountRecovery, "enable-stale-mount-recovery", false, "remount stale or unreachable staged SMB/NFS mounts found by the mount health monitor and refresh their bind mounts, only available on Linux")
+	fs.IntVar(&o.StaleMountRecoveryMinIntervalSeconds, "stale-mount-recovery-min-interval-seconds", 300, "minimum interval in seconds between two recoveries of the same volume")
+	fs.IntVar(&o.MaxStaleMountRecoveriesPerMinute, "max-stale-mount-recoveries-per-minute", 5, "maximum number of stale mount recoveries per minute on the node")
+	fs.StringVar(&o.StaleMountRecoveryKillSwitchFile, "stale-mount-recovery-kill-switch-file", "", "stale mount recovery is paused while this file exists on the node, empty string disables the kill switch")
+	fs.StringVar(&o.MountOptionPolicyFile, "mount-option-policy-file", "", "yaml or json file of the mount option policy which allows, denies, forces and defaults SMB/NFS mount options, e.g. a ConfigMap mounted into the driver pod, the file is reloaded every minute, empty string disables the policy")
+	fs.IntVar(&o.VolumeStatsRefreshIntervalSeconds, "volume-stats-refresh-interval-seconds", 60, "interval in seconds to refresh the stats of staged volumes in background, NodeGetVolumeStats answers from the last refreshed stats, the first call of a volume waits up to volume-stats-timeout-seconds and returns Unavailable if the stats are not collected in time, 0 disables the background refresh and stats are got on each NodeGetVolumeStats call")
+	fs.IntVar(&o.VolumeStatsTimeoutSeconds, "volume-stats-timeout-seconds", 10, "timeout in seconds of a background volume stats call, the last stats is kept if the call times out")
+	fs.BoolVar(&o.EnableVolumeIOStats, "enable-volume-io-stats", false, "export per volume I/O counters read from /proc/self/mountstats (NFS) and /proc/fs/cifs/Stats (SMB) on the metrics endpoint of the node, SMB counters are per share and labeled with the share, only available on Linux")
+	fs.IntVar(&o.VolumeIOStatsMaxVolumes, "volume-io-stats-max-volumes", 100, "maximum number of volumes whose I/O counters are exported on the node to limit the label cardinality, 0 means no limit")
+	fs.IntVar(&o.FolderUsageScanIntervalSeconds, "folder-usage-scan-interval-seconds", 60, "interval in seconds to scan the usage of staged folder-based volumes with a folderQuota volume attribute, NodeGetVolumeStats reports the usage against the quota, 0 disables the folder usage tracker")
+	fs.IntVar(&o.FolderUsageMaxEntriesPerScan, "folder-usage-max-entries-per-scan", 10000, "maximum number of directory entries read per volume in each folder usage scan interval, a large folder is scanned over several intervals, 0 means no limit")
+	fs.StringVar(&o.FolderQuotaExceededAction, "folder-quota-exceeded-action", "metric", "action when the usage of a folder-based volume exceeds its folderQuota: metric records a metric, event also records a warning event on the PVC, readonly also remounts the staged volume read-only (Linux only)")
+	fs.BoolVar(&o.EnableVHDLeaseFencing, "enable-vhd-lease-fencing", false, "acquire a lease on a lock file next to the VHD disk in NodeStageVolume and release it in NodeUnstageVolume so that a VHD disk volume is staged on a single node, the lease of a node which is deleted or tainted with node.kubernetes.io/out-of-service is broken by the next node")
+	fs.IntVar(&o.VHDLeaseRenewIntervalSeconds, "vhd-lease-renew-interval-seconds", 60, "interval in seconds to make sure the leases of staged VHD disk volumes are still held by the node, a broken lease is acquired again, 0 disables the renewal")
+	fs.StringVar(&o.VHDDiscardMode, "vhd-discard-mode", "", "reclaim the share space of deleted blocks inside VHD disk volumes: online mounts the filesystem with discard option, fstrim runs fstrim on staged VHD disk volumes every vhd-trim-interval-seconds, empty disables it (Linux only)")
+	fs.IntVar(&o.VHDTrimIntervalSeconds, "vhd-trim-interval-seconds", 86400, "interval in seconds to run fstrim on staged VHD disk volumes when vhd-discard-mode is fstrim")
 	fs.BoolVar(&o.EnableWindowsHostProcess, "enable-windows-host-process", false, "enable windows host process")
 	fs.BoolVar(&o.RemoveSMBMountOnWindows, "remove-smb-mount-on-windows", true, "remove smb global mapping on windows during unmount")
 	fs.BoolVar(&o.AppendClosetimeoOption, "append-closetimeo-option", false, "Whether appending closetimeo=0 option to smb mount command")
@@ -0,0 +1,226 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
+	csicommon "sigs.k8s.io/azurefile-csi-driver/pkg/csi-common"
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
+)
+
+const (
+	// AzurefileProxyAPIVersion is the version of the azurefile-proxy API served by this build,
+	// 1: MountAzureFile only
+	// 2: UnmountAzureFile, ListMounts, Health and GetVersion
+	AzurefileProxyAPIVersion uint32 = 2
+
+	// azurefileProxyHealthCheckTimeout is the timeout of a health check of azurefile-proxy
+	azurefileProxyHealthCheckTimeout = 5 * time.Second
+	// azurefileProxyHealthCheckInterval is the interval of the background health check of azurefile-proxy,
+	// Probe only reads the last result so that it's never blocked by the proxy
+	azurefileProxyHealthCheckInterval = 30 * time.Second
+)
+
+// azurefileProxyHealth is the result of the last health check of azurefile-proxy
+type azurefileProxyHealth struct {
+	err       error
+	checkedAt time.Time
+}
+
+// dialAzurefileProxy returns a client of azurefile-proxy, the returned func closes the connection,
+// mTLS is used on TCP endpoint since the proxy does not serve plaintext over TCP
+func (d *Driver) dialAzurefileProxy(ctx context.Context) (*MountClient, func(), error) {
+	logger := klog.FromContext(ctx)
+	target, creds := d.azurefileProxyEndpoint, insecure.NewCredentials()
+	if proto, addr, err := csicommon.ParseEndpoint(d.azurefileProxyEndpoint); err == nil && proto == "tcp" {
+		tlsConfig, err := newAzurefileProxyTLSConfig(addr, d.azurefileProxyTLSCertFile, d.azurefileProxyTLSKeyFile, d.azurefileProxyTLSCAFile)
+		if err != nil {
+			logger.Error(err, "failed to set up mTLS to azurefile proxy")
+			return nil, nil, err
+		}
+		target, creds = addr, credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds), grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor))
+	if err != nil {
+		logger.Error(err, "failed to connect to azurefile proxy")
+		return nil, nil, err
+	}
+	closeFunc := func() {
+		if err := conn.Close(); err != nil {
+			logger.Error(err, "failed to close connection to azurefile proxy")
+		}
+	}
+	return NewMountClient(conn), closeFunc, nil
+}
+
+// newAzurefileProxyTLSConfig returns the mTLS config to azurefile-proxy on addr, the files are loaded on each call
+// so that rotated certificates are picked up
+func newAzurefileProxyTLSConfig(addr, certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("certificate, key and CA files are all required for mTLS")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %v", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate is found in %s", caFile)
+	}
+	serverName, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// getAzurefileProxyAPIVersion returns the API version of azurefile-proxy,
+// a proxy without GetVersion only serves MountAzureFile
+func getAzurefileProxyAPIVersion(ctx context.Context, client *MountClient) (uint32, error) {
+	resp, err := client.service.GetVersion(ctx, &mount_azurefile.GetVersionRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return 1, nil
+		}
+		return 0, err
+	}
+	return resp.GetApiVersion(), nil
+}
+
+// unmountWithProxy unmounts target by azurefile-proxy if it's an nfs mount made by the proxy, e.g. aznfs mount,
+// nothing is done if the proxy is not available, does not support UnmountAzureFile or target is not mounted on the host,
+// the caller still needs to unmount and clean up target in the driver container
+func (d *Driver) unmountWithProxy(ctx context.Context, target string) error {
+	logger := klog.FromContext(ctx)
+	client, closeFunc, err := d.dialAzurefileProxy(ctx)
+	if err != nil {
+		logger.Error(err, "azurefile proxy is not available, unmount in driver", "target", target)
+		return nil
+	}
+	defer closeFunc()
+
+	ctx, cancel := context.WithTimeout(ctx, MountTimeoutInSec*time.Second)
+	defer cancel()
+	apiVersion, err := getAzurefileProxyAPIVersion(ctx, client)
+	if err != nil {
+		logger.Error(err, "failed to get azurefile proxy version, unmount in driver", "target", target)
+		return nil
+	}
+	if apiVersion < 2 {
+		logger.V(4).Info("azurefile proxy does not support unmount, unmount in driver", "target", target, "apiVersion", apiVersion)
+		return nil
+	}
+
+	resp, err := client.service.ListMounts(ctx, &mount_azurefile.ListMountsRequest{TargetPrefix: target})
+	if err != nil {
+		logger.Error(err, "failed to list mounts by azurefile proxy, unmount in driver", "target", target)
+		return nil
+	}
+	for _, mp := range resp.GetMounts() {
+		if mp.GetTarget() != target || (mp.GetFstype() != nfs && mp.GetFstype() != "nfs4") {
+			continue
+		}
+		logger.V(2).Info("begin to unmount with azurefile proxy", "source", mp.GetSource(), "target", target, "fsType", mp.GetFstype())
+		if _, err := client.service.UnmountAzureFile(ctx, &mount_azurefile.UnmountAzureFileRequest{Target: target}); err != nil {
+			return fmt.Errorf("unmount with azurefile proxy failed: %v", err)
+		}
+		logger.V(2).Info("unmount with azurefile proxy completed", "target", target)
+		return nil
+	}
+	return nil
+}
+
+// runAzurefileProxyHealthCheck checks the health of azurefile-proxy every interval until ctx is done,
+// the result is cached for Probe and recorded in metric
+func (d *Driver) runAzurefileProxyHealthCheck(ctx context.Context, interval time.Duration) {
+	klog.FromContext(ctx).V(2).Info("azurefile proxy health check started", "interval", interval, "timeout", azurefileProxyHealthCheckTimeout)
+	wait.UntilWithContext(ctx, d.updateAzurefileProxyHealth, interval)
+}
+
+// updateAzurefileProxyHealth checks the health of azurefile-proxy and caches the result,
+// it's only logged when the proxy becomes unhealthy or healthy again
+func (d *Driver) updateAzurefileProxyHealth(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	err := d.checkAzurefileProxyHealth(ctx)
+	last := d.azurefileProxyHealth.Load()
+	switch {
+	case err != nil && (last == nil || last.err == nil):
+		logger.Error(err, "azurefile proxy is not healthy")
+	case err == nil && last != nil && last.err != nil:
+		logger.Info("azurefile proxy is healthy again")
+	}
+	d.azurefileProxyHealth.Store(&azurefileProxyHealth{err: err, checkedAt: time.Now()})
+	csiMetrics.SetAzurefileProxyHealthy(err == nil)
+}
+
+// checkAzurefileProxyHealth returns an error if azurefile-proxy is not reachable or not healthy,
+// a proxy without Health is regarded as healthy once it's reachable
+func (d *Driver) checkAzurefileProxyHealth(ctx context.Context) error {
+	client, closeFunc, err := d.dialAzurefileProxy(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFunc()
+
+	ctx, cancel := context.WithTimeout(ctx, azurefileProxyHealthCheckTimeout)
+	defer cancel()
+	apiVersion, err := getAzurefileProxyAPIVersion(ctx, client)
+	if err != nil {
+		return fmt.Errorf("azurefile proxy is not reachable: %v", err)
+	}
+	if apiVersion < 2 {
+		return nil
+	}
+	resp, err := client.service.Health(ctx, &mount_azurefile.HealthRequest{})
+	if err != nil {
+		return fmt.Errorf("azurefile proxy health check failed: %v", err)
+	}
+	if !resp.GetHealthy() {
+		return fmt.Errorf("azurefile proxy is not healthy: %s", resp.GetMessage())
+	}
+	if resp.GetMessage() != "" {
+		klog.FromContext(ctx).V(4).Info("azurefile proxy is healthy", "message", resp.GetMessage())
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
+)
+
+// fakeProxyServer serves the v2 azurefile-proxy API
+type fakeProxyServer struct {
+	mount_azurefile.UnimplementedMountServiceServer
+
+	mounts     []*mount_azurefile.MountPoint
+	unmounted  []string
+	unmountErr error
+	healthy    bool
+}
+
+func (s *fakeProxyServer) UnmountAzureFile(_ context.Context, req *mount_azurefile.UnmountAzureFileRequest) (*mount_azurefile.UnmountAzureFileResponse, error) {
+	if s.unmountErr != nil {
+		return nil, s.unmountErr
+	}
+	s.unmounted = append(s.unmounted, req.GetTarget())
+	return &mount_azurefile.UnmountAzureFileResponse{}, nil
+}
+
+func (s *fakeProxyServer) ListMounts(_ context.Context, req *mount_azurefile.ListMountsRequest) (*mount_azurefile.ListMountsResponse, error) {
+	resp := &mount_azurefile.ListMountsResponse{}
+	for _, mp := range s.mounts {
+		if strings.HasPrefix(mp.GetTarget(), req.GetTargetPrefix()) {
+			resp.Mounts = append(resp.Mounts, mp)
+		}
+	}
+	return resp, nil
+}
+
+func (s *fakeProxyServer) Health(_ context.Context, _ *mount_azurefile.HealthRequest) (*mount_azurefile.HealthResponse, error) {
+	return &mount_azurefile.HealthResponse{Healthy: s.healthy, Message: "fake"}, nil
+}
+
+func (s *fakeProxyServer) GetVersion(_ context.Context, _ *mount_azurefile.GetVersionRequest) (*mount_azurefile.GetVersionResponse, error) {
+	return &mount_azurefile.GetVersionResponse{Version: "fake", ApiVersion: AzurefileProxyAPIVersion}, nil
+}
+
+// startFakeProxy serves server on a unix socket and returns the endpoint
+func startFakeProxy(t *testing.T, server mount_azurefile.MountServiceServer) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "azurefile-proxy.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	mount_azurefile.RegisterMountServiceServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return "unix://" + socket
+}
+
+func TestUnmountWithProxy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("azurefile-proxy is only supported on Linux")
+	}
+	target := "/var/lib/kubelet/plugins/kubernetes.io/csi/file.csi.azure.com/vol/globalmount"
+	server := &fakeProxyServer{mounts: []*mount_azurefile.MountPoint{
+		{Source: "account.file.core.windows.net:/account/share", Target: target + "-other", Fstype: "nfs4"},
+		{Source: "account.file.core.windows.net:/account/share", Target: target, Fstype: "nfs4"},
+	}}
+	d := NewFakeDriver()
+	d.azurefileProxyEndpoint = startFakeProxy(t, server)
+
+	require.NoError(t, d.unmountWithProxy(context.Background(), target))
+	assert.Equal(t, []string{target}, server.unmounted)
+
+	// smb mounts are not made by the proxy
+	server.unmounted = nil
+	server.mounts = []*mount_azurefile.MountPoint{{Source: "//account.file.core.windows.net/share", Target: target, Fstype: "cifs"}}
+	require.NoError(t, d.unmountWithProxy(context.Background(), target))
+	assert.Empty(t, server.unmounted)
+
+	server.mounts = []*mount_azurefile.MountPoint{{Source: "account.file.core.windows.net:/account/share", Target: target, Fstype: "nfs4"}}
+	server.unmountErr = errors.New("device is busy")
+	err := d.unmountWithProxy(context.Background(), target)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "device is busy")
+
+	// an old proxy only serves MountAzureFile
+	d.azurefileProxyEndpoint = startFakeProxy(t, &mount_azurefile.UnimplementedMountServiceServer{})
+	assert.NoError(t, d.unmountWithProxy(context.Background(), target))
+
+	// unmount in driver if the proxy is not available
+	d.azurefileProxyEndpoint = "unix://" + filepath.Join(t.TempDir(), "notexist.sock")
+	assert.NoError(t, d.unmountWithProxy(context.Background(), target))
+}
+
+func TestProbeWithAzurefileProxy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("azurefile-proxy is only supported on Linux")
+	}
+	tests := []struct {
+		desc           string
+		server         mount_azurefile.MountServiceServer
+		nodeID         string
+		expectedHealth error
+	}{
+		{
+			desc:   "healthy proxy",
+			server: &fakeProxyServer{healthy: true},
+			nodeID: fakeNodeID,
+		},
+		{
+			desc:           "unhealthy proxy",
+			server:         &fakeProxyServer{},
+			nodeID:         fakeNodeID,
+			expectedHealth: errors.New("azurefile proxy is not healthy"),
+		},
+		{
+			desc:   "old proxy",
+			server: &mount_azurefile.UnimplementedMountServiceServer{},
+			nodeID: fakeNodeID,
+		},
+		{
+			desc:           "proxy not available",
+			nodeID:         fakeNodeID,
+			expectedHealth: errors.New("azurefile proxy is not reachable"),
+		},
+		{
+			desc: "proxy is not checked by controller",
+		},
+	}
+	for _, test := range tests {
+		d := NewFakeDriver()
+		d.NodeID = test.nodeID
+		d.enableAzurefileProxy = true
+		d.azurefileProxyEndpoint = "unix://" + filepath.Join(t.TempDir(), "notexist.sock")
+		if test.server != nil {
+			d.azurefileProxyEndpoint = startFakeProxy(t, test.server)
+		}
+		// the node driver is ready before the first health check
+		resp, err := d.Probe(context.Background(), &csi.ProbeRequest{})
+		require.NoError(t, err, test.desc)
+		assert.True(t, resp.GetReady().GetValue(), test.desc)
+
+		if test.nodeID == "" {
+			continue
+		}
+		d.updateAzurefileProxyHealth(context.Background())
+		health := d.azurefileProxyHealth.Load()
+		require.NotNil(t, health, test.desc)
+		if test.expectedHealth == nil {
+			assert.NoError(t, health.err, test.desc)
+		} else {
+			assert.ErrorContains(t, health.err, test.expectedHealth.Error(), test.desc)
+		}
+
+		// the node driver is ready whatever the health of the proxy is, and Probe does not call the proxy
+		d.azurefileProxyEndpoint = "unix://" + filepath.Join(t.TempDir(), "notexist.sock")
+		resp, err = d.Probe(context.Background(), &csi.ProbeRequest{})
+		require.NoError(t, err, test.desc)
+		assert.True(t, resp.GetReady().GetValue(), test.desc)
+		assert.Equal(t, health, d.azurefileProxyHealth.Load(), test.desc)
+	}
+}
+
+func TestRunAzurefileProxyHealthCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("azurefile-proxy is only supported on Linux")
+	}
+	d := NewFakeDriver()
+	d.NodeID = fakeNodeID
+	d.enableAzurefileProxy = true
+	d.azurefileProxyEndpoint = startFakeProxy(t, &fakeProxyServer{healthy: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.runAzurefileProxyHealthCheck(ctx, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		health := d.azurefileProxyHealth.Load()
+		return health != nil && health.err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// writeProxyTestCert writes a certificate signed by parent, or a self-signed CA if parent is nil, and returns its files
+func writeProxyTestCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return cert, key, certFile, keyFile
+}
+
+func TestNewAzurefileProxyTLSConfig(t *testing.T) {
+	ca, caKey, caFile, _ := writeProxyTestCert(t, "ca", nil, nil)
+	_, _, certFile, keyFile := writeProxyTestCert(t, "client", ca, caKey)
+
+	tests := []struct {
+		desc          string
+		addr          string
+		certFile      string
+		keyFile       string
+		caFile        string
+		expectedError string
+	}{
+		{desc: "valid", addr: "10.0.0.1:8080", certFile: certFile, keyFile: keyFile, caFile: caFile},
+		{desc: "no CA", addr: "10.0.0.1:8080", certFile: certFile, keyFile: keyFile, expectedError: "all required"},
+		{desc: "invalid key", addr: "10.0.0.1:8080", certFile: certFile, keyFile: certFile, caFile: caFile, expectedError: "failed to load certificate"},
+		{desc: "invalid CA", addr: "10.0.0.1:8080", certFile: certFile, keyFile: keyFile, caFile: keyFile, expectedError: "no certificate is found"},
+		{desc: "no port", addr: "10.0.0.1", certFile: certFile, keyFile: keyFile, caFile: caFile, expectedError: "missing port"},
+	}
+	for _, test := range tests {
+		tlsConfig, err := newAzurefileProxyTLSConfig(test.addr, test.certFile, test.keyFile, test.caFile)
+		if test.expectedError != "" {
+			require.Error(t, err, test.desc)
+			assert.Contains(t, err.Error(), test.expectedError, test.desc)
+			continue
+		}
+		require.NoError(t, err, test.desc)
+		assert.Equal(t, "10.0.0.1", tlsConfig.ServerName, test.desc)
+		assert.Len(t, tlsConfig.Certificates, 1, test.desc)
+	}
+}
+
+func TestDialAzurefileProxyWithMTLS(t *testing.T) {
+	ca, caKey, caFile, _ := writeProxyTestCert(t, "ca", nil, nil)
+	_, _, serverCertFile, serverKeyFile := writeProxyTestCert(t, "server", ca, caKey)
+	_, _, clientCertFile, clientKeyFile := writeProxyTestCert(t, "client", ca, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	})))
+	mount_azurefile.RegisterMountServiceServer(grpcServer, &fakeProxyServer{healthy: true})
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	defer grpcServer.Stop()
+
+	d := NewFakeDriver()
+	d.azurefileProxyEndpoint = "tcp://" + listener.Addr().String()
+	assert.Error(t, d.checkAzurefileProxyHealth(context.Background()), "no client certificate")
+
+	d.azurefileProxyTLSCertFile = clientCertFile
+	d.azurefileProxyTLSKeyFile = clientKeyFile
+	d.azurefileProxyTLSCAFile = caFile
+	assert.NoError(t, d.checkAzurefileProxyHealth(context.Background()))
+}
@@ -42,6 +42,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/accountclient/mock_accountclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient/mock_fileshareclient"
@@ -1939,6 +1941,91 @@ func TestCreateFolderIfNotExists(t *testing.T) {
 	}
 }
 
+func TestCreateFolderIfNotExistsWithFakeBackend(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}
+	secrets := createStorageAccountSecret("testaccount", accountKey)
+
+	err := d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "testshare", RequestGiB: 10}, secrets, "")
+	assert.NoError(t, err)
+	// create an existing file share is regarded as success
+	err = d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "testshare", RequestGiB: 10}, secrets, "")
+	assert.NoError(t, err)
+
+	for _, folderName := range []string{"level1/level2/level3", "/level1/level2/", "path//to///folder"} {
+		err = d.createFolderIfNotExists(ctx, "testaccount", accountKey, "testshare", folderName, fakeStorageEndpointSuffix)
+		assert.NoError(t, err)
+	}
+	assert.True(t, server.DirectoryExists("testaccount", "testshare", "level1/level2/level3"))
+	assert.True(t, server.DirectoryExists("testaccount", "testshare", "path/to/folder"))
+
+	err = d.ResizeFileShare(ctx, "subsID", "rg", "testaccount", "testshare", 20, secrets, "")
+	assert.NoError(t, err)
+	quota, err := d.getFileShareQuota(ctx, &storage.AccountOptions{Name: "testaccount"}, "testshare", secrets, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, quota)
+
+	err = d.DeleteFileShare(ctx, "subsID", "rg", "testaccount", "testshare", secrets, "")
+	assert.NoError(t, err)
+	quota, err = d.getFileShareQuota(ctx, &storage.AccountOptions{Name: "testaccount"}, "testshare", secrets, "")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, quota)
+}
+
+func TestDeleteFileShareNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	notFoundErr := &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "StorageAccountNotFound"}
+	d := NewFakeDriver()
+	computeClientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	d.cloud.ComputeClientFactory = computeClientFactory
+
+	// a missing account when creating the client is not a deleted share
+	computeClientFactory.EXPECT().GetFileShareClientForSub("subsID").Return(nil, notFoundErr).Times(1)
+	err := d.DeleteFileShare(context.Background(), "subsID", "rg", "account", "share", nil, "")
+	assert.ErrorIs(t, err, notFoundErr)
+
+	mockFileClient := mock_fileshareclient.NewMockInterface(ctrl)
+	computeClientFactory.EXPECT().GetFileShareClientForSub("subsID").Return(mockFileClient, nil).Times(1)
+	mockFileClient.EXPECT().Delete(gomock.Any(), "rg", "account", "share", gomock.Any()).Return(&azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ShareNotFound"}).Times(1)
+	err = d.DeleteFileShare(context.Background(), "subsID", "rg", "account", "share", nil, "")
+	assert.NoError(t, err)
+}
+
+func TestResizeFileShareRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	retriableErr := &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: azerrors.CodeStorageAccountIsNotProvisioned}
+	d := NewFakeDriver()
+	d.cloud.Config.CloudProviderBackoffRetries = 3
+	computeClientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	d.cloud.ComputeClientFactory = computeClientFactory
+	mockFileClient := mock_fileshareclient.NewMockInterface(ctrl)
+	computeClientFactory.EXPECT().GetFileShareClientForSub("subsID").Return(mockFileClient, nil).AnyTimes()
+
+	// getting the file share is not retried
+	mockFileClient.EXPECT().Get(gomock.Any(), "rg", "account", "share", gomock.Any()).Return(nil, retriableErr).Times(1)
+	err := d.ResizeFileShare(context.Background(), "subsID", "rg", "account", "share", 20, nil, "")
+	assert.ErrorIs(t, err, retriableErr)
+
+	// the update call is retried
+	mockFileClient.EXPECT().Get(gomock.Any(), "rg", "account", "share", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, _ string, _ *armstorage.FileSharesClientGetOptions) (*armstorage.FileShare, error) {
+			return &armstorage.FileShare{FileShareProperties: &armstorage.FileShareProperties{ShareQuota: to.Ptr(int32(10))}}, nil
+		}).Times(2)
+	gomock.InOrder(
+		mockFileClient.EXPECT().Update(gomock.Any(), "rg", "account", "share", gomock.Any()).Return(nil, retriableErr).Times(1),
+		mockFileClient.EXPECT().Update(gomock.Any(), "rg", "account", "share", gomock.Any()).Return(&armstorage.FileShare{}, nil).Times(1),
+	)
+	err = d.ResizeFileShare(context.Background(), "subsID", "rg", "account", "share", 20, nil, "")
+	assert.NoError(t, err)
+}
+
 func TestGetInfoFromSnapshotID(t *testing.T) {
 	tests := []struct {
 		name          string
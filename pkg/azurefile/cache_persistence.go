@@ -0,0 +1,230 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+)
+
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+)
+
+// persistedCacheEntry is a cache entry saved in the ConfigMap,
+// createdOn is kept so that the entry expires at the same time after being restored.
+type persistedCacheEntry struct {
+	Value     string    `json:"value"`
+	CreatedOn time.Time `json:"createdOn"`
+}
+
+// persistedCache is a cache whose string entries are persisted, caches with credentials,
+// e.g. azcopySasTokenCache and accountCacheMap, must not be persisted since ConfigMap is not encrypted.
+type persistedCache struct {
+	name  string
+	cache azcache.Resource
+	ttl   time.Duration
+}
+
+// cachePersister saves controller caches in a ConfigMap periodically and restores them on start,
+// so that a new controller leader does not start with a burst of ARM list calls.
+type cachePersister struct {
+	kubeClient clientset.Interface
+	namespace  string
+	name       string
+	owner      string
+	caches     []persistedCache
+	// lastSaved is the time of the last successful save, only entries created after it trigger a new save,
+	// so that standby controller replicas which only restored entries do not overwrite the ConfigMap
+	lastSaved time.Time
+}
+
+// newCachePersister creates a persister of the ConfigMap in the format of <namespace>/<name>
+func newCachePersister(kubeClient clientset.Interface, configMap, owner string, caches []persistedCache) (*cachePersister, error) {
+	namespace, name, found := strings.Cut(configMap, "/")
+	if !found || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid cache persistence ConfigMap %q, it should be in the format of <namespace>/<name>", configMap)
+	}
+	if kubeClient == nil {
+		return nil, fmt.Errorf("kubeClient is nil")
+	}
+	return &cachePersister{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		owner:      owner,
+		caches:     caches,
+	}, nil
+}
+
+// persistedCaches returns the controller caches which could be persisted
+func (d *Driver) persistedCaches() []persistedCache {
+	return []persistedCache{
+		{name: "accountSearchCache", cache: d.accountSearchCache, ttl: accountSearchCacheTTL},
+		{name: "skipMatchingTagCache", cache: d.skipMatchingTagCache, ttl: time.Duration(d.skipMatchingTagCacheExpireInMinutes) * time.Minute},
+		{name: "dataPlaneAPIAccountCache", cache: d.dataPlaneAPIAccountCache, ttl: dataPlaneAPIAccountCacheTTL},
+	}
+}
+
+// load restores the unexpired entries from the ConfigMap, the restored entries are regarded as saved
+func (p *cachePersister) load(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+	now := time.Now()
+	cm, err := p.kubeClient.CoreV1().ConfigMaps(p.namespace).Get(ctx, p.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.V(2).Info("cache persistence ConfigMap does not exist, start with empty caches", "configMap", klog.KRef(p.namespace, p.name))
+			p.lastSaved = now
+			return nil
+		}
+		return fmt.Errorf("failed to get cache persistence ConfigMap(%s/%s): %w", p.namespace, p.name, err)
+	}
+	for _, c := range p.caches {
+		data, ok := cm.Data[c.name]
+		if !ok || c.cache == nil || c.cache.GetStore() == nil {
+			continue
+		}
+		entries := map[string]persistedCacheEntry{}
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			logger.Error(err, "failed to parse cache in cache persistence ConfigMap", "cache", c.name, "configMap", klog.KRef(p.namespace, p.name))
+			continue
+		}
+		restored := 0
+		for key, entry := range entries {
+			if now.Sub(entry.CreatedOn) >= c.ttl {
+				continue
+			}
+			_ = c.cache.GetStore().Add(&azcache.AzureCacheEntry{Key: key, Data: entry.Value, CreatedOn: entry.CreatedOn})
+			restored++
+		}
+		logger.V(2).Info("restored cache entries from ConfigMap", "entries", restored, "cache", c.name, "configMap", klog.KRef(p.namespace, p.name))
+	}
+	p.lastSaved = now
+	return nil
+}
+
+// snapshot returns the unexpired string entries of the caches, and whether there is any entry created after the last save
+func (p *cachePersister) snapshot() (map[string]string, bool, error) {
+	now := time.Now()
+	data := map[string]string{}
+	changed := false
+	for _, c := range p.caches {
+		if c.cache == nil || c.cache.GetStore() == nil {
+			continue
+		}
+		entries := map[string]persistedCacheEntry{}
+		for _, obj := range c.cache.GetStore().List() {
+			entry, ok := obj.(*azcache.AzureCacheEntry)
+			if !ok {
+				continue
+			}
+			// Data and CreatedOn are written by the getter of the cache under the entry lock
+			entry.Lock.Lock()
+			data, createdOn := entry.Data, entry.CreatedOn
+			entry.Lock.Unlock()
+			value, ok := data.(string)
+			if !ok || now.Sub(createdOn) >= c.ttl {
+				continue
+			}
+			entries[entry.Key] = persistedCacheEntry{Value: value, CreatedOn: createdOn}
+			if createdOn.After(p.lastSaved) {
+				changed = true
+			}
+		}
+		bytes, err := json.Marshal(entries)
+		if err != nil {
+			return nil, false, err
+		}
+		data[c.name] = string(bytes)
+	}
+	return data, changed, nil
+}
+
+// save writes the caches into the ConfigMap if there is any new entry since the last save
+func (p *cachePersister) save(ctx context.Context) error {
+	start := time.Now()
+	data, changed, err := p.snapshot()
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	configMaps := p.kubeClient.CoreV1().ConfigMaps(p.namespace)
+	cm, err := configMaps.Get(ctx, p.name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      p.name,
+				Namespace: p.namespace,
+				Labels:    map[string]string{managedByLabel: p.owner},
+			},
+			Data: data,
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	case err == nil:
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save caches in ConfigMap(%s/%s): %w", p.namespace, p.name, err)
+	}
+	p.lastSaved = start
+	klog.FromContext(ctx).V(4).Info("saved caches in ConfigMap", "configMap", klog.KRef(p.namespace, p.name))
+	return nil
+}
+
+// run saves the caches periodically until the context is done
+func (p *cachePersister) run(ctx context.Context, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.save(ctx); err != nil {
+			klog.FromContext(ctx).Error(err, "failed to save caches")
+		}
+	}, interval)
+}
+
+// startCachePersistence restores controller caches from the ConfigMap and saves them periodically,
+// failures are logged only since caches are an optimization.
+func (d *Driver) startCachePersistence(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	persister, err := newCachePersister(d.kubeClient, d.cachePersistenceConfigMap, d.Name, d.persistedCaches())
+	if err != nil {
+		logger.Error(err, "cache persistence is disabled")
+		return
+	}
+	if err := persister.load(ctx); err != nil {
+		logger.Error(err, "failed to restore caches")
+	}
+	interval := d.cachePersistenceInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	go persister.run(ctx, interval)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+)
+
+func TestNewCachePersister(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	for _, configMap := range []string{"", "name", "/name", "namespace/"} {
+		_, err := newCachePersister(kubeClient, configMap, "owner", nil)
+		assert.ErrorContains(t, err, "invalid cache persistence ConfigMap", configMap)
+	}
+	_, err := newCachePersister(nil, "kube-system/cache", "owner", nil)
+	assert.ErrorContains(t, err, "kubeClient is nil")
+
+	p, err := newCachePersister(kubeClient, "kube-system/cache", "owner", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "kube-system", p.namespace)
+	assert.Equal(t, "cache", p.name)
+}
+
+func TestCachePersistenceWarmStart(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset()
+
+	// the old controller fills its caches and saves them
+	d := NewFakeDriver()
+	d.accountSearchCache.Set("lockKey", "account1")
+	d.skipMatchingTagCache.Set("account2", "")
+	d.dataPlaneAPIAccountCache.Set("account3", "")
+	d.azcopySasTokenCache.Set("account1", "sasToken")
+	d.accountCacheMap.Set("account1", "accountKey")
+	// expired entry should not be saved
+	require.NoError(t, d.dataPlaneAPIAccountCache.GetStore().Add(&azcache.AzureCacheEntry{
+		Key: "expired", Data: "", CreatedOn: time.Now().Add(-dataPlaneAPIAccountCacheTTL),
+	}))
+	p, err := newCachePersister(kubeClient, "kube-system/azurefile-csi-cache", d.Name, d.persistedCaches())
+	require.NoError(t, err)
+	require.NoError(t, p.save(ctx))
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(ctx, "azurefile-csi-cache", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, d.Name, cm.Labels[managedByLabel])
+	assert.Len(t, cm.Data, 3)
+	for _, data := range cm.Data {
+		assert.NotContains(t, data, "sasToken")
+		assert.NotContains(t, data, "accountKey")
+		assert.NotContains(t, data, "expired")
+	}
+
+	// the new controller restores the caches
+	newDriver := NewFakeDriver()
+	newPersister, err := newCachePersister(kubeClient, "kube-system/azurefile-csi-cache", newDriver.Name, newDriver.persistedCaches())
+	require.NoError(t, err)
+	require.NoError(t, newPersister.load(ctx))
+
+	cache, err := newDriver.accountSearchCache.Get(ctx, "lockKey", azcache.CacheReadTypeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, "account1", cache)
+	cache, err = newDriver.skipMatchingTagCache.Get(ctx, "account2", azcache.CacheReadTypeDefault)
+	require.NoError(t, err)
+	assert.NotNil(t, cache)
+	cache, err = newDriver.dataPlaneAPIAccountCache.Get(ctx, "account3", azcache.CacheReadTypeDefault)
+	require.NoError(t, err)
+	assert.NotNil(t, cache)
+	cache, err = newDriver.azcopySasTokenCache.Get(ctx, "account1", azcache.CacheReadTypeDefault)
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	// restored entries are not saved again, e.g. by a standby replica
+	kubeClient.ClearActions()
+	require.NoError(t, newPersister.save(ctx))
+	assert.Empty(t, kubeClient.Actions())
+
+	// TTL is kept after restore
+	oldEntry, _, err := d.accountSearchCache.GetStore().GetByKey("lockKey")
+	require.NoError(t, err)
+	newEntry, _, err := newDriver.accountSearchCache.GetStore().GetByKey("lockKey")
+	require.NoError(t, err)
+	assert.True(t, oldEntry.(*azcache.AzureCacheEntry).CreatedOn.Equal(newEntry.(*azcache.AzureCacheEntry).CreatedOn))
+}
+
+func TestCachePersistenceLoad(t *testing.T) {
+	ctx := context.Background()
+	d := NewFakeDriver()
+
+	// ConfigMap does not exist
+	p, err := newCachePersister(fake.NewSimpleClientset(), "kube-system/cache", d.Name, d.persistedCaches())
+	require.NoError(t, err)
+	assert.NoError(t, p.load(ctx))
+
+	expired, err := json.Marshal(map[string]persistedCacheEntry{
+		"lockKey": {Value: "account1", CreatedOn: time.Now().Add(-2 * accountSearchCacheTTL)},
+	})
+	require.NoError(t, err)
+	kubeClient := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "kube-system"},
+		Data: map[string]string{
+			"accountSearchCache":       string(expired),
+			"dataPlaneAPIAccountCache": "invalid json",
+		},
+	})
+	p, err = newCachePersister(kubeClient, "kube-system/cache", d.Name, d.persistedCaches())
+	require.NoError(t, err)
+	require.NoError(t, p.load(ctx))
+	assert.Empty(t, d.accountSearchCache.GetStore().List())
+	assert.Empty(t, d.dataPlaneAPIAccountCache.GetStore().List())
+}
+
+func TestCachePersistenceSaveOnlyOnChange(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset()
+	d := NewFakeDriver()
+	p, err := newCachePersister(kubeClient, "kube-system/cache", d.Name, d.persistedCaches())
+	require.NoError(t, err)
+
+	// nothing to save
+	require.NoError(t, p.save(ctx))
+	_, err = kubeClient.CoreV1().ConfigMaps("kube-system").Get(ctx, "cache", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	d.accountSearchCache.Set("lockKey1", "account1")
+	require.NoError(t, p.save(ctx))
+	kubeClient.ClearActions()
+
+	// no new entry since the last save, e.g. on a standby replica
+	require.NoError(t, p.save(ctx))
+	assert.Empty(t, kubeClient.Actions())
+
+	time.Sleep(10 * time.Millisecond)
+	d.accountSearchCache.Set("lockKey2", "account2")
+	require.NoError(t, p.save(ctx))
+	cm, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(ctx, "cache", metav1.GetOptions{})
+	require.NoError(t, err)
+	entries := map[string]persistedCacheEntry{}
+	require.NoError(t, json.Unmarshal([]byte(cm.Data["accountSearchCache"]), &entries))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "account2", entries["lockKey2"].Value)
+}
+
+func TestCachePersistenceSnapshotLocksEntry(t *testing.T) {
+	d := NewFakeDriver()
+	p, err := newCachePersister(fake.NewSimpleClientset(), "kube-system/cache", d.Name, d.persistedCaches())
+	require.NoError(t, err)
+	d.accountSearchCache.Set("lockKey", "account1")
+	obj, exists, err := d.accountSearchCache.GetStore().GetByKey("lockKey")
+	require.NoError(t, err)
+	require.True(t, exists)
+	entry := obj.(*azcache.AzureCacheEntry)
+
+	// the entry is being refreshed by the getter of the cache
+	entry.Lock.Lock()
+	done := make(chan map[string]string, 1)
+	go func() {
+		data, _, _ := p.snapshot()
+		done <- data
+	}()
+	select {
+	case <-done:
+		t.Fatal("snapshot should wait for the refresh of the entry")
+	case <-time.After(50 * time.Millisecond):
+	}
+	entry.Data = "account2"
+	entry.CreatedOn = time.Now()
+	entry.Lock.Unlock()
+
+	data := <-done
+	entries := map[string]persistedCacheEntry{}
+	require.NoError(t, json.Unmarshal([]byte(data["accountSearchCache"]), &entries))
+	assert.Equal(t, "account2", entries["lockKey"].Value)
+}
@@ -26,6 +26,8 @@ import (
 	"strings"
 	"time"
 
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	"sigs.k8s.io/azurefile-csi-driver/pkg/util"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
@@ -35,6 +37,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/share"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
@@ -83,9 +86,10 @@ var (
 )
 
 // CreateVolume provisions an azure file
-func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (resp *csi.CreateVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	if err := d.ValidateControllerServiceRequest(csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME); err != nil {
-		klog.Errorf("invalid create volume req: %v", req)
+		logger.Error(err, "invalid create volume request")
 		return nil, err
 	}
 
@@ -94,7 +98,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		return nil, status.Error(codes.InvalidArgument, "CreateVolume Name must be provided")
 	}
 	volumeCapabilities := req.GetVolumeCapabilities()
-	if err := isValidVolumeCapabilities(volumeCapabilities); err != nil {
+	if err := isValidVolumeCapabilities(volumeCapabilities, isDiskFsType(getValueInMap(req.GetParameters(), fsTypeField))); err != nil {
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("CreateVolume Volume capabilities not valid: %v", err))
 	}
 
@@ -102,7 +106,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 	requestGiB := util.RoundUpGiB(capacityBytes)
 	if requestGiB == 0 {
 		requestGiB = defaultAzureFileQuota
-		klog.Warningf("no quota specified, set as default value(%d GiB)", defaultAzureFileQuota)
+		logger.Info("no quota specified, set as default value", "quotaGiB", defaultAzureFileQuota)
 	}
 
 	if acquired := d.volumeLocks.TryAcquire(volName); !acquired {
@@ -123,6 +127,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 	var secretNamespace, pvcNamespace, protocol, customTags, storageEndpointSuffix, networkEndpointType, shareAccessTier, accountAccessTier, rootSquashType, tagValueDelimiter string
 	var createAccount, useSeretCache, matchTags, selectRandomMatchingAccount, getLatestAccountKey, encryptInTransit, mountWithManagedIdentity, mountWithWIToken bool
 	var vnetResourceGroup, vnetName, vnetLinkName, publicNetworkAccess, subnetName, shareNamePrefix, fsGroupChangePolicy, useDataPlaneAPI string
+	var provisioningMode, onDelete string
 	var requireInfraEncryption, disableDeleteRetentionPolicy, enableLFS, isMultichannelEnabled, allowSharedKeyAccess *bool
 	var provisionedBandwidthMibps, provisionedIops *int32
 	// set allowBlobPublicAccess as false by default
@@ -309,6 +314,10 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 			if err != nil {
 				return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %s in storage class", mountWithWITokenField, v)
 			}
+		case provisioningModeField:
+			provisioningMode = strings.ToLower(v)
+		case onDeleteField:
+			onDelete = strings.ToLower(v)
 		default:
 			return nil, status.Errorf(codes.InvalidArgument, "invalid parameter %q in storage class", k)
 		}
@@ -376,6 +385,45 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		return nil, status.Errorf(codes.InvalidArgument, "fsType(%s) is not supported with protocol(%s)", fsType, protocol)
 	}
 
+	if !isSupportedProvisioningMode(provisioningMode) {
+		return nil, status.Errorf(codes.InvalidArgument, "provisioningMode(%s) is not supported, supported provisioningMode list: %v", provisioningMode, supportedProvisioningModeList)
+	}
+
+	if !isSupportedOnDelete(onDelete) {
+		return nil, status.Errorf(codes.InvalidArgument, "onDelete(%s) is not supported, supported onDelete list: %v", onDelete, supportedOnDeleteList)
+	}
+
+	isSubDirectoryMode := provisioningMode == provisioningModeSubDirectory
+	if isSubDirectoryMode {
+		// a directory is created in the base file share with account key, NFS file share is not accessible with data plane API
+		if fileShareName == "" || account == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "shareName and storageAccount of the base file share must be provided in provisioningMode(%s)", provisioningMode)
+		}
+		if protocol == nfs || fsType == nfs || isDiskFsType(fsType) {
+			return nil, status.Errorf(codes.InvalidArgument, "provisioningMode(%s) is only supported with smb protocol", provisioningMode)
+		}
+		if req.GetVolumeContentSource() != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "volume content source is not supported in provisioningMode(%s)", provisioningMode)
+		}
+		if onDelete == "" {
+			onDelete = onDeleteDelete
+		}
+	} else if onDelete != "" {
+		return nil, status.Errorf(codes.InvalidArgument, "onDelete is only supported in provisioningMode(%s)", provisioningModeSubDirectory)
+	}
+
+	if !isDiskFsType(fsType) {
+		policyProtocol := protocol
+		if fsType == nfs {
+			policyProtocol = nfs
+		}
+		for _, c := range volumeCapabilities {
+			if err := d.getMountOptionPolicy().validate(policyProtocol, c.GetMount().GetMountFlags()); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+	}
+
 	enableHTTPSTrafficOnly := true
 	shareProtocol := armstorage.EnabledProtocolsSMB
 	var createPrivateEndpoint *bool
@@ -397,7 +445,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		protocol = nfs
 		enableHTTPSTrafficOnly = false
 		if encryptInTransit {
-			klog.V(2).Info("encryptInTransit is enabled")
+			logger.V(2).Info("encryptInTransit is enabled")
 			// Right now we have to disable secure transfer on accounts to be able to mount an NFS share.
 			// Even though encryptInTransit is enabled.
 			// enableHTTPSTrafficOnly = true
@@ -436,7 +484,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 
 	fileShareSize := int(requestGiB)
 
-	if account != "" && resourceGroup != "" && sku == "" && fileShareSize < minimumPremiumV2ShareSize {
+	if !isSubDirectoryMode && account != "" && resourceGroup != "" && sku == "" && fileShareSize < minimumPremiumV2ShareSize {
 		if d.cloud == nil || d.cloud.ComputeClientFactory == nil {
 			return nil, status.Errorf(codes.Internal, "cloud provider is not initialized")
 		}
@@ -446,10 +494,10 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		}
 		accountProperties, err := client.GetProperties(ctx, resourceGroup, account, nil)
 		if err != nil || accountProperties == nil {
-			klog.Warningf("failed to get properties on storage account account(%s) rg(%s), error: %v", account, resourceGroup, err)
+			logger.Info("failed to get properties on storage account", "account", account, "resourceGroup", resourceGroup, "err", err)
 		} else if accountProperties.SKU != nil && accountProperties.SKU.Name != nil {
 			sku = string(*accountProperties.SKU.Name)
-			klog.V(2).Infof("storage account(%s) rg(%s) sku is %s", account, resourceGroup, sku)
+			logger.V(2).Info("got storage account sku", "account", account, "resourceGroup", resourceGroup, "sku", sku)
 		}
 	}
 
@@ -457,14 +505,15 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 	accountKind := string(armstorage.KindStorageV2)
 	if strings.HasPrefix(strings.ToLower(sku), premium) {
 		accountKind = string(armstorage.KindFileStorage)
+		// minimum share size does not apply to a subdirectory volume since the base file share already exists
 		if strings.Contains(strings.ToLower(sku), "v2") {
-			if fileShareSize < minimumPremiumV2ShareSize {
-				klog.V(2).Infof("fileShareSize(%d) is less than minimumPremiumV2ShareSize(%d), using minimumPremiumV2ShareSize", fileShareSize, minimumPremiumV2ShareSize)
+			if !isSubDirectoryMode && fileShareSize < minimumPremiumV2ShareSize {
+				logger.V(2).Info("fileShareSize is less than minimumPremiumV2ShareSize, using minimumPremiumV2ShareSize", "fileShareSize", fileShareSize, "minimumPremiumV2ShareSize", minimumPremiumV2ShareSize)
 				fileShareSize = minimumPremiumV2ShareSize
 			}
 		} else {
-			if fileShareSize < minimumPremiumShareSize {
-				klog.V(2).Infof("fileShareSize(%d) is less than minimumPremiumShareSize(%d), using minimumPremiumShareSize", fileShareSize, minimumPremiumShareSize)
+			if !isSubDirectoryMode && fileShareSize < minimumPremiumShareSize {
+				logger.V(2).Info("fileShareSize is less than minimumPremiumShareSize, using minimumPremiumShareSize", "fileShareSize", fileShareSize, "minimumPremiumShareSize", minimumPremiumShareSize)
 				fileShareSize = minimumPremiumShareSize
 			}
 		}
@@ -475,11 +524,11 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		accountKind = string(armstorage.KindFileStorage)
 		if provisionedIops == nil {
 			provisionedIops = getDefaultIOPS(fileShareSize, sku)
-			klog.V(2).Infof("setting provisionedIops as %d", ptr.Deref(provisionedIops, 0))
+			logger.V(2).Info("setting default provisionedIops", "provisionedIops", ptr.Deref(provisionedIops, 0))
 		}
 		if provisionedBandwidthMibps == nil {
 			provisionedBandwidthMibps = getDefaultBandwidth(fileShareSize, sku)
-			klog.V(2).Infof("setting provisionedBandwidthMibps as %d", ptr.Deref(provisionedBandwidthMibps, 0))
+			logger.V(2).Info("setting default provisionedBandwidthMibps", "provisionedBandwidthMibps", ptr.Deref(provisionedBandwidthMibps, 0))
 		}
 	}
 
@@ -530,23 +579,23 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	isOperationSucceeded := false
 	defer func() {
-		csiMC.ObserveWithLabels(isOperationSucceeded,
-			"protocol", string(shareProtocol),
-			"storage_account_type", sku)
+		csiMC.ObserveWithError(returnedErr,
+			csiMetrics.LabelProtocol, string(shareProtocol),
+			csiMetrics.LabelStorageAccountType, sku)
 	}()
 
 	if sourceID != "" {
 		_, srcAccountName, _, _, _, _, err = GetFileShareInfo(sourceID) //nolint:dogsled
 		if err != nil {
-			klog.Errorf("failed to get source volume info from sourceID(%s), error: %v", sourceID, err)
+			logger.Error(err, "failed to get source volume info", "sourceID", sourceID)
 		} else {
-			klog.V(2).Infof("source volume account name: %s, sourceID: %s", srcAccountName, sourceID)
+			logger.V(2).Info("got source volume account", "account", srcAccountName, "sourceID", sourceID)
 		}
 	}
 
 	var requiresSmbOAuth *bool
 	if mountWithManagedIdentity || mountWithWIToken {
-		klog.V(2).Info("enabling smb oauth for managed identity or work identity token based mount")
+		logger.V(2).Info("enabling smb oauth for managed identity or work identity token based mount")
 		requiresSmbOAuth = to.Ptr(true)
 	}
 
@@ -608,23 +657,23 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 				d.volLockMap.LockEntry(lockKey)
 				accountName, accountKey, err = d.cloud.EnsureStorageAccount(ctx, accountOptions, defaultAccountNamePrefix)
 				if isRetriableError(err) {
-					klog.Warningf("EnsureStorageAccount(%s) failed with error(%v), waiting for retrying", account, err)
+					logger.Info("EnsureStorageAccount failed, waiting for retrying", "account", account, "err", err)
 					sleepIfThrottled(err, accountOpThrottlingSleepSec)
 				}
 				d.volLockMap.UnlockEntry(lockKey)
 				if err != nil {
-					return nil, status.Errorf(codes.Internal, "failed to ensure storage account: %v", err)
+					return nil, azureStatusErrorf(codes.Internal, err, "failed to ensure storage account: %v", err)
 				}
 				if accountQuota > minimumAccountQuota {
 					totalQuotaGB, fileshareNum, err := d.GetTotalAccountQuota(ctx, subsID, resourceGroup, accountName)
 					if err != nil {
-						return nil, status.Errorf(codes.Internal, "failed to get total quota on account(%s), error: %v", accountName, err)
+						return nil, azureStatusErrorf(codes.Internal, err, "failed to get total quota on account(%s), error: %v", accountName, err)
 					}
-					klog.V(2).Infof("total used quota on account(%s) is %d GB, file share number: %d", accountName, totalQuotaGB, fileshareNum)
+					logger.V(2).Info("got total used quota on account", "account", accountName, "usedQuotaGB", totalQuotaGB, "fileShareNum", fileshareNum)
 					if totalQuotaGB > accountQuota {
-						klog.Warningf("account(%s) used quota(%d GB) is over %d GB, skip matching current account", accountName, totalQuotaGB, accountQuota)
+						logger.Info("account used quota is over the limit, skip matching current account", "account", accountName, "usedQuotaGB", totalQuotaGB, "accountQuotaGB", accountQuota)
 						if rerr := d.cloud.AddStorageAccountTags(ctx, subsID, resourceGroup, accountName, skipMatchingTag); rerr != nil {
-							klog.Warningf("AddStorageAccountTags(%v) on account(%s) subsID(%s) rg(%s) failed with error: %v", tags, accountName, subsID, resourceGroup, rerr.Error())
+							logger.Error(rerr, "AddStorageAccountTags failed", "tags", tags, "account", accountName, "subsID", subsID, "resourceGroup", resourceGroup)
 						}
 						// release volume lock first to prevent deadlock
 						d.volumeLocks.Release(volName)
@@ -646,10 +695,21 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 
 	accountOptions.Name = accountName
 	secret := req.GetSecrets()
-	if len(secret) == 0 && strings.EqualFold(useDataPlaneAPI, trueValue) {
+	if isSubDirectoryMode {
+		if quota, err := d.getFileShareQuota(ctx, accountOptions, validFileShareName, secret, useDataPlaneAPI); err != nil {
+			return nil, status.Errorf(codes.Internal, "%v", err)
+		} else if quota == -1 {
+			return nil, status.Errorf(codes.InvalidArgument, "base file share(%s) does not exist on account(%s)", validFileShareName, accountName)
+		}
 		if accountKey == "" {
 			if accountKey, err = d.GetStorageAccesskey(ctx, accountOptions, secret, secretName, secretNamespace); err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
+				return nil, azureStatusErrorf(codes.Internal, err, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
+			}
+		}
+	} else if len(secret) == 0 && strings.EqualFold(useDataPlaneAPI, trueValue) {
+		if accountKey == "" {
+			if accountKey, err = d.GetStorageAccesskey(ctx, accountOptions, secret, secretName, secretNamespace); err != nil {
+				return nil, azureStatusErrorf(codes.Internal, err, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
 			}
 		}
 		secret = createStorageAccountSecret(accountName, accountKey)
@@ -673,12 +733,28 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		Metadata:                  map[string]*string{createdByMetadata: ptr.To(d.Name)},
 	}
 
-	klog.V(2).Infof("begin to create file share(%s) on account(%s) type(%s) subID(%s) rg(%s) location(%s) size(%d) protocol(%s)", validFileShareName, accountName, sku, subsID, resourceGroup, location, fileShareSize, shareProtocol)
-	if err := d.CreateFileShare(ctx, accountOptions, shareOptions, secret, useDataPlaneAPI); err != nil {
-		if strings.Contains(err.Error(), accountLimitExceedManagementAPI) || strings.Contains(err.Error(), accountLimitExceedDataPlaneAPI) {
-			klog.Warningf("create file share(%s) on account(%s) type(%s) subID(%s) rg(%s) location(%s) size(%d), error: %v, skip matching current account", validFileShareName, accountName, sku, subsID, resourceGroup, location, fileShareSize, err)
+	release, err := d.accountScheduler.acquire(ctx, accountName, "create_volume")
+	if err != nil {
+		return nil, err
+	}
+	if isSubDirectoryMode {
+		logger.V(2).Info("begin to create subdirectory in base file share", "subDir", volName, "share", validFileShareName, "account", accountName)
+		err = d.createFolderIfNotExists(ctx, accountName, accountKey, validFileShareName, volName, storageEndpointSuffix)
+		release()
+		if err != nil {
+			return nil, azureStatusErrorf(codes.Internal, err, "failed to create subdirectory(%s) in file share(%s) on account(%s), error: %v", volName, validFileShareName, accountName, err)
+		}
+		logger.V(2).Info("created subdirectory in base file share successfully", "subDir", volName, "share", validFileShareName, "account", accountName)
+	} else {
+		logger.V(2).Info("begin to create file share", "share", validFileShareName, "account", accountName, "sku", sku, "subsID", subsID, "resourceGroup", resourceGroup, "location", location, "sizeGiB", fileShareSize, "protocol", shareProtocol)
+		err = d.CreateFileShare(ctx, accountOptions, shareOptions, secret, useDataPlaneAPI)
+		release()
+	}
+	if err != nil {
+		if isAccountLimitExceededError(err) {
+			logger.Info("failed to create file share, skip matching current account", "share", validFileShareName, "account", accountName, "sku", sku, "subsID", subsID, "resourceGroup", resourceGroup, "location", location, "sizeGiB", fileShareSize, "err", err)
 			if rerr := d.cloud.AddStorageAccountTags(ctx, subsID, resourceGroup, accountName, skipMatchingTag); rerr != nil {
-				klog.Warningf("AddStorageAccountTags(%v) on account(%s) subsID(%s) rg(%s) failed with error: %v", tags, accountName, subsID, resourceGroup, rerr.Error())
+				logger.Error(rerr, "AddStorageAccountTags failed", "tags", tags, "account", accountName, "subsID", subsID, "resourceGroup", resourceGroup)
 			}
 			// do not remove skipMatchingTag in a period of time
 			d.skipMatchingTagCache.Set(accountName, "")
@@ -692,12 +768,12 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 			d.volMap.Delete(volName)
 			return d.CreateVolume(ctx, req)
 		}
-		if req.GetVolumeContentSource() != nil && strings.Contains(err.Error(), "ShareAlreadyExists") {
+		if req.GetVolumeContentSource() != nil && azerrors.HasErrorCode(err, azerrors.CodeShareAlreadyExists) {
 			// for snapshot restore and volume cloning, ignore ShareAlreadyExists error since the file share should be created first
-			klog.Warningf("create file share(%s) on account(%s) type(%s) subID(%s) rg(%s) location(%s) size(%d), ignore ShareAlreadyExists error for snapshot restore and volume cloning", validFileShareName, accountName, sku, subsID, resourceGroup, location, fileShareSize)
+			logger.Info("file share already exists, ignore ShareAlreadyExists error for snapshot restore and volume cloning", "share", validFileShareName, "account", accountName, "sku", sku, "subsID", subsID, "resourceGroup", resourceGroup, "location", location, "sizeGiB", fileShareSize)
 			err = nil
 		} else {
-			return nil, status.Errorf(codes.Internal, "failed to create file share(%s) on account(%s) type(%s) subsID(%s) rg(%s) location(%s) size(%d), error: %v", validFileShareName, account, sku, subsID, resourceGroup, location, fileShareSize, err)
+			return nil, azureStatusErrorf(codes.Internal, err, "failed to create file share(%s) on account(%s) type(%s) subsID(%s) rg(%s) location(%s) size(%d), error: %v", validFileShareName, account, sku, subsID, resourceGroup, location, fileShareSize, err)
 		}
 	}
 	if req.GetVolumeContentSource() != nil {
@@ -707,7 +783,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		}
 		copyErr := d.copyVolume(ctx, req, accountName, accountSASToken, authAzcopyEnv, secretNamespace, shareOptions, accountOptions, storageEndpointSuffix)
 		if accountSASToken == "" && copyErr != nil && strings.Contains(copyErr.Error(), authorizationPermissionMismatch) {
-			klog.Warningf("azcopy copy failed with AuthorizationPermissionMismatch error, should assign \"Storage File Data Privileged Contributor\" role to controller identity, fall back to use sas token, original error: %v", copyErr)
+			logger.Info("azcopy copy failed with AuthorizationPermissionMismatch error, should assign \"Storage File Data Privileged Contributor\" role to controller identity, fall back to use sas token", "err", copyErr)
 			accountSASToken, authAzcopyEnv, err := d.getAzcopyAuth(ctx, accountName, accountKey, storageEndpointSuffix, accountOptions, secret, secretName, secretNamespace, true)
 			if err != nil {
 				return nil, status.Errorf(codes.Internal, "failed to getAzcopyAuth on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
@@ -720,12 +796,14 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		// storeAccountKey is not needed here since copy volume is only using SAS token
 		storeAccountKey = false
 	}
-	klog.V(2).Infof("create file share %s on storage account %s successfully", validFileShareName, accountName)
+	if !isSubDirectoryMode {
+		logger.V(2).Info("created file share successfully", "share", validFileShareName, "account", accountName)
+	}
 
 	if isDiskFsType(fsType) && !strings.HasSuffix(diskName, vhdSuffix) && req.GetVolumeContentSource() == nil {
 		if accountKey == "" {
 			if accountKey, err = d.GetStorageAccesskey(ctx, accountOptions, req.GetSecrets(), secretName, secretNamespace); err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
+				return nil, azureStatusErrorf(codes.Internal, err, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
 			}
 		}
 		if fileShareName == "" {
@@ -736,13 +814,13 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 			diskName = uuid.NewString() + vhdSuffix
 		}
 		diskSizeBytes := util.GiBToBytes(requestGiB)
-		klog.V(2).Infof("begin to create vhd file(%s) size(%d) on share(%s) on account(%s) type(%s) rg(%s) location(%s)",
-			diskName, diskSizeBytes, validFileShareName, account, sku, resourceGroup, location)
+		logger.V(2).Info("begin to create vhd file", "disk", diskName, "sizeBytes", diskSizeBytes, "share", validFileShareName,
+			"account", account, "sku", sku, "resourceGroup", resourceGroup, "location", location)
 		if err := createDisk(ctx, accountName, accountKey, d.getStorageEndPointSuffix(), validFileShareName, diskName, diskSizeBytes); err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to create VHD disk: %v", err)
 		}
-		klog.V(2).Infof("create vhd file(%s) size(%d) on share(%s) on account(%s) type(%s) rg(%s) location(%s) successfully",
-			diskName, diskSizeBytes, validFileShareName, account, sku, resourceGroup, location)
+		logger.V(2).Info("created vhd file successfully", "disk", diskName, "sizeBytes", diskSizeBytes, "share", validFileShareName,
+			"account", account, "sku", sku, "resourceGroup", resourceGroup, "location", location)
 		setKeyValueInMap(parameters, diskNameField, diskName)
 	}
 
@@ -758,7 +836,7 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		if !useSeretCache {
 			if accountKey == "" {
 				if accountKey, err = d.GetStorageAccesskey(ctx, accountOptions, req.GetSecrets(), secretName, secretNamespace); err != nil {
-					return nil, status.Errorf(codes.Internal, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
+					return nil, azureStatusErrorf(codes.Internal, err, "failed to GetStorageAccesskey on account(%s) rg(%s), error: %v", accountOptions.Name, accountOptions.ResourceGroup, err)
 				}
 			}
 			storeSecretName, err := d.SetAzureCredentials(ctx, accountName, accountKey, secretName, secretNamespace)
@@ -766,21 +844,38 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 				return nil, status.Errorf(codes.Internal, "failed to store storage account key: %v", err)
 			}
 			if storeSecretName != "" {
-				klog.V(2).Infof("store account key to k8s secret(%v) in %s namespace", storeSecretName, secretNamespace)
+				logger.V(2).Info("store account key to k8s secret", "secret", storeSecretName, "namespace", secretNamespace)
 			}
 			d.secretCacheMap.Set(secretCacheKey, "")
 		}
 	}
 
-	var uuid string
-	if fileShareName != "" {
-		// add volume name as suffix to differentiate volumeID since "shareName" is specified
-		// not necessary for dynamic file share name creation since volumeID already contains volume name
-		uuid = volName
-	}
-	volumeID = fmt.Sprintf(volumeIDTemplate, resourceGroup, accountName, validFileShareName, diskName, uuid, secretNamespace)
-	if subsID != "" && subsID != d.cloud.SubscriptionID {
-		volumeID = volumeID + "#" + subsID
+	if isSubDirectoryMode {
+		volumeSubsID := subsID
+		if volumeSubsID == d.cloud.SubscriptionID {
+			volumeSubsID = ""
+		}
+		// the storage endpoint suffix of the StorageClass is saved in the volume ID since it's not passed to DeleteVolume
+		volumeStorageEndpointSuffix := storageEndpointSuffix
+		if volumeStorageEndpointSuffix == d.getStorageEndPointSuffix() {
+			volumeStorageEndpointSuffix = ""
+		}
+		volumeID = getSubDirectoryVolumeID(resourceGroup, accountName, validFileShareName, volName, secretNamespace, volumeSubsID, onDelete, volumeStorageEndpointSuffix)
+		// node mounts the subdirectory since shareName in volume context takes precedence over volume ID
+		setKeyValueInMap(parameters, shareNameField, validFileShareName+"/"+volName)
+		// node enforces the requested size as the soft quota of the subdirectory
+		setKeyValueInMap(parameters, folderQuotaField, strconv.FormatInt(util.GiBToBytes(requestGiB), 10))
+	} else {
+		var uuid string
+		if fileShareName != "" {
+			// add volume name as suffix to differentiate volumeID since "shareName" is specified
+			// not necessary for dynamic file share name creation since volumeID already contains volume name
+			uuid = volName
+		}
+		volumeID = fmt.Sprintf(volumeIDTemplate, resourceGroup, accountName, validFileShareName, diskName, uuid, secretNamespace)
+		if subsID != "" && subsID != d.cloud.SubscriptionID {
+			volumeID = volumeID + "#" + subsID
+		}
 	}
 
 	if strings.EqualFold(useDataPlaneAPI, trueValue) || strings.EqualFold(useDataPlaneAPI, oauth) {
@@ -807,10 +902,11 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 
 // DeleteVolume delete an azure file
 func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (resp *csi.DeleteVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "controller_delete_volume"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	defer func() {
-		csiMC.Observe(returnedErr == nil)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	volumeID := req.GetVolumeId()
@@ -830,7 +926,7 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 	resourceGroupName, accountName, fileShareName, _, secretNamespace, subsID, err := GetFileShareInfo(volumeID)
 	if err != nil {
 		// According to CSI Driver Sanity Tester, should succeed when an invalid volume id is used
-		klog.Errorf("GetFileShareInfo(%s) in DeleteVolume failed with error: %v", volumeID, err)
+		logger.Error(err, "GetFileShareInfo in DeleteVolume failed")
 		return &csi.DeleteVolumeResponse{}, nil
 	}
 
@@ -862,12 +958,35 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 		mc.ObserveOperationWithResult(returnedErr == nil, VolumeID, volumeID)
 	}()
 
+	release, err := d.accountScheduler.acquire(ctx, accountName, "delete_volume")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if baseShare, subDir, onDelete, ok := getSubDirectoryInfo(volumeID); ok {
+		reqContext := map[string]string{}
+		if secretNamespace != "" {
+			setKeyValueInMap(reqContext, secretNamespaceField, secretNamespace)
+		}
+		_, _, accountKey, _, _, _, _, _, err := d.GetAccountInfo(ctx, volumeID, req.GetSecrets(), reqContext)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "get account info from(%s) failed with error: %v", volumeID, err)
+		}
+		storageEndpointSuffix := getSubDirectoryStorageEndpointSuffix(volumeID)
+		if storageEndpointSuffix == "" {
+			storageEndpointSuffix = d.getStorageEndPointSuffix()
+		}
+		if err := d.deleteSubDirectory(ctx, accountName, accountKey, baseShare, subDir, onDelete, storageEndpointSuffix); err != nil {
+			return nil, azureStatusErrorf(codes.Internal, err, "delete subdirectory %s in file share %s under account(%s) rg(%s) failed with error: %v", subDir, baseShare, accountName, resourceGroupName, err)
+		}
+		return &csi.DeleteVolumeResponse{}, nil
+	}
 	if err := d.DeleteFileShare(ctx, subsID, resourceGroupName, accountName, fileShareName, secret, useDataPlaneAPI); err != nil {
-		return nil, status.Errorf(codes.Internal, "DeleteFileShare %s under account(%s) rg(%s) failed with error: %v", fileShareName, accountName, resourceGroupName, err)
+		return nil, azureStatusErrorf(codes.Internal, err, "DeleteFileShare %s under account(%s) rg(%s) failed with error: %v", fileShareName, accountName, resourceGroupName, err)
 	}
-	klog.V(2).Infof("azure file(%s) under subsID(%s) rg(%s) account(%s) volume(%s) is deleted successfully", fileShareName, subsID, resourceGroupName, accountName, volumeID)
+	logger.V(2).Info("deleted azure file successfully", "share", fileShareName, "subsID", subsID, "resourceGroup", resourceGroupName, "account", accountName)
 	if err := d.RemoveStorageAccountTag(ctx, subsID, resourceGroupName, accountName, storage.SkipMatchingTag); err != nil {
-		klog.Warningf("RemoveStorageAccountTag(%s) under rg(%s) account(%s) failed with %v", storage.SkipMatchingTag, resourceGroupName, accountName, err)
+		logger.Error(err, "RemoveStorageAccountTag failed", "tag", storage.SkipMatchingTag, "resourceGroup", resourceGroupName, "account", accountName)
 	}
 
 	return &csi.DeleteVolumeResponse{}, nil
@@ -906,6 +1025,9 @@ func (d *Driver) ValidateVolumeCapabilities(ctx context.Context, req *csi.Valida
 	if err != nil || accountName == "" || fileShareName == "" {
 		return nil, status.Errorf(codes.NotFound, "get account info from(%s) failed with error: %v", volumeID, err)
 	}
+	if baseShare, _, _, ok := getSubDirectoryInfo(volumeID); ok {
+		fileShareName = baseShare
+	}
 	if resourceGroupName == "" {
 		resourceGroupName = d.cloud.ResourceGroup
 	}
@@ -927,6 +1049,11 @@ func (d *Driver) ValidateVolumeCapabilities(ctx context.Context, req *csi.Valida
 
 	confirmed := &csi.ValidateVolumeCapabilitiesResponse_Confirmed{VolumeCapabilities: volCaps}
 	if !strings.HasSuffix(diskName, vhdSuffix) {
+		for _, c := range volCaps {
+			if c.GetBlock() != nil {
+				return &csi.ValidateVolumeCapabilitiesResponse{}, nil
+			}
+		}
 		return &csi.ValidateVolumeCapabilitiesResponse{Confirmed: confirmed}, nil
 	}
 	for _, c := range volCaps {
@@ -966,10 +1093,11 @@ func (d *Driver) ControllerUnpublishVolume(_ context.Context, _ *csi.ControllerU
 
 // CreateSnapshot create a snapshot
 func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (resp *csi.CreateSnapshotResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "controller_create_snapshot"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	defer func() {
-		csiMC.Observe(returnedErr == nil)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	sourceVolumeID := req.GetSourceVolumeId()
@@ -985,6 +1113,10 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 	if err != nil {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("GetFileShareInfo(%s) failed with error: %v", sourceVolumeID, err))
 	}
+	if _, _, _, ok := getSubDirectoryInfo(sourceVolumeID); ok {
+		// snapshot is taken on the whole file share which is shared by other subdirectory volumes
+		return nil, status.Errorf(codes.InvalidArgument, "snapshot of subdirectory volume(%s) is not supported", sourceVolumeID)
+	}
 	if rgName == "" {
 		rgName = d.cloud.ResourceGroup
 	}
@@ -1022,7 +1154,7 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 		return nil, status.Errorf(codes.Internal, "failed to check if snapshot(%v) exists: %v", snapshotName, err)
 	}
 	if exists {
-		klog.V(2).Infof("snapshot(%s) already exists", snapshotName)
+		logger.V(2).Info("snapshot already exists", "snapshot", snapshotName)
 		return &csi.CreateSnapshotResponse{
 			Snapshot: &csi.Snapshot{
 				SizeBytes:      util.GiBToBytes(int64(itemSnapshotQuota)),
@@ -1035,6 +1167,11 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 		}, nil
 	}
 
+	release, err := d.accountScheduler.acquire(ctx, accountName, "create_snapshot")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	if len(req.GetSecrets()) > 0 || useDataPlaneAPI != "" {
 		shareClient, err := d.getShareClient(ctx, sourceVolumeID, req.GetSecrets(), useDataPlaneAPI)
 		if err != nil {
@@ -1045,12 +1182,12 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 			Metadata: map[string]*string{snapshotNameKey: to.Ptr(snapshotName)},
 		})
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "create snapshot from(%s) failed with %v", sourceVolumeID, err)
+			return nil, azureStatusErrorf(codes.Internal, err, "create snapshot from(%s) failed with %v", sourceVolumeID, err)
 		}
 
 		properties, err := shareClient.GetProperties(ctx, nil)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to get snapshot properties from (%s): %v", *snapshotShare.Snapshot, err)
+			return nil, azureStatusErrorf(codes.Internal, err, "failed to get snapshot properties from (%s): %v", *snapshotShare.Snapshot, err)
 		}
 
 		itemSnapshot = *snapshotShare.Snapshot
@@ -1065,7 +1202,7 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 			FileShareProperties: &armstorage.FileShareProperties{Metadata: map[string]*string{snapshotNameKey: &snapshotName}}}, to.Ptr(snapshotsExpand))
 		if err != nil {
 			if isThrottlingError(err) {
-				klog.Warningf("switch to use data plane API instead for account %s since it's throttled", accountName)
+				logger.Info("switch to use data plane API instead since account is throttled", "account", accountName)
 				d.dataPlaneAPIAccountCache.Set(accountName, "")
 			}
 			return nil, status.Errorf(codes.Internal, "create snapshot from(%s) failed with %v, accountName: %q", sourceVolumeID, err, accountName)
@@ -1080,7 +1217,7 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 		itemSnapshotQuota = ptr.Deref(snapshotShare.FileShareProperties.ShareQuota, 0)
 	}
 
-	klog.V(2).Infof("created share snapshot: %s, time: %v, quota: %dGiB", itemSnapshot, itemSnapshotTime, itemSnapshotQuota)
+	logger.V(2).Info("created share snapshot", "snapshot", itemSnapshot, "time", itemSnapshotTime, "quotaGiB", itemSnapshotQuota)
 	if itemSnapshotQuota == 0 {
 		key := fmt.Sprintf("%s-%s", accountName, fileShareName)
 		cache, err := d.getFileShareSizeCache.Get(ctx, key, azcache.CacheReadTypeDefault)
@@ -1088,17 +1225,17 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 			return nil, status.Errorf(codes.Internal, "failed to get file share size cache(%s): %v", key, err)
 		}
 		if cache != nil {
-			klog.V(2).Infof("get file share(%s) account(%s) quota from cache", fileShareName, accountName)
+			logger.V(2).Info("get file share quota from cache", "share", fileShareName, "account", accountName)
 			itemSnapshotQuota = cache.(int32)
 		} else {
-			klog.V(2).Infof("get file share(%s) account(%s) quota from cloud", fileShareName, accountName)
+			logger.V(2).Info("get file share quota from cloud", "share", fileShareName, "account", accountName)
 			fileshareClient, err := d.getFileShareClientForSub(subsID)
 			if err != nil {
 				return nil, status.Errorf(codes.Internal, "failed to get file share client for subID(%s): %v", subsID, err)
 			}
 			fileshare, err := fileshareClient.Get(ctx, rgName, accountName, fileShareName, nil)
 			if err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to get file share(%s) quota: %v", fileShareName, err)
+				return nil, azureStatusErrorf(codes.Internal, err, "failed to get file share(%s) quota: %v", fileShareName, err)
 			}
 			itemSnapshotQuota = ptr.Deref(fileshare.FileShareProperties.ShareQuota, defaultAzureFileQuota)
 			d.getFileShareSizeCache.Set(key, itemSnapshotQuota)
@@ -1120,12 +1257,13 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 }
 
 // DeleteSnapshot delete a snapshot (todo)
-func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
+func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (resp *csi.DeleteSnapshotResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "controller_delete_snapshot"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	isOperationSucceeded := false
 	defer func() {
-		csiMC.Observe(isOperationSucceeded)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	if len(req.SnapshotId) == 0 {
@@ -1134,7 +1272,7 @@ func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequ
 	rgName, accountName, fileShareName, snapshot, subsID, err := GetInfoFromSnapshotID(req.SnapshotId)
 	if fileShareName == "" || err != nil {
 		// According to CSI Driver Sanity Tester, should succeed when an invalid snapshot id is used
-		klog.V(4).Infof("failed to get share url with (%s): %v, returning with success", req.SnapshotId, err)
+		logger.V(4).Info("failed to get share url, returning with success", "snapshotID", req.SnapshotId, "err", err)
 		return &csi.DeleteSnapshotResponse{}, nil
 	}
 
@@ -1154,13 +1292,18 @@ func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequ
 		mc.ObserveOperationWithResult(isOperationSucceeded, SnapshotID, req.SnapshotId)
 	}()
 
+	release, err := d.accountScheduler.acquire(ctx, accountName, "delete_snapshot")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	var deleteErr error
 	if len(req.GetSecrets()) > 0 {
 		useDataPlaneAPI := d.useDataPlaneAPI(ctx, req.SnapshotId, accountName)
 		shareClient, err := d.getShareClient(ctx, req.SnapshotId, req.GetSecrets(), useDataPlaneAPI)
 		if err != nil {
 			// According to CSI Driver Sanity Tester, should succeed when an invalid snapshot id is used
-			klog.V(4).Infof("failed to get share url with (%s): %v, returning with success", req.SnapshotId, err)
+			logger.V(4).Info("failed to get share url, returning with success", "snapshotID", req.SnapshotId, "err", err)
 			return &csi.DeleteSnapshotResponse{}, nil
 		}
 		client, err := shareClient.WithSnapshot(snapshot)
@@ -1180,13 +1323,13 @@ func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequ
 
 	if deleteErr != nil {
 		if strings.Contains(deleteErr.Error(), "ShareSnapshotNotFound") {
-			klog.Warningf("the specify snapshot(%s) was not found", snapshot)
+			logger.Info("the specified snapshot was not found", "snapshot", snapshot)
 			return &csi.DeleteSnapshotResponse{}, nil
 		}
 		return nil, status.Errorf(codes.Internal, "failed to delete snapshot(%s): %v", snapshot, deleteErr)
 	}
 
-	klog.V(2).Infof("delete snapshot(%s) successfully", snapshot)
+	logger.V(2).Info("deleted snapshot successfully", "snapshot", snapshot)
 	isOperationSucceeded = true
 	return &csi.DeleteSnapshotResponse{}, nil
 }
@@ -1241,7 +1384,14 @@ func (d *Driver) restoreSnapshot(ctx context.Context, req *csi.CreateVolumeReque
 	return d.copyFileShareByAzcopy(ctx, srcFileShareSnapshotName, dstFileShareName, srcPath, dstPath, snapshot, srcAccountName, dstAccountName, srcAccountSasToken, authAzcopyEnv, shareOptions, accountOptions)
 }
 
-func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, dstFileShareName, srcPath, dstPath, snapshot, srcAccountName, dstAccountName, accountSASToken string, authAzcopyEnv []string, shareOptions *ShareOptions, accountOptions *storage.AccountOptions) error {
+func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, dstFileShareName, srcPath, dstPath, snapshot, srcAccountName, dstAccountName, accountSASToken string, authAzcopyEnv []string, shareOptions *ShareOptions, accountOptions *storage.AccountOptions) (err error) {
+	logger := klog.FromContext(ctx)
+	_, span := tracing.StartSpan(ctx, "CopyFileShareByAzcopy",
+		attribute.String("source", srcAccountName+"/"+srcFileShareName),
+		attribute.String("destination", dstAccountName+"/"+dstFileShareName),
+		attribute.String("snapshot", snapshot))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	azcopyCopyOptions := azcopyCloneVolumeOptions
 	srcPathAuth := srcPath
 	if snapshot != "" {
@@ -1258,7 +1408,7 @@ func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, ds
 	}
 
 	jobState, percent, err := d.azcopy.GetAzcopyJob(dstFileShareName, authAzcopyEnv)
-	klog.V(2).Infof("azcopy job status: %s, copy percent: %s%%, error: %v", jobState, percent, err)
+	logger.V(2).Info("got azcopy job status", "status", jobState, "copyPercent", percent, "err", err)
 
 	switch jobState {
 	case util.AzcopyJobError, util.AzcopyJobCompleted, util.AzcopyJobCompletedWithErrors, util.AzcopyJobCompletedWithSkipped, util.AzcopyJobCompletedWithErrorsAndSkipped:
@@ -1266,7 +1416,7 @@ func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, ds
 	case util.AzcopyJobRunning:
 		err = wait.PollUntilContextTimeout(ctx, 20*time.Second, time.Duration(d.waitForAzCopyTimeoutMinutes)*time.Minute, true, func(context.Context) (bool, error) {
 			jobState, percent, err := d.azcopy.GetAzcopyJob(dstFileShareName, authAzcopyEnv)
-			klog.V(2).Infof("azcopy job status: %s, copy percent: %s%%, error: %v", jobState, percent, err)
+			logger.V(2).Info("got azcopy job status", "status", jobState, "copyPercent", percent, "err", err)
 			if err != nil {
 				return false, err
 			}
@@ -1276,7 +1426,7 @@ func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, ds
 			return true, nil
 		})
 	case util.AzcopyJobNotFound:
-		klog.V(2).Infof("copy fileshare %s:%s to %s:%s", srcAccountName, srcFileShareName, dstAccountName, dstFileShareName)
+		logger.V(2).Info("copy fileshare", "srcAccount", srcAccountName, "srcShare", srcFileShareName, "dstAccount", dstAccountName, "dstShare", dstFileShareName)
 		execAzcopyJob := func() error {
 			if out, err := d.execAzcopyCopy(srcPathAuth, dstPath, azcopyCopyOptions, authAzcopyEnv); err != nil {
 				return fmt.Errorf("exec error: %v, output: %v", err, string(out))
@@ -1291,11 +1441,11 @@ func (d *Driver) copyFileShareByAzcopy(ctx context.Context, srcFileShareName, ds
 	}
 
 	if err != nil {
-		klog.Warningf("CopyFileShare(%s, %s, %s) failed with error: %v", accountOptions.ResourceGroup, dstAccountName, dstFileShareName, err)
+		logger.Error(err, "CopyFileShare failed", "resourceGroup", accountOptions.ResourceGroup, "account", dstAccountName, "share", dstFileShareName)
 	} else {
-		klog.V(2).Infof("copied fileshare %s to %s successfully", srcFileShareName, dstFileShareName)
+		logger.V(2).Info("copied fileshare successfully", "srcShare", srcFileShareName, "dstShare", dstFileShareName)
 		if out, err := d.azcopy.CleanJobs(); err != nil {
-			klog.Warningf("clean azcopy jobs failed with error: %v, output: %s", err, string(out))
+			logger.Error(err, "clean azcopy jobs failed", "output", string(out))
 		}
 	}
 	return err
@@ -1318,12 +1468,13 @@ func (d *Driver) execAzcopyCopy(srcPath, dstPath string, azcopyCopyOptions, auth
 }
 
 // ControllerExpandVolume controller expand volume
-func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (resp *csi.ControllerExpandVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "controller_expand_volume"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	isOperationSucceeded := false
 	defer func() {
-		csiMC.Observe(isOperationSucceeded)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	volumeID := req.GetVolumeId()
@@ -1347,6 +1498,10 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 		// todo: figure out how to support vhd disk resize
 		return nil, status.Error(codes.Unimplemented, fmt.Sprintf("vhd disk volume(%s, diskName:%s) is not supported on ControllerExpandVolume", volumeID, diskName))
 	}
+	if _, _, _, ok := getSubDirectoryInfo(volumeID); ok {
+		// capacity of a subdirectory volume is the folderQuota in volume context enforced on the node, which could not be updated
+		return nil, status.Error(codes.Unimplemented, fmt.Sprintf("subdirectory volume(%s) is not supported on ControllerExpandVolume", volumeID))
+	}
 	if resourceGroupName == "" {
 		resourceGroupName = d.cloud.ResourceGroup
 	}
@@ -1384,17 +1539,22 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 		secrets = createStorageAccountSecret(accountName, accountKey)
 	}
 
+	release, err := d.accountScheduler.acquire(ctx, accountName, "expand_volume")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	if err = d.ResizeFileShare(ctx, subsID, resourceGroupName, accountName, fileShareName, int(requestGiB), secrets, useDataPlaneAPI); err != nil {
-		if strings.Contains(err.Error(), accountLimitExceedManagementAPI) || strings.Contains(err.Error(), accountLimitExceedDataPlaneAPI) {
+		if isAccountLimitExceededError(err) {
 			if accountName != "" {
 				d.resizeFileShareFailureCache.Set(accountName, "")
 			}
 		}
-		return nil, status.Errorf(codes.Internal, "expand volume error: %v", err)
+		return nil, azureStatusErrorf(codes.Internal, err, "expand volume error: %v", err)
 	}
 
 	isOperationSucceeded = true
-	klog.V(2).Infof("ControllerExpandVolume(%s) successfully, currentQuota: %d Gi", volumeID, int(requestGiB))
+	logger.V(2).Info("ControllerExpandVolume successfully", "currentQuotaGiB", int(requestGiB))
 	return &csi.ControllerExpandVolumeResponse{CapacityBytes: capacityBytes}, nil
 }
 
@@ -1426,7 +1586,7 @@ func (d *Driver) getServiceClient(ctx context.Context, sourceVolumeID string, se
 	if err != nil {
 		return nil, fileShareName, err
 	}
-	return fileClient.(*azureFileDataplaneClient).Client, fileShareName, err
+	return getDataplaneServiceClient(fileClient), fileShareName, err
 }
 
 // snapshotExists: sourceVolumeID is the id of source file share, returns the existence of snapshot and its detail info.
@@ -1436,6 +1596,7 @@ func (d *Driver) getServiceClient(ctx context.Context, sourceVolumeID string, se
 // As long as the snapshot already exists, returns true. But when the source is different, an error will be returned.
 // If its source file share name equals that we specify, also returns its x-ms-snapshot string, last modeified time and share quota.
 func (d *Driver) snapshotExists(ctx context.Context, sourceVolumeID, snapshotName string, secrets map[string]string, useDataPlaneAPI string) (bool, string, time.Time, int32, error) {
+	logger := klog.FromContext(ctx)
 	if len(secrets) > 0 || useDataPlaneAPI != "" {
 		serviceURL, fileShareName, err := d.getServiceClient(ctx, sourceVolumeID, secrets, useDataPlaneAPI)
 		if err != nil {
@@ -1455,7 +1616,7 @@ func (d *Driver) snapshotExists(ctx context.Context, sourceVolumeID, snapshotNam
 			for _, share := range response.Shares {
 				if name, ok := share.Metadata[snapshotNameKey]; ok && *name == snapshotName {
 					if *share.Name == fileShareName {
-						klog.V(2).Infof("found share(%s) snapshot(%s) Metadata(%v)", *share.Name, *share.Snapshot, share.Metadata)
+						logger.V(2).Info("found share snapshot", "share", *share.Name, "snapshot", *share.Snapshot, "metadata", share.Metadata)
 						if share.Snapshot == nil {
 							return true, "", *share.Properties.LastModified, *share.Properties.Quota, status.Errorf(codes.Internal, "Snapshot property of %s is nil", *share.Name)
 						}
@@ -1487,7 +1648,7 @@ func (d *Driver) snapshotExists(ctx context.Context, sourceVolumeID, snapshotNam
 		if err != nil || listSnapshot == nil {
 			return false, "", time.Time{}, 0, err
 		}
-		klog.V(2).Infof("list snapshot of share(%s) under account(%s) rg(%s) subsID(%s) with total number(%d)", fileShareName, accountName, rgName, subsID, len(listSnapshot))
+		logger.V(2).Info("listed snapshots of share", "share", fileShareName, "account", accountName, "resourceGroup", rgName, "subsID", subsID, "count", len(listSnapshot))
 		for _, share := range listSnapshot {
 			if share.Properties.SnapshotTime == nil { //the fileshare is not a snapshot
 				continue
@@ -1497,12 +1658,12 @@ func (d *Driver) snapshotExists(ctx context.Context, sourceVolumeID, snapshotNam
 				XMSSnapshot: to.Ptr(shareSnapshotTime),
 			})
 			if err != nil {
-				klog.V(2).Infof("get share(%s) snapshot(%s) error(%s)", ptr.Deref(share.Name, ""), shareSnapshotTime, err)
+				logger.V(2).Info("failed to get share snapshot", "share", ptr.Deref(share.Name, ""), "snapshot", shareSnapshotTime, "err", err)
 				return false, "", time.Time{}, 0, nil
 			}
 			if fileshare.FileShareProperties != nil && fileshare.FileShareProperties.Metadata != nil && ptr.Deref(fileshare.FileShareProperties.Metadata[snapshotNameKey], "") == snapshotName {
 				if ptr.Deref(fileshare.Name, "") == fileShareName {
-					klog.V(2).Infof("found share(%s) snapshot(%s) Metadata(%v)", ptr.Deref(fileshare.Name, ""), shareSnapshotTime, fileshare.FileShareProperties.Metadata)
+					logger.V(2).Info("found share snapshot", "share", ptr.Deref(fileshare.Name, ""), "snapshot", shareSnapshotTime, "metadata", fileshare.FileShareProperties.Metadata)
 					return true, shareSnapshotTime, *share.Properties.SnapshotTime, ptr.Deref(share.Properties.ShareQuota, 0), nil
 				}
 				return true, "", time.Time{}, 0, fmt.Errorf("snapshot(%s) already exists, while the current file share name(%s) does not equal to %s, SourceVolumeId(%s)", snapshotName, ptr.Deref(share.Name, ""), fileShareName, sourceVolumeID)
@@ -1512,13 +1673,14 @@ func (d *Driver) snapshotExists(ctx context.Context, sourceVolumeID, snapshotNam
 	return false, "", time.Time{}, 0, nil
 }
 
-// isValidVolumeCapabilities validates the given VolumeCapability array is valid
-func isValidVolumeCapabilities(volCaps []*csi.VolumeCapability) error {
+// isValidVolumeCapabilities validates the given VolumeCapability array is valid,
+// block volume is only supported for VHD disk volume
+func isValidVolumeCapabilities(volCaps []*csi.VolumeCapability, isDiskVolume bool) error {
 	if len(volCaps) == 0 {
 		return fmt.Errorf("CreateVolume Volume capabilities must be provided")
 	}
 	hasSupport := func(c *csi.VolumeCapability) error {
-		if blk := c.GetBlock(); blk != nil {
+		if blk := c.GetBlock(); blk != nil && !isDiskVolume {
 			return fmt.Errorf("driver does not support block volumes")
 		}
 		for _, vc := range volumeCaps {
@@ -1571,17 +1733,18 @@ func (d *Driver) authorizeAzcopyWithIdentity() ([]string, error) {
 // 2. driver is not using managed identity and service principal
 // 3. parameter useSasToken is true
 func (d *Driver) getAzcopyAuth(ctx context.Context, accountName, accountKey, storageEndpointSuffix string, accountOptions *storage.AccountOptions, secrets map[string]string, secretName, secretNamespace string, useSasToken bool) (string, []string, error) {
+	logger := klog.FromContext(ctx)
 	var authAzcopyEnv []string
 	var err error
 	if !useSasToken && !strings.EqualFold(d.useDataPlaneAPI(ctx, "", accountName), trueValue) && len(secrets) == 0 && len(secretName) == 0 {
 		// search in cache first
 		if cache, err := d.azcopySasTokenCache.Get(ctx, accountName, azcache.CacheReadTypeDefault); err == nil && cache != nil {
-			klog.V(2).Infof("use sas token for account(%s) since this account is found in azcopySasTokenCache", accountName)
+			logger.V(2).Info("use sas token since the account is found in azcopySasTokenCache", "account", accountName)
 			return cache.(string), nil, nil
 		}
 		authAzcopyEnv, err = d.authorizeAzcopyWithIdentity()
 		if err != nil {
-			klog.Warningf("failed to authorize azcopy with identity, error: %v", err)
+			logger.Error(err, "failed to authorize azcopy with identity")
 		}
 	}
 
@@ -1591,7 +1754,7 @@ func (d *Driver) getAzcopyAuth(ctx context.Context, accountName, accountKey, sto
 				return "", nil, err
 			}
 		}
-		klog.V(2).Infof("generate sas token for account(%s)", accountName)
+		logger.V(2).Info("generate sas token", "account", accountName)
 		sasToken, err := d.generateSASToken(ctx, accountName, accountKey, storageEndpointSuffix, d.sasTokenExpirationMinutes)
 		return sasToken, nil, err
 	}
@@ -1600,13 +1763,14 @@ func (d *Driver) getAzcopyAuth(ctx context.Context, accountName, accountKey, sto
 
 // generateSASToken generate a sas token for storage account
 func (d *Driver) generateSASToken(ctx context.Context, accountName, accountKey, storageEndpointSuffix string, expiryTime int) (string, error) {
+	logger := klog.FromContext(ctx)
 	// search in cache first
 	cache, err := d.azcopySasTokenCache.Get(ctx, accountName, azcache.CacheReadTypeDefault)
 	if err != nil {
 		return "", fmt.Errorf("get(%s) from azcopySasTokenCache failed with error: %v", accountName, err)
 	}
 	if cache != nil {
-		klog.V(2).Infof("use sas token for account(%s) since this account is found in azcopySasTokenCache", accountName)
+		logger.V(2).Info("use sas token since the account is found in azcopySasTokenCache", "account", accountName)
 		return cache.(string), nil
 	}
 
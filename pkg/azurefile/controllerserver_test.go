@@ -1476,7 +1476,8 @@ var _ = ginkgo.Describe("TestDeleteVolume", func() {
 			mockFileClient.EXPECT().Delete(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("test error")).Times(1)
 			expectedErr := status.Errorf(codes.Internal, "DeleteFileShare fileshare under account(f5713de20cde511e8ba4900) rg() failed with error: test error")
 			_, err = d.DeleteVolume(ctx, req)
-			gomega.Expect(err).To(gomega.Equal(expectedErr))
+			gomega.Expect(status.Code(err)).To(gomega.Equal(codes.Internal))
+			gomega.Expect(err).To(gomega.MatchError(expectedErr.Error()))
 		})
 	})
 	ginkgo.When("Valid request", func() {
@@ -2199,7 +2200,8 @@ var _ = ginkgo.Describe("TestControllerExpandVolume", func() {
 
 			expectErr := status.Errorf(codes.Internal, "expand volume error: test error")
 			_, err := d.ControllerExpandVolume(ctx, req)
-			gomega.Expect(err).To(gomega.Equal(expectErr))
+			gomega.Expect(status.Code(err)).To(gomega.Equal(codes.Internal))
+			gomega.Expect(err).To(gomega.MatchError(expectErr.Error()))
 		})
 	})
 	ginkgo.When("get account info failed", func() {
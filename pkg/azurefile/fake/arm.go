@@ -0,0 +1,384 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+)
+
+var filterPrefixRegex = regexp.MustCompile(`startswith\(name,\s*'?([^')]*)'?\)`)
+
+// serveARM serves the following resource paths (case insensitive):
+//
+//	/subscriptions/{sub}/providers/Microsoft.Storage/storageAccounts
+//	/subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Storage/storageAccounts
+//	/subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Storage/storageAccounts/{account}
+//	/subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Storage/storageAccounts/{account}/listKeys
+//	/subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Storage/storageAccounts/{account}/fileServices/default/shares
+//	/subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Storage/storageAccounts/{account}/fileServices/default/shares/{share}
+func (s *Server) serveARM(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	lower := make([]string, len(segments))
+	for i := range segments {
+		lower[i] = strings.ToLower(segments[i])
+	}
+
+	switch {
+	case len(segments) == 4 && lower[2] == "providers" && lower[3] == "microsoft.storage":
+		writeARMError(w, http.StatusNotFound, "InvalidResourceType", r.URL.Path)
+	case len(segments) == 5 && lower[2] == "providers" && lower[4] == "storageaccounts":
+		s.listAccounts(w, r, segments[1], "")
+	case len(segments) >= 7 && lower[2] == "resourcegroups" && lower[4] == "providers" && lower[6] == "storageaccounts":
+		subsID, rg := segments[1], segments[3]
+		if len(segments) == 7 {
+			s.listAccounts(w, r, subsID, rg)
+			return
+		}
+		accountName := segments[7]
+		switch {
+		case len(segments) == 8:
+			s.serveARMAccount(w, r, subsID, rg, accountName)
+		case len(segments) == 9 && lower[8] == "listkeys" && r.Method == http.MethodPost:
+			s.listKeys(w, accountName)
+		case len(segments) == 11 && lower[8] == "fileservices" && lower[10] == "shares" && r.Method == http.MethodGet:
+			s.listARMShares(w, r, accountName)
+		case len(segments) == 12 && lower[8] == "fileservices" && lower[10] == "shares":
+			s.serveARMShare(w, r, accountName, segments[11])
+		default:
+			writeARMError(w, http.StatusNotFound, "ResourceNotFound", r.URL.Path)
+		}
+	default:
+		writeARMError(w, http.StatusNotFound, "ResourceNotFound", r.URL.Path)
+	}
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request, subsID, rg string) {
+	if r.Method != http.MethodGet {
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+	result := armstorage.AccountListResult{Value: []*armstorage.Account{}}
+	for _, acct := range s.sortedAccounts() {
+		if !strings.EqualFold(acct.subscriptionID, subsID) || (rg != "" && !strings.EqualFold(acct.resourceGroup, rg)) {
+			continue
+		}
+		model := acct.model
+		result.Value = append(result.Value, &model)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveARMAccount(w http.ResponseWriter, r *http.Request, subsID, rg, accountName string) {
+	acct := s.accounts[strings.ToLower(accountName)]
+	switch r.Method {
+	case http.MethodGet:
+		if acct == nil {
+			writeARMError(w, http.StatusNotFound, "StorageAccountNotFound", fmt.Sprintf("The storage account %s was not found.", accountName))
+			return
+		}
+		writeJSON(w, http.StatusOK, acct.model)
+	case http.MethodPut:
+		var params armstorage.AccountCreateParameters
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		model := armstorage.Account{
+			Location: params.Location,
+			Kind:     params.Kind,
+			SKU:      params.SKU,
+			Tags:     params.Tags,
+		}
+		if params.Properties != nil {
+			// create parameters share json field names with account properties
+			model.Properties = &armstorage.AccountProperties{}
+			if err := convert(params.Properties, model.Properties); err != nil {
+				writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+				return
+			}
+		}
+		if acct != nil {
+			if !strings.EqualFold(acct.resourceGroup, rg) {
+				writeARMError(w, http.StatusConflict, "StorageAccountAlreadyTaken", fmt.Sprintf("The storage account named %s is already taken.", accountName))
+				return
+			}
+			shares, keys := acct.shares, acct.keys
+			acct = s.newAccount(subsID, rg, accountName, model)
+			acct.shares, acct.keys = shares, keys
+		} else {
+			acct = s.newAccount(subsID, rg, accountName, model)
+		}
+		writeJSON(w, http.StatusOK, acct.model)
+	case http.MethodPatch:
+		if acct == nil {
+			writeARMError(w, http.StatusNotFound, "StorageAccountNotFound", fmt.Sprintf("The storage account %s was not found.", accountName))
+			return
+		}
+		var params armstorage.AccountUpdateParameters
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		if params.Tags != nil {
+			acct.model.Tags = params.Tags
+		}
+		if params.SKU != nil {
+			acct.model.SKU = params.SKU
+		}
+		if params.Properties != nil {
+			if err := convert(params.Properties, acct.model.Properties); err != nil {
+				writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, acct.model)
+	case http.MethodDelete:
+		if acct == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(s.accounts, strings.ToLower(accountName))
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (s *Server) listKeys(w http.ResponseWriter, accountName string) {
+	acct := s.accounts[strings.ToLower(accountName)]
+	if acct == nil {
+		writeARMError(w, http.StatusNotFound, "StorageAccountNotFound", fmt.Sprintf("The storage account %s was not found.", accountName))
+		return
+	}
+	result := armstorage.AccountListKeysResult{}
+	for i, key := range acct.keys {
+		result.Keys = append(result.Keys, &armstorage.AccountKey{
+			KeyName:     to.Ptr(fmt.Sprintf("key%d", i+1)),
+			Value:       to.Ptr(key),
+			Permissions: to.Ptr(armstorage.KeyPermissionFull),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) listARMShares(w http.ResponseWriter, r *http.Request, accountName string) {
+	acct := s.accounts[strings.ToLower(accountName)]
+	if acct == nil {
+		writeARMError(w, http.StatusNotFound, "StorageAccountNotFound", fmt.Sprintf("The storage account %s was not found.", accountName))
+		return
+	}
+	var prefix string
+	if match := filterPrefixRegex.FindStringSubmatch(r.URL.Query().Get("$filter")); len(match) > 1 {
+		prefix = match[1]
+	}
+	expandSnapshots := strings.Contains(strings.ToLower(r.URL.Query().Get("$expand")), "snapshots")
+
+	result := armstorage.FileShareItems{Value: []*armstorage.FileShareItem{}}
+	for _, share := range sortedShares(acct.shares) {
+		if !strings.HasPrefix(share.name, prefix) {
+			continue
+		}
+		item := armShare(acct, share)
+		result.Value = append(result.Value, &armstorage.FileShareItem{
+			ID: item.ID, Name: item.Name, Type: item.Type, Etag: item.Etag, Properties: item.FileShareProperties,
+		})
+		if expandSnapshots {
+			for _, snapshot := range sortedShares(share.snapshots) {
+				item := armShare(acct, snapshot)
+				result.Value = append(result.Value, &armstorage.FileShareItem{
+					ID: item.ID, Name: item.Name, Type: item.Type, Etag: item.Etag, Properties: item.FileShareProperties,
+				})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveARMShare(w http.ResponseWriter, r *http.Request, accountName, shareName string) {
+	acct := s.accounts[strings.ToLower(accountName)]
+	if acct == nil {
+		writeARMError(w, http.StatusNotFound, "StorageAccountNotFound", fmt.Sprintf("The storage account %s was not found.", accountName))
+		return
+	}
+	share := acct.shares[shareName]
+	snapshotTime := r.Header.Get("x-ms-snapshot")
+
+	switch r.Method {
+	case http.MethodGet:
+		if share == nil {
+			writeARMError(w, http.StatusNotFound, "ShareNotFound", "The specified share does not exist.")
+			return
+		}
+		if snapshotTime != "" {
+			snapshot := share.snapshots[snapshotTime]
+			if snapshot == nil {
+				writeARMError(w, http.StatusNotFound, "ShareSnapshotNotFound", "The specified share snapshot does not exist.")
+				return
+			}
+			share = snapshot
+		}
+		writeJSON(w, http.StatusOK, armShare(acct, share))
+	case http.MethodPut:
+		var params armstorage.FileShare
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		if strings.Contains(strings.ToLower(r.URL.Query().Get("$expand")), "snapshots") {
+			if share == nil {
+				writeARMError(w, http.StatusNotFound, "ShareNotFound", "The specified share does not exist.")
+				return
+			}
+			var metadata map[string]*string
+			if params.FileShareProperties != nil {
+				metadata = params.FileShareProperties.Metadata
+			}
+			writeJSON(w, http.StatusOK, armShare(acct, s.snapshot(share, metadata)))
+			return
+		}
+		if share != nil {
+			writeARMError(w, http.StatusConflict, "ShareAlreadyExists", "The specified share already exists.")
+			return
+		}
+		share = s.newShare(shareName, params.FileShareProperties)
+		acct.shares[shareName] = share
+		writeJSON(w, http.StatusCreated, armShare(acct, share))
+	case http.MethodPatch:
+		if share == nil {
+			writeARMError(w, http.StatusNotFound, "ShareNotFound", "The specified share does not exist.")
+			return
+		}
+		var params armstorage.FileShare
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeARMError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		if props := params.FileShareProperties; props != nil {
+			if props.ShareQuota != nil {
+				share.properties.ShareQuota = props.ShareQuota
+			}
+			if props.Metadata != nil {
+				share.properties.Metadata = props.Metadata
+			}
+			if props.AccessTier != nil {
+				share.properties.AccessTier = props.AccessTier
+			}
+			if props.RootSquash != nil {
+				share.properties.RootSquash = props.RootSquash
+			}
+			if props.ProvisionedIops != nil {
+				share.properties.ProvisionedIops = props.ProvisionedIops
+			}
+			if props.ProvisionedBandwidthMibps != nil {
+				share.properties.ProvisionedBandwidthMibps = props.ProvisionedBandwidthMibps
+			}
+		}
+		share.lastModified = s.now()
+		writeJSON(w, http.StatusOK, armShare(acct, share))
+	case http.MethodDelete:
+		if share == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if snapshotTime != "" {
+			if _, ok := share.snapshots[snapshotTime]; !ok {
+				writeARMError(w, http.StatusNotFound, "ShareSnapshotNotFound", "The specified share snapshot does not exist.")
+				return
+			}
+			delete(share.snapshots, snapshotTime)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if len(share.snapshots) > 0 && !strings.Contains(strings.ToLower(r.URL.Query().Get("$include")), "snapshots") {
+			writeARMError(w, http.StatusConflict, "ShareHasSnapshots", "The share has snapshots and the operation requires no snapshots.")
+			return
+		}
+		delete(acct.shares, shareName)
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func armShare(acct *account, share *fileShare) armstorage.FileShare {
+	properties := share.properties
+	properties.LastModifiedTime = to.Ptr(share.lastModified)
+	return armstorage.FileShare{
+		ID:                  to.Ptr(fmt.Sprintf("%s/fileServices/default/shares/%s", *acct.model.ID, share.name)),
+		Name:                to.Ptr(share.name),
+		Type:                to.Ptr("Microsoft.Storage/storageAccounts/fileServices/shares"),
+		Etag:                to.Ptr(share.etag()),
+		FileShareProperties: &properties,
+	}
+}
+
+func (s *Server) sortedAccounts() []*account {
+	var accounts []*account
+	for _, acct := range s.accounts {
+		accounts = append(accounts, acct)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return *accounts[i].model.Name < *accounts[j].model.Name })
+	return accounts
+}
+
+func sortedShares(shares map[string]*fileShare) []*fileShare {
+	var keys []string
+	for k := range shares {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]*fileShare, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, shares[k])
+	}
+	return result
+}
+
+// convert copies fields between two ARM models through their json representation
+func convert(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeARMError(w, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(data)
+}
+
+func writeARMError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(statusCode)
+	_, _ = fmt.Fprintf(w, `{"error":{"code":%q,"message":%q}}`, code, message)
+}
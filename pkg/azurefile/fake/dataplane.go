@@ -0,0 +1,678 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+	"k8s.io/utils/ptr"
+)
+
+const metadataHeaderPrefix = "x-ms-meta-"
+
+// serveDataPlane serves path-style Azure Files REST API calls, e.g.
+//
+//	GET    /{account}?comp=list
+//	PUT    /{account}/{share}?restype=share[&comp=properties|metadata|snapshot]
+//	GET    /{account}/{share}?restype=share[&comp=stats][&sharesnapshot=...]
+//	DELETE /{account}/{share}?restype=share[&sharesnapshot=...]
+//	PUT    /{account}/{share}/{directory}?restype=directory[&comp=metadata|rename]
+//	GET    /{account}/{share}/{directory}?restype=directory&comp=list
+//	PUT    /{account}/{share}/{file}[?comp=range|metadata|lease]
+func (s *Server) serveDataPlane(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	acct := s.accounts[strings.ToLower(parts[0])]
+	if acct == nil {
+		writeStorageError(w, r, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("The storage account %s does not exist.", parts[0]))
+		return
+	}
+	query := r.URL.Query()
+	w.Header().Set("x-ms-request-id", fmt.Sprintf("%d", s.now().UnixNano()))
+	w.Header().Set("x-ms-version", r.Header.Get("x-ms-version"))
+
+	if len(parts) == 1 || parts[1] == "" {
+		if r.Method == http.MethodGet && query.Get("comp") == "list" {
+			s.listShares(w, r, acct)
+			return
+		}
+		writeStorageError(w, r, http.StatusBadRequest, "UnsupportedQueryParameter", r.URL.RawQuery)
+		return
+	}
+
+	shareName := parts[1]
+	var filePath string
+	if len(parts) == 3 {
+		filePath = cleanPath(parts[2])
+	}
+	switch query.Get("restype") {
+	case "share":
+		s.serveShare(w, r, acct, shareName)
+		return
+	case "directory":
+		share := s.getShareForRequest(w, r, acct, shareName)
+		if share == nil {
+			return
+		}
+		s.serveDirectory(w, r, acct, share, filePath)
+		return
+	}
+	share := s.getShareForRequest(w, r, acct, shareName)
+	if share == nil {
+		return
+	}
+	s.serveFile(w, r, share, filePath)
+}
+
+// getShareForRequest returns the share or share snapshot of the request, writes error if not found
+func (s *Server) getShareForRequest(w http.ResponseWriter, r *http.Request, acct *account, shareName string) *fileShare {
+	share := acct.shares[shareName]
+	if share == nil {
+		writeStorageError(w, r, http.StatusNotFound, "ShareNotFound", "The specified share does not exist.")
+		return nil
+	}
+	if snapshotTime := r.URL.Query().Get("sharesnapshot"); snapshotTime != "" {
+		snapshot := share.snapshots[snapshotTime]
+		if snapshot == nil {
+			writeStorageError(w, r, http.StatusNotFound, "ShareSnapshotNotFound", "The specified share snapshot does not exist.")
+			return nil
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodDelete {
+			writeStorageError(w, r, http.StatusBadRequest, "OperationNotSupportedOnSnapshot", "The operation is not supported on a share snapshot.")
+			return nil
+		}
+		return snapshot
+	}
+	return share
+}
+
+func (s *Server) serveShare(w http.ResponseWriter, r *http.Request, acct *account, shareName string) {
+	comp := r.URL.Query().Get("comp")
+	if r.Method == http.MethodPut && comp == "" {
+		if acct.shares[shareName] != nil {
+			writeStorageError(w, r, http.StatusConflict, "ShareAlreadyExists", "The specified share already exists.")
+			return
+		}
+		properties := &armstorage.FileShareProperties{Metadata: metadataFromHeader(r.Header)}
+		if quota, err := strconv.Atoi(r.Header.Get("x-ms-share-quota")); err == nil {
+			properties.ShareQuota = to.Ptr(int32(quota))
+		}
+		if v := r.Header.Get("x-ms-enabled-protocols"); v != "" {
+			properties.EnabledProtocols = to.Ptr(armstorage.EnabledProtocols(v))
+		}
+		if v := r.Header.Get("x-ms-access-tier"); v != "" {
+			properties.AccessTier = to.Ptr(armstorage.ShareAccessTier(v))
+		}
+		if v := r.Header.Get("x-ms-root-squash"); v != "" {
+			properties.RootSquash = to.Ptr(armstorage.RootSquashType(v))
+		}
+		share := s.newShare(shareName, properties)
+		acct.shares[shareName] = share
+		writeShareHeaders(w, share)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	share := s.getShareForRequest(w, r, acct, shareName)
+	if share == nil {
+		return
+	}
+	switch {
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && comp == "":
+		writeShareHeaders(w, share)
+		for k, v := range share.properties.Metadata {
+			w.Header().Set(metadataHeaderPrefix+k, ptr.Deref(v, ""))
+		}
+		if share.properties.EnabledProtocols != nil {
+			w.Header().Set("x-ms-enabled-protocols", string(*share.properties.EnabledProtocols))
+		}
+		if share.properties.AccessTier != nil {
+			w.Header().Set("x-ms-access-tier", string(*share.properties.AccessTier))
+		}
+		if share.properties.RootSquash != nil {
+			w.Header().Set("x-ms-root-squash", string(*share.properties.RootSquash))
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && comp == "stats":
+		var usage int64
+		for _, f := range share.files {
+			usage += f.size
+		}
+		writeShareHeaders(w, share)
+		writeXML(w, http.StatusOK, struct {
+			XMLName         xml.Name `xml:"ShareStats"`
+			ShareUsageBytes int64    `xml:"ShareUsageBytes"`
+		}{ShareUsageBytes: usage})
+	case r.Method == http.MethodPut && comp == "properties":
+		if quota, err := strconv.Atoi(r.Header.Get("x-ms-share-quota")); err == nil {
+			share.properties.ShareQuota = to.Ptr(int32(quota))
+		}
+		if v := r.Header.Get("x-ms-access-tier"); v != "" {
+			share.properties.AccessTier = to.Ptr(armstorage.ShareAccessTier(v))
+		}
+		if v := r.Header.Get("x-ms-root-squash"); v != "" {
+			share.properties.RootSquash = to.Ptr(armstorage.RootSquashType(v))
+		}
+		share.lastModified = s.now()
+		writeShareHeaders(w, share)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && comp == "metadata":
+		share.properties.Metadata = metadataFromHeader(r.Header)
+		share.lastModified = s.now()
+		writeShareHeaders(w, share)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && comp == "snapshot":
+		snapshot := s.snapshot(share, metadataFromHeader(r.Header))
+		writeShareHeaders(w, snapshot)
+		w.Header().Set("x-ms-snapshot", snapshot.properties.SnapshotTime.Format(SnapshotTimeFormat))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodDelete && comp == "":
+		if snapshotTime := r.URL.Query().Get("sharesnapshot"); snapshotTime != "" {
+			delete(acct.shares[shareName].snapshots, snapshotTime)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if len(share.snapshots) > 0 && !strings.HasPrefix(strings.ToLower(r.Header.Get("x-ms-delete-snapshots")), "include") {
+			writeStorageError(w, r, http.StatusConflict, "ShareHasSnapshots", "The share has snapshots and the operation requires no snapshots.")
+			return
+		}
+		delete(acct.shares, shareName)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeStorageError(w, r, http.StatusBadRequest, "UnsupportedHttpVerb", fmt.Sprintf("%s %s", r.Method, r.URL.RawQuery))
+	}
+}
+
+func (s *Server) serveDirectory(w http.ResponseWriter, r *http.Request, acct *account, share *fileShare, dirPath string) {
+	comp := r.URL.Query().Get("comp")
+	dir := share.directories[dirPath]
+	switch {
+	case r.Method == http.MethodPut && comp == "rename":
+		s.renameDirectory(w, r, acct, share, dirPath)
+	case r.Method == http.MethodPut && comp == "":
+		if dir != nil {
+			writeStorageError(w, r, http.StatusConflict, "ResourceAlreadyExists", "The specified resource already exists.")
+			return
+		}
+		if _, ok := share.files[dirPath]; ok {
+			writeStorageError(w, r, http.StatusConflict, "ResourceTypeMismatch", "The specified resource type does not match the type of the resource.")
+			return
+		}
+		if _, ok := share.directories[parentPath(dirPath)]; !ok {
+			writeStorageError(w, r, http.StatusNotFound, "ParentNotFound", "The specified parent path does not exist.")
+			return
+		}
+		dir = &entry{metadata: metadataFromHeader(r.Header), lastModified: s.now()}
+		share.directories[dirPath] = dir
+		writeEntryHeaders(w, dir)
+		w.WriteHeader(http.StatusCreated)
+	case dir == nil:
+		writeStorageError(w, r, http.StatusNotFound, "ResourceNotFound", "The specified resource does not exist.")
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && comp == "":
+		writeEntryHeaders(w, dir)
+		for k, v := range dir.metadata {
+			w.Header().Set(metadataHeaderPrefix+k, ptr.Deref(v, ""))
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && comp == "list":
+		listDirectory(w, share, dirPath)
+	case r.Method == http.MethodPut && comp == "metadata":
+		dir.metadata = metadataFromHeader(r.Header)
+		dir.lastModified = s.now()
+		writeEntryHeaders(w, dir)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && comp == "":
+		if dirPath == "" {
+			writeStorageError(w, r, http.StatusBadRequest, "OperationNotAllowedOnRootDirectory", "The operation is not allowed on the root directory.")
+			return
+		}
+		for p := range share.directories {
+			if parentPath(p) == dirPath && p != "" {
+				writeStorageError(w, r, http.StatusConflict, "DirectoryNotEmpty", "The specified directory is not empty.")
+				return
+			}
+		}
+		for p := range share.files {
+			if parentPath(p) == dirPath {
+				writeStorageError(w, r, http.StatusConflict, "DirectoryNotEmpty", "The specified directory is not empty.")
+				return
+			}
+		}
+		delete(share.directories, dirPath)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeStorageError(w, r, http.StatusBadRequest, "UnsupportedHttpVerb", fmt.Sprintf("%s %s", r.Method, r.URL.RawQuery))
+	}
+}
+
+// renameDirectory moves the source directory in x-ms-file-rename-source and all its children to dstPath in the same share
+func (s *Server) renameDirectory(w http.ResponseWriter, r *http.Request, acct *account, share *fileShare, dstPath string) {
+	source, err := url.Parse(r.Header.Get("x-ms-file-rename-source"))
+	if err != nil {
+		writeStorageError(w, r, http.StatusBadRequest, "InvalidHeaderValue", "x-ms-file-rename-source is invalid.")
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(source.Path, "/"), "/", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], *acct.model.Name) || parts[1] != share.name {
+		writeStorageError(w, r, http.StatusBadRequest, "InvalidHeaderValue", "x-ms-file-rename-source must be in the same share.")
+		return
+	}
+	srcPath := cleanPath(parts[2])
+	if _, ok := share.directories[srcPath]; !ok || srcPath == "" {
+		writeStorageError(w, r, http.StatusNotFound, "ResourceNotFound", "The specified resource does not exist.")
+		return
+	}
+	if _, ok := share.directories[dstPath]; ok {
+		writeStorageError(w, r, http.StatusConflict, "ResourceAlreadyExists", "The specified resource already exists.")
+		return
+	}
+	if _, ok := share.directories[parentPath(dstPath)]; !ok {
+		writeStorageError(w, r, http.StatusNotFound, "ParentNotFound", "The specified parent path does not exist.")
+		return
+	}
+	move := func(entries map[string]*entry) {
+		for p, e := range entries {
+			if p == srcPath || strings.HasPrefix(p, srcPath+"/") {
+				delete(entries, p)
+				entries[dstPath+strings.TrimPrefix(p, srcPath)] = e
+			}
+		}
+	}
+	move(share.directories)
+	move(share.files)
+	dir := share.directories[dstPath]
+	dir.lastModified = s.now()
+	writeEntryHeaders(w, dir)
+	w.WriteHeader(http.StatusOK)
+}
+
+// listDirectory lists the direct children of a directory in a single page
+func listDirectory(w http.ResponseWriter, share *fileShare, dirPath string) {
+	type xmlFileProperties struct {
+		ContentLength int64 `xml:"Content-Length"`
+	}
+	type xmlFile struct {
+		Name       string            `xml:"Name"`
+		Properties xmlFileProperties `xml:"Properties"`
+	}
+	type xmlDirectory struct {
+		Name string `xml:"Name"`
+	}
+	result := struct {
+		XMLName       xml.Name       `xml:"EnumerationResults"`
+		ShareName     string         `xml:"ShareName,attr"`
+		DirectoryPath string         `xml:"DirectoryPath,attr"`
+		Files         []xmlFile      `xml:"Entries>File"`
+		Directories   []xmlDirectory `xml:"Entries>Directory"`
+		NextMarker    string         `xml:"NextMarker"`
+	}{ShareName: share.name, DirectoryPath: dirPath}
+	for _, p := range sortedKeys(share.files) {
+		if parentPath(p) == dirPath {
+			result.Files = append(result.Files, xmlFile{Name: path.Base(p), Properties: xmlFileProperties{ContentLength: share.files[p].size}})
+		}
+	}
+	for _, p := range sortedKeys(share.directories) {
+		if p != "" && parentPath(p) == dirPath {
+			result.Directories = append(result.Directories, xmlDirectory{Name: path.Base(p)})
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func sortedKeys(entries map[string]*entry) []string {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, share *fileShare, filePath string) {
+	comp := r.URL.Query().Get("comp")
+	f := share.files[filePath]
+	switch {
+	case r.Method == http.MethodPut && comp == "":
+		if _, ok := share.directories[filePath]; ok {
+			writeStorageError(w, r, http.StatusConflict, "ResourceTypeMismatch", "The specified resource type does not match the type of the resource.")
+			return
+		}
+		if _, ok := share.directories[parentPath(filePath)]; !ok {
+			writeStorageError(w, r, http.StatusNotFound, "ParentNotFound", "The specified parent path does not exist.")
+			return
+		}
+		if f != nil && !checkFileLease(w, r, f) {
+			return
+		}
+		size, err := strconv.ParseInt(r.Header.Get("x-ms-content-length"), 10, 64)
+		if err != nil || size < 0 {
+			writeStorageError(w, r, http.StatusBadRequest, "InvalidHeaderValue", "x-ms-content-length is invalid.")
+			return
+		}
+		f = &entry{metadata: metadataFromHeader(r.Header), size: size, lastModified: s.now()}
+		share.files[filePath] = f
+		writeEntryHeaders(w, f)
+		w.WriteHeader(http.StatusCreated)
+	case f == nil:
+		writeStorageError(w, r, http.StatusNotFound, "ResourceNotFound", "The specified resource does not exist.")
+	case r.Method == http.MethodPut && comp == "lease":
+		leaseFile(w, r, f)
+	case (r.Method == http.MethodPut || r.Method == http.MethodDelete) && !checkFileLease(w, r, f):
+		// writes to a leased file must carry the lease ID
+	case r.Method == http.MethodHead && comp == "":
+		writeEntryHeaders(w, f)
+		for k, v := range f.metadata {
+			w.Header().Set(metadataHeaderPrefix+k, ptr.Deref(v, ""))
+		}
+		w.Header().Set("x-ms-type", "File")
+		if f.leaseID != "" {
+			w.Header().Set("x-ms-lease-state", "leased")
+			w.Header().Set("x-ms-lease-status", "locked")
+			w.Header().Set("x-ms-lease-duration", "infinite")
+		} else {
+			w.Header().Set("x-ms-lease-state", "available")
+			w.Header().Set("x-ms-lease-status", "unlocked")
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(f.size, 10))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && comp == "range":
+		start, end, err := parseRange(r.Header.Get("x-ms-range"))
+		if err != nil || end >= f.size {
+			writeStorageError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The range specified is invalid for the current size of the resource.")
+			return
+		}
+		if strings.EqualFold(r.Header.Get("x-ms-write"), "clear") {
+			f.ranges = clearRange(f.ranges, byteRange{start: start, end: end})
+		} else {
+			f.ranges = addRange(f.ranges, byteRange{start: start, end: end})
+		}
+		f.lastModified = s.now()
+		writeEntryHeaders(w, f)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && comp == "rangelist":
+		type xmlRange struct {
+			Start int64 `xml:"Start"`
+			End   int64 `xml:"End"`
+		}
+		result := struct {
+			XMLName xml.Name   `xml:"Ranges"`
+			Ranges  []xmlRange `xml:"Range"`
+		}{}
+		for _, rng := range f.ranges {
+			result.Ranges = append(result.Ranges, xmlRange{Start: rng.start, End: rng.end})
+		}
+		writeEntryHeaders(w, f)
+		w.Header().Set("x-ms-content-length", strconv.FormatInt(f.size, 10))
+		writeXML(w, http.StatusOK, result)
+	case r.Method == http.MethodPut && comp == "metadata":
+		f.metadata = metadataFromHeader(r.Header)
+		f.lastModified = s.now()
+		writeEntryHeaders(w, f)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && comp == "":
+		delete(share.files, filePath)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeStorageError(w, r, http.StatusBadRequest, "UnsupportedHttpVerb", fmt.Sprintf("%s %s", r.Method, r.URL.RawQuery))
+	}
+}
+
+// leaseFile serves the acquire, change, release and break actions of infinite file leases
+func leaseFile(w http.ResponseWriter, r *http.Request, f *entry) {
+	action := r.Header.Get("x-ms-lease-action")
+	leaseID := r.Header.Get("x-ms-lease-id")
+	proposedLeaseID := r.Header.Get("x-ms-proposed-lease-id")
+	switch {
+	case action == "acquire":
+		if f.leaseID != "" {
+			writeStorageError(w, r, http.StatusConflict, "LeaseAlreadyPresent", "There is already a lease present.")
+			return
+		}
+		if proposedLeaseID == "" {
+			writeStorageError(w, r, http.StatusBadRequest, "MissingRequiredHeader", "x-ms-proposed-lease-id is required.")
+			return
+		}
+		f.leaseID = proposedLeaseID
+		w.Header().Set("x-ms-lease-id", f.leaseID)
+		w.WriteHeader(http.StatusCreated)
+	case f.leaseID == "":
+		writeStorageError(w, r, http.StatusConflict, "LeaseNotPresentWithLeaseOperation", "There is currently no lease on the file.")
+	case action == "break":
+		f.leaseID = ""
+		w.WriteHeader(http.StatusAccepted)
+	case leaseID != f.leaseID:
+		writeStorageError(w, r, http.StatusConflict, "LeaseIdMismatchWithLeaseOperation", "The lease ID specified did not match the lease ID for the file.")
+	case action == "change":
+		f.leaseID = proposedLeaseID
+		w.Header().Set("x-ms-lease-id", f.leaseID)
+		w.WriteHeader(http.StatusOK)
+	case action == "release":
+		f.leaseID = ""
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeStorageError(w, r, http.StatusBadRequest, "InvalidHeaderValue", fmt.Sprintf("x-ms-lease-action %s is invalid.", action))
+	}
+}
+
+// checkFileLease writes an error and returns false if the file is leased and the request does not carry the lease ID
+func checkFileLease(w http.ResponseWriter, r *http.Request, f *entry) bool {
+	leaseID := r.Header.Get("x-ms-lease-id")
+	switch {
+	case f.leaseID == "":
+		return true
+	case leaseID == "":
+		writeStorageError(w, r, http.StatusPreconditionFailed, "LeaseIdMissing", "There is currently a lease on the file and no lease ID was specified in the request.")
+		return false
+	case leaseID != f.leaseID:
+		writeStorageError(w, r, http.StatusPreconditionFailed, "LeaseIdMismatchWithFileOperation", "The lease ID specified did not match the lease ID for the file.")
+		return false
+	}
+	return true
+}
+
+type xmlMetadata map[string]*string
+
+// MarshalXML writes metadata as <Metadata><key>value</key></Metadata>
+func (m xmlMetadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for k, v := range m {
+		if err := e.EncodeElement(ptr.Deref(v, ""), xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+type xmlShareProperties struct {
+	LastModified string `xml:"Last-Modified"`
+	Etag         string `xml:"Etag"`
+	Quota        int32  `xml:"Quota"`
+}
+
+type xmlShare struct {
+	Name       string             `xml:"Name"`
+	Snapshot   string             `xml:"Snapshot,omitempty"`
+	Properties xmlShareProperties `xml:"Properties"`
+	Metadata   xmlMetadata        `xml:"Metadata,omitempty"`
+}
+
+func (s *Server) listShares(w http.ResponseWriter, r *http.Request, acct *account) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	include := strings.ToLower(query.Get("include"))
+	result := struct {
+		XMLName    xml.Name   `xml:"EnumerationResults"`
+		Endpoint   string     `xml:"ServiceEndpoint,attr"`
+		Prefix     string     `xml:"Prefix,omitempty"`
+		Shares     []xmlShare `xml:"Shares>Share"`
+		NextMarker string     `xml:"NextMarker"`
+	}{
+		Endpoint: fmt.Sprintf("%s/%s/", s.httpServer.URL, *acct.model.Name),
+		Prefix:   prefix,
+	}
+	toXML := func(share *fileShare) xmlShare {
+		item := xmlShare{
+			Name: share.name,
+			Properties: xmlShareProperties{
+				LastModified: share.lastModified.Format(http.TimeFormat),
+				Etag:         share.etag(),
+				Quota:        ptr.Deref(share.properties.ShareQuota, 0),
+			},
+		}
+		if share.properties.SnapshotTime != nil {
+			item.Snapshot = share.properties.SnapshotTime.Format(SnapshotTimeFormat)
+		}
+		if strings.Contains(include, "metadata") {
+			item.Metadata = share.properties.Metadata
+		}
+		return item
+	}
+	for _, share := range sortedShares(acct.shares) {
+		if !strings.HasPrefix(share.name, prefix) {
+			continue
+		}
+		if strings.Contains(include, "snapshots") {
+			for _, snapshot := range sortedShares(share.snapshots) {
+				result.Shares = append(result.Shares, toXML(snapshot))
+			}
+		}
+		result.Shares = append(result.Shares, toXML(share))
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func writeShareHeaders(w http.ResponseWriter, share *fileShare) {
+	w.Header().Set("ETag", share.etag())
+	w.Header().Set("Last-Modified", share.lastModified.Format(http.TimeFormat))
+	w.Header().Set("x-ms-share-quota", strconv.Itoa(int(ptr.Deref(share.properties.ShareQuota, 0))))
+}
+
+func writeEntryHeaders(w http.ResponseWriter, e *entry) {
+	w.Header().Set("ETag", fmt.Sprintf("\"0x%X\"", e.lastModified.UnixNano()))
+	w.Header().Set("Last-Modified", e.lastModified.Format(http.TimeFormat))
+	w.Header().Set("x-ms-file-last-write-time", e.lastModified.Format(SnapshotTimeFormat))
+}
+
+func writeXML(w http.ResponseWriter, statusCode int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(data)
+}
+
+func writeStorageError(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	w.Header().Set("x-ms-error-code", code)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_, _ = fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, message)
+}
+
+func metadataFromHeader(header http.Header) map[string]*string {
+	var metadata map[string]*string
+	for k, v := range header {
+		if key := strings.ToLower(k); strings.HasPrefix(key, metadataHeaderPrefix) && len(v) > 0 {
+			if metadata == nil {
+				metadata = make(map[string]*string)
+			}
+			metadata[strings.TrimPrefix(key, metadataHeaderPrefix)] = to.Ptr(v[0])
+		}
+	}
+	return metadata
+}
+
+func parentPath(p string) string {
+	parent := path.Dir(p)
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return parent
+}
+
+// parseRange parses "bytes=start-end"
+func parseRange(value string) (int64, int64, error) {
+	value = strings.TrimPrefix(value, "bytes=")
+	fields := strings.SplitN(value, "-", 2)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q", value)
+	}
+	start, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || end < start {
+		return 0, 0, fmt.Errorf("invalid range %q", value)
+	}
+	return start, end, nil
+}
+
+// addRange merges a range into sorted and non-overlapping ranges
+func addRange(ranges []byteRange, rng byteRange) []byteRange {
+	var result []byteRange
+	for _, r := range ranges {
+		switch {
+		case r.end+1 < rng.start:
+			result = append(result, r)
+		case rng.end+1 < r.start:
+			result = append(result, rng)
+			rng = r
+		default:
+			rng = byteRange{start: min(r.start, rng.start), end: max(r.end, rng.end)}
+		}
+	}
+	return append(result, rng)
+}
+
+// clearRange removes a range from sorted and non-overlapping ranges
+func clearRange(ranges []byteRange, rng byteRange) []byteRange {
+	var result []byteRange
+	for _, r := range ranges {
+		if r.end < rng.start || rng.end < r.start {
+			result = append(result, r)
+			continue
+		}
+		if r.start < rng.start {
+			result = append(result, byteRange{start: r.start, end: rng.start - 1})
+		}
+		if rng.end < r.end {
+			result = append(result, byteRange{start: rng.end + 1, end: r.end})
+		}
+	}
+	return result
+}
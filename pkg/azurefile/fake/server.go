@@ -0,0 +1,364 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-process fake of the Azure Storage resource provider (ARM)
+// and the Azure Files data plane REST API, so that the driver can be exercised hermetically.
+//
+// The server listens on a single plain HTTP endpoint:
+//   - requests under /subscriptions/ are served as ARM storage account and file share calls
+//   - all other requests are served as path-style data plane calls, e.g. http://127.0.0.1:port/<account>/<share>
+package fake
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/accountclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/fileshareclient"
+)
+
+const (
+	// SnapshotTimeFormat is the format of x-ms-snapshot values returned by the fake
+	SnapshotTimeFormat = "2006-01-02T15:04:05.0000000Z"
+
+	armPathPrefix = "/subscriptions/"
+	defaultQuota  = 100 // GiB
+)
+
+// Server is an in-process fake Azure Files backend
+type Server struct {
+	sync.Mutex
+	httpServer *httptest.Server
+	// accounts keyed by lower case account name, account names are globally unique in Azure
+	accounts map[string]*account
+	// now is used to generate timestamps, it could be overridden in tests
+	now func() time.Time
+	// interceptor is invoked before each request is served, it could be used to inject faults
+	interceptor func(w http.ResponseWriter, r *http.Request) bool
+}
+
+type account struct {
+	subscriptionID string
+	resourceGroup  string
+	model          armstorage.Account
+	keys           []string
+	shares         map[string]*fileShare
+}
+
+type fileShare struct {
+	name         string
+	properties   armstorage.FileShareProperties
+	lastModified time.Time
+	// snapshots keyed by snapshot time string
+	snapshots map[string]*fileShare
+	// directories and files keyed by path without leading slash
+	directories map[string]*entry
+	files       map[string]*entry
+}
+
+type entry struct {
+	metadata     map[string]*string
+	size         int64
+	lastModified time.Time
+	// ranges which hold data, sorted and non-overlapping, only for files
+	ranges []byteRange
+	// leaseID is the ID of the active infinite lease, only for files
+	leaseID string
+}
+
+type byteRange struct {
+	start, end int64
+}
+
+// NewServer starts a new fake Azure Files backend, caller should call Close when done
+func NewServer() *Server {
+	s := &Server{
+		accounts: make(map[string]*account),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.ServeHTTP))
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// URL returns the base URL of the server, e.g. http://127.0.0.1:12345
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
+
+// ServiceURL returns the path-style data plane service URL of an account, e.g. http://127.0.0.1:12345/<account>
+func (s *Server) ServiceURL(accountName string) string {
+	return fmt.Sprintf("%s/%s", s.httpServer.URL, accountName)
+}
+
+// SetInterceptor sets a function which is called before serving each request,
+// if it returns true, the request is regarded as served and the fake does nothing.
+func (s *Server) SetInterceptor(interceptor func(w http.ResponseWriter, r *http.Request) bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.interceptor = interceptor
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	interceptor := s.interceptor
+	s.Unlock()
+	if interceptor != nil && interceptor(w, r) {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if strings.HasPrefix(strings.ToLower(r.URL.Path), armPathPrefix) {
+		s.serveARM(w, r)
+		return
+	}
+	s.serveDataPlane(w, r)
+}
+
+// ARMClientOptions returns client options which point ARM clients at the fake
+func (s *Server) ARMClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: s.httpServer.URL,
+				Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {
+						Audience: s.httpServer.URL,
+						Endpoint: s.httpServer.URL,
+					},
+				},
+			},
+			InsecureAllowCredentialWithHTTP: true,
+			Retry:                           policy.RetryOptions{MaxRetries: -1},
+		},
+		DisableRPRegistration: true,
+	}
+}
+
+// NewFileShareClient returns a file share client of the subscription which talks to the fake
+func (s *Server) NewFileShareClient(subscriptionID string) (fileshareclient.Interface, error) {
+	return fileshareclient.New(subscriptionID, TokenCredential{}, s.ARMClientOptions())
+}
+
+// NewAccountClient returns a storage account client of the subscription which talks to the fake
+func (s *Server) NewAccountClient(subscriptionID string) (accountclient.Interface, error) {
+	return accountclient.New(subscriptionID, TokenCredential{}, s.ARMClientOptions())
+}
+
+// AddAccount adds a storage account and returns its primary account key
+func (s *Server) AddAccount(subscriptionID, resourceGroup, accountName, location string) string {
+	s.Lock()
+	defer s.Unlock()
+	acct := s.newAccount(subscriptionID, resourceGroup, accountName, armstorage.Account{
+		Location: to.Ptr(location),
+		Kind:     to.Ptr(armstorage.KindStorageV2),
+		SKU:      &armstorage.SKU{Name: to.Ptr(armstorage.SKUNameStandardLRS), Tier: to.Ptr(armstorage.SKUTierStandard)},
+	})
+	return acct.keys[0]
+}
+
+// ShareQuota returns the quota of a file share, -1 means the file share does not exist
+func (s *Server) ShareQuota(accountName, shareName string) int32 {
+	s.Lock()
+	defer s.Unlock()
+	share := s.getShare(accountName, shareName)
+	if share == nil {
+		return -1
+	}
+	return *share.properties.ShareQuota
+}
+
+// Shares returns the sorted names of all file shares in an account
+func (s *Server) Shares(accountName string) []string {
+	s.Lock()
+	defer s.Unlock()
+	var names []string
+	if acct := s.accounts[strings.ToLower(accountName)]; acct != nil {
+		for name := range acct.shares {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshots returns the sorted snapshot times of a file share
+func (s *Server) Snapshots(accountName, shareName string) []string {
+	s.Lock()
+	defer s.Unlock()
+	var snapshots []string
+	if share := s.getShare(accountName, shareName); share != nil {
+		for snapshot := range share.snapshots {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Strings(snapshots)
+	return snapshots
+}
+
+// FileLeaseID returns the ID of the active lease of a file, or empty string if the file is not leased
+func (s *Server) FileLeaseID(accountName, shareName, path string) string {
+	s.Lock()
+	defer s.Unlock()
+	share := s.getShare(accountName, shareName)
+	if share == nil {
+		return ""
+	}
+	if f := share.files[cleanPath(path)]; f != nil {
+		return f.leaseID
+	}
+	return ""
+}
+
+// DirectoryExists returns whether a directory exists in a file share
+func (s *Server) DirectoryExists(accountName, shareName, path string) bool {
+	s.Lock()
+	defer s.Unlock()
+	share := s.getShare(accountName, shareName)
+	if share == nil {
+		return false
+	}
+	_, ok := share.directories[cleanPath(path)]
+	return ok
+}
+
+func (s *Server) newAccount(subscriptionID, resourceGroup, accountName string, model armstorage.Account) *account {
+	model.ID = to.Ptr(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s", subscriptionID, resourceGroup, accountName))
+	model.Name = to.Ptr(accountName)
+	model.Type = to.Ptr("Microsoft.Storage/storageAccounts")
+	if model.Properties == nil {
+		model.Properties = &armstorage.AccountProperties{}
+	}
+	model.Properties.ProvisioningState = to.Ptr(armstorage.ProvisioningStateSucceeded)
+	model.Properties.CreationTime = to.Ptr(s.now())
+	model.Properties.PrimaryEndpoints = &armstorage.Endpoints{
+		File: to.Ptr(fmt.Sprintf("%s/%s/", s.httpServer.URL, accountName)),
+	}
+	acct := &account{
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		model:          model,
+		keys: []string{
+			base64.StdEncoding.EncodeToString([]byte(accountName + "-key1")),
+			base64.StdEncoding.EncodeToString([]byte(accountName + "-key2")),
+		},
+		shares: make(map[string]*fileShare),
+	}
+	s.accounts[strings.ToLower(accountName)] = acct
+	return acct
+}
+
+func (s *Server) getShare(accountName, shareName string) *fileShare {
+	acct := s.accounts[strings.ToLower(accountName)]
+	if acct == nil {
+		return nil
+	}
+	return acct.shares[shareName]
+}
+
+func (s *Server) newShare(name string, properties *armstorage.FileShareProperties) *fileShare {
+	share := &fileShare{
+		name:         name,
+		lastModified: s.now(),
+		snapshots:    make(map[string]*fileShare),
+		directories:  map[string]*entry{"": {lastModified: s.now()}},
+		files:        make(map[string]*entry),
+	}
+	if properties != nil {
+		share.properties = *properties
+	}
+	if share.properties.ShareQuota == nil || *share.properties.ShareQuota <= 0 {
+		share.properties.ShareQuota = to.Ptr(int32(defaultQuota))
+	}
+	return share
+}
+
+// snapshot creates a read only copy of the share
+func (s *Server) snapshot(share *fileShare, metadata map[string]*string) *fileShare {
+	now := s.now()
+	snapshotTime := now.Format(SnapshotTimeFormat)
+	// snapshot time must be unique within a share
+	for _, exists := share.snapshots[snapshotTime]; exists; _, exists = share.snapshots[snapshotTime] {
+		now = now.Add(100 * time.Nanosecond)
+		snapshotTime = now.Format(SnapshotTimeFormat)
+	}
+	snapshot := &fileShare{
+		name:         share.name,
+		properties:   share.properties,
+		lastModified: now,
+		directories:  copyEntries(share.directories),
+		files:        copyEntries(share.files),
+	}
+	snapshot.properties.SnapshotTime = to.Ptr(now)
+	snapshot.properties.Metadata = metadata
+	share.snapshots[snapshotTime] = snapshot
+	return snapshot
+}
+
+// copyEntries deep copies entries, so that later writes to the share do not show up in the snapshot
+func copyEntries(entries map[string]*entry) map[string]*entry {
+	copied := make(map[string]*entry, len(entries))
+	for path, e := range entries {
+		c := *e
+		if e.metadata != nil {
+			c.metadata = make(map[string]*string, len(e.metadata))
+			for k, v := range e.metadata {
+				if v != nil {
+					v = to.Ptr(*v)
+				}
+				c.metadata[k] = v
+			}
+		}
+		c.ranges = append([]byteRange(nil), e.ranges...)
+		copied[path] = &c
+	}
+	return copied
+}
+
+func (share *fileShare) etag() string {
+	return fmt.Sprintf("\"0x%X\"", share.lastModified.UnixNano())
+}
+
+func cleanPath(path string) string {
+	return strings.Trim(path, "/")
+}
+
+// TokenCredential is a fake azcore.TokenCredential which returns a static token
+type TokenCredential struct{}
+
+// GetToken implements azcore.TokenCredential
+func (TokenCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "fake-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
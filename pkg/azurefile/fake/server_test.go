@@ -0,0 +1,342 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	armstorage "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/file"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/lease"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/service"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSubsID  = "00000000-0000-0000-0000-000000000000"
+	testRG      = "rg"
+	testAccount = "fakeaccount"
+)
+
+func newServiceClient(t *testing.T, s *Server, accountName, accountKey string) *service.Client {
+	cred, err := service.NewSharedKeyCredential(accountName, accountKey)
+	require.NoError(t, err)
+	client, err := service.NewClientWithSharedKeyCredential(s.URL()+"/"+accountName, cred, nil)
+	require.NoError(t, err)
+	return client
+}
+
+func statusCode(err error) int {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode
+	}
+	return 0
+}
+
+func TestDataPlaneShare(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	key := s.AddAccount(testSubsID, testRG, testAccount, "eastus")
+	shareClient := newServiceClient(t, s, testAccount, key).NewShareClient("share1")
+
+	_, err := shareClient.GetProperties(ctx, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	_, err = shareClient.Create(ctx, &share.CreateOptions{Quota: to.Ptr(int32(10))})
+	require.NoError(t, err)
+	_, err = shareClient.Create(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	assert.Equal(t, int32(10), s.ShareQuota(testAccount, "share1"))
+
+	_, err = shareClient.SetProperties(ctx, &share.SetPropertiesOptions{Quota: to.Ptr(int32(20))})
+	require.NoError(t, err)
+	props, err := shareClient.GetProperties(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), *props.Quota)
+
+	snapshot, err := shareClient.CreateSnapshot(ctx, &share.CreateSnapshotOptions{Metadata: map[string]*string{"initiator": to.Ptr("snap1")}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{*snapshot.Snapshot}, s.Snapshots(testAccount, "share1"))
+
+	pager := newServiceClient(t, s, testAccount, key).NewListSharesPager(&service.ListSharesOptions{Include: service.ListSharesInclude{Metadata: true, Snapshots: true}})
+	var found bool
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		require.NoError(t, err)
+		for _, item := range resp.Shares {
+			if item.Snapshot != nil && *item.Snapshot == *snapshot.Snapshot {
+				found = true
+				assert.Equal(t, "snap1", *item.Metadata["initiator"])
+				assert.Equal(t, int32(20), *item.Properties.Quota)
+			}
+		}
+	}
+	assert.True(t, found)
+
+	_, err = shareClient.Delete(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	snapshotClient, err := shareClient.WithSnapshot(*snapshot.Snapshot)
+	require.NoError(t, err)
+	_, err = snapshotClient.Delete(ctx, nil)
+	require.NoError(t, err)
+	_, err = shareClient.Delete(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, s.Shares(testAccount))
+}
+
+func TestDataPlaneDirectoryAndFile(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	key := s.AddAccount(testSubsID, testRG, testAccount, "eastus")
+	shareClient := newServiceClient(t, s, testAccount, key).NewShareClient("share1")
+	_, err := shareClient.Create(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = shareClient.NewDirectoryClient("a/b").Create(ctx, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+	_, err = shareClient.NewDirectoryClient("a").Create(ctx, nil)
+	require.NoError(t, err)
+	_, err = shareClient.NewDirectoryClient("a/b").Create(ctx, nil)
+	require.NoError(t, err)
+	_, err = shareClient.NewDirectoryClient("a/b").GetProperties(ctx, nil)
+	require.NoError(t, err)
+	assert.True(t, s.DirectoryExists(testAccount, "share1", "a/b"))
+
+	fileClient := shareClient.NewDirectoryClient("a").NewFileClient("disk.vhd")
+	_, err = fileClient.Create(ctx, 1024, nil)
+	require.NoError(t, err)
+	_, err = fileClient.ClearRange(ctx, file.HTTPRange{Offset: 0, Count: 512}, nil)
+	require.NoError(t, err)
+	props, err := fileClient.GetProperties(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), *props.ContentLength)
+
+	_, err = shareClient.NewDirectoryClient("a").Delete(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+
+	pager := shareClient.NewDirectoryClient("a").NewListFilesAndDirectoriesPager(nil)
+	require.True(t, pager.More())
+	page, err := pager.NextPage(ctx)
+	require.NoError(t, err)
+	require.Len(t, page.Segment.Files, 1)
+	assert.Equal(t, "disk.vhd", *page.Segment.Files[0].Name)
+	require.Len(t, page.Segment.Directories, 1)
+	assert.Equal(t, "b", *page.Segment.Directories[0].Name)
+	assert.False(t, pager.More())
+
+	_, err = shareClient.NewDirectoryClient("a").Rename(ctx, "c", nil)
+	require.NoError(t, err)
+	assert.False(t, s.DirectoryExists(testAccount, "share1", "a"))
+	assert.True(t, s.DirectoryExists(testAccount, "share1", "c/b"))
+	_, err = shareClient.NewDirectoryClient("c").NewFileClient("disk.vhd").GetProperties(ctx, nil)
+	require.NoError(t, err)
+	_, err = shareClient.NewDirectoryClient("a").Rename(ctx, "d", nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	key := s.AddAccount(testSubsID, testRG, testAccount, "eastus")
+	shareClient := newServiceClient(t, s, testAccount, key).NewShareClient("share1")
+	_, err := shareClient.Create(ctx, nil)
+	require.NoError(t, err)
+	fileClient := shareClient.NewRootDirectoryClient().NewFileClient("disk.vhd")
+	_, err = fileClient.Create(ctx, 1024, &file.CreateOptions{Metadata: map[string]*string{"key": to.Ptr("old")}})
+	require.NoError(t, err)
+
+	snapshot, err := shareClient.CreateSnapshot(ctx, nil)
+	require.NoError(t, err)
+
+	// writes to the share after the snapshot do not show up in the snapshot
+	_, err = fileClient.UploadRange(ctx, 0, streaming.NopCloser(strings.NewReader(strings.Repeat("a", 512))), nil)
+	require.NoError(t, err)
+	_, err = fileClient.SetMetadata(ctx, &file.SetMetadataOptions{Metadata: map[string]*string{"key": to.Ptr("new")}})
+	require.NoError(t, err)
+	_, err = shareClient.NewDirectoryClient("dir").Create(ctx, nil)
+	require.NoError(t, err)
+
+	snapshotClient, err := shareClient.WithSnapshot(*snapshot.Snapshot)
+	require.NoError(t, err)
+	snapshotFileClient := snapshotClient.NewRootDirectoryClient().NewFileClient("disk.vhd")
+	props, err := snapshotFileClient.GetProperties(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "old", *props.Metadata["Key"])
+	ranges, err := snapshotFileClient.GetRangeList(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, ranges.Ranges)
+	_, err = snapshotClient.NewDirectoryClient("dir").GetProperties(ctx, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	ranges, err = fileClient.GetRangeList(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, ranges.Ranges, 1)
+}
+
+func TestFileLease(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	key := s.AddAccount(testSubsID, testRG, testAccount, "eastus")
+	shareClient := newServiceClient(t, s, testAccount, key).NewShareClient("share1")
+	_, err := shareClient.Create(ctx, nil)
+	require.NoError(t, err)
+	fileClient := shareClient.NewRootDirectoryClient().NewFileClient("disk.vhd.lock")
+	_, err = fileClient.Create(ctx, 0, nil)
+	require.NoError(t, err)
+
+	leaseID1, leaseID2 := "11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"
+	lease1, err := lease.NewFileClient(fileClient, &lease.FileClientOptions{LeaseID: to.Ptr(leaseID1)})
+	require.NoError(t, err)
+	lease2, err := lease.NewFileClient(fileClient, &lease.FileClientOptions{LeaseID: to.Ptr(leaseID2)})
+	require.NoError(t, err)
+
+	_, err = lease2.Release(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	_, err = lease1.Acquire(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, leaseID1, s.FileLeaseID(testAccount, "share1", "disk.vhd.lock"))
+	props, err := fileClient.GetProperties(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, lease.StateTypeLeased, *props.LeaseState)
+	_, err = lease2.Acquire(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	_, err = lease2.Change(ctx, leaseID2, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	_, err = lease1.Change(ctx, leaseID1, nil)
+	require.NoError(t, err)
+
+	// writes to a leased file must carry the lease ID
+	_, err = fileClient.SetMetadata(ctx, &file.SetMetadataOptions{Metadata: map[string]*string{"holder": to.Ptr("node1")}})
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode(err))
+	_, err = fileClient.SetMetadata(ctx, &file.SetMetadataOptions{
+		Metadata:              map[string]*string{"holder": to.Ptr("node1")},
+		LeaseAccessConditions: &file.LeaseAccessConditions{LeaseID: to.Ptr(leaseID1)},
+	})
+	require.NoError(t, err)
+	_, err = fileClient.Delete(ctx, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode(err))
+
+	_, err = lease2.Break(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, s.FileLeaseID(testAccount, "share1", "disk.vhd.lock"))
+	_, err = lease2.Acquire(ctx, nil)
+	require.NoError(t, err)
+	_, err = lease1.Release(ctx, nil)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+	_, err = lease2.Release(ctx, nil)
+	require.NoError(t, err)
+	_, err = fileClient.Delete(ctx, nil)
+	require.NoError(t, err)
+}
+
+func TestARMFileShare(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	s.AddAccount(testSubsID, testRG, testAccount, "eastus")
+	client, err := s.NewFileShareClient(testSubsID)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, testRG, testAccount, "share1", nil)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	_, err = client.Create(ctx, testRG, testAccount, "share1", armstorage.FileShare{
+		FileShareProperties: &armstorage.FileShareProperties{ShareQuota: to.Ptr(int32(5))},
+	}, nil)
+	require.NoError(t, err)
+
+	fileShare, err := client.Get(ctx, testRG, testAccount, "share1", nil)
+	require.NoError(t, err)
+	fileShare.FileShareProperties.ShareQuota = to.Ptr(int32(50))
+	_, err = client.Update(ctx, testRG, testAccount, "share1", *fileShare)
+	require.NoError(t, err)
+	assert.Equal(t, int32(50), s.ShareQuota(testAccount, "share1"))
+
+	snapshot, err := client.Create(ctx, testRG, testAccount, "share1", armstorage.FileShare{
+		FileShareProperties: &armstorage.FileShareProperties{Metadata: map[string]*string{"initiator": to.Ptr("snap1")}},
+	}, to.Ptr("snapshots"))
+	require.NoError(t, err)
+	require.NotNil(t, snapshot.FileShareProperties.SnapshotTime)
+
+	shares, err := client.List(ctx, testRG, testAccount, &armstorage.FileSharesClientListOptions{Expand: to.Ptr("snapshots")})
+	require.NoError(t, err)
+	assert.Len(t, shares, 2)
+
+	err = client.Delete(ctx, testRG, testAccount, "share1", &armstorage.FileSharesClientDeleteOptions{
+		XMSSnapshot: to.Ptr(snapshot.FileShareProperties.SnapshotTime.Format(SnapshotTimeFormat)),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Delete(ctx, testRG, testAccount, "share1", nil))
+	assert.Equal(t, int32(-1), s.ShareQuota(testAccount, "share1"))
+}
+
+func TestARMAccount(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	client, err := s.NewAccountClient(testSubsID)
+	require.NoError(t, err)
+
+	_, err = client.Create(ctx, testRG, testAccount, &armstorage.AccountCreateParameters{
+		Location: to.Ptr("eastus"),
+		Kind:     to.Ptr(armstorage.KindFileStorage),
+		SKU:      &armstorage.SKU{Name: to.Ptr(armstorage.SKUNamePremiumLRS)},
+		Tags:     map[string]*string{"k8s-azure-created-by": to.Ptr("azure")},
+		Properties: &armstorage.AccountPropertiesCreateParameters{
+			EnableHTTPSTrafficOnly: to.Ptr(true),
+		},
+	})
+	require.NoError(t, err)
+
+	accounts, err := client.List(ctx, testRG)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, armstorage.KindFileStorage, *accounts[0].Kind)
+	assert.True(t, *accounts[0].Properties.EnableHTTPSTrafficOnly)
+
+	keys, err := client.ListKeys(ctx, testRG, testAccount)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// the key from ARM could be used to access the data plane
+	_, err = newServiceClient(t, s, testAccount, *keys[0].Value).NewShareClient("share1").Create(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"share1"}, s.Shares(testAccount))
+}
+
+func TestRanges(t *testing.T) {
+	ranges := addRange(nil, byteRange{0, 9})
+	ranges = addRange(ranges, byteRange{20, 29})
+	ranges = addRange(ranges, byteRange{10, 12})
+	assert.Equal(t, []byteRange{{0, 12}, {20, 29}}, ranges)
+	ranges = clearRange(ranges, byteRange{5, 24})
+	assert.Equal(t, []byteRange{{0, 4}, {25, 29}}, ranges)
+}
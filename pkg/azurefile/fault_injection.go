@@ -0,0 +1,351 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/service"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+)
+
+// supported fault types in fault injection scenario
+const (
+	faultTooManyRequests      = "TooManyRequests"
+	faultNotFound             = "NotFound"
+	faultShareBeingDeleted    = "ShareBeingDeleted"
+	faultAccountLimitExceeded = "AccountLimitExceeded"
+)
+
+// faultInjection is the fault injector loaded from the fault injection scenario file,
+// nil means fault injection is disabled, it's only set in test builds or unit tests.
+var faultInjection *faultInjector
+
+// FaultScenario defines the faults which are injected into Azure calls, e.g.
+//
+//	faults:
+//	- operation: CreateFileShare
+//	  fault: TooManyRequests
+//	  retryAfterSeconds: 5
+//	  count: 2
+//	- method: PUT
+//	  path: "/pvc-.*"
+//	  latency: 3s
+type FaultScenario struct {
+	Faults []FaultRule `json:"faults"`
+}
+
+// FaultRule defines one fault, a rule matches either azureFileClient calls (by operation)
+// or data plane HTTP requests of share/service/file clients (by method and path).
+type FaultRule struct {
+	// Operation is the azureFileClient method name, e.g. CreateFileShare, DeleteFileShare, GetFileShareQuota, ResizeFileShare
+	Operation string `json:"operation,omitempty"`
+	// Method is the HTTP method of data plane requests, empty matches all methods
+	Method string `json:"method,omitempty"`
+	// Path is a regular expression matched against the URL path of data plane requests
+	Path string `json:"path,omitempty"`
+	// Fault is the error returned, supported values: TooManyRequests, NotFound, ShareBeingDeleted, AccountLimitExceeded,
+	// empty means only latency is injected
+	Fault string `json:"fault,omitempty"`
+	// RetryAfterSeconds is returned with TooManyRequests fault
+	RetryAfterSeconds int `json:"retryAfterSeconds,omitempty"`
+	// Latency is added before the call is served or fails
+	Latency metav1.Duration `json:"latency,omitempty"`
+	// Count is the number of times the fault is injected, 0 means unlimited
+	Count int `json:"count,omitempty"`
+
+	pathRegexp *regexp.Regexp
+	// fired is the number of times the fault has been injected
+	fired int
+}
+
+type faultInjector struct {
+	sync.Mutex
+	rules []*FaultRule
+}
+
+// newFaultInjector validates the scenario and returns a fault injector
+func newFaultInjector(scenario *FaultScenario) (*faultInjector, error) {
+	injector := &faultInjector{}
+	for i := range scenario.Faults {
+		rule := scenario.Faults[i]
+		if rule.Operation != "" && (rule.Method != "" || rule.Path != "") {
+			return nil, fmt.Errorf("fault rule %d: operation could not be specified together with method or path", i)
+		}
+		if rule.Operation == "" && rule.Method == "" && rule.Path == "" {
+			return nil, fmt.Errorf("fault rule %d: one of operation, method or path must be specified", i)
+		}
+		if rule.Fault != "" {
+			if _, _, _, err := faultResponse(rule.Fault); err != nil {
+				return nil, fmt.Errorf("fault rule %d: %v", i, err)
+			}
+		}
+		if rule.Path != "" {
+			re, err := regexp.Compile(rule.Path)
+			if err != nil {
+				return nil, fmt.Errorf("fault rule %d: invalid path %q: %v", i, rule.Path, err)
+			}
+			rule.pathRegexp = re
+		}
+		injector.rules = append(injector.rules, &rule)
+	}
+	return injector, nil
+}
+
+// loadFaultInjector reads the fault injection scenario from a yaml or json file
+func loadFaultInjector(path string) (*faultInjector, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fault injection scenario file(%s): %v", path, err)
+	}
+	scenario := &FaultScenario{}
+	if err := yaml.UnmarshalStrict(content, scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse fault injection scenario file(%s): %v", path, err)
+	}
+	return newFaultInjector(scenario)
+}
+
+// EnableFaultInjection injects the faults defined in the scenario file into Azure calls,
+// it's only used in test builds of azurefileplugin and must not be used in production.
+func EnableFaultInjection(ctx context.Context, scenarioFile string) error {
+	injector, err := loadFaultInjector(scenarioFile)
+	if err != nil {
+		return err
+	}
+	faultInjection = injector
+	klog.FromContext(ctx).Info("fault injection is enabled, it must not be used in production", "scenarioFile", scenarioFile)
+	return nil
+}
+
+// match returns the first rule which matches the operation or the request and still has count left
+func (f *faultInjector) match(operation string, req *http.Request) *FaultRule {
+	f.Lock()
+	defer f.Unlock()
+	for _, rule := range f.rules {
+		if rule.Count > 0 && rule.fired >= rule.Count {
+			continue
+		}
+		if operation != "" {
+			if !strings.EqualFold(rule.Operation, operation) {
+				continue
+			}
+		} else {
+			if rule.Operation != "" {
+				continue
+			}
+			if rule.Method != "" && !strings.EqualFold(rule.Method, req.Method) {
+				continue
+			}
+			if rule.pathRegexp != nil && !rule.pathRegexp.MatchString(req.URL.Path) {
+				continue
+			}
+		}
+		rule.fired++
+		// return a copy since the rule could be modified concurrently
+		matched := *rule
+		return &matched
+	}
+	return nil
+}
+
+// wrap returns an azureFileClient which injects faults before calling the client,
+// the client is returned as is if fault injection is disabled.
+func (f *faultInjector) wrap(client azureFileClient) azureFileClient {
+	if f == nil {
+		return client
+	}
+	return &faultInjectionClient{azureFileClient: client, injector: f}
+}
+
+// policies returns the per retry policies which inject faults into data plane HTTP requests
+func (f *faultInjector) policies() []policy.Policy {
+	if f == nil {
+		return nil
+	}
+	return []policy.Policy{&faultInjectionPolicy{injector: f}}
+}
+
+// inject sleeps for the latency of the rule and returns the fault response, nil means no fault
+func (rule *FaultRule) inject(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if rule.Latency.Duration > 0 {
+		klog.FromContext(ctx).V(2).Info("fault injection: add latency", "latency", rule.Latency.Duration, "operation", rule.Operation, "path", rule.Path)
+		select {
+		case <-time.After(rule.Latency.Duration):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if rule.Fault == "" {
+		return nil, nil
+	}
+	statusCode, errorCode, message, err := faultResponse(rule.Fault)
+	if err != nil {
+		return nil, err
+	}
+	klog.FromContext(ctx).V(2).Info("fault injection: return error", "errorCode", errorCode, "statusCode", statusCode, "operation", rule.Operation, "path", rule.Path)
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, errorCode, message)
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	resp.Header.Set("Content-Type", "application/xml")
+	resp.Header.Set("x-ms-error-code", errorCode)
+	if rule.Fault == faultTooManyRequests && rule.RetryAfterSeconds > 0 {
+		resp.Header.Set("Retry-After", strconv.Itoa(rule.RetryAfterSeconds))
+	}
+	return resp, nil
+}
+
+// faultResponse returns the status code, error code and message which Azure returns for the fault
+func faultResponse(fault string) (int, string, string, error) {
+	switch fault {
+	case faultTooManyRequests:
+		return http.StatusTooManyRequests, azerrors.CodeTooManyRequests, "The request is being throttled.", nil
+	case faultNotFound:
+		return http.StatusNotFound, azerrors.CodeShareNotFound, "The specified share does not exist.", nil
+	case faultShareBeingDeleted:
+		return http.StatusConflict, azerrors.CodeShareBeingDeleted, "The specified share is being deleted. Try operation later.", nil
+	case faultAccountLimitExceeded:
+		return http.StatusBadRequest, accountLimitExceedManagementAPI, "The total provisioned capacity of shares exceeds the account limit.", nil
+	default:
+		return 0, "", "", fmt.Errorf("unsupported fault %q, supported values: %s, %s, %s, %s",
+			fault, faultTooManyRequests, faultNotFound, faultShareBeingDeleted, faultAccountLimitExceeded)
+	}
+}
+
+// faultInjectionError is returned by faultInjectionClient, the message follows the format of
+// the retry errors returned by cloud provider so that message based error handling could also be exercised.
+type faultInjectionError struct {
+	statusCode        int
+	retryAfterSeconds int
+	err               error
+}
+
+func (e *faultInjectionError) Error() string {
+	return fmt.Sprintf("Retriable: %v, RetryAfter: %ds, HTTPStatusCode: %d, RawError: %v",
+		e.statusCode == http.StatusTooManyRequests, e.retryAfterSeconds, e.statusCode, e.err)
+}
+
+// Unwrap returns the underlying *azcore.ResponseError
+func (e *faultInjectionError) Unwrap() error {
+	return e.err
+}
+
+type faultInjectionClient struct {
+	azureFileClient
+	injector *faultInjector
+}
+
+func (c *faultInjectionClient) injectFault(ctx context.Context, operation string) error {
+	rule := c.injector.match(operation, nil)
+	if rule == nil {
+		return nil
+	}
+	// azcore.ResponseError requires the request of the response
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "faultinjection:///"+operation, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := rule.inject(ctx, req)
+	if err != nil || resp == nil {
+		return err
+	}
+	return &faultInjectionError{
+		statusCode:        resp.StatusCode,
+		retryAfterSeconds: rule.RetryAfterSeconds,
+		err:               runtime.NewResponseError(resp),
+	}
+}
+
+func (c *faultInjectionClient) CreateFileShare(ctx context.Context, shareOptions *ShareOptions) error {
+	if err := c.injectFault(ctx, "CreateFileShare"); err != nil {
+		return err
+	}
+	return c.azureFileClient.CreateFileShare(ctx, shareOptions)
+}
+
+func (c *faultInjectionClient) DeleteFileShare(ctx context.Context, name string) error {
+	if err := c.injectFault(ctx, "DeleteFileShare"); err != nil {
+		return err
+	}
+	return c.azureFileClient.DeleteFileShare(ctx, name)
+}
+
+func (c *faultInjectionClient) GetFileShareQuota(ctx context.Context, name string) (int, error) {
+	if err := c.injectFault(ctx, "GetFileShareQuota"); err != nil {
+		return -1, err
+	}
+	return c.azureFileClient.GetFileShareQuota(ctx, name)
+}
+
+func (c *faultInjectionClient) ResizeFileShare(ctx context.Context, name string, sizeGiB int) error {
+	if err := c.injectFault(ctx, "ResizeFileShare"); err != nil {
+		return err
+	}
+	return c.azureFileClient.ResizeFileShare(ctx, name, sizeGiB)
+}
+
+type faultInjectionPolicy struct {
+	injector *faultInjector
+}
+
+// Do implements policy.Policy
+func (p *faultInjectionPolicy) Do(req *policy.Request) (*http.Response, error) {
+	rule := p.injector.match("", req.Raw())
+	if rule == nil {
+		return req.Next()
+	}
+	resp, err := rule.inject(req.Raw().Context(), req.Raw())
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return req.Next()
+	}
+	return resp, nil
+}
+
+// getDataplaneServiceClient returns the service client of a data plane azureFileClient
+func getDataplaneServiceClient(client azureFileClient) *service.Client {
+	if c, ok := client.(*faultInjectionClient); ok {
+		client = c.azureFileClient
+	}
+	if c, ok := client.(*azureFileDataplaneClient); ok {
+		return c.Client
+	}
+	return nil
+}
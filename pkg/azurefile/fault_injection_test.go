@@ -0,0 +1,226 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
+)
+
+// setFaultInjection enables fault injection with the scenario until the test finishes
+func setFaultInjection(t *testing.T, scenario *FaultScenario) {
+	injector, err := newFaultInjector(scenario)
+	require.NoError(t, err)
+	faultInjection = injector
+	t.Cleanup(func() { faultInjection = nil })
+}
+
+func TestNewFaultInjector(t *testing.T) {
+	tests := []struct {
+		desc        string
+		rule        FaultRule
+		expectedErr string
+	}{
+		{
+			desc: "operation rule",
+			rule: FaultRule{Operation: "CreateFileShare", Fault: faultTooManyRequests, RetryAfterSeconds: 5},
+		},
+		{
+			desc: "http rule with latency only",
+			rule: FaultRule{Method: http.MethodPut, Path: "/pvc-.*", Latency: metav1.Duration{Duration: time.Second}},
+		},
+		{
+			desc:        "operation together with path",
+			rule:        FaultRule{Operation: "CreateFileShare", Path: "/pvc-.*"},
+			expectedErr: "operation could not be specified together with method or path",
+		},
+		{
+			desc:        "no matcher",
+			rule:        FaultRule{Fault: faultNotFound},
+			expectedErr: "one of operation, method or path must be specified",
+		},
+		{
+			desc:        "unsupported fault",
+			rule:        FaultRule{Operation: "CreateFileShare", Fault: "InternalError"},
+			expectedErr: "unsupported fault",
+		},
+		{
+			desc:        "invalid path",
+			rule:        FaultRule{Path: "["},
+			expectedErr: "invalid path",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := newFaultInjector(&FaultScenario{Faults: []FaultRule{test.rule}})
+		if test.expectedErr == "" {
+			assert.NoError(t, err, test.desc)
+		} else {
+			assert.ErrorContains(t, err, test.expectedErr, test.desc)
+		}
+	}
+}
+
+func TestLoadFaultInjector(t *testing.T) {
+	scenarioFile := filepath.Join(t.TempDir(), "scenario.yaml")
+	content := `faults:
+- operation: CreateFileShare
+  fault: TooManyRequests
+  retryAfterSeconds: 5
+  count: 2
+- method: PUT
+  path: "/pvc-.*"
+  latency: 3s
+`
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(content), 0600))
+	injector, err := loadFaultInjector(scenarioFile)
+	require.NoError(t, err)
+	require.Len(t, injector.rules, 2)
+	assert.Equal(t, 5, injector.rules[0].RetryAfterSeconds)
+	assert.Equal(t, 3*time.Second, injector.rules[1].Latency.Duration)
+
+	require.NoError(t, os.WriteFile(scenarioFile, []byte("faults:\n- operation: CreateFileShare\n  unknown: true\n"), 0600))
+	_, err = loadFaultInjector(scenarioFile)
+	assert.ErrorContains(t, err, "failed to parse fault injection scenario file")
+
+	_, err = loadFaultInjector(filepath.Join(t.TempDir(), "notexist.yaml"))
+	assert.ErrorContains(t, err, "failed to read fault injection scenario file")
+}
+
+func TestEnableFaultInjection(t *testing.T) {
+	t.Cleanup(func() { faultInjection = nil })
+	scenarioFile := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte("faults:\n- operation: ResizeFileShare\n  fault: AccountLimitExceeded\n"), 0600))
+
+	assert.Error(t, EnableFaultInjection(context.Background(), filepath.Join(t.TempDir(), "notexist.yaml")))
+	assert.Nil(t, faultInjection)
+	require.NoError(t, EnableFaultInjection(context.Background(), scenarioFile))
+	require.NotNil(t, faultInjection)
+	_, ok := faultInjection.wrap(&azureFileDataplaneClient{}).(*faultInjectionClient)
+	assert.True(t, ok)
+}
+
+func TestFaultInjectionMatch(t *testing.T) {
+	injector, err := newFaultInjector(&FaultScenario{Faults: []FaultRule{
+		{Operation: "DeleteFileShare", Fault: faultShareBeingDeleted, Count: 1},
+		{Method: http.MethodDelete, Path: "^/account/share$", Fault: faultNotFound},
+	}})
+	require.NoError(t, err)
+
+	assert.Nil(t, injector.match("CreateFileShare", nil))
+	rule := injector.match("DeleteFileShare", nil)
+	require.NotNil(t, rule)
+	assert.Equal(t, faultShareBeingDeleted, rule.Fault)
+	// count is exhausted
+	assert.Nil(t, injector.match("DeleteFileShare", nil))
+
+	req, err := http.NewRequest(http.MethodDelete, "http://127.0.0.1/account/share?restype=share", nil)
+	require.NoError(t, err)
+	assert.NotNil(t, injector.match("", req))
+	req, err = http.NewRequest(http.MethodGet, "http://127.0.0.1/account/share?restype=share", nil)
+	require.NoError(t, err)
+	assert.Nil(t, injector.match("", req))
+}
+
+func TestFaultInjectionErrors(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+
+	setFaultInjection(t, &FaultScenario{Faults: []FaultRule{
+		{Operation: "CreateFileShare", Fault: faultTooManyRequests, RetryAfterSeconds: 30},
+		{Operation: "DeleteFileShare", Fault: faultShareBeingDeleted},
+		{Operation: "ResizeFileShare", Fault: faultAccountLimitExceeded},
+		{Method: http.MethodGet, Path: "/testaccount/missing", Fault: faultNotFound},
+	}})
+	fileClient, err := newAzureFileClient("testaccount", accountKey, fakeStorageEndpointSuffix)
+	require.NoError(t, err)
+	assert.NotNil(t, getDataplaneServiceClient(fileClient))
+
+	err = fileClient.CreateFileShare(ctx, &ShareOptions{Name: "share", RequestGiB: 10})
+	assert.True(t, isRetriableError(err))
+	assert.True(t, isThrottlingError(err))
+	assert.Equal(t, 30, getRetryAfterSeconds(err))
+	var respErr *azcore.ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusTooManyRequests, respErr.StatusCode)
+	assert.Empty(t, server.Shares("testaccount"))
+
+	err = fileClient.DeleteFileShare(ctx, "share")
+	assert.True(t, isRetriableError(err))
+	assert.False(t, isThrottlingError(err))
+
+	err = fileClient.ResizeFileShare(ctx, "share", 20)
+	assert.ErrorContains(t, err, accountLimitExceedManagementAPI)
+
+	// data plane requests are failed by the policy before reaching the fake
+	_, err = getDataplaneServiceClient(fileClient).NewShareClient("missing").GetProperties(ctx, nil)
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusNotFound, respErr.StatusCode)
+	assert.Equal(t, azerrors.CodeShareNotFound, respErr.ErrorCode)
+}
+
+func TestFaultInjectionRetryPaths(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}
+	d.cloud.Config.CloudProviderBackoffRetries = 3
+	secrets := createStorageAccountSecret("testaccount", accountKey)
+
+	setFaultInjection(t, &FaultScenario{Faults: []FaultRule{
+		{Operation: "CreateFileShare", Fault: faultTooManyRequests, RetryAfterSeconds: 1, Count: 1},
+		{Operation: "DeleteFileShare", Fault: faultShareBeingDeleted, Count: 2},
+		{Method: http.MethodPut, Path: "/testaccount/testshare/folder", Latency: metav1.Duration{Duration: 100 * time.Millisecond}},
+	}})
+
+	start := time.Now()
+	err := d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "testshare", RequestGiB: 10}, secrets, "")
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "should sleep for Retry-After when throttled")
+	assert.Equal(t, []string{"testshare"}, server.Shares("testaccount"))
+
+	start = time.Now()
+	err = d.createFolderIfNotExists(ctx, "testaccount", accountKey, "testshare", "folder", fakeStorageEndpointSuffix)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.True(t, server.DirectoryExists("testaccount", "testshare", "folder"))
+
+	err = d.DeleteFileShare(ctx, "subsID", "rg", "testaccount", "testshare", secrets, "")
+	assert.NoError(t, err)
+	assert.Empty(t, server.Shares("testaccount"))
+}
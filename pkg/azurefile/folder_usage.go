@@ -0,0 +1,510 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+// actions taken when the usage of a folder-based volume exceeds its quota,
+// each action includes the previous ones
+const (
+	folderQuotaExceededActionMetric   = "metric"
+	folderQuotaExceededActionEvent    = "event"
+	folderQuotaExceededActionReadOnly = "readonly"
+
+	folderQuotaExceededReason = "FolderQuotaExceeded"
+	// number of directory entries read from the file share at once
+	folderScanReadDirBatch = 256
+	// folderScanTimeout is the time waiting for the scan step of one volume
+	folderScanTimeout = time.Minute
+	// folderQuotaFileName is the file saving the quota of a staged folder-based volume next to its staging path,
+	// so that the usage is tracked again after the driver restarts
+	folderQuotaFileName = "azurefile-folder-quota.json"
+)
+
+var supportedFolderQuotaExceededActionList = []string{folderQuotaExceededActionMetric, folderQuotaExceededActionEvent, folderQuotaExceededActionReadOnly}
+
+func isSupportedFolderQuotaExceededAction(action string) bool {
+	for _, v := range supportedFolderQuotaExceededActionList {
+		if action == v {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFolderQuota parses the folderQuota volume attribute, e.g. 10Gi, 0 is returned if the quota is not set
+func parseFolderQuota(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s: %v", folderQuotaField, value, err)
+	}
+	if quantity.Sign() < 0 {
+		return 0, fmt.Errorf("invalid %s %s: must not be negative", folderQuotaField, value)
+	}
+	return quantity.Value(), nil
+}
+
+// folderScan walks a folder breadth first in bounded steps and sums up the size of the regular files,
+// the directory being read is kept open between steps so that reading resumes where the last step stopped,
+// it's closed once the scan is stopped so that the folder can be unmounted
+type folderScan struct {
+	sync.Mutex
+	// dirs are the directories to be read, dir is the open handle of the first one
+	dirs      []string
+	dir       *os.File
+	usedBytes int64
+	stopped   atomic.Bool
+}
+
+func newFolderScan(path string) *folderScan {
+	return &folderScan{dirs: []string{path}}
+}
+
+// stop closes the open directory, the scan could not be resumed after it's stopped,
+// the directory is closed by the step in progress if there is one
+func (s *folderScan) stop() {
+	s.stopped.Store(true)
+	if !s.TryLock() {
+		return
+	}
+	defer s.Unlock()
+	s.closeDir()
+}
+
+func (s *folderScan) closeDir() {
+	if s.dir != nil {
+		s.dir.Close()
+		s.dir = nil
+	}
+}
+
+// step reads at most maxEntries directory entries, 0 means no limit, it returns true if the scan is complete
+func (s *folderScan) step(maxEntries int) (bool, error) {
+	s.Lock()
+	defer func() {
+		s.Unlock()
+		if s.stopped.Load() {
+			s.stop()
+		}
+	}()
+	if s.stopped.Load() {
+		return false, errors.New("folder scan is stopped")
+	}
+
+	processed := 0
+	for len(s.dirs) > 0 {
+		if maxEntries > 0 && processed >= maxEntries {
+			return false, nil
+		}
+		budget := 0
+		if maxEntries > 0 {
+			budget = maxEntries - processed
+		}
+		n, finished, err := s.readDir(budget)
+		processed += n
+		if err != nil {
+			s.closeDir()
+			return false, err
+		}
+		if finished {
+			s.closeDir()
+			s.dirs = s.dirs[1:]
+		}
+	}
+	return true, nil
+}
+
+// readDir reads at most budget entries of the first directory, 0 means no limit, it returns the number of entries read
+// and true if the end of the directory is reached, a directory removed during the scan is regarded as read
+func (s *folderScan) readDir(budget int) (int, bool, error) {
+	dir := s.dirs[0]
+	if s.dir == nil {
+		f, err := os.Open(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return 0, true, nil
+			}
+			return 0, false, err
+		}
+		s.dir = f
+	}
+
+	processed := 0
+	for budget <= 0 || processed < budget {
+		batch := folderScanReadDirBatch
+		if budget > 0 {
+			batch = min(batch, budget-processed)
+		}
+		entries, err := s.dir.ReadDir(batch)
+		for _, entry := range entries {
+			// symbolic links are not followed
+			switch {
+			case entry.IsDir():
+				s.dirs = append(s.dirs, filepath.Join(dir, entry.Name()))
+			case entry.Type().IsRegular():
+				if info, err := entry.Info(); err == nil {
+					s.usedBytes += info.Size()
+				}
+			}
+		}
+		processed += len(entries)
+		if errors.Is(err, io.EOF) {
+			return processed, true, nil
+		}
+		if err != nil {
+			return processed, false, err
+		}
+	}
+	return processed, false, nil
+}
+
+type trackedFolder struct {
+	path         string
+	quotaBytes   int64
+	pvcName      string
+	pvcNamespace string
+	usedBytes    int64
+	// scannedAt is zero until the first scan of the folder is complete
+	scannedAt time.Time
+	exceeded  bool
+	// scan is the scan in progress, scanning is true while a step is running,
+	// a step hanging on a dead mount is never started again
+	scan     *folderScan
+	scanning bool
+}
+
+// folderUsageTracker computes the usage of folder-based volumes staged on the node with an incremental scan
+// and takes an action when the usage exceeds the soft quota of the volume
+type folderUsageTracker struct {
+	sync.Mutex
+	// maxEntriesPerScan is the maximum number of directory entries read per volume in each round, 0 means no limit
+	maxEntriesPerScan int
+	// scanTimeout bounds the time waiting for the scan step of one volume in each round
+	scanTimeout time.Duration
+	action      string
+	// volumes are indexed by volume ID
+	volumes map[string]*trackedFolder
+	// recordEvent is nil if events can not be recorded, e.g. no kubeClient
+	recordEvent func(pvcNamespace, pvcName, message string)
+	// remountReadOnly is replaced in unit tests
+	remountReadOnly func(volumeID, path string) error
+}
+
+func newFolderUsageTracker(maxEntriesPerScan int, action string) *folderUsageTracker {
+	return &folderUsageTracker{
+		maxEntriesPerScan: maxEntriesPerScan,
+		scanTimeout:       folderScanTimeout,
+		action:            action,
+		volumes:           map[string]*trackedFolder{},
+	}
+}
+
+// add starts tracking the usage of the volume, the last usage is kept if the path and the quota are not changed
+func (t *folderUsageTracker) add(volumeID, path string, quotaBytes int64, pvcName, pvcNamespace string) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	v, ok := t.volumes[volumeID]
+	if ok && v.path == path && v.quotaBytes == quotaBytes {
+		return
+	}
+	if ok && v.scan != nil {
+		v.scan.stop()
+	}
+	t.volumes[volumeID] = &trackedFolder{path: path, quotaBytes: quotaBytes, pvcName: pvcName, pvcNamespace: pvcNamespace}
+}
+
+// remove stops tracking the usage of the volume and closes the directory opened by its scan
+func (t *folderUsageTracker) remove(volumeID string) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	if v, ok := t.volumes[volumeID]; ok && v.scan != nil {
+		v.scan.stop()
+	}
+	delete(t.volumes, volumeID)
+}
+
+// isTracked returns true if the usage of the volume is tracked
+func (t *folderUsageTracker) isTracked(volumeID string) bool {
+	t.Lock()
+	defer t.Unlock()
+	_, ok := t.volumes[volumeID]
+	return ok
+}
+
+// usage returns the last scanned usage and the quota of the volume, false if the volume is not tracked or not scanned yet
+func (t *folderUsageTracker) usage(volumeID string) (usedBytes, quotaBytes int64, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	t.Lock()
+	defer t.Unlock()
+	if v, found := t.volumes[volumeID]; found && !v.scannedAt.IsZero() {
+		return v.usedBytes, v.quotaBytes, true
+	}
+	return 0, 0, false
+}
+
+// run scans the tracked volumes every interval until ctx is done
+func (t *folderUsageTracker) run(ctx context.Context, interval time.Duration) {
+	klog.FromContext(ctx).V(2).Info("folder usage tracker started", "interval", interval, "maxEntriesPerScan", t.maxEntriesPerScan, "quotaExceededAction", t.action)
+	wait.UntilWithContext(ctx, t.scanAll, interval)
+}
+
+// scanAll advances the scan of each tracked volume by one step and updates the usage of the volumes whose scan is complete,
+// a step which does not return in scanTimeout, e.g. on a dead mount, is left running and the volume is skipped until it returns
+func (t *folderUsageTracker) scanAll(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	t.Lock()
+	volumes := make(map[string]*trackedFolder, len(t.volumes))
+	for volumeID, v := range t.volumes {
+		volumes[volumeID] = v
+	}
+	t.Unlock()
+
+	for volumeID, v := range volumes {
+		t.Lock()
+		if v.scanning {
+			t.Unlock()
+			logger.V(4).Info("skip scanning the usage of volume since the previous step is still hanging", "volumeID", volumeID, "path", v.path)
+			continue
+		}
+		if v.scan == nil {
+			v.scan = newFolderScan(v.path)
+		}
+		scan := v.scan
+		v.scanning = true
+		t.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			t.scanStep(ctx, volumeID, v, scan)
+		}()
+		select {
+		case <-done:
+		case <-time.After(t.scanTimeout):
+			logger.Info("scanning the usage of volume timed out", "volumeID", volumeID, "path", v.path, "timeout", t.scanTimeout)
+		}
+	}
+}
+
+// scanStep advances the scan of the volume by one step and updates the usage of the volume if the scan is complete
+func (t *folderUsageTracker) scanStep(ctx context.Context, volumeID string, v *trackedFolder, scan *folderScan) {
+	logger := klog.FromContext(ctx)
+	finished, err := scan.step(t.maxEntriesPerScan)
+
+	t.Lock()
+	v.scanning = false
+	if err != nil || finished {
+		scan.stop()
+		if v.scan == scan {
+			v.scan = nil
+		}
+	}
+	if err != nil {
+		t.Unlock()
+		logger.Error(err, "failed to scan the usage of volume", "volumeID", volumeID, "path", v.path)
+		return
+	}
+	if !finished || t.volumes[volumeID] != v {
+		// the scan is not complete yet, or the volume is removed or re-added during the scan
+		t.Unlock()
+		return
+	}
+	usedBytes := scan.usedBytes
+	v.usedBytes = usedBytes
+	v.scannedAt = time.Now()
+	exceeded := usedBytes > v.quotaBytes
+	newlyExceeded := exceeded && !v.exceeded
+	v.exceeded = exceeded
+	t.Unlock()
+
+	logger.V(6).Info("usage of volume", "volumeID", volumeID, "path", v.path, "usedBytes", usedBytes, "quotaBytes", v.quotaBytes)
+	if newlyExceeded {
+		t.onQuotaExceeded(ctx, volumeID, v, usedBytes)
+	}
+}
+
+// onQuotaExceeded takes the configured action on a volume whose usage has just exceeded its quota
+func (t *folderUsageTracker) onQuotaExceeded(ctx context.Context, volumeID string, v *trackedFolder, usedBytes int64) {
+	logger := klog.FromContext(ctx)
+	message := fmt.Sprintf("usage %d bytes of volume(%s) exceeds its quota %d bytes", usedBytes, volumeID, v.quotaBytes)
+	logger.Info("usage of volume exceeds its quota", "volumeID", volumeID, "usedBytes", usedBytes, "quotaBytes", v.quotaBytes, "action", t.action)
+	csiMetrics.RecordFolderQuotaExceeded(t.action)
+	if t.action == folderQuotaExceededActionMetric {
+		return
+	}
+
+	if t.action == folderQuotaExceededActionReadOnly && t.remountReadOnly != nil {
+		if err := t.remountReadOnly(volumeID, v.path); err != nil {
+			logger.Error(err, "failed to remount volume read-only", "volumeID", volumeID, "path", v.path)
+			message = fmt.Sprintf("%s, failed to remount the volume read-only: %v", message, err)
+		} else {
+			logger.V(2).Info("remounted volume read-only", "volumeID", volumeID, "path", v.path)
+			message += ", the volume is remounted read-only on node until it's staged again"
+		}
+	}
+	if t.recordEvent != nil && v.pvcName != "" && v.pvcNamespace != "" {
+		t.recordEvent(v.pvcNamespace, v.pvcName, message)
+	}
+}
+
+// newPVCEventRecorder returns a function recording warning events on PVCs
+func newPVCEventRecorder(kubeClient clientset.Interface, nodeID string) func(pvcNamespace, pvcName, message string) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: DefaultDriverName, Host: nodeID})
+	return func(pvcNamespace, pvcName, message string) {
+		ref := &v1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: pvcNamespace, Name: pvcName}
+		recorder.Event(ref, v1.EventTypeWarning, folderQuotaExceededReason, message)
+	}
+}
+
+// folderQuotaInfo is saved in folderQuotaFileName
+type folderQuotaInfo struct {
+	QuotaBytes   int64  `json:"quotaBytes"`
+	PVCName      string `json:"pvcName,omitempty"`
+	PVCNamespace string `json:"pvcNamespace,omitempty"`
+}
+
+// getFolderQuotaFile returns the file saving the folder quota of the volume staged on stagingTargetPath,
+// it's in the parent directory of the staging path which is created by kubelet per volume, not in the file share
+func getFolderQuotaFile(stagingTargetPath string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(stagingTargetPath)), folderQuotaFileName)
+}
+
+// saveFolderQuota saves the folder quota of the volume staged on stagingTargetPath
+func saveFolderQuota(stagingTargetPath string, info folderQuotaInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getFolderQuotaFile(stagingTargetPath), data, 0600)
+}
+
+// loadFolderQuota returns the folder quota of the volume staged on stagingTargetPath, false if it's not saved
+func loadFolderQuota(stagingTargetPath string) (folderQuotaInfo, bool, error) {
+	var info folderQuotaInfo
+	data, err := os.ReadFile(getFolderQuotaFile(stagingTargetPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return info, false, nil
+		}
+		return info, false, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, false, err
+	}
+	return info, true, nil
+}
+
+// removeFolderQuota removes the saved folder quota of the volume staged on stagingTargetPath
+func removeFolderQuota(stagingTargetPath string) error {
+	if err := os.Remove(getFolderQuotaFile(stagingTargetPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// trackFolderUsage tracks the usage of the folder-based volume again if it was staged before the driver restarted
+func (d *Driver) trackFolderUsage(ctx context.Context, volumeID, stagingTargetPath string) {
+	if d.folderUsageTracker == nil || stagingTargetPath == "" || d.folderUsageTracker.isTracked(volumeID) {
+		return
+	}
+	info, ok, err := loadFolderQuota(stagingTargetPath)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "failed to load folder quota", "stagingTargetPath", stagingTargetPath)
+		return
+	}
+	if ok && info.QuotaBytes > 0 {
+		d.folderUsageTracker.add(volumeID, stagingTargetPath, info.QuotaBytes, info.PVCName, info.PVCNamespace)
+	}
+}
+
+// remountFolderReadOnly remounts the staged folder read-only and tells the mount health monitor
+// that the volume is read-only on purpose
+func (d *Driver) remountFolderReadOnly(volumeID, path string) error {
+	if err := d.mounter.Mount("", path, "", []string{"remount", "ro"}); err != nil {
+		return err
+	}
+	d.mountHealthMonitor.add(volumeID, path, true)
+	return nil
+}
+
+// withFolderUsage returns a copy of the volume stats whose bytes usage is the scanned usage of the folder
+// against its quota, the stats is returned as is if the volume is not tracked or not scanned yet
+func (d *Driver) withFolderUsage(volumeID string, resp *csi.NodeGetVolumeStatsResponse) *csi.NodeGetVolumeStatsResponse {
+	if resp == nil {
+		return nil
+	}
+	usedBytes, quotaBytes, ok := d.folderUsageTracker.usage(volumeID)
+	if !ok {
+		return resp
+	}
+	usage := []*csi.VolumeUsage{{
+		Unit:      csi.VolumeUsage_BYTES,
+		Total:     quotaBytes,
+		Used:      usedBytes,
+		Available: max(quotaBytes-usedBytes, 0),
+	}}
+	for _, u := range resp.GetUsage() {
+		if u.GetUnit() != csi.VolumeUsage_BYTES {
+			usage = append(usage, u)
+		}
+	}
+	condition := resp.GetVolumeCondition()
+	if usedBytes > quotaBytes {
+		message := fmt.Sprintf("usage %d bytes exceeds quota %d bytes", usedBytes, quotaBytes)
+		if condition.GetMessage() != "" {
+			message = condition.GetMessage() + ", " + message
+		}
+		condition = &csi.VolumeCondition{Abnormal: condition.GetAbnormal(), Message: message}
+	}
+	return &csi.NodeGetVolumeStatsResponse{Usage: usage, VolumeCondition: condition}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFolderUsageTestFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+}
+
+func TestParseFolderQuota(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      int64
+		expectedError bool
+	}{
+		{value: "", expected: 0},
+		{value: "1024", expected: 1024},
+		{value: "10Gi", expected: 10 * 1024 * 1024 * 1024},
+		{value: "-1Gi", expectedError: true},
+		{value: "invalid", expectedError: true},
+	}
+	for _, test := range tests {
+		quota, err := parseFolderQuota(test.value)
+		assert.Equal(t, test.expectedError, err != nil, test.value)
+		assert.Equal(t, test.expected, quota, test.value)
+	}
+}
+
+func TestFolderScanStep(t *testing.T) {
+	dir := t.TempDir()
+	writeFolderUsageTestFile(t, filepath.Join(dir, "a"), 100)
+	writeFolderUsageTestFile(t, filepath.Join(dir, "b"), 200)
+	writeFolderUsageTestFile(t, filepath.Join(dir, "sub", "c"), 300)
+	writeFolderUsageTestFile(t, filepath.Join(dir, "sub", "deep", "d"), 400)
+	// symbolic links are not followed
+	require.NoError(t, os.Symlink(filepath.Join(dir, "sub"), filepath.Join(dir, "link")))
+
+	// 8 entries are read in total, 2 per step
+	scan := newFolderScan(dir)
+	steps := 0
+	for {
+		steps++
+		done, err := scan.step(2)
+		require.NoError(t, err)
+		if done {
+			break
+		}
+		require.Less(t, steps, 10, "scan is not complete")
+	}
+	assert.Equal(t, int64(1000), scan.usedBytes)
+	assert.GreaterOrEqual(t, steps, 4)
+	assert.Nil(t, scan.dir, "directory is closed once the scan is complete")
+
+	// the directory is kept open between steps and closed once the scan is stopped
+	scan = newFolderScan(dir)
+	done, err := scan.step(1)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.NotNil(t, scan.dir)
+	scan.stop()
+	assert.Nil(t, scan.dir)
+	_, err = scan.step(1)
+	assert.Error(t, err)
+
+	scan = newFolderScan(dir)
+	done, err = scan.step(0)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, int64(1000), scan.usedBytes)
+
+	// a folder removed during the scan is regarded as empty
+	scan = newFolderScan(filepath.Join(dir, "notexist"))
+	done, err = scan.step(2)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, int64(0), scan.usedBytes)
+}
+
+func TestFolderUsageTracker(t *testing.T) {
+	var nilTracker *folderUsageTracker
+	nilTracker.add("vol", "/mnt", 100, "", "")
+	nilTracker.remove("vol")
+	_, _, ok := nilTracker.usage("vol")
+	assert.False(t, ok)
+
+	dir := t.TempDir()
+	writeFolderUsageTestFile(t, filepath.Join(dir, "a"), 100)
+	writeFolderUsageTestFile(t, filepath.Join(dir, "sub", "b"), 100)
+
+	tracker := newFolderUsageTracker(1, folderQuotaExceededActionReadOnly)
+	var remounted, events []string
+	tracker.remountReadOnly = func(volumeID, path string) error {
+		assert.Equal(t, dir, path)
+		remounted = append(remounted, volumeID)
+		return nil
+	}
+	tracker.recordEvent = func(pvcNamespace, pvcName, message string) {
+		events = append(events, pvcNamespace+"/"+pvcName+": "+message)
+	}
+	tracker.add("vol", dir, 150, "pvc", "ns")
+	_, _, ok = tracker.usage("vol")
+	assert.False(t, ok, "volume is not scanned yet")
+
+	// one entry is read per round
+	for i := 0; i < 10; i++ {
+		if _, _, ok = tracker.usage("vol"); ok {
+			break
+		}
+		tracker.scanAll(context.Background())
+	}
+	used, quota, ok := tracker.usage("vol")
+	require.True(t, ok)
+	assert.Equal(t, int64(200), used)
+	assert.Equal(t, int64(150), quota)
+	assert.Equal(t, []string{"vol"}, remounted)
+	assert.Equal(t, []string{"ns/pvc: usage 200 bytes of volume(vol) exceeds its quota 150 bytes, the volume is remounted read-only on node until it's staged again"}, events)
+
+	// the action is only taken when the volume starts exceeding its quota
+	for i := 0; i < 10; i++ {
+		tracker.scanAll(context.Background())
+	}
+	assert.Len(t, remounted, 1)
+	assert.Len(t, events, 1)
+
+	// usage is kept if the volume is added with the same path and quota again
+	tracker.add("vol", dir, 150, "pvc", "ns")
+	_, _, ok = tracker.usage("vol")
+	assert.True(t, ok)
+
+	tracker.remove("vol")
+	_, _, ok = tracker.usage("vol")
+	assert.False(t, ok)
+}
+
+func TestFolderUsageTrackerScanTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeFolderUsageTestFile(t, filepath.Join(dir, "a"), 100)
+	tracker := newFolderUsageTracker(0, folderQuotaExceededActionMetric)
+	tracker.scanTimeout = 10 * time.Millisecond
+	tracker.add("vol", dir, 1000, "", "")
+
+	// the step hangs until the scan is unlocked
+	scan := newFolderScan(dir)
+	scan.Lock()
+	tracker.volumes["vol"].scan = scan
+	tracker.scanAll(context.Background())
+	tracker.Lock()
+	assert.True(t, tracker.volumes["vol"].scanning)
+	tracker.Unlock()
+	// the hanging step is not started again
+	tracker.scanAll(context.Background())
+	_, _, ok := tracker.usage("vol")
+	assert.False(t, ok)
+
+	scan.Unlock()
+	assert.Eventually(t, func() bool {
+		used, _, ok := tracker.usage("vol")
+		return ok && used == 100
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTrackFolderUsage(t *testing.T) {
+	d := NewFakeDriver()
+	stagingTargetPath := filepath.Join(t.TempDir(), "globalmount")
+	require.NoError(t, os.Mkdir(stagingTargetPath, 0755))
+	writeFolderUsageTestFile(t, filepath.Join(stagingTargetPath, "a"), 100)
+
+	// no-op if the tracker is disabled or the folder quota is not saved
+	d.trackFolderUsage(context.Background(), "vol", stagingTargetPath)
+	d.folderUsageTracker = newFolderUsageTracker(0, folderQuotaExceededActionMetric)
+	d.trackFolderUsage(context.Background(), "vol", stagingTargetPath)
+	assert.False(t, d.folderUsageTracker.isTracked("vol"))
+
+	// the volume staged before the driver restarted is tracked again
+	require.NoError(t, saveFolderQuota(stagingTargetPath, folderQuotaInfo{QuotaBytes: 1000, PVCName: "pvc", PVCNamespace: "ns"}))
+	info, ok, err := loadFolderQuota(stagingTargetPath)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, folderQuotaInfo{QuotaBytes: 1000, PVCName: "pvc", PVCNamespace: "ns"}, info)
+	d.trackFolderUsage(context.Background(), "vol", stagingTargetPath)
+	require.True(t, d.folderUsageTracker.isTracked("vol"))
+	assert.Equal(t, "pvc", d.folderUsageTracker.volumes["vol"].pvcName)
+	d.folderUsageTracker.scanAll(context.Background())
+	used, quota, ok := d.folderUsageTracker.usage("vol")
+	require.True(t, ok)
+	assert.Equal(t, int64(100), used)
+	assert.Equal(t, int64(1000), quota)
+
+	require.NoError(t, removeFolderQuota(stagingTargetPath))
+	require.NoError(t, removeFolderQuota(stagingTargetPath))
+	_, ok, err = loadFolderQuota(stagingTargetPath)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFolderUsageTrackerQuotaExceededActions(t *testing.T) {
+	dir := t.TempDir()
+	writeFolderUsageTestFile(t, filepath.Join(dir, "a"), 200)
+
+	tests := []struct {
+		action            string
+		remountErr        error
+		expectedRemounted bool
+		expectedEvent     string
+	}{
+		{action: folderQuotaExceededActionMetric},
+		{
+			action:        folderQuotaExceededActionEvent,
+			expectedEvent: "usage 200 bytes of volume(vol) exceeds its quota 100 bytes",
+		},
+		{
+			action:            folderQuotaExceededActionReadOnly,
+			remountErr:        errors.New("remount failed"),
+			expectedRemounted: true,
+			expectedEvent:     "usage 200 bytes of volume(vol) exceeds its quota 100 bytes, failed to remount the volume read-only: remount failed",
+		},
+	}
+	for _, test := range tests {
+		tracker := newFolderUsageTracker(0, test.action)
+		remounted := false
+		var event string
+		tracker.remountReadOnly = func(string, string) error {
+			remounted = true
+			return test.remountErr
+		}
+		tracker.recordEvent = func(_, _, message string) {
+			event = message
+		}
+		tracker.add("vol", dir, 100, "pvc", "ns")
+		tracker.scanAll(context.Background())
+		assert.Equal(t, test.expectedRemounted, remounted, test.action)
+		assert.Equal(t, test.expectedEvent, event, test.action)
+	}
+}
+
+func TestNodeGetVolumeStatsWithFolderUsage(t *testing.T) {
+	d := NewFakeDriver()
+	d.folderUsageTracker = newFolderUsageTracker(0, folderQuotaExceededActionMetric)
+	resp := &csi.NodeGetVolumeStatsResponse{Usage: []*csi.VolumeUsage{
+		{Unit: csi.VolumeUsage_BYTES, Total: 100 * 1024 * 1024 * 1024, Used: 1024, Available: 100*1024*1024*1024 - 1024},
+		{Unit: csi.VolumeUsage_INODES, Total: 1000, Used: 10},
+	}}
+
+	// stats of the file share are returned before the folder is scanned
+	assert.Equal(t, resp, d.withVolumeCondition("vol", resp))
+
+	dir := t.TempDir()
+	writeFolderUsageTestFile(t, filepath.Join(dir, "a"), 300)
+	d.folderUsageTracker.add("vol", dir, 1000, "", "")
+	d.folderUsageTracker.scanAll(context.Background())
+	stats := d.withVolumeCondition("vol", resp)
+	assert.Equal(t, []*csi.VolumeUsage{
+		{Unit: csi.VolumeUsage_BYTES, Total: 1000, Used: 300, Available: 700},
+		{Unit: csi.VolumeUsage_INODES, Total: 1000, Used: 10},
+	}, stats.GetUsage())
+	assert.Nil(t, stats.GetVolumeCondition())
+
+	writeFolderUsageTestFile(t, filepath.Join(dir, "b"), 1000)
+	d.folderUsageTracker.scanAll(context.Background())
+	stats = d.withVolumeCondition("vol", resp)
+	assert.Equal(t, &csi.VolumeUsage{Unit: csi.VolumeUsage_BYTES, Total: 1000, Used: 1300, Available: 0}, stats.GetUsage()[0])
+	assert.Equal(t, &csi.VolumeCondition{Abnormal: false, Message: "usage 1300 bytes exceeds quota 1000 bytes"}, stats.GetVolumeCondition())
+}
@@ -46,6 +46,8 @@ func (f *Driver) GetPluginInfo(_ context.Context, _ *csi.GetPluginInfoRequest) (
 // This method does not need to return anything.
 // Currently the spec does not dictate what you should return either.
 // Hence, return an empty response
+// The health of azurefile-proxy is not reported here, it's checked in background and only reported by log and metric,
+// since Probe is called by the livenessprobe, which would restart the node driver that could not recover the proxy on the host.
 func (f *Driver) Probe(_ context.Context, _ *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	return &csi.ProbeResponse{Ready: &wrappers.BoolValue{Value: true}}, nil
 }
@@ -0,0 +1,126 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// metadata keys of the direct volume mount info saved for Kata confidential containers
+const (
+	// sensitiveMountOptionsMetadataKey holds the sensitive mount options in plaintext,
+	// it's only set when no public key of the confidential guest is configured
+	sensitiveMountOptionsMetadataKey = "sensitiveMountOptions"
+	// encryptedSensitiveMountOptionsMetadataKey holds the nonce followed by the AES-256-GCM sealed sensitive mount options,
+	// the device of the mount info is the additional authenticated data
+	encryptedSensitiveMountOptionsMetadataKey = "encryptedSensitiveMountOptions"
+	// encryptedKeyMetadataKey holds the AES key wrapped with RSA-OAEP-SHA256 to the public key of the confidential guest
+	encryptedKeyMetadataKey = "encryptedKey"
+	// encryptionAlgorithmMetadataKey holds the algorithm of the sealed sensitive mount options
+	encryptionAlgorithmMetadataKey = "encryptionAlgorithm"
+	// encryptionKeyIDMetadataKey holds the hex SHA-256 digest of the PKCS#1 DER encoded public key, so that the guest picks the right private key
+	encryptionKeyIDMetadataKey = "encryptionKeyID"
+
+	kataMountInfoEncryptionAlgorithm = "RSA-OAEP-256+A256GCM"
+)
+
+// loadKataMountInfoPublicKey loads the PEM encoded RSA public key of the confidential guest,
+// both PKIX "PUBLIC KEY" and PKCS#1 "RSA PUBLIC KEY" blocks are accepted
+func loadKataMountInfoPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block is found in %s", path)
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key in %s is %T, only RSA public key is supported", path, key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("PEM block type %s in %s is not supported", block.Type, path)
+	}
+}
+
+// getKataMountInfoMetadata returns the metadata of the direct volume mount info carrying the sensitive mount options,
+// the options are sealed to the public key of the confidential guest if it's set, so that the account key is never saved in plaintext on the host,
+// nil is returned if there is no sensitive mount option, e.g. for nfs
+func getKataMountInfoMetadata(publicKey *rsa.PublicKey, device string, sensitiveMountOptions []string) (map[string]string, error) {
+	if len(sensitiveMountOptions) == 0 {
+		return nil, nil
+	}
+	options := strings.Join(sensitiveMountOptions, ",")
+	if publicKey == nil {
+		return map[string]string{sensitiveMountOptionsMetadataKey: options}, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(options), []byte(device))
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %v", err)
+	}
+	return map[string]string{
+		encryptedSensitiveMountOptionsMetadataKey: base64.StdEncoding.EncodeToString(sealed),
+		encryptedKeyMetadataKey:                   base64.StdEncoding.EncodeToString(encryptedKey),
+		encryptionAlgorithmMetadataKey:            kataMountInfoEncryptionAlgorithm,
+		encryptionKeyIDMetadataKey:                getKataMountInfoKeyID(publicKey),
+	}, nil
+}
+
+// getKataMountInfoKeyID returns the hex SHA-256 digest of the PKCS#1 DER encoded public key
+func getKataMountInfoKeyID(publicKey *rsa.PublicKey) string {
+	digest := sha256.Sum256(x509.MarshalPKCS1PublicKey(publicKey))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKataTestPEM(t *testing.T, blockType string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600))
+	return path
+}
+
+// openKataMountInfoMetadata decrypts the sealed sensitive mount options as the confidential guest does
+func openKataMountInfoMetadata(privateKey *rsa.PrivateKey, device string, metadata map[string]string) (string, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(metadata[encryptedKeyMetadataKey])
+	if err != nil {
+		return "", err
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, encryptedKey, nil)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(metadata[encryptedSensitiveMountOptionsMetadataKey])
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	options, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(device))
+	return string(options), err
+}
+
+func TestLoadKataMountInfoPublicKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkixKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPKIXKey, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+	invalidPEM := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPEM, []byte("invalid"), 0600))
+
+	tests := []struct {
+		desc          string
+		path          string
+		expectedError string
+	}{
+		{
+			desc: "PKIX public key",
+			path: writeKataTestPEM(t, "PUBLIC KEY", pkixKey),
+		},
+		{
+			desc: "PKCS#1 public key",
+			path: writeKataTestPEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)),
+		},
+		{
+			desc:          "ECDSA public key",
+			path:          writeKataTestPEM(t, "PUBLIC KEY", ecPKIXKey),
+			expectedError: "only RSA public key is supported",
+		},
+		{
+			desc:          "private key",
+			path:          writeKataTestPEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey)),
+			expectedError: "PEM block type RSA PRIVATE KEY",
+		},
+		{
+			desc:          "no PEM block",
+			path:          invalidPEM,
+			expectedError: "no PEM block is found",
+		},
+		{
+			desc:          "file not found",
+			path:          filepath.Join(t.TempDir(), "notexist"),
+			expectedError: "no such file or directory",
+		},
+	}
+	for _, test := range tests {
+		key, err := loadKataMountInfoPublicKey(test.path)
+		if test.expectedError != "" {
+			require.Error(t, err, test.desc)
+			assert.Contains(t, err.Error(), test.expectedError, test.desc)
+			continue
+		}
+		require.NoError(t, err, test.desc)
+		assert.True(t, privateKey.PublicKey.Equal(key), test.desc)
+	}
+}
+
+func TestGetKataMountInfoMetadata(t *testing.T) {
+	sensitiveMountOptions := []string{"username=account,password=secretkey"}
+	device := "//account.file.core.windows.net/share"
+
+	metadata, err := getKataMountInfoMetadata(nil, device, nil)
+	require.NoError(t, err)
+	assert.Nil(t, metadata, "no metadata for nfs")
+
+	metadata, err = getKataMountInfoMetadata(nil, device, sensitiveMountOptions)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{sensitiveMountOptionsMetadataKey: "username=account,password=secretkey"}, metadata)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	metadata, err = getKataMountInfoMetadata(&privateKey.PublicKey, device, sensitiveMountOptions)
+	require.NoError(t, err)
+	assert.NotContains(t, metadata, sensitiveMountOptionsMetadataKey)
+	for k, v := range metadata {
+		assert.False(t, strings.Contains(v, "secretkey"), "account key is saved in plaintext in %s", k)
+	}
+	assert.Equal(t, kataMountInfoEncryptionAlgorithm, metadata[encryptionAlgorithmMetadataKey])
+	assert.Equal(t, getKataMountInfoKeyID(&privateKey.PublicKey), metadata[encryptionKeyIDMetadataKey])
+
+	options, err := openKataMountInfoMetadata(privateKey, device, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "username=account,password=secretkey", options)
+
+	// the sealed options could not be moved to the mount info of another share
+	_, err = openKataMountInfoMetadata(privateKey, "//account.file.core.windows.net/other", metadata)
+	assert.Error(t, err)
+
+	// a fresh key is used for each mount info
+	another, err := getKataMountInfoMetadata(&privateKey.PublicKey, device, sensitiveMountOptions)
+	require.NoError(t, err)
+	assert.NotEqual(t, metadata[encryptedKeyMetadataKey], another[encryptedKeyMetadataKey])
+	assert.NotEqual(t, metadata[encryptedSensitiveMountOptionsMetadataKey], another[encryptedSensitiveMountOptionsMetadataKey])
+}
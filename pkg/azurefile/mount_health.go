@@ -0,0 +1,264 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// mountHealth is the health of a mount classified by the probe
+type mountHealth string
+
+const (
+	mountHealthy     mountHealth = "healthy"
+	mountStale       mountHealth = "stale"
+	mountUnreachable mountHealth = "unreachable"
+	mountReadOnly    mountHealth = "read_only"
+)
+
+// mountCondition is the result of the last probe of a mount
+type mountCondition struct {
+	health    mountHealth
+	message   string
+	checkedAt time.Time
+}
+
+// abnormal returns true if the mount is not healthy
+func (c mountCondition) abnormal() bool {
+	return c.health != mountHealthy
+}
+
+// volumeCondition converts the condition into the VolumeCondition of NodeGetVolumeStats
+func (c mountCondition) volumeCondition() *csi.VolumeCondition {
+	return &csi.VolumeCondition{Abnormal: c.abnormal(), Message: c.message}
+}
+
+type monitoredMount struct {
+	path string
+	// readOnly is true if the volume is mounted read-only on purpose
+	readOnly  bool
+	condition *mountCondition
+	// probing is true while a probe of the mount is running, a probe hanging on a dead mount is never started again
+	probing bool
+}
+
+// mountHealthMonitor periodically probes the staged mounts on the node with a bounded-timeout stat
+type mountHealthMonitor struct {
+	sync.Mutex
+	timeout time.Duration
+	// mounts are indexed by volume ID
+	mounts map[string]*monitoredMount
+	// onAbnormal is called asynchronously with the abnormal mounts after each round of probes, e.g. to recover stale mounts,
+	// so that a slow call does not delay the next probes, concurrent calls on the same volume must be serialized by onAbnormal
+	onAbnormal func(ctx context.Context, volumeID string, condition mountCondition)
+	// stat and isReadOnly are replaced in unit tests
+	stat       func(path string) error
+	isReadOnly func(path string) (bool, error)
+}
+
+func newMountHealthMonitor(timeout time.Duration) *mountHealthMonitor {
+	return &mountHealthMonitor{
+		timeout: timeout,
+		mounts:  map[string]*monitoredMount{},
+		stat: func(path string) error {
+			_, err := os.Stat(path)
+			return err
+		},
+		isReadOnly: isReadOnlyFilesystem,
+	}
+}
+
+// add starts monitoring the mount of the volume, the last condition is kept if the path is not changed
+func (m *mountHealthMonitor) add(volumeID, path string, readOnly bool) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	if mm, ok := m.mounts[volumeID]; ok && mm.path == path {
+		mm.readOnly = readOnly
+		return
+	}
+	m.mounts[volumeID] = &monitoredMount{path: path, readOnly: readOnly}
+}
+
+// remove stops monitoring the mount of the volume
+func (m *mountHealthMonitor) remove(volumeID string) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	delete(m.mounts, volumeID)
+}
+
+// condition returns the last probe result of the volume, false if the volume is not monitored or not probed yet
+func (m *mountHealthMonitor) condition(volumeID string) (mountCondition, bool) {
+	if m == nil {
+		return mountCondition{}, false
+	}
+	m.Lock()
+	defer m.Unlock()
+	if mm, ok := m.mounts[volumeID]; ok && mm.condition != nil {
+		return *mm.condition, true
+	}
+	return mountCondition{}, false
+}
+
+// isMonitored returns true if the mount of the volume is monitored
+func (m *mountHealthMonitor) isMonitored(volumeID string) bool {
+	if m == nil {
+		return false
+	}
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.mounts[volumeID]
+	return ok
+}
+
+// run probes all mounts every interval until ctx is done
+func (m *mountHealthMonitor) run(ctx context.Context, interval time.Duration) {
+	klog.FromContext(ctx).V(2).Info("mount health monitor started", "interval", interval, "timeout", m.timeout)
+	wait.UntilWithContext(ctx, m.checkAll, interval)
+}
+
+// checkAll probes all mounts in parallel and waits until all probes are finished or timed out,
+// then onAbnormal is started in background with each abnormal mount
+func (m *mountHealthMonitor) checkAll(ctx context.Context) {
+	m.Lock()
+	volumeIDs := make([]string, 0, len(m.mounts))
+	for volumeID := range m.mounts {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	m.Unlock()
+
+	var wg sync.WaitGroup
+	for _, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(volumeID string) {
+			defer wg.Done()
+			m.check(ctx, volumeID)
+		}(volumeID)
+	}
+	wg.Wait()
+
+	if m.onAbnormal == nil {
+		return
+	}
+	for _, volumeID := range volumeIDs {
+		if condition, ok := m.condition(volumeID); ok && condition.abnormal() {
+			go m.onAbnormal(ctx, volumeID, condition)
+		}
+	}
+}
+
+// check probes the mount of the volume and saves the result
+func (m *mountHealthMonitor) check(ctx context.Context, volumeID string) {
+	m.Lock()
+	mm, ok := m.mounts[volumeID]
+	if !ok {
+		m.Unlock()
+		return
+	}
+	path, readOnly := mm.path, mm.readOnly
+	if mm.probing {
+		// the previous probe is still hanging on the mount
+		m.setCondition(ctx, volumeID, mm, mountCondition{
+			health:    mountUnreachable,
+			message:   fmt.Sprintf("mount %s is unreachable: previous probe is still hanging", path),
+			checkedAt: time.Now(),
+		})
+		m.Unlock()
+		return
+	}
+	mm.probing = true
+	m.Unlock()
+
+	done := make(chan mountCondition, 1)
+	go func() {
+		condition := m.probe(path, readOnly)
+		m.Lock()
+		mm.probing = false
+		m.Unlock()
+		done <- condition
+	}()
+
+	var condition mountCondition
+	select {
+	case condition = <-done:
+	case <-time.After(m.timeout):
+		condition = mountCondition{
+			health:    mountUnreachable,
+			message:   fmt.Sprintf("mount %s is unreachable: stat timed out after %v", path, m.timeout),
+			checkedAt: time.Now(),
+		}
+	}
+	m.Lock()
+	defer m.Unlock()
+	// the volume may be unstaged or restaged on another path during the probe
+	if current, ok := m.mounts[volumeID]; ok && current == mm {
+		m.setCondition(ctx, volumeID, mm, condition)
+	}
+}
+
+// setCondition must be called with the lock held
+func (m *mountHealthMonitor) setCondition(ctx context.Context, volumeID string, mm *monitoredMount, condition mountCondition) {
+	if mm.condition == nil || mm.condition.health != condition.health {
+		if condition.abnormal() {
+			klog.FromContext(ctx).Info("volume mount health changed", "volumeID", volumeID, "health", condition.health, "message", condition.message)
+		} else if mm.condition != nil {
+			klog.FromContext(ctx).V(2).Info("volume mount is healthy again", "volumeID", volumeID, "path", mm.path)
+		}
+	}
+	mm.condition = &condition
+}
+
+// probe stats the mount and classifies the result
+func (m *mountHealthMonitor) probe(path string, readOnly bool) mountCondition {
+	condition := mountCondition{health: mountHealthy, checkedAt: time.Now()}
+	if err := m.stat(path); err != nil {
+		condition.health, condition.message = classifyMountError(path, err)
+		return condition
+	}
+	if !readOnly {
+		if ro, err := m.isReadOnly(path); err != nil {
+			condition.health, condition.message = classifyMountError(path, err)
+		} else if ro {
+			condition.health = mountReadOnly
+			condition.message = fmt.Sprintf("mount %s is read-only", path)
+		}
+	}
+	return condition
+}
+
+// classifyMountError classifies the error of stat on a mount
+func classifyMountError(path string, err error) (mountHealth, string) {
+	if errors.Is(err, syscall.ESTALE) {
+		return mountStale, fmt.Sprintf("mount %s is stale: %v", path, err)
+	}
+	return mountUnreachable, fmt.Sprintf("mount %s is unreachable: %v", path, err)
+}
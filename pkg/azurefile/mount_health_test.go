@@ -0,0 +1,257 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountHealthMonitorProbe(t *testing.T) {
+	tests := []struct {
+		desc           string
+		statErr        error
+		isReadOnly     bool
+		readOnlyErr    error
+		expectReadOnly bool
+		expected       mountHealth
+	}{
+		{desc: "healthy", expected: mountHealthy},
+		{desc: "stale", statErr: fmt.Errorf("stat /mnt: %w", syscall.ESTALE), expected: mountStale},
+		{desc: "host down", statErr: syscall.EHOSTDOWN, expected: mountUnreachable},
+		{desc: "read-only", isReadOnly: true, expected: mountReadOnly},
+		{desc: "read-only on purpose", isReadOnly: true, expectReadOnly: true, expected: mountHealthy},
+		{desc: "statfs failure", readOnlyErr: syscall.EIO, expected: mountUnreachable},
+	}
+	for _, test := range tests {
+		m := newMountHealthMonitor(time.Second)
+		m.stat = func(string) error { return test.statErr }
+		m.isReadOnly = func(string) (bool, error) { return test.isReadOnly, test.readOnlyErr }
+		m.add("vol", "/mnt", test.expectReadOnly)
+		m.checkAll(context.Background())
+
+		condition, ok := m.condition("vol")
+		require.True(t, ok, test.desc)
+		assert.Equal(t, test.expected, condition.health, test.desc)
+		assert.Equal(t, test.expected != mountHealthy, condition.volumeCondition().GetAbnormal(), test.desc)
+		if test.expected != mountHealthy {
+			assert.Contains(t, condition.message, "/mnt", test.desc)
+		}
+	}
+}
+
+func TestMountHealthMonitorHangingProbe(t *testing.T) {
+	release := make(chan struct{})
+	m := newMountHealthMonitor(10 * time.Millisecond)
+	m.stat = func(string) error {
+		<-release
+		return nil
+	}
+	m.isReadOnly = func(string) (bool, error) { return false, nil }
+	m.add("vol", "/mnt", false)
+
+	m.check(context.Background(), "vol")
+	condition, _ := m.condition("vol")
+	assert.Equal(t, mountUnreachable, condition.health)
+	assert.Contains(t, condition.message, "timed out")
+
+	// no more probe is started while the previous one is hanging
+	m.check(context.Background(), "vol")
+	condition, _ = m.condition("vol")
+	assert.Equal(t, mountUnreachable, condition.health)
+	assert.Contains(t, condition.message, "still hanging")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		m.check(context.Background(), "vol")
+		condition, _ := m.condition("vol")
+		return condition.health == mountHealthy
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMountHealthMonitorAddRemove(t *testing.T) {
+	var nilMonitor *mountHealthMonitor
+	nilMonitor.add("vol", "/mnt", false)
+	nilMonitor.remove("vol")
+	_, ok := nilMonitor.condition("vol")
+	assert.False(t, ok)
+	assert.False(t, nilMonitor.isMonitored("vol"))
+
+	m := newMountHealthMonitor(time.Second)
+	m.stat = func(string) error { return syscall.ESTALE }
+	m.add("vol", "/mnt", false)
+	assert.True(t, m.isMonitored("vol"))
+	_, ok = m.condition("vol")
+	assert.False(t, ok, "volume is not probed yet")
+
+	m.checkAll(context.Background())
+	_, ok = m.condition("vol")
+	assert.True(t, ok)
+	// condition is kept if the volume is added on the same path again
+	m.add("vol", "/mnt", true)
+	_, ok = m.condition("vol")
+	assert.True(t, ok)
+	// condition is reset if the path is changed
+	m.add("vol", "/mnt2", false)
+	_, ok = m.condition("vol")
+	assert.False(t, ok)
+
+	m.remove("vol")
+	assert.False(t, m.isMonitored("vol"))
+}
+
+func TestMountHealthMonitorOnAbnormal(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	called := make(chan string, 2)
+	m := newMountHealthMonitor(time.Second)
+	m.stat = func(path string) error {
+		if path == "/stale" {
+			return syscall.ESTALE
+		}
+		return nil
+	}
+	m.isReadOnly = func(string) (bool, error) { return false, nil }
+	m.onAbnormal = func(_ context.Context, volumeID string, condition mountCondition) {
+		assert.Equal(t, mountStale, condition.health)
+		called <- volumeID
+		// a slow recovery does not block the next probes
+		<-release
+	}
+	m.add("vol-stale", "/stale", false)
+	m.add("vol-healthy", "/healthy", false)
+
+	m.checkAll(context.Background())
+	m.checkAll(context.Background())
+	for i := 0; i < 2; i++ {
+		select {
+		case volumeID := <-called:
+			assert.Equal(t, "vol-stale", volumeID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("onAbnormal is not called")
+		}
+	}
+}
+
+func TestMountHealthMonitorRun(t *testing.T) {
+	m := newMountHealthMonitor(time.Second)
+	m.stat = func(string) error { return errors.New("host is down") }
+	m.add("vol", "/mnt", true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.run(ctx, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		condition, ok := m.condition("vol")
+		return ok && condition.health == mountUnreachable
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNodeGetVolumeStatsWithVolumeCondition(t *testing.T) {
+	d := NewFakeDriver()
+	d.mountHealthMonitor = newMountHealthMonitor(time.Second)
+	d.mountHealthMonitor.isReadOnly = func(string) (bool, error) { return false, nil }
+	volumeID := "rg#account#share-health"
+	req := &csi.NodeGetVolumeStatsRequest{VolumeId: volumeID, VolumePath: "/pods/volume", StagingTargetPath: "/staging"}
+
+	// the stats call does not start monitoring a volume which is not staged or published by the driver
+	cached := &csi.NodeGetVolumeStatsResponse{Usage: []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Total: 100}}}
+	d.volStatsCache.Set(volumeID, cached)
+	resp, err := d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, cached, resp)
+	assert.False(t, d.mountHealthMonitor.isMonitored(volumeID))
+
+	// stats is returned as is before the first probe
+	d.mountHealthMonitor.add(volumeID, "/staging", false)
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, cached, resp)
+
+	// healthy condition is attached to a copy of the cached stats
+	d.mountHealthMonitor.stat = func(string) error { return nil }
+	d.mountHealthMonitor.checkAll(context.Background())
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, cached.GetUsage(), resp.GetUsage())
+	assert.False(t, resp.GetVolumeCondition().GetAbnormal())
+	assert.Nil(t, cached.GetVolumeCondition())
+
+	// the cached usage is returned with the abnormal condition without accessing the mount
+	d.mountHealthMonitor.stat = func(string) error { return syscall.ESTALE }
+	d.mountHealthMonitor.checkAll(context.Background())
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, cached.GetUsage(), resp.GetUsage())
+	assert.True(t, resp.GetVolumeCondition().GetAbnormal())
+	assert.Contains(t, resp.GetVolumeCondition().GetMessage(), "stale")
+
+	// zeroed usage is returned with the abnormal condition if the stats of the volume are not known
+	d.volStatsCache.Delete(volumeID)
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES}, {Unit: csi.VolumeUsage_INODES}}, resp.GetUsage())
+	assert.True(t, resp.GetVolumeCondition().GetAbnormal())
+
+	// usage of the last snapshot is returned if the stats collector is enabled
+	d.volumeStatsCollector = newVolumeStatsCollector(time.Minute, time.Second, func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		return fakeVolumeStats(200), nil
+	})
+	d.volumeStatsCollector.add(volumeID, "/staging")
+	d.volumeStatsCollector.refreshAll(context.Background())
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), resp.GetUsage()[0].GetTotal())
+	assert.True(t, resp.GetVolumeCondition().GetAbnormal())
+}
+
+func TestMonitorStagedMount(t *testing.T) {
+	d := NewFakeDriver()
+	d.mountHealthMonitor = newMountHealthMonitor(time.Second)
+	readOnlyCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY},
+	}
+	mountCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{MountFlags: []string{"ro"}}},
+	}
+
+	// the mount of a volume staged before the driver restarted is monitored from the publish call
+	d.monitorStagedMount("vol-1", "/staging-1", readOnlyCap)
+	require.True(t, d.mountHealthMonitor.isMonitored("vol-1"))
+	assert.Equal(t, "/staging-1", d.mountHealthMonitor.mounts["vol-1"].path)
+	assert.True(t, d.mountHealthMonitor.mounts["vol-1"].readOnly)
+
+	d.monitorStagedMount("vol-2", "/staging-2", mountCap)
+	assert.True(t, d.mountHealthMonitor.mounts["vol-2"].readOnly)
+
+	// the mount monitored since NodeStageVolume is kept as is
+	d.mountHealthMonitor.add("vol-3", "/staging-3", false)
+	d.monitorStagedMount("vol-3", "/other", readOnlyCap)
+	assert.Equal(t, "/staging-3", d.mountHealthMonitor.mounts["vol-3"].path)
+	assert.False(t, d.mountHealthMonitor.mounts["vol-3"].readOnly)
+
+	// nil monitor is a no-op
+	d.mountHealthMonitor = nil
+	d.monitorStagedMount("vol-4", "/staging-4", readOnlyCap)
+}
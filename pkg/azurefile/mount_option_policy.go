@@ -0,0 +1,220 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// mountOptionPolicyReloadInterval is the interval to reload the policy file, e.g. a ConfigMap mounted into the driver pod
+const mountOptionPolicyReloadInterval = time.Minute
+
+// MountOptionPolicy defines the mount options allowed, denied, forced and defaulted by cluster admins per protocol, e.g.
+//
+//	smb:
+//	  denied: ["noperm", "sec=none"]
+//	  forced: ["nosuid", "nodev"]
+//	nfs:
+//	  allowed: ["nconnect", "rsize", "wsize", "actimeo"]
+//	  defaults: ["nconnect=4"]
+//
+// An option without value, e.g. noperm, matches the option with any value,
+// while an option with value, e.g. sec=none, only matches the same value.
+type MountOptionPolicy struct {
+	SMB *ProtocolMountOptionPolicy `json:"smb,omitempty"`
+	NFS *ProtocolMountOptionPolicy `json:"nfs,omitempty"`
+}
+
+// ProtocolMountOptionPolicy is the mount option policy of one protocol
+type ProtocolMountOptionPolicy struct {
+	// Allowed are the only mount options users could specify, empty allows all options which are not denied
+	Allowed []string `json:"allowed,omitempty"`
+	// Denied are the mount options users could not specify
+	Denied []string `json:"denied,omitempty"`
+	// Forced are always appended to the mount options, overriding the values users specify
+	Forced []string `json:"forced,omitempty"`
+	// Defaults are appended to the mount options if users do not specify them
+	Defaults []string `json:"defaults,omitempty"`
+}
+
+// loadMountOptionPolicy reads the mount option policy from a yaml or json file
+func loadMountOptionPolicy(path string) (*MountOptionPolicy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mount option policy file(%s): %v", path, err)
+	}
+	policy := &MountOptionPolicy{}
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse mount option policy file(%s): %v", path, err)
+	}
+	if err := policy.validatePolicy(); err != nil {
+		return nil, fmt.Errorf("invalid mount option policy file(%s): %v", path, err)
+	}
+	return policy, nil
+}
+
+// validatePolicy returns error if a forced or default option conflicts with the denied options
+func (p *MountOptionPolicy) validatePolicy() error {
+	for protocol, policy := range map[string]*ProtocolMountOptionPolicy{smb: p.SMB, nfs: p.NFS} {
+		if policy == nil {
+			continue
+		}
+		for _, options := range [][]string{policy.Allowed, policy.Denied, policy.Forced, policy.Defaults} {
+			for _, option := range options {
+				if strings.TrimSpace(option) == "" || strings.Contains(option, ",") {
+					return fmt.Errorf("%s: invalid mount option %q, it should be one option without comma", protocol, option)
+				}
+			}
+		}
+		for _, option := range append(append([]string{}, policy.Forced...), policy.Defaults...) {
+			if rule := matchMountOption(policy.Denied, option); rule != "" {
+				return fmt.Errorf("%s: mount option %q is forced or defaulted but denied by %q", protocol, option, rule)
+			}
+		}
+	}
+	return nil
+}
+
+// forProtocol returns the policy of the protocol, smb is used if protocol is empty
+func (p *MountOptionPolicy) forProtocol(protocol string) *ProtocolMountOptionPolicy {
+	if p == nil {
+		return nil
+	}
+	if protocol == nfs {
+		return p.NFS
+	}
+	return p.SMB
+}
+
+// validate returns error if any of the mount options specified by users is denied or not allowed
+func (p *MountOptionPolicy) validate(protocol string, mountFlags []string) error {
+	policy := p.forProtocol(protocol)
+	if policy == nil {
+		return nil
+	}
+	if protocol == "" {
+		protocol = smb
+	}
+	for _, option := range splitMountOptions(mountFlags) {
+		if rule := matchMountOption(policy.Denied, option); rule != "" {
+			return fmt.Errorf("mount option %q is denied by %q in the %s mount option policy", option, rule, protocol)
+		}
+		if len(policy.Allowed) > 0 && matchMountOption(policy.Allowed, option) == "" {
+			return fmt.Errorf("mount option %q is not allowed by the %s mount option policy, allowed options: %v", option, protocol, policy.Allowed)
+		}
+	}
+	return nil
+}
+
+// apply appends the forced options, replacing the options with the same name, and the default options which are not specified
+func (p *MountOptionPolicy) apply(protocol string, mountOptions []string) []string {
+	policy := p.forProtocol(protocol)
+	if policy == nil || (len(policy.Forced) == 0 && len(policy.Defaults) == 0) {
+		return mountOptions
+	}
+	forced := map[string]bool{}
+	for _, option := range policy.Forced {
+		forced[mountOptionName(option)] = true
+	}
+	included := map[string]bool{}
+	result := make([]string, 0, len(mountOptions)+len(policy.Forced)+len(policy.Defaults))
+	for _, option := range splitMountOptions(mountOptions) {
+		if forced[mountOptionName(option)] {
+			continue
+		}
+		included[mountOptionName(option)] = true
+		result = append(result, option)
+	}
+	result = append(result, policy.Forced...)
+	for _, option := range policy.Defaults {
+		if name := mountOptionName(option); !included[name] && !forced[name] {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// splitMountOptions splits the comma separated mount options, e.g. ["dir_mode=0777,file_mode=0777"]
+func splitMountOptions(mountOptions []string) []string {
+	var options []string
+	for _, mountOption := range mountOptions {
+		for _, option := range strings.Split(mountOption, ",") {
+			if option = strings.TrimSpace(option); option != "" {
+				options = append(options, option)
+			}
+		}
+	}
+	return options
+}
+
+// mountOptionName returns the name of the mount option in lower case, e.g. "sec" of "sec=none"
+func mountOptionName(option string) string {
+	name, _, _ := strings.Cut(option, "=")
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// matchMountOption returns the first rule matching the option, empty string if no rule matches
+func matchMountOption(rules []string, option string) string {
+	for _, rule := range rules {
+		if strings.Contains(rule, "=") {
+			if mountOptionName(rule) == mountOptionName(option) && strings.EqualFold(strings.TrimSpace(rule), strings.TrimSpace(option)) {
+				return rule
+			}
+		} else if mountOptionName(rule) == mountOptionName(option) {
+			return rule
+		}
+	}
+	return ""
+}
+
+// getMountOptionPolicy returns the mount option policy, nil if no policy is configured
+func (d *Driver) getMountOptionPolicy() *MountOptionPolicy {
+	return d.mountOptionPolicy.Load()
+}
+
+// startMountOptionPolicyReload loads the mount option policy file and reloads it periodically,
+// the last valid policy is kept if the file could not be loaded
+func (d *Driver) startMountOptionPolicyReload(ctx context.Context) error {
+	policy, err := loadMountOptionPolicy(d.mountOptionPolicyFile)
+	if err != nil {
+		return err
+	}
+	d.mountOptionPolicy.Store(policy)
+	logger := klog.FromContext(ctx)
+	logger.V(2).Info("mount option policy is loaded", "path", d.mountOptionPolicyFile)
+	go wait.UntilWithContext(ctx, func(_ context.Context) {
+		policy, err := loadMountOptionPolicy(d.mountOptionPolicyFile)
+		if err != nil {
+			logger.Error(err, "failed to reload mount option policy, keep the last valid policy")
+			return
+		}
+		if !reflect.DeepEqual(policy, d.mountOptionPolicy.Load()) {
+			d.mountOptionPolicy.Store(policy)
+			logger.V(2).Info("mount option policy is reloaded", "path", d.mountOptionPolicyFile)
+		}
+	}, mountOptionPolicyReloadInterval)
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testMountOptionPolicy = `
+smb:
+  denied: ["noperm", "sec=none"]
+  forced: ["nosuid", "nodev", "vers=3.1.1"]
+  defaults: ["actimeo=60", "cache=strict"]
+nfs:
+  allowed: ["nconnect", "rsize", "wsize", "ro"]
+  defaults: ["nconnect=4"]
+`
+
+func writeMountOptionPolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadMountOptionPolicy(t *testing.T) {
+	policy, err := loadMountOptionPolicy(writeMountOptionPolicy(t, testMountOptionPolicy))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"noperm", "sec=none"}, policy.SMB.Denied)
+	assert.Equal(t, []string{"nconnect", "rsize", "wsize", "ro"}, policy.NFS.Allowed)
+
+	tests := []struct {
+		desc        string
+		content     string
+		expectedErr string
+	}{
+		{desc: "unknown field", content: "smb:\n  deny: [noperm]\n", expectedErr: "failed to parse"},
+		{desc: "unknown protocol", content: "cifs: {}\n", expectedErr: "failed to parse"},
+		{desc: "forced option is denied", content: "nfs:\n  denied: [sec]\n  forced: [sec=sys]\n", expectedErr: `nfs: mount option "sec=sys" is forced or defaulted but denied by "sec"`},
+		{desc: "default option is denied", content: "smb:\n  denied: [noperm]\n  defaults: [noperm]\n", expectedErr: "denied"},
+		{desc: "comma separated options", content: "smb:\n  forced: [\"nosuid,nodev\"]\n", expectedErr: "without comma"},
+		{desc: "empty option", content: "smb:\n  allowed: [\"\"]\n", expectedErr: "without comma"},
+	}
+	for _, test := range tests {
+		_, err := loadMountOptionPolicy(writeMountOptionPolicy(t, test.content))
+		assert.ErrorContains(t, err, test.expectedErr, test.desc)
+	}
+
+	_, err = loadMountOptionPolicy(filepath.Join(t.TempDir(), "not-exist.yaml"))
+	assert.ErrorContains(t, err, "failed to read")
+}
+
+func TestMountOptionPolicyValidate(t *testing.T) {
+	var nilPolicy *MountOptionPolicy
+	assert.NoError(t, nilPolicy.validate(smb, []string{"noperm"}))
+
+	policy, err := loadMountOptionPolicy(writeMountOptionPolicy(t, testMountOptionPolicy))
+	require.NoError(t, err)
+	tests := []struct {
+		desc        string
+		protocol    string
+		mountFlags  []string
+		expectedErr string
+	}{
+		{desc: "allowed smb options", protocol: smb, mountFlags: []string{"dir_mode=0777,file_mode=0777", "sec=krb5"}},
+		{desc: "denied option", protocol: smb, mountFlags: []string{"uid=1000,noperm"}, expectedErr: `mount option "noperm" is denied by "noperm" in the smb mount option policy`},
+		{desc: "denied option with value", protocol: "", mountFlags: []string{"SEC=none"}, expectedErr: `mount option "SEC=none" is denied by "sec=none" in the smb mount option policy`},
+		{desc: "allowed nfs options", protocol: nfs, mountFlags: []string{"nconnect=8", "rsize=1048576,wsize=1048576"}},
+		{desc: "nfs option not allowed", protocol: nfs, mountFlags: []string{"nconnect=8", "sec=none"}, expectedErr: `mount option "sec=none" is not allowed by the nfs mount option policy, allowed options: [nconnect rsize wsize ro]`},
+	}
+	for _, test := range tests {
+		err := policy.validate(test.protocol, test.mountFlags)
+		if test.expectedErr == "" {
+			assert.NoError(t, err, test.desc)
+		} else {
+			assert.EqualError(t, err, test.expectedErr, test.desc)
+		}
+	}
+}
+
+func TestMountOptionPolicyApply(t *testing.T) {
+	var nilPolicy *MountOptionPolicy
+	assert.Equal(t, []string{"a,b"}, nilPolicy.apply(smb, []string{"a,b"}))
+
+	policy, err := loadMountOptionPolicy(writeMountOptionPolicy(t, testMountOptionPolicy))
+	require.NoError(t, err)
+	// forced options override the options with the same name, defaults are only appended if not specified
+	assert.Equal(t,
+		[]string{"dir_mode=0777", "actimeo=30", "mfsymlinks", "nosuid", "nodev", "vers=3.1.1", "cache=strict"},
+		policy.apply(smb, []string{"dir_mode=0777,vers=3.0", "actimeo=30", "mfsymlinks", "nodev"}))
+	assert.Equal(t, []string{"vers=4", "minorversion=1", "sec=sys", "nconnect=8"}, policy.apply(nfs, []string{"vers=4,minorversion=1,sec=sys", "nconnect=8"}))
+	assert.Equal(t, []string{"vers=4", "nconnect=4"}, policy.apply(nfs, []string{"vers=4"}))
+}
+
+func TestMountOptionPolicyEnforcement(t *testing.T) {
+	d := NewFakeDriver()
+	policy, err := loadMountOptionPolicy(writeMountOptionPolicy(t, testMountOptionPolicy))
+	require.NoError(t, err)
+	d.mountOptionPolicy.Store(policy)
+
+	volCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{MountFlags: []string{"dir_mode=0777", "noperm"}}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+	}
+	expectedErr := status.Error(codes.InvalidArgument, `mount option "noperm" is denied by "noperm" in the smb mount option policy`)
+
+	_, err = d.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "policy-vol",
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 5 * 1024 * 1024 * 1024},
+		VolumeCapabilities: []*csi.VolumeCapability{volCap},
+	})
+	assert.Equal(t, expectedErr, err)
+
+	_, err = d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          "vol_1",
+		StagingTargetPath: t.TempDir(),
+		VolumeCapability:  volCap,
+		VolumeContext: map[string]string{
+			shareNameField:  "test_sharename",
+			serverNameField: "test_servername",
+		},
+	})
+	assert.Equal(t, expectedErr, err)
+
+	// inline volume mount options are validated as well
+	volCap.GetMount().MountFlags = nil
+	_, err = d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          "vol_1",
+		StagingTargetPath: t.TempDir(),
+		VolumeCapability:  volCap,
+		VolumeContext: map[string]string{
+			shareNameField:    "test_sharename",
+			serverNameField:   "test_servername",
+			ephemeralField:    trueValue,
+			mountOptionsField: "cache=none,noperm",
+		},
+	})
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestStartMountOptionPolicyReload(t *testing.T) {
+	d := NewFakeDriver()
+	assert.Nil(t, d.getMountOptionPolicy())
+
+	d.mountOptionPolicyFile = writeMountOptionPolicy(t, "smb:\n  denied: [")
+	assert.ErrorContains(t, d.startMountOptionPolicyReload(context.Background()), "failed to parse")
+
+	d.mountOptionPolicyFile = writeMountOptionPolicy(t, testMountOptionPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, d.startMountOptionPolicyReload(ctx))
+	require.NotNil(t, d.getMountOptionPolicy())
+	assert.Equal(t, []string{"noperm", "sec=none"}, d.getMountOptionPolicy().SMB.Denied)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+// stagedMount are the parameters to remount a staged volume, they are only kept in memory since
+// sensitive mount options contain the account key, so volumes staged before the driver restarts are not recovered
+type stagedMount struct {
+	source                string
+	target                string
+	fsType                string
+	protocol              string
+	mountOptions          []string
+	sensitiveMountOptions []string
+	// publishTargets are the bind mount targets of the staging path and their mount options
+	publishTargets map[string][]string
+	lastAttempt    time.Time
+}
+
+// mountRecovery remounts stale staged mounts reported by the mount health monitor and refreshes their bind mounts
+type mountRecovery struct {
+	sync.Mutex
+	// minInterval is the minimum interval between two recoveries of the same volume
+	minInterval time.Duration
+	// limiter limits the recoveries on the node
+	limiter *rate.Limiter
+	// recovery is paused while killSwitchFile exists
+	killSwitchFile string
+	mounts         map[string]*stagedMount
+
+	lazyUnmount func(target string) error
+	mount       func(source, target, fsType string, options, sensitiveOptions []string) error
+	bindMount   func(source, target string, options []string) error
+}
+
+func newMountRecovery(minInterval time.Duration, maxRecoveriesPerMinute int, killSwitchFile string) *mountRecovery {
+	if maxRecoveriesPerMinute <= 0 {
+		maxRecoveriesPerMinute = 1
+	}
+	return &mountRecovery{
+		minInterval:    minInterval,
+		limiter:        rate.NewLimiter(rate.Every(time.Minute/time.Duration(maxRecoveriesPerMinute)), maxRecoveriesPerMinute),
+		killSwitchFile: killSwitchFile,
+		mounts:         map[string]*stagedMount{},
+	}
+}
+
+// addStaged saves the mount parameters of the staged volume
+func (r *mountRecovery) addStaged(volumeID string, mount *stagedMount) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if existing, ok := r.mounts[volumeID]; ok && existing.target == mount.target {
+		mount.publishTargets = existing.publishTargets
+		mount.lastAttempt = existing.lastAttempt
+	}
+	if mount.publishTargets == nil {
+		mount.publishTargets = map[string][]string{}
+	}
+	r.mounts[volumeID] = mount
+}
+
+// removeStaged drops the mount parameters of the volume
+func (r *mountRecovery) removeStaged(volumeID string) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	delete(r.mounts, volumeID)
+}
+
+// addPublishTarget saves the bind mount of the staging path on target
+func (r *mountRecovery) addPublishTarget(volumeID, stagingPath, target string, options []string) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if mount, ok := r.mounts[volumeID]; ok && mount.target == stagingPath {
+		mount.publishTargets[target] = options
+	}
+}
+
+// removePublishTarget drops the bind mount on target
+func (r *mountRecovery) removePublishTarget(volumeID, target string) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if mount, ok := r.mounts[volumeID]; ok {
+		delete(mount.publishTargets, target)
+	}
+}
+
+// get returns a copy of the mount parameters of the volume
+func (r *mountRecovery) get(volumeID string) (stagedMount, bool) {
+	r.Lock()
+	defer r.Unlock()
+	mount, ok := r.mounts[volumeID]
+	if !ok {
+		return stagedMount{}, false
+	}
+	copied := *mount
+	copied.publishTargets = make(map[string][]string, len(mount.publishTargets))
+	for target, options := range mount.publishTargets {
+		copied.publishTargets[target] = options
+	}
+	return copied, true
+}
+
+// paused returns true if the kill switch file exists
+func (r *mountRecovery) paused() bool {
+	if r.killSwitchFile == "" {
+		return false
+	}
+	_, err := os.Stat(r.killSwitchFile)
+	return err == nil
+}
+
+// allow returns true if the volume could be recovered now, the attempt is recorded if allowed
+func (r *mountRecovery) allow(volumeID string) bool {
+	r.Lock()
+	defer r.Unlock()
+	mount, ok := r.mounts[volumeID]
+	if !ok || time.Since(mount.lastAttempt) < r.minInterval {
+		return false
+	}
+	if !r.limiter.Allow() {
+		return false
+	}
+	mount.lastAttempt = time.Now()
+	return true
+}
+
+// remount lazily unmounts the staging path, mounts it again and refreshes the bind mounts on the publish targets
+func (r *mountRecovery) remount(ctx context.Context, mount stagedMount) error {
+	if err := r.lazyUnmount(mount.target); err != nil {
+		return fmt.Errorf("lazy unmount %s failed: %w", mount.target, err)
+	}
+	if err := r.mount(mount.source, mount.target, mount.fsType, mount.mountOptions, mount.sensitiveMountOptions); err != nil {
+		return fmt.Errorf("remount %s on %s failed: %w", mount.source, mount.target, err)
+	}
+	var failedTargets []string
+	for target, options := range mount.publishTargets {
+		if err := r.lazyUnmount(target); err != nil {
+			klog.FromContext(ctx).Error(err, "lazy unmount publish target failed", "target", target)
+		}
+		if err := r.bindMount(mount.target, target, options); err != nil {
+			klog.FromContext(ctx).Error(err, "refresh bind mount failed", "source", mount.target, "target", target)
+			failedTargets = append(failedTargets, target)
+		}
+	}
+	if len(failedTargets) > 0 {
+		return fmt.Errorf("refresh bind mounts on %v failed", failedTargets)
+	}
+	return nil
+}
+
+// recoverStaleMount is called in background by the mount health monitor when the mount of the volume is abnormal,
+// stale and unreachable mounts are remounted with the parameters saved at NodeStageVolume, recoveries of the same
+// volume are serialized by the volume lock and recoveries on the node are limited by the rate limiter
+func (d *Driver) recoverStaleMount(ctx context.Context, volumeID string, condition mountCondition) {
+	r := d.mountRecovery
+	if r == nil || (condition.health != mountStale && condition.health != mountUnreachable) {
+		return
+	}
+	logger := klog.FromContext(ctx)
+	mount, ok := r.get(volumeID)
+	if !ok {
+		logger.V(4).Info("volume could not be recovered since its mount parameters are unknown", "volumeID", volumeID)
+		return
+	}
+	if r.paused() {
+		logger.V(2).Info("stale mount recovery is paused, skip recovering volume", "killSwitchFile", r.killSwitchFile, "volumeID", volumeID)
+		return
+	}
+	lockKey := fmt.Sprintf("%s-%s", volumeID, mount.target)
+	if acquired := d.volumeLocks.TryAcquire(lockKey); !acquired {
+		logger.V(2).Info("volume has an ongoing operation, skip recovering its mount", "volumeID", volumeID)
+		return
+	}
+	defer d.volumeLocks.Release(lockKey)
+	if !r.allow(volumeID) {
+		logger.V(4).Info("stale mount recovery of volume is rate limited", "volumeID", volumeID)
+		return
+	}
+	if mount.protocol == "" {
+		mount.protocol = smb
+	}
+
+	logger.Info("recovering mount of volume", "health", condition.health, "volumeID", volumeID, "target", mount.target, "message", condition.message)
+	if err := r.remount(ctx, mount); err != nil {
+		logger.Error(err, "failed to recover mount of volume", "volumeID", volumeID)
+		csiMetrics.RecordStaleMountRecovery(mount.protocol, false)
+		return
+	}
+	logger.V(2).Info("recovered mount of volume", "volumeID", volumeID, "target", mount.target, "publishTargets", len(mount.publishTargets))
+	csiMetrics.RecordStaleMountRecovery(mount.protocol, true)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMountRecovery records the unmount and mount calls instead of running them
+func fakeMountRecovery(minInterval time.Duration, maxRecoveriesPerMinute int, killSwitchFile string, calls *[]string) *mountRecovery {
+	r := newMountRecovery(minInterval, maxRecoveriesPerMinute, killSwitchFile)
+	r.lazyUnmount = func(target string) error {
+		*calls = append(*calls, "umount -l "+target)
+		return nil
+	}
+	r.mount = func(source, target, _ string, _, _ []string) error {
+		*calls = append(*calls, "mount "+source+" "+target)
+		return nil
+	}
+	r.bindMount = func(source, target string, _ []string) error {
+		*calls = append(*calls, "bind "+source+" "+target)
+		return nil
+	}
+	return r
+}
+
+func TestMountRecoveryTracking(t *testing.T) {
+	var nilRecovery *mountRecovery
+	nilRecovery.addStaged("vol", &stagedMount{target: "/staging"})
+	nilRecovery.addPublishTarget("vol", "/staging", "/pod", nil)
+	nilRecovery.removePublishTarget("vol", "/pod")
+	nilRecovery.removeStaged("vol")
+
+	r := newMountRecovery(time.Minute, 1, "")
+	// publish target of an unknown volume or another staging path is not tracked
+	r.addPublishTarget("vol", "/staging", "/pod1", nil)
+	r.addStaged("vol", &stagedMount{source: "//account/share", target: "/staging"})
+	r.addPublishTarget("vol", "/other", "/pod1", nil)
+	r.addPublishTarget("vol", "/staging", "/pod1", []string{"bind"})
+	r.addPublishTarget("vol", "/staging", "/pod2", []string{"bind", "ro"})
+
+	mount, ok := r.get("vol")
+	require.True(t, ok)
+	assert.Equal(t, map[string][]string{"/pod1": {"bind"}, "/pod2": {"bind", "ro"}}, mount.publishTargets)
+	// get returns a copy
+	delete(mount.publishTargets, "/pod1")
+	mount, _ = r.get("vol")
+	assert.Len(t, mount.publishTargets, 2)
+
+	// publish targets are kept when the volume is staged again on the same path
+	r.addStaged("vol", &stagedMount{source: "//account/share", target: "/staging"})
+	mount, _ = r.get("vol")
+	assert.Len(t, mount.publishTargets, 2)
+
+	r.removePublishTarget("vol", "/pod1")
+	mount, _ = r.get("vol")
+	assert.Equal(t, map[string][]string{"/pod2": {"bind", "ro"}}, mount.publishTargets)
+
+	r.removeStaged("vol")
+	_, ok = r.get("vol")
+	assert.False(t, ok)
+}
+
+func TestMountRecoveryAllow(t *testing.T) {
+	r := newMountRecovery(time.Hour, 2, "")
+	assert.False(t, r.allow("vol"), "unknown volume")
+
+	r.addStaged("vol1", &stagedMount{target: "/staging1"})
+	r.addStaged("vol2", &stagedMount{target: "/staging2"})
+	r.addStaged("vol3", &stagedMount{target: "/staging3"})
+	assert.True(t, r.allow("vol1"))
+	assert.False(t, r.allow("vol1"), "min interval of the volume")
+	assert.True(t, r.allow("vol2"))
+	assert.False(t, r.allow("vol3"), "recoveries per minute on the node")
+
+	killSwitchFile := filepath.Join(t.TempDir(), "pause")
+	r = newMountRecovery(0, 1, killSwitchFile)
+	assert.False(t, r.paused())
+	require.NoError(t, os.WriteFile(killSwitchFile, nil, 0600))
+	assert.True(t, r.paused())
+}
+
+func TestMountRecoveryRemount(t *testing.T) {
+	var calls []string
+	r := fakeMountRecovery(0, 1, "", &calls)
+	mount := stagedMount{source: "//account/share", target: "/staging", publishTargets: map[string][]string{"/pod": nil}}
+	require.NoError(t, r.remount(context.Background(), mount))
+	assert.Equal(t, []string{"umount -l /staging", "mount //account/share /staging", "umount -l /pod", "bind /staging /pod"}, calls)
+
+	// bind mounts are not refreshed if the staging path is not remounted
+	calls = nil
+	r.mount = func(string, string, string, []string, []string) error { return errors.New("host is down") }
+	assert.ErrorContains(t, r.remount(context.Background(), mount), "host is down")
+	assert.Equal(t, []string{"umount -l /staging"}, calls)
+
+	r = fakeMountRecovery(0, 1, "", &calls)
+	r.bindMount = func(string, string, []string) error { return errors.New("bind failed") }
+	assert.ErrorContains(t, r.remount(context.Background(), mount), "/pod")
+}
+
+func TestRecoverStaleMount(t *testing.T) {
+	var calls []string
+	d := NewFakeDriver()
+	killSwitchFile := filepath.Join(t.TempDir(), "pause")
+	d.mountRecovery = fakeMountRecovery(time.Hour, 10, killSwitchFile, &calls)
+	stale := mountCondition{health: mountStale, message: "mount /staging is stale"}
+
+	// nothing is done without the mount parameters
+	d.recoverStaleMount(context.Background(), "vol", stale)
+	assert.Empty(t, calls)
+
+	d.mountRecovery.addStaged("vol", &stagedMount{source: "//account/share", target: "/staging"})
+	// read-only mount is not recovered
+	d.recoverStaleMount(context.Background(), "vol", mountCondition{health: mountReadOnly})
+	assert.Empty(t, calls)
+
+	// recovery is paused by the kill switch
+	require.NoError(t, os.WriteFile(killSwitchFile, nil, 0600))
+	d.recoverStaleMount(context.Background(), "vol", stale)
+	assert.Empty(t, calls)
+	require.NoError(t, os.Remove(killSwitchFile))
+
+	// ongoing operation on the volume
+	lockKey := "vol-/staging"
+	require.True(t, d.volumeLocks.TryAcquire(lockKey))
+	d.recoverStaleMount(context.Background(), "vol", stale)
+	assert.Empty(t, calls)
+	d.volumeLocks.Release(lockKey)
+
+	d.recoverStaleMount(context.Background(), "vol", stale)
+	assert.Equal(t, []string{"umount -l /staging", "mount //account/share /staging"}, calls)
+
+	// the volume is recovered at most once in min interval
+	calls = nil
+	d.recoverStaleMount(context.Background(), "vol", stale)
+	assert.Empty(t, calls)
+
+	d.mountRecovery.addStaged("vol2", &stagedMount{source: "//account/share2", target: "/staging2", protocol: nfs})
+	d.recoverStaleMount(context.Background(), "vol2", mountCondition{health: mountUnreachable, message: syscall.EHOSTDOWN.Error()})
+	assert.Equal(t, []string{"umount -l /staging2", "mount //account/share2 /staging2"}, calls)
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -33,14 +34,15 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/volume/util"
 
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	mount_azurefile "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile-proxy/pb"
 	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 	volumehelper "sigs.k8s.io/azurefile-csi-driver/pkg/util"
 	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
 	"sigs.k8s.io/cloud-provider-azure/pkg/metrics"
@@ -61,9 +63,10 @@ func NewMountClient(cc *grpc.ClientConn) *MountClient {
 
 // NodePublishVolume mount the volume from staging to target path
 func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (resp *csi.NodePublishVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	csiMC := csiMetrics.NewCSIMetricContext("node_publish_volume")
 	defer func() {
-		csiMC.Observe(returnedErr == nil)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	volCap := req.GetVolumeCapability()
@@ -79,12 +82,15 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 	}
 
 	volumeID := req.GetVolumeId()
+	if volCap.GetBlock() != nil {
+		return d.publishBlockVolume(ctx, req)
+	}
 
 	mountPermissions := d.mountPermissions
 	context := req.GetVolumeContext()
 	if context != nil {
 		if getValueInMap(context, serviceAccountTokenField) != "" && shouldUseServiceAccountToken(context) {
-			klog.V(2).Infof("NodePublishVolume: volume(%s) mount on %s with service account token, clientID: %s, mountWithWIToken: %s", volumeID, target, getValueInMap(context, clientIDField), getValueInMap(context, mountWithWITokenField))
+			logger.V(2).Info("NodePublishVolume: mount with service account token", "target", target, "clientID", getValueInMap(context, clientIDField), "mountWithWIToken", getValueInMap(context, mountWithWITokenField))
 			_, err := d.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
 				StagingTargetPath: target,
 				VolumeContext:     context,
@@ -102,7 +108,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 				setKeyValueInMap(context, getAccountKeyFromSecretField, trueValue)
 				setKeyValueInMap(context, storageAccountField, "")
 			}
-			klog.V(2).Infof("NodePublishVolume: ephemeral volume(%s) mount on %s", volumeID, target)
+			logger.V(2).Info("NodePublishVolume: mount ephemeral volume", "target", target)
 			_, err := d.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
 				StagingTargetPath: target,
 				VolumeContext:     context,
@@ -122,7 +128,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 		if d.enableKataCCMount && context[podNameField] != "" && context[podNamespaceField] != "" {
 			confidentialContainerLabel := getValueInMap(context, confidentialContainerLabelField)
 			if !d.isKataNode && confidentialContainerLabel != "" {
-				klog.V(2).Infof("NodePublishVolume: checking if node %s is a kata node with confidential container label %s", d.NodeID, confidentialContainerLabel)
+				logger.V(2).Info("NodePublishVolume: checking if node is a kata node with confidential container label", "node", d.NodeID, "label", confidentialContainerLabel)
 				d.isKataNode = isKataNode(ctx, d.NodeID, confidentialContainerLabel, d.kubeClient)
 			}
 
@@ -131,7 +137,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 				if err != nil {
 					return nil, status.Errorf(codes.Internal, "failed to get runtime class for pod %s/%s: %v", context[podNamespaceField], context[podNameField], err)
 				}
-				klog.V(2).Infof("NodePublishVolume: volume(%s) mount on %s with runtimeClass %s", volumeID, target, runtimeClass)
+				logger.V(2).Info("NodePublishVolume: mount with runtimeClass", "target", target, "runtimeClass", runtimeClass)
 				runtimeClassHandler := getValueInMap(context, runtimeClassHandlerField)
 				if runtimeClassHandler == "" {
 					runtimeClassHandler = defaultRuntimeClassHandler
@@ -141,7 +147,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 					return nil, status.Errorf(codes.Internal, "failed to check if runtime class %s is confidential: %v", runtimeClass, err)
 				}
 				if isConfidentialRuntimeClass {
-					klog.V(2).Infof("NodePublishVolume for volume(%s) where runtimeClass is %s", volumeID, runtimeClass)
+					logger.V(2).Info("NodePublishVolume: mount with confidential runtimeClass", "runtimeClass", runtimeClass)
 					source := req.GetStagingTargetPath()
 					if len(source) == 0 {
 						return nil, status.Error(codes.InvalidArgument, "Staging target not provided")
@@ -161,7 +167,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 					if err = d.directVolume.Add(target, string(data)); err != nil {
 						return nil, status.Errorf(codes.Internal, "failed to save mount info %s: %v", target, err)
 					}
-					klog.V(2).Infof("NodePublishVolume: direct volume mount %s at %s successfully", source, target)
+					logger.V(2).Info("NodePublishVolume: direct volume mount successfully", "source", source, "target", target)
 					return &csi.NodePublishVolumeResponse{}, nil
 				}
 			}
@@ -183,7 +189,9 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 		return nil, status.Errorf(codes.Internal, "Could not mount target %s: %v", target, err)
 	}
 	if mnt {
-		klog.V(2).Infof("NodePublishVolume: %s is already mounted", target)
+		logger.V(2).Info("NodePublishVolume: target is already mounted", "target", target)
+		d.mountRecovery.addPublishTarget(volumeID, source, target, mountOptions)
+		d.monitorStagedMount(volumeID, source, volCap)
 		return &csi.NodePublishVolumeResponse{}, nil
 	}
 
@@ -191,23 +199,36 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 		return nil, status.Errorf(codes.Internal, "prepare publish failed for %s with error: %v", target, err)
 	}
 
-	klog.V(2).Infof("NodePublishVolume: mounting %s at %s with mountOptions: %v", source, target, mountOptions)
+	logger.V(2).Info("NodePublishVolume: mounting", "source", source, "target", target, "mountOptions", mountOptions)
 	if err := d.mounter.Mount(source, target, "", mountOptions); err != nil {
 		if removeErr := os.Remove(target); removeErr != nil {
 			return nil, status.Errorf(codes.Internal, "Could not remove mount target %s: %v", target, removeErr)
 		}
 		return nil, status.Errorf(codes.Internal, "Could not mount %s at %s: %v", source, target, err)
 	}
-	klog.V(2).Infof("NodePublishVolume: mount %s at %s successfully", source, target)
+	logger.V(2).Info("NodePublishVolume: mount successfully", "source", source, "target", target)
+	d.mountRecovery.addPublishTarget(volumeID, source, target, mountOptions)
+	d.monitorStagedMount(volumeID, source, volCap)
 
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// monitorStagedMount starts monitoring the staging mount of a published volume if it's not monitored yet,
+// i.e. the volume was staged before the driver restarted, the mount monitored since NodeStageVolume is kept as is
+func (d *Driver) monitorStagedMount(volumeID, stagingPath string, volCap *csi.VolumeCapability) {
+	if d.mountHealthMonitor.isMonitored(volumeID) {
+		return
+	}
+	readOnly := isReadOnlyFromCapability(volCap) || slices.Contains(volCap.GetMount().GetMountFlags(), "ro")
+	d.mountHealthMonitor.add(volumeID, stagingPath, readOnly)
+}
+
 // NodeUnpublishVolume unmount the volume from the target path
-func (d *Driver) NodeUnpublishVolume(_ context.Context, req *csi.NodeUnpublishVolumeRequest) (resp *csi.NodeUnpublishVolumeResponse, returnedErr error) {
+func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (resp *csi.NodeUnpublishVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	csiMC := csiMetrics.NewCSIMetricContext("node_unpublish_volume")
 	defer func() {
-		csiMC.Observe(returnedErr == nil)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	if len(req.GetVolumeId()) == 0 {
@@ -217,36 +238,38 @@ func (d *Driver) NodeUnpublishVolume(_ context.Context, req *csi.NodeUnpublishVo
 		return nil, status.Error(codes.InvalidArgument, "Target path missing in request")
 	}
 	targetPath := req.GetTargetPath()
-	volumeID := req.GetVolumeId()
 
-	klog.V(2).Infof("NodeUnpublishVolume: unmounting volume %s on %s", volumeID, targetPath)
+	d.mountRecovery.removePublishTarget(req.GetVolumeId(), targetPath)
+	d.blockVolumeTargets.Delete(targetPath)
+	logger.V(2).Info("NodeUnpublishVolume: unmounting volume", "target", targetPath)
 	if err := CleanupMountPoint(d.mounter, targetPath, true /*extensiveMountPointCheck*/); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to unmount target %s: %v", targetPath, err)
 	}
 
 	if d.enableKataCCMount && d.isKataNode {
-		klog.V(2).Infof("NodeUnpublishVolume: remove direct volume mount info %s from %s", volumeID, targetPath)
+		logger.V(2).Info("NodeUnpublishVolume: remove direct volume mount info", "target", targetPath)
 		// Remove deletes the direct volume path including all the files inside it.
 		// if there is no kata-cc mountinfo present on this path, it will return nil.
 		if err := d.directVolume.Remove(targetPath); err != nil {
 			if strings.Contains(err.Error(), "file name too long") {
-				klog.Warningf("NodeUnpublishVolume: direct volume mount info %s not found on %s, ignoring error", volumeID, targetPath)
+				logger.Info("NodeUnpublishVolume: direct volume mount info not found, ignoring error", "target", targetPath)
 				return &csi.NodeUnpublishVolumeResponse{}, nil
 			}
 			return nil, status.Errorf(codes.Internal, "failed to direct volume remove mount info %s: %v", targetPath, err)
 		}
 	}
-	klog.V(2).Infof("NodeUnpublishVolume: unmount volume %s on %s successfully", volumeID, targetPath)
+	logger.V(2).Info("NodeUnpublishVolume: unmount volume successfully", "target", targetPath)
 
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
 // NodeStageVolume mount the volume to a staging path
 func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (resp *csi.NodeStageVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "node_stage_volume"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	defer func() {
-		csiMC.Observe(returnedErr == nil)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	if len(req.GetVolumeId()) == 0 {
@@ -265,25 +288,39 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	context := req.GetVolumeContext()
 
 	if getValueInMap(context, serviceAccountTokenField) == "" && shouldUseServiceAccountToken(context) {
-		klog.V(2).Infof("Skip NodeStageVolume for volume(%s) since clientID(%s) or mountWithWIToken(%s) is provided but service account token is empty", volumeID, getValueInMap(context, clientIDField), getValueInMap(context, mountWithWITokenField))
+		logger.V(2).Info("skip NodeStageVolume since clientID or mountWithWIToken is provided but service account token is empty", "clientID", getValueInMap(context, clientIDField), "mountWithWIToken", getValueInMap(context, mountWithWITokenField))
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
 	mountFlags := req.GetVolumeCapability().GetMount().GetMountFlags()
 	volumeMountGroup := req.GetVolumeCapability().GetMount().GetVolumeMountGroup()
 	gidPresent := checkGidPresentInMountFlags(mountFlags)
+	isBlock := volumeCapability.GetBlock() != nil
 
 	if isReadOnlyFromCapability(volumeCapability) {
 		mountFlags = util.JoinMountOptions(mountFlags, []string{"ro"})
-		klog.V(2).Infof("CSI volume is read-only, mounting with extra option ro")
+		logger.V(2).Info("CSI volume is read-only, mounting with extra option ro")
 	}
+	defer func() {
+		// there is no file system mounted on the staging path of a block volume
+		if returnedErr == nil && !isBlock {
+			d.mountHealthMonitor.add(volumeID, targetPath, slices.Contains(mountFlags, "ro"))
+			d.volumeStatsCollector.add(volumeID, targetPath)
+		}
+	}()
 
 	mc := metrics.NewMetricContext(azureFileCSIDriverName, requestName, d.cloud.ResourceGroup, "", d.Name)
 	defer func() {
 		mc.ObserveOperationWithResult(returnedErr == nil, VolumeID, volumeID)
 	}()
+	stageTimer := newStagePhaseTimer()
+	defer func() {
+		stageTimer.report(logger, returnedErr)
+	}()
 
+	phaseStart := time.Now()
 	_, accountName, accountKey, fileShareName, diskName, _, tenantID, tokenFilePath, err := d.GetAccountInfo(ctx, volumeID, req.GetSecrets(), context)
+	stageTimer.observe(stagePhaseCredentials, phaseStart)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("GetAccountInfo(%s) failed with error: %v", volumeID, err))
 	}
@@ -294,6 +331,7 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	// since it's ext4 by default on Linux
 	var fsType, server, protocol, ephemeralVolMountOptions, storageEndpointSuffix, folderName, clientID string
 	var ephemeralVol, createFolderIfNotExist, encryptInTransit, mountWithManagedIdentity, mountWithWIToken bool
+	var folderQuota int64
 	fileShareNameReplaceMap := map[string]string{}
 
 	mountPermissions := d.mountPermissions
@@ -356,6 +394,10 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 			}
 		case clientIDField:
 			clientID = v
+		case folderQuotaField:
+			if folderQuota, err = parseFolderQuota(v); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
 		}
 	}
 
@@ -370,6 +412,17 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	if !isSupportedProtocol(protocol) {
 		return nil, status.Errorf(codes.InvalidArgument, "protocol(%s) is not supported, supported protocol list: %v", protocol, supportedProtocolList)
 	}
+	stageTimer.protocol = protocol
+
+	if !isDiskFsType(fsType) {
+		userMountOptions := req.GetVolumeCapability().GetMount().GetMountFlags()
+		if ephemeralVol && ephemeralVolMountOptions != "" {
+			userMountOptions = util.JoinMountOptions(userMountOptions, strings.Split(ephemeralVolMountOptions, ","))
+		}
+		if err := d.getMountOptionPolicy().validate(protocol, userMountOptions); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
 
 	if !isSupportedFSGroupChangePolicy(fsGroupChangePolicy) {
 		return nil, status.Errorf(codes.InvalidArgument, "fsGroupChangePolicy(%s) is not supported, supported fsGroupChangePolicy list: %v", fsGroupChangePolicy, supportedFSGroupChangePolicyList)
@@ -403,8 +456,11 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	}
 	if folderName != "" {
 		if createFolderIfNotExist {
-			if err := d.createFolderIfNotExists(ctx, accountName, accountKey, fileShareName, folderName, storageEndpointSuffix); err != nil {
-				klog.Warningf("Failed to create folder %s in share %s: %v", folderName, fileShareName, err)
+			phaseStart := time.Now()
+			err := d.createFolderIfNotExists(ctx, accountName, accountKey, fileShareName, folderName, storageEndpointSuffix)
+			stageTimer.observe(stagePhaseCreateFolder, phaseStart)
+			if err != nil {
+				logger.Error(err, "failed to create folder in share", "folder", folderName, "share", fileShareName)
 				// Continue with mounting - folder might already exist or be created by other means
 			}
 		}
@@ -417,18 +473,35 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 		cifsMountFlags = append(cifsMountFlags, fmt.Sprintf("gid=%s", volumeMountGroup))
 	}
 	isDiskMount := isDiskFsType(fsType)
+	if isBlock && !isDiskMount {
+		return nil, status.Errorf(codes.InvalidArgument, "block volume(%s) is only supported for VHD disk", volumeID)
+	}
 	if isDiskMount {
 		if !strings.HasSuffix(diskName, vhdSuffix) {
 			return nil, status.Errorf(codes.Internal, "diskname could not be empty, targetPath: %s", targetPath)
 		}
 		cifsMountFlags = []string{"dir_mode=0777,file_mode=0777,cache=strict,actimeo=30", "nostrictsync"}
 		cifsMountPath = filepath.Join(filepath.Dir(targetPath), proxyMount)
+		if isBlock {
+			cifsMountPath = getBlockProxyMountPath(targetPath)
+		}
 	}
 
 	var mountOptions, sensitiveMountOptions []string
+	mountFsType := cifs
 	if protocol == nfs {
 		mountOptions = util.JoinMountOptions(mountFlags, []string{"vers=4,minorversion=1,sec=sys"})
 		mountOptions = appendDefaultNfsMountOptions(mountOptions, d.appendNoResvPortOption, d.appendActimeoOption)
+		mountOptions = d.getMountOptionPolicy().apply(protocol, mountOptions)
+		mountFsType = nfs
+		if newOptions, exists := removeOptionIfExists(mountOptions, encryptInTransitField); exists {
+			logger.V(2).Info("encryptInTransit is set in mountOptions, enabling encryptInTransit", "mountOptions", mountOptions)
+			encryptInTransit = true
+			mountOptions = newOptions
+		}
+		if encryptInTransit {
+			mountFsType = aznfs
+		}
 	} else {
 		if (mountWithManagedIdentity || mountWithWIToken) && clientID == "" {
 			clientID = d.cloud.Config.AzureAuthConfig.UserAssignedIdentityID
@@ -436,10 +509,10 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 
 		if mountWithManagedIdentity && runtime.GOOS != "windows" {
 			sensitiveMountOptions = []string{"sec=krb5,cruid=0,upcall_target=mount", fmt.Sprintf("username=%s", clientID)}
-			klog.V(2).Infof("using managed identity %s for volume %s with mount options: %v", clientID, volumeID, sensitiveMountOptions)
+			logger.V(2).Info("using managed identity", "clientID", clientID, "mountOptions", sensitiveMountOptions)
 		} else if mountWithWIToken && runtime.GOOS != "windows" {
 			sensitiveMountOptions = []string{"sec=krb5,cruid=0,upcall_target=mount"}
-			klog.V(2).Infof("using workload identity token for volume %s with mount options: %v", volumeID, sensitiveMountOptions)
+			logger.V(2).Info("using workload identity token", "mountOptions", sensitiveMountOptions)
 			if tokenFilePath != "" {
 				// always set credential cache when token file is provided even mount does not happen
 				if out, err := setCredentialCache(server, clientID, tenantID, tokenFilePath); err != nil {
@@ -467,30 +540,19 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 				cifsMountFlags = util.JoinMountOptions(cifsMountFlags, strings.Split(ephemeralVolMountOptions, ","))
 			}
 			mountOptions = appendDefaultCifsMountOptions(cifsMountFlags, d.appendNoShareSockOption, d.appendClosetimeoOption)
+			mountOptions = d.getMountOptionPolicy().apply(protocol, mountOptions)
 		}
 	}
 
-	klog.V(2).Infof("cifsMountPath(%v) fstype(%v) volumeID(%v) mountflags(%v) mountOptions(%v) volumeMountGroup(%s)", cifsMountPath, fsType, volumeID, mountFlags, mountOptions, volumeMountGroup)
+	logger.V(2).Info("NodeStageVolume: mount parameters", "cifsMountPath", cifsMountPath, "fsType", fsType, "mountFlags", mountFlags, "mountOptions", mountOptions, "volumeMountGroup", volumeMountGroup)
 
 	isDirMounted, err := d.ensureMountPoint(cifsMountPath, os.FileMode(mountPermissions))
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Could not mount target %s: %v", cifsMountPath, err)
 	}
 	if isDirMounted {
-		klog.V(2).Infof("NodeStageVolume: volume %s is already mounted on %s", volumeID, targetPath)
+		logger.V(2).Info("NodeStageVolume: volume is already mounted", "target", targetPath)
 	} else {
-		mountFsType := cifs
-		if protocol == nfs {
-			mountFsType = nfs
-			if newOptions, exists := removeOptionIfExists(mountOptions, encryptInTransitField); exists {
-				klog.V(2).Infof("encryptInTransit is set in mountOptions(%v), enabling encryptInTransit", mountOptions)
-				encryptInTransit = true
-				mountOptions = newOptions
-			}
-			if encryptInTransit {
-				mountFsType = aznfs
-			}
-		}
 		if mountFsType == aznfs && !d.enableAzurefileProxy {
 			return nil, status.Error(codes.InvalidArgument, "encryptInTransit is only available when azurefile-proxy is enabled")
 		}
@@ -499,11 +561,14 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 			return nil, status.Errorf(codes.Internal, "prepare stage path failed for %s with error: %v", cifsMountPath, err)
 		}
 		if mountFsType == aznfs {
-			klog.V(2).Infof("encryptInTransit is enabled, mount by azurefile-proxy")
-			if err := d.mountWithProxy(ctx, source, cifsMountPath, mountFsType, mountOptions, sensitiveMountOptions); err != nil {
+			logger.V(2).Info("encryptInTransit is enabled, mount by azurefile-proxy")
+			phaseStart := time.Now()
+			err := d.mountWithProxy(ctx, source, cifsMountPath, mountFsType, mountOptions, sensitiveMountOptions)
+			stageTimer.observe(stagePhaseProxyMount, phaseStart)
+			if err != nil {
 				return nil, status.Errorf(codes.Internal, "mount with proxy failed for %s with error: %v", cifsMountPath, err)
 			}
-			klog.V(2).Infof("mount with proxy succeeded for %s", cifsMountPath)
+			logger.V(2).Info("mount with proxy succeeded", "target", cifsMountPath)
 		} else {
 			execFunc := func() error {
 				if mountWithManagedIdentity && protocol != nfs && runtime.GOOS != "windows" {
@@ -516,71 +581,147 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 			timeoutFunc := func() error {
 				return fmt.Errorf("mount operation timed out after %d seconds: source=%s, target=%s", MountTimeoutInSec, source, cifsMountPath)
 			}
-			if err := volumehelper.WaitUntilTimeout(MountTimeoutInSec*time.Second, execFunc, timeoutFunc); err != nil {
+			_, span := tracing.StartSpan(ctx, "SMBMount", attribute.String("source", source), attribute.String("fsType", mountFsType))
+			phaseStart := time.Now()
+			err := volumehelper.WaitUntilTimeout(MountTimeoutInSec*time.Second, execFunc, timeoutFunc)
+			stageTimer.observe(stagePhaseMount, phaseStart)
+			tracing.EndSpan(span, err)
+			if err != nil {
 				var helpLinkMsg string
 				if d.appendMountErrorHelpLink {
 					helpLinkMsg = "\nPlease refer to http://aka.ms/filemounterror for possible causes and solutions for mount errors."
 				}
 				return nil, status.Error(codes.Internal, fmt.Sprintf("volume(%s) mount %s on %s failed with %v%s", volumeID, source, cifsMountPath, err, helpLinkMsg))
 			}
+			if !isDiskMount {
+				d.mountRecovery.addStaged(volumeID, &stagedMount{
+					source:                source,
+					target:                cifsMountPath,
+					fsType:                mountFsType,
+					protocol:              protocol,
+					mountOptions:          mountOptions,
+					sensitiveMountOptions: sensitiveMountOptions,
+				})
+			}
 		}
 		if protocol == nfs {
 			if performChmodOp {
-				if err := chmodIfPermissionMismatch(targetPath, os.FileMode(mountPermissions)); err != nil {
+				phaseStart := time.Now()
+				err := chmodIfPermissionMismatch(targetPath, os.FileMode(mountPermissions))
+				stageTimer.observe(stagePhaseChmod, phaseStart)
+				if err != nil {
 					return nil, status.Error(codes.Internal, err.Error())
 				}
 			} else {
-				klog.V(2).Infof("skip chmod on targetPath(%s) since mountPermissions is set as 0", targetPath)
+				logger.V(2).Info("skip chmod on targetPath since mountPermissions is set as 0", "target", targetPath)
 			}
 		}
-		klog.V(2).Infof("volume(%s) mount %s on %s succeeded", volumeID, source, cifsMountPath)
+		logger.V(2).Info("NodeStageVolume: mount succeeded", "source", source, "target", cifsMountPath)
+	}
+
+	d.volumeIOStats.add(volumeID, ioStatsVolume{
+		namespace:   getValueInMap(context, pvcNamespaceKey),
+		pvc:         getValueInMap(context, pvcNameKey),
+		protocol:    protocol,
+		source:      source,
+		stagingPath: cifsMountPath,
+	})
+	// the soft quota is only enforced on folder-based volumes, a file share has its own quota
+	if folderQuota > 0 && !isDiskMount && (folderName != "" || strings.Contains(fileShareName, "/")) && d.folderUsageTracker != nil {
+		info := folderQuotaInfo{QuotaBytes: folderQuota, PVCName: getValueInMap(context, pvcNameKey), PVCNamespace: getValueInMap(context, pvcNamespaceKey)}
+		d.folderUsageTracker.add(volumeID, cifsMountPath, info.QuotaBytes, info.PVCName, info.PVCNamespace)
+		if err := saveFolderQuota(cifsMountPath, info); err != nil {
+			logger.Error(err, "failed to save folder quota, the usage is not tracked after the driver restarts", "target", cifsMountPath)
+		}
 	}
 
-	// If runtime OS is not windows and protocol is not nfs, save mountInfo.json
+	// If runtime OS is not windows, save mountInfo.json
 	if d.enableKataCCMount && d.isKataNode {
-		if runtime.GOOS != "windows" && protocol != nfs {
+		if runtime.GOOS != "windows" {
 			// Check if mountInfo.json is already present at the targetPath
 			isMountInfoPresent, err := d.directVolume.VolumeMountInfo(cifsMountPath)
 			if err != nil && !os.IsNotExist(err) {
 				return nil, status.Errorf(codes.Internal, "Could not save direct volume mount info %s: %v", cifsMountPath, err)
 			}
 			if isMountInfoPresent != nil {
-				klog.V(2).Infof("NodeStageVolume: mount info for volume %s is already present on %s", volumeID, targetPath)
+				logger.V(2).Info("NodeStageVolume: mount info is already present", "target", targetPath)
 			} else {
-				mountFsType := cifs
+				_, span := tracing.StartSpan(ctx, "ResolveIPAddr", attribute.String("server", server))
+				phaseStart := time.Now()
 				ipAddr, err := d.resolver.ResolveIPAddr("ip", server)
+				stageTimer.observe(stagePhaseResolveIP, phaseStart)
+				tracing.EndSpan(span, err)
 				if err != nil {
-					klog.V(2).ErrorS(err, "Couldn't resolve IP")
+					logger.Error(err, "could not resolve IP", "server", server)
 					return nil, err
 				}
+				// the guest mounts the share with the server IP resolved on the host, aznfs is mounted by the aznfs mount helper in the guest
 				mountOptions = append(mountOptions, "addr="+ipAddr.IP.String())
+				metadata, err := getKataMountInfoMetadata(d.kataCCMountInfoPublicKey, source, sensitiveMountOptions)
+				if err != nil {
+					return nil, status.Errorf(codes.Internal, "failed to encrypt sensitive mount options of %s: %v", cifsMountPath, err)
+				}
 				mountInfo := volume.MountInfo{
 					VolumeType: "azurefile",
 					Device:     source,
 					FsType:     mountFsType,
-					Metadata: map[string]string{
-						"sensitiveMountOptions": strings.Join(sensitiveMountOptions, ","),
-					},
-					Options: mountOptions,
+					Metadata:   metadata,
+					Options:    mountOptions,
 				}
 				data, _ := json.Marshal(mountInfo)
 				if err := d.directVolume.Add(cifsMountPath, string(data)); err != nil {
 					return nil, status.Errorf(codes.Internal, "Could not save direct volume mount info %s: %v", cifsMountPath, err)
 				}
-				klog.V(2).Infof("NodeStageVolume: mount info for volume %s saved on %s", volumeID, targetPath)
+				logger.V(2).Info("NodeStageVolume: mount info saved", "target", targetPath, "fsType", mountFsType)
 			}
 		} else {
-			klog.V(2).Infof("NodeStageVolume: skip saving mount info for volume %s on %s, runtime OS: %s, protocol: %s", volumeID, targetPath, runtime.GOOS, protocol)
+			logger.V(2).Info("NodeStageVolume: skip saving mount info", "target", targetPath, "os", runtime.GOOS)
 		}
 	}
 
 	if isDiskMount {
+		if d.vhdLeaseKeeper != nil {
+			if accountKey == "" {
+				logger.Info("skip acquiring lease of disk since account key is not available", "disk", diskName)
+			} else {
+				l := &vhdLease{accountName: accountName, accountKey: accountKey, shareName: fileShareName, diskName: diskName, storageEndpointSuffix: storageEndpointSuffix}
+				if err := d.vhdLeaseKeeper.acquire(ctx, volumeID, l); err != nil {
+					if _, ok := status.FromError(err); ok {
+						return nil, err
+					}
+					return nil, status.Error(codes.Internal, err.Error())
+				}
+				defer func() {
+					// the disk can be staged on another node if it's not mounted on this node
+					if returnedErr != nil {
+						if err := d.vhdLeaseKeeper.release(ctx, volumeID, l); err != nil {
+							logger.Error(err, "failed to release lease of disk", "disk", diskName)
+						}
+					}
+				}()
+			}
+		}
+		if isBlock {
+			diskPath := filepath.Join(cifsMountPath, diskName)
+			// AttachFileDevice reuses the loop device if the disk is already attached
+			devicePath, err := d.blockVolumePathHandler.AttachFileDevice(diskPath)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "could not attach %s to a loop device: %v", diskPath, err)
+			}
+			logger.V(2).Info("NodeStageVolume: attach disk to loop device successfully", "disk", diskPath, "device", devicePath)
+			return &csi.NodeStageVolumeResponse{}, nil
+		}
+		defer func() {
+			if returnedErr == nil {
+				d.vhdTrimmer.add(volumeID, targetPath)
+			}
+		}()
 		mnt, err := d.ensureMountPoint(targetPath, os.FileMode(mountPermissions))
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "mount %s on target %s failed with %v", volumeID, targetPath, err)
 		}
 		if mnt {
-			klog.V(2).Infof("NodeStageVolume: volume %s is already mounted on %s", volumeID, targetPath)
+			logger.V(2).Info("NodeStageVolume: volume is already mounted", "target", targetPath)
 			return &csi.NodeStageVolumeResponse{}, nil
 		}
 
@@ -590,19 +731,32 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 			// following mount options are only valid for ext2/ext3/ext4 file systems
 			options = util.JoinMountOptions(options, []string{"noatime", "barrier=1", "errors=remount-ro"})
 		}
+		if d.vhdDiscardMode == vhdDiscardModeOnline {
+			options = util.JoinMountOptions(options, []string{"discard"})
+		}
 
-		klog.V(2).Infof("NodeStageVolume: volume %s formatting %s and mounting at %s with mount options(%s)", volumeID, targetPath, diskPath, options)
+		logger.V(2).Info("NodeStageVolume: formatting and mounting", "target", targetPath, "disk", diskPath, "mountOptions", options)
 		// FormatAndMount will format only if needed
-		if err := d.mounter.FormatAndMount(diskPath, targetPath, fsType, options); err != nil {
+		_, span := tracing.StartSpan(ctx, "FormatAndMount", attribute.String("fsType", fsType))
+		phaseStart := time.Now()
+		err = d.mounter.FormatAndMount(diskPath, targetPath, fsType, options)
+		stageTimer.observe(stagePhaseFormatAndMount, phaseStart)
+		tracing.EndSpan(span, err)
+		if err != nil {
 			return nil, status.Error(codes.Internal, fmt.Sprintf("could not format %s and mount it at %s", targetPath, diskPath))
 		}
-		klog.V(2).Infof("NodeStageVolume: volume %s format %s and mounting at %s successfully", volumeID, targetPath, diskPath)
+		logger.V(2).Info("NodeStageVolume: format and mount successfully", "target", targetPath, "disk", diskPath)
 	}
 
 	if protocol == nfs || isDiskMount {
 		if volumeMountGroup != "" && fsGroupChangePolicy != FSGroupChangeNone {
-			klog.V(2).Infof("set gid of volume(%s) as %s using fsGroupChangePolicy(%s)", volumeID, volumeMountGroup, fsGroupChangePolicy)
-			if err := SetVolumeOwnership(cifsMountPath, volumeMountGroup, fsGroupChangePolicy); err != nil {
+			logger.V(2).Info("set gid of volume", "gid", volumeMountGroup, "fsGroupChangePolicy", fsGroupChangePolicy)
+			_, span := tracing.StartSpan(ctx, "SetVolumeOwnership", attribute.String("fsGroupChangePolicy", fsGroupChangePolicy))
+			phaseStart := time.Now()
+			err := SetVolumeOwnership(cifsMountPath, volumeMountGroup, fsGroupChangePolicy)
+			stageTimer.observe(stagePhaseSetVolumeOwnership, phaseStart)
+			tracing.EndSpan(span, err)
+			if err != nil {
 				return nil, status.Error(codes.Internal, fmt.Sprintf("SetVolumeOwnership with volume(%s) on %s failed with %v", volumeID, cifsMountPath, err))
 			}
 		}
@@ -612,12 +766,13 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 }
 
 // NodeUnstageVolume unmount the volume from the staging path
-func (d *Driver) NodeUnstageVolume(_ context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (resp *csi.NodeUnstageVolumeResponse, returnedErr error) {
+	logger := klog.FromContext(ctx)
 	requestName := "node_unstage_volume"
 	csiMC := csiMetrics.NewCSIMetricContext(requestName)
 	isOperationSucceeded := false
 	defer func() {
-		csiMC.Observe(isOperationSucceeded)
+		csiMC.ObserveWithError(returnedErr)
 	}()
 
 	volumeID := req.GetVolumeId()
@@ -640,27 +795,48 @@ func (d *Driver) NodeUnstageVolume(_ context.Context, req *csi.NodeUnstageVolume
 		mc.ObserveOperationWithResult(isOperationSucceeded, VolumeID, volumeID)
 	}()
 
-	klog.V(2).Infof("NodeUnstageVolume: unmount volume %s on %s", volumeID, stagingTargetPath)
+	d.mountHealthMonitor.remove(volumeID)
+	d.mountRecovery.removeStaged(volumeID)
+	d.volumeIOStats.remove(volumeID)
+	d.volumeStatsCollector.remove(volumeID)
+	d.folderUsageTracker.remove(volumeID)
+	if err := removeFolderQuota(stagingTargetPath); err != nil {
+		logger.Error(err, "failed to remove saved folder quota", "target", stagingTargetPath)
+	}
+	d.vhdTrimmer.remove(volumeID)
+	if err := d.unstageBlockVolume(ctx, stagingTargetPath); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unstage block volume %s: %v", stagingTargetPath, err)
+	}
+	if d.enableAzurefileProxy && runtime.GOOS == "linux" {
+		if err := d.unmountWithProxy(ctx, stagingTargetPath); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to unmount staging target %s with azurefile proxy: %v", stagingTargetPath, err)
+		}
+	}
+	logger.V(2).Info("NodeUnstageVolume: unmount volume", "target", stagingTargetPath)
 	if err := SMBUnmount(d.mounter, stagingTargetPath, true /*extensiveMountPointCheck*/, d.removeSMBMountOnWindows); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to unmount staging target %s: %v", stagingTargetPath, err)
 	}
 
 	if runtime.GOOS != "windows" {
 		targetPath := filepath.Join(filepath.Dir(stagingTargetPath), proxyMount)
-		klog.V(2).Infof("NodeUnstageVolume: CleanupMountPoint volume %s on %s", volumeID, targetPath)
+		logger.V(2).Info("NodeUnstageVolume: CleanupMountPoint", "target", targetPath)
 		if err := CleanupMountPoint(d.mounter, targetPath, false); err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to unmount staging target %s: %v", targetPath, err)
 		}
 	}
 
 	if d.enableKataCCMount && d.isKataNode {
-		klog.V(2).Infof("NodeUnstageVolume: remove direct volume mount info %s from %s", volumeID, stagingTargetPath)
+		logger.V(2).Info("NodeUnstageVolume: remove direct volume mount info", "target", stagingTargetPath)
 		if err := d.directVolume.Remove(stagingTargetPath); err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to remove mount info %s: %v", stagingTargetPath, err)
 		}
 	}
 
-	klog.V(2).Infof("NodeUnstageVolume: unmount volume %s on %s successfully", volumeID, stagingTargetPath)
+	if err := d.releaseVHDLease(ctx, volumeID); err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	logger.V(2).Info("NodeUnstageVolume: unmount volume successfully", "target", stagingTargetPath)
 
 	isOperationSucceeded = true
 	return &csi.NodeUnstageVolumeResponse{}, nil
@@ -682,6 +858,7 @@ func (d *Driver) NodeGetInfo(_ context.Context, _ *csi.NodeGetInfoRequest) (*csi
 
 // NodeGetVolumeStats get volume stats
 func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
+	logger := klog.FromContext(ctx)
 	if len(req.VolumeId) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats volume ID was empty")
 	}
@@ -689,6 +866,30 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats volume path was empty")
 	}
 
+	// the lease of a VHD disk volume staged before the driver restarted is renewed from now on
+	d.trackVHDLease(ctx, req.VolumeId, req.GetStagingTargetPath())
+
+	// there is no file system on a raw block volume, the staging path of which is a local directory,
+	// nothing is looked up on the volume path unless a block volume is published on it
+	if _, ok := d.blockVolumeTargets.Load(req.VolumePath); ok {
+		return getBlockVolumeStats(req.VolumePath)
+	}
+
+	// volumes are monitored from NodeStageVolume or NodePublishVolume, the stats call only reads the last probe result
+	if condition, ok := d.mountHealthMonitor.condition(req.VolumeId); ok && condition.abnormal() {
+		// stat on an abnormal mount may hang or fail, the last known usage is returned with the volume condition
+		// since kubelet drops a response without usage
+		logger.V(2).Info("NodeGetVolumeStats: volume is abnormal", "path", req.VolumePath, "health", condition.health, "message", condition.message)
+		return &csi.NodeGetVolumeStatsResponse{Usage: d.lastVolumeUsage(ctx, req.VolumeId), VolumeCondition: condition.volumeCondition()}, nil
+	}
+
+	// the usage of a folder-based volume staged before the driver restarted is tracked from now on
+	d.trackFolderUsage(ctx, req.VolumeId, req.GetStagingTargetPath())
+
+	if d.volumeStatsCollector != nil {
+		return d.volumeStatsFromSnapshot(ctx, req, getShareVolumeID(req.VolumeId))
+	}
+
 	// check if the volume stats is cached
 	cache, err := d.volStatsCache.Get(ctx, req.VolumeId, azcache.CacheReadTypeDefault)
 	if err != nil {
@@ -696,43 +897,30 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 	}
 	if cache != nil {
 		resp := cache.(*csi.NodeGetVolumeStatsResponse)
-		klog.V(6).Infof("NodeGetVolumeStats: volume stats for volume %s path %s is cached", req.VolumeId, req.VolumePath)
-		return resp, nil
+		logger.V(6).Info("NodeGetVolumeStats: volume stats is cached", "path", req.VolumePath)
+		return d.withVolumeCondition(req.VolumeId, resp), nil
 	}
 
 	// fileShareName in volumeID may contain subPath, e.g. csi-shared-config/ASCP01/certs
-	// get the file share name without subPath from volumeID and check the cache again using new volumeID
-	var newVolID string
-	if _, accountName, fileShareName, _, secretNamespace, _, err := GetFileShareInfo(req.VolumeId); err == nil {
-		if splitStr := strings.Split(fileShareName, "/"); len(splitStr) > 1 {
-			fileShareName = splitStr[0]
-		}
-		// get new volumeID
-		if accountName != "" && fileShareName != "" {
-			newVolID = fmt.Sprintf(volumeIDTemplate, "", accountName, fileShareName, "", "", secretNamespace)
-		}
-	}
-
+	// check the cache again using the volumeID of the file share
+	newVolID := getShareVolumeID(req.VolumeId)
 	if cache, err = d.volStatsCache.Get(ctx, newVolID, azcache.CacheReadTypeDefault); err != nil {
 		return nil, status.Errorf(codes.Internal, "%v", err)
 	}
 	if cache != nil {
 		resp := cache.(*csi.NodeGetVolumeStatsResponse)
-		klog.V(6).Infof("NodeGetVolumeStats: volume stats for volume %s path %s is cached", req.VolumeId, req.VolumePath)
-		return resp, nil
+		logger.V(6).Info("NodeGetVolumeStats: volume stats is cached", "path", req.VolumePath)
+		return d.withVolumeCondition(req.VolumeId, resp), nil
 	}
 
-	if _, err := os.Lstat(req.VolumePath); err != nil {
-		if os.IsNotExist(err) {
-			return nil, status.Errorf(codes.NotFound, "path %s does not exist", req.VolumePath)
-		}
-		return nil, status.Errorf(codes.Internal, "failed to stat file %s: %v", req.VolumePath, err)
+	if err := checkVolumePathExists(req.VolumePath); err != nil {
+		return nil, err
 	}
 
 	if d.printVolumeStatsCallLogs {
-		klog.V(2).Infof("NodeGetVolumeStats: begin to get VolumeStats on volume %s path %s", req.VolumeId, req.VolumePath)
+		logger.V(2).Info("NodeGetVolumeStats: begin to get VolumeStats", "path", req.VolumePath)
 	} else {
-		klog.V(6).Infof("NodeGetVolumeStats: begin to get VolumeStats on volume %s path %s", req.VolumeId, req.VolumePath)
+		logger.V(6).Info("NodeGetVolumeStats: begin to get VolumeStats", "path", req.VolumePath)
 	}
 
 	mc := metrics.NewMetricContext(azureFileCSIDriverName, "node_get_volume_stats", d.cloud.ResourceGroup, "", d.Name)
@@ -745,9 +933,9 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 	resp, err := GetVolumeStats(req.VolumePath, d.enableWindowsHostProcess)
 	if err == nil && resp != nil {
 		if d.printVolumeStatsCallLogs {
-			klog.V(2).Infof("NodeGetVolumeStats: volume stats for volume %s path %s is %v", req.VolumeId, req.VolumePath, resp)
+			logger.V(2).Info("NodeGetVolumeStats: got volume stats", "path", req.VolumePath, "stats", resp)
 		} else {
-			klog.V(6).Infof("NodeGetVolumeStats: volume stats for volume %s path %s is %v", req.VolumeId, req.VolumePath, resp)
+			logger.V(6).Info("NodeGetVolumeStats: got volume stats", "path", req.VolumePath, "stats", resp)
 		}
 		// cache the volume stats per volume
 		d.volStatsCache.Set(req.VolumeId, resp)
@@ -756,7 +944,69 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 		}
 	}
 	isOperationSucceeded = true
-	return resp, err
+	return d.withVolumeCondition(req.VolumeId, resp), err
+}
+
+// getShareVolumeID returns the volumeID of the file share without subPath, e.g. the volumeID of csi-shared-config
+// for csi-shared-config/ASCP01/certs, so that the stats of volumes on the same file share are shared,
+// empty string is returned if the volumeID could not be parsed
+func getShareVolumeID(volumeID string) string {
+	_, accountName, fileShareName, _, secretNamespace, _, err := GetFileShareInfo(volumeID)
+	if err != nil {
+		return ""
+	}
+	if splitStr := strings.Split(fileShareName, "/"); len(splitStr) > 1 {
+		fileShareName = splitStr[0]
+	}
+	if accountName == "" || fileShareName == "" {
+		return ""
+	}
+	return fmt.Sprintf(volumeIDTemplate, "", accountName, fileShareName, "", "", secretNamespace)
+}
+
+// checkVolumePathExists returns NotFound if the volume path does not exist
+func checkVolumePathExists(volumePath string) error {
+	if _, err := os.Lstat(volumePath); err != nil {
+		if os.IsNotExist(err) {
+			return status.Errorf(codes.NotFound, "path %s does not exist", volumePath)
+		}
+		return status.Errorf(codes.Internal, "failed to stat file %s: %v", volumePath, err)
+	}
+	return nil
+}
+
+// withVolumeCondition returns a copy of the volume stats with the volume condition from the mount health monitor
+// and the usage of folder-based volumes from the folder usage tracker,
+// the stats is returned as is if the volume has not been probed or scanned yet
+func (d *Driver) withVolumeCondition(volumeID string, resp *csi.NodeGetVolumeStatsResponse) *csi.NodeGetVolumeStatsResponse {
+	if resp == nil {
+		return nil
+	}
+	if condition, ok := d.mountHealthMonitor.condition(volumeID); ok {
+		resp = &csi.NodeGetVolumeStatsResponse{Usage: resp.GetUsage(), VolumeCondition: condition.volumeCondition()}
+	}
+	return d.withFolderUsage(volumeID, resp)
+}
+
+// lastVolumeUsage returns the usage of the last snapshot or cached stats of the volume or its file share without
+// accessing the mount, zeroed usage is returned if the stats of the volume are not known
+func (d *Driver) lastVolumeUsage(ctx context.Context, volumeID string) []*csi.VolumeUsage {
+	if d.volumeStatsCollector != nil {
+		if snapshot, ok := d.volumeStatsCollector.snapshot(volumeID); ok && len(snapshot.resp.GetUsage()) > 0 {
+			return snapshot.resp.GetUsage()
+		}
+	}
+	for _, id := range []string{volumeID, getShareVolumeID(volumeID)} {
+		if id == "" {
+			continue
+		}
+		if cache, err := d.volStatsCache.Get(ctx, id, azcache.CacheReadTypeDefault); err == nil && cache != nil {
+			if usage := cache.(*csi.NodeGetVolumeStatsResponse).GetUsage(); len(usage) > 0 {
+				return usage
+			}
+		}
+	}
+	return []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES}, {Unit: csi.VolumeUsage_INODES}}
 }
 
 // NodeExpandVolume node expand volume
@@ -832,18 +1082,13 @@ func (d *Driver) ensureMountPoint(target string, perm os.FileMode) (bool, error)
 }
 
 func (d *Driver) mountWithProxy(ctx context.Context, source, target, fsType string, options, sensitiveMountOptions []string) error {
-	conn, err := grpc.NewClient(d.azurefileProxyEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	logger := klog.FromContext(ctx)
+	mountClient, closeFunc, err := d.dialAzurefileProxy(ctx)
 	if err != nil {
-		klog.Error("failed to connect to azurefile proxy:", err)
 		return err
 	}
-	defer func() {
-		if err := conn.Close(); err != nil {
-			klog.Error("failed to close connection to azurefile proxy:", err)
-		}
-	}()
+	defer closeFunc()
 
-	mountClient := NewMountClient(conn)
 	mountreq := mount_azurefile.MountAzureFileRequest{
 		Source:           source,
 		Target:           target,
@@ -851,7 +1096,7 @@ func (d *Driver) mountWithProxy(ctx context.Context, source, target, fsType stri
 		MountOptions:     options,
 		SensitiveOptions: sensitiveMountOptions,
 	}
-	klog.V(2).Infof("begin to mount with azurefile proxy, source: %s, target: %s, fstype: %s, mountOptions: %v", source, target, fsType, options)
+	logger.V(2).Info("begin to mount with azurefile proxy", "source", source, "target", target, "fsType", fsType, "mountOptions", options)
 	newCtx, cancel := context.WithTimeout(ctx, MountTimeoutInSec*time.Second)
 	defer cancel()
 	execFunc := func() error {
@@ -863,9 +1108,9 @@ func (d *Driver) mountWithProxy(ctx context.Context, source, target, fsType stri
 	}
 
 	if err = volumehelper.WaitUntilTimeout(MountTimeoutInSec*time.Second, execFunc, timeoutFunc); err != nil {
-		klog.Error("GRPC call returned with an error:", err)
+		logger.Error(err, "GRPC call to azurefile proxy returned with an error")
 	}
-	klog.V(2).Infof("mount %s on %s with azurefile proxy completed with error: %v", source, target, err)
+	logger.V(2).Info("mount with azurefile proxy completed", "source", source, "target", target, "err", err)
 	return err
 }
 
@@ -18,6 +18,7 @@ package azurefile
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -25,6 +26,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"syscall"
 	"testing"
@@ -802,6 +804,37 @@ func TestNodeStageVolume(t *testing.T) {
 				Secrets: secrets},
 			skipOnWindows: true,
 		},
+		{
+			desc: "[Success] Valid nfs request with Kata CC Mount enabled",
+			setup: func() {
+				d.resolver = mockResolver
+				d.directVolume = mockDirectVolume
+				if runtime.GOOS != "windows" {
+					d.isKataNode = true
+					mockIPAddr := &net.IPAddr{IP: net.ParseIP("192.168.1.1")}
+					mockDirectVolume.EXPECT().VolumeMountInfo(sourceTest).Return(nil, nil)
+					mockResolver.EXPECT().ResolveIPAddr("ip", "test_servername").Return(mockIPAddr, nil)
+					mockDirectVolume.EXPECT().Add(sourceTest, gomock.Cond(func(data string) bool {
+						var mountInfo volume.MountInfo
+						if err := json.Unmarshal([]byte(data), &mountInfo); err != nil {
+							return false
+						}
+						return mountInfo.FsType == nfs && mountInfo.Device == "test_servername:/test_accountname/test_sharename" &&
+							slices.Contains(mountInfo.Options, "addr=192.168.1.1") && len(mountInfo.Metadata) == 0
+					})).Return(nil)
+				}
+			},
+			req: &csi.NodeStageVolumeRequest{VolumeId: "vol_1##", StagingTargetPath: sourceTest,
+				VolumeCapability: &stdVolCap,
+				VolumeContext: map[string]string{
+					protocolField:       "nfs",
+					shareNameField:      "test_sharename",
+					storageAccountField: "test_accountname",
+					serverNameField:     "test_servername",
+				},
+				Secrets: secrets},
+			skipOnWindows: true,
+		},
 	}
 
 	// Setup
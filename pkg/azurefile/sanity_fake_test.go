@@ -0,0 +1,148 @@
+//go:build sanity
+// +build sanity
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/mock_azclient"
+	azureconfig "sigs.k8s.io/cloud-provider-azure/pkg/provider/config"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+)
+
+const (
+	sanitySubscriptionID = "sanity-subscription"
+	sanityResourceGroup  = "sanity-rg"
+	sanityAccountName    = "sanityaccount"
+	sanityLocation       = "eastus"
+)
+
+// sanitySkip are the csi-sanity cases which could not pass against the fake backend and the fake mounter
+var sanitySkip = []string{
+	// the fake mounter does not create anything on the target path
+	"should remove target path",
+	// same as the real Azure sanity run in test/sanity/run-test.sh
+	"should fail when requesting to create a snapshot with already existing name and different source volume ID",
+}
+
+// startSanityDriver serves the controller, node and identity services of a driver backed by the fake Azure Files
+// backend and the fake mounter on a unix socket, and returns the endpoint
+func startSanityDriver(t *testing.T, server *fakeazure.Server) string {
+	t.Helper()
+	server.AddAccount(sanitySubscriptionID, sanityResourceGroup, sanityAccountName, sanityLocation)
+	fileShareClient, err := server.NewFileShareClient(sanitySubscriptionID)
+	require.NoError(t, err)
+	accountClient, err := server.NewAccountClient(sanitySubscriptionID)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	clientFactory := mock_azclient.NewMockClientFactory(ctrl)
+	clientFactory.EXPECT().GetFileShareClient().Return(fileShareClient).AnyTimes()
+	clientFactory.EXPECT().GetFileShareClientForSub(gomock.Any()).Return(fileShareClient, nil).AnyTimes()
+	clientFactory.EXPECT().GetAccountClient().Return(accountClient).AnyTimes()
+	clientFactory.EXPECT().GetAccountClientForSub(gomock.Any()).Return(accountClient, nil).AnyTimes()
+	clientFactory.EXPECT().GetSubnetClient().Return(nil).AnyTimes()
+
+	config := azureconfig.Config{}
+	config.SubscriptionID = sanitySubscriptionID
+	config.ResourceGroup = sanityResourceGroup
+	config.Location = sanityLocation
+	d := NewFakeDriver()
+	d.cloud, err = storage.NewRepository(config, &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}, nil, clientFactory, clientFactory)
+	require.NoError(t, err)
+	d.mounter, err = NewFakeMounter()
+	require.NoError(t, err)
+	d.AddControllerServiceCapabilities([]csi.ControllerServiceCapability_RPC_Type{
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+	})
+	d.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+	})
+	d.AddNodeServiceCapabilities([]csi.NodeServiceCapability_RPC_Type{
+		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+	})
+
+	socket := filepath.Join(t.TempDir(), "csi.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	csi.RegisterIdentityServer(grpcServer, d)
+	csi.RegisterControllerServer(grpcServer, d)
+	csi.RegisterNodeServer(grpcServer, d)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return "unix://" + socket
+}
+
+// TestSanityWithFakeBackend runs csi-sanity against the driver backed by the fake Azure Files backend,
+// no Azure subscription is needed, run it by "make sanity-test-fake"
+func TestSanityWithFakeBackend(t *testing.T) {
+	sanityBin := os.Getenv("CSI_SANITY_BIN")
+	if sanityBin == "" {
+		sanityBin = "csi-sanity"
+	}
+	sanityPath, err := exec.LookPath(sanityBin)
+	require.NoError(t, err, "csi-sanity is required, set CSI_SANITY_BIN to its path")
+
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	endpoint := startSanityDriver(t, server)
+
+	dir := t.TempDir()
+	parameters := filepath.Join(dir, "parameters.yaml")
+	require.NoError(t, os.WriteFile(parameters, []byte("storageAccount: "+sanityAccountName+"\nresourceGroup: "+sanityResourceGroup+"\n"), 0600))
+	cmd := exec.Command(sanityPath,
+		"--ginkgo.v",
+		"--ginkgo.no-color",
+		"--csi.endpoint="+endpoint,
+		"--csi.testvolumeparameters="+parameters,
+		"--csi.mountdir="+filepath.Join(dir, "mount"),
+		"--csi.stagingdir="+filepath.Join(dir, "staging"),
+		"--ginkgo.skip="+strings.Join(sanitySkip, "|"),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Run(), "csi-sanity failed")
+}
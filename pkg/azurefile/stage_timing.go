@@ -0,0 +1,73 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+// NodeStageVolume phases whose durations are recorded
+const (
+	stagePhaseCredentials        = "credentials"
+	stagePhaseCreateFolder       = "create_folder"
+	stagePhaseResolveIP          = "resolve_ip"
+	stagePhaseProxyMount         = "proxy_mount"
+	stagePhaseMount              = "mount"
+	stagePhaseChmod              = "chmod"
+	stagePhaseFormatAndMount     = "format_and_mount"
+	stagePhaseSetVolumeOwnership = "set_volume_ownership"
+)
+
+type stagePhase struct {
+	name     string
+	duration time.Duration
+}
+
+// stagePhaseTimer records the durations of NodeStageVolume phases, a phase is only recorded if it's run
+type stagePhaseTimer struct {
+	protocol string
+	start    time.Time
+	phases   []stagePhase
+}
+
+func newStagePhaseTimer() *stagePhaseTimer {
+	return &stagePhaseTimer{start: time.Now()}
+}
+
+// observe records the duration of phase since start
+func (t *stagePhaseTimer) observe(phase string, start time.Time) {
+	t.phases = append(t.phases, stagePhase{name: phase, duration: time.Since(start)})
+}
+
+// report writes the phase durations into the log and node_stage_phase_duration_seconds histogram
+func (t *stagePhaseTimer) report(logger klog.Logger, err error) {
+	protocol := t.protocol
+	if protocol == "" {
+		protocol = smb
+	}
+	keysAndValues := make([]interface{}, 0, 2*len(t.phases)+6)
+	keysAndValues = append(keysAndValues, "protocol", protocol, "succeeded", err == nil, "total", time.Since(t.start).String())
+	for _, phase := range t.phases {
+		csiMetrics.ObserveNodeStagePhase(phase.name, protocol, phase.duration)
+		keysAndValues = append(keysAndValues, phase.name, phase.duration.String())
+	}
+	logger.V(2).Info("NodeStageVolume: phase durations", keysAndValues...)
+}
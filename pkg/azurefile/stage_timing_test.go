@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func getNodeStagePhaseCounts(t *testing.T) map[string]uint64 {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_node_stage_phase_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["phase"]+"/"+labels["protocol"]] = m.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
+
+func TestStagePhaseTimer(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(_, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{Verbosity: 2})
+
+	before := getNodeStagePhaseCounts(t)
+
+	timer := newStagePhaseTimer()
+	timer.observe(stagePhaseCredentials, time.Now().Add(-time.Second))
+	timer.protocol = nfs
+	timer.observe(stagePhaseMount, time.Now())
+	timer.observe(stagePhaseSetVolumeOwnership, time.Now())
+	timer.report(logger, nil)
+
+	// protocol is smb by default
+	timer = newStagePhaseTimer()
+	timer.observe(stagePhaseCredentials, time.Now())
+	timer.report(logger, errors.New("failed"))
+
+	after := getNodeStagePhaseCounts(t)
+	for key, delta := range map[string]uint64{
+		"credentials/nfs": 1, "mount/nfs": 1, "set_volume_ownership/nfs": 1, "credentials/smb": 1,
+	} {
+		assert.Equal(t, before[key]+delta, after[key], key)
+	}
+	assert.Equal(t, before["chmod/nfs"], after["chmod/nfs"])
+
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], `"msg"="NodeStageVolume: phase durations"`)
+	assert.Contains(t, logs[0], `"protocol"="nfs" "succeeded"=true`)
+	assert.Contains(t, logs[0], `"credentials"="1`)
+	assert.Contains(t, logs[0], `"set_volume_ownership"=`)
+	assert.Contains(t, logs[1], `"protocol"="smb" "succeeded"=false`)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/directory"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+)
+
+const (
+	// provisioningModeShare creates a file share per volume, which is the default
+	provisioningModeShare = "share"
+	// provisioningModeSubDirectory creates a directory per volume in a pre-existing base file share
+	provisioningModeSubDirectory = "subdirectory"
+
+	onDeleteDelete  = "delete"
+	onDeleteRetain  = "retain"
+	onDeleteArchive = "archive"
+
+	// archived directory is renamed with this prefix in the root directory of the base file share
+	archivedSubDirectoryPrefix = "archived-"
+	// subdirectory volume ID has 8 segments, e.g. rg#account#share/pvc-xxx##pvc-xxx#ns#subsID#delete,
+	// the storage endpoint suffix of the StorageClass is appended as the 9th segment if it's not the default one
+	subDirectoryVolumeIDSegments = 8
+)
+
+var (
+	supportedProvisioningModeList = []string{provisioningModeShare, provisioningModeSubDirectory}
+	supportedOnDeleteList         = []string{onDeleteDelete, onDeleteRetain, onDeleteArchive}
+)
+
+func isSupportedProvisioningMode(mode string) bool {
+	if mode == "" {
+		return true
+	}
+	for _, v := range supportedProvisioningModeList {
+		if mode == v {
+			return true
+		}
+	}
+	return false
+}
+
+func isSupportedOnDelete(onDelete string) bool {
+	if onDelete == "" {
+		return true
+	}
+	for _, v := range supportedOnDeleteList {
+		if onDelete == v {
+			return true
+		}
+	}
+	return false
+}
+
+// getSubDirectoryVolumeID returns the volume ID of a volume provisioned in subdirectory mode,
+// the subscription ID segment is always present so that onDelete is the 8th segment, e.g.
+//
+//	rg#account#share/pvc-xxx##pvc-xxx#ns##delete
+//	rg#account#share/pvc-xxx##pvc-xxx#ns##delete#core.chinacloudapi.cn
+func getSubDirectoryVolumeID(resourceGroup, accountName, baseShare, subDir, secretNamespace, subsID, onDelete, storageEndpointSuffix string) string {
+	volumeID := fmt.Sprintf(volumeIDTemplate, resourceGroup, accountName, baseShare+"/"+subDir, "", subDir, secretNamespace)
+	volumeID = volumeID + separator + subsID + separator + onDelete
+	if storageEndpointSuffix != "" {
+		volumeID = volumeID + separator + storageEndpointSuffix
+	}
+	return volumeID
+}
+
+// getSubDirectoryInfo returns the base file share, the subdirectory and the onDelete policy of a volume
+// provisioned in subdirectory mode, ok is false for other volumes, e.g. static volumes with a subPath
+func getSubDirectoryInfo(volumeID string) (baseShare, subDir, onDelete string, ok bool) {
+	segments := strings.Split(volumeID, separator)
+	if (len(segments) != subDirectoryVolumeIDSegments && len(segments) != subDirectoryVolumeIDSegments+1) || segments[0] == "" {
+		return "", "", "", false
+	}
+	onDelete = segments[7]
+	if onDelete == "" || !isSupportedOnDelete(onDelete) {
+		return "", "", "", false
+	}
+	baseShare, subDir, found := strings.Cut(segments[2], "/")
+	// the subdirectory is named after the volume
+	if !found || baseShare == "" || subDir == "" || subDir != segments[4] {
+		return "", "", "", false
+	}
+	return baseShare, subDir, onDelete, true
+}
+
+// getSubDirectoryStorageEndpointSuffix returns the storage endpoint suffix of the StorageClass saved in the volume ID
+// of a volume provisioned in subdirectory mode, empty string is returned if the default suffix is used
+func getSubDirectoryStorageEndpointSuffix(volumeID string) string {
+	if _, _, _, ok := getSubDirectoryInfo(volumeID); !ok {
+		return ""
+	}
+	if segments := strings.Split(volumeID, separator); len(segments) > subDirectoryVolumeIDSegments {
+		return segments[subDirectoryVolumeIDSegments]
+	}
+	return ""
+}
+
+// deleteSubDirectory deletes, archives or retains the subdirectory of a volume according to onDelete policy,
+// a subdirectory which does not exist is regarded as deleted
+func (d *Driver) deleteSubDirectory(ctx context.Context, accountName, accountKey, baseShare, subDir, onDelete, storageEndpointSuffix string) error {
+	logger := klog.FromContext(ctx)
+	if onDelete == onDeleteRetain {
+		logger.V(2).Info("subdirectory is retained", "subDir", subDir, "share", baseShare, "account", accountName)
+		return nil
+	}
+	fileClient, err := newAzureFileClient(accountName, accountKey, storageEndpointSuffix)
+	if err != nil || getDataplaneServiceClient(fileClient) == nil {
+		return fmt.Errorf("create Azure File client(%s) failed: %v", accountName, err)
+	}
+	dirClient := getDataplaneServiceClient(fileClient).NewShareClient(baseShare).NewDirectoryClient(subDir)
+
+	if onDelete == onDeleteArchive {
+		archivedDir := archivedSubDirectoryPrefix + subDir
+		if _, err := dirClient.Rename(ctx, archivedDir, nil); err != nil {
+			if azerrors.IsNotFound(err) {
+				logger.V(2).Info("subdirectory does not exist, skip archiving", "subDir", subDir, "share", baseShare, "account", accountName)
+				return nil
+			}
+			return fmt.Errorf("failed to archive subdirectory %s to %s: %w", subDir, archivedDir, err)
+		}
+		logger.V(2).Info("archived subdirectory successfully", "subDir", subDir, "archivedDir", archivedDir, "share", baseShare, "account", accountName)
+		return nil
+	}
+
+	if err := deleteDirectoryRecursively(ctx, dirClient); err != nil {
+		if azerrors.IsNotFound(err) {
+			logger.V(2).Info("subdirectory does not exist, skip deleting", "subDir", subDir, "share", baseShare, "account", accountName)
+			return nil
+		}
+		return fmt.Errorf("failed to delete subdirectory %s: %w", subDir, err)
+	}
+	logger.V(2).Info("deleted subdirectory successfully", "subDir", subDir, "share", baseShare, "account", accountName)
+	return nil
+}
+
+// deleteDirectoryRecursively deletes all files and directories in the directory and then the directory itself
+// since Azure Files only deletes empty directories
+func deleteDirectoryRecursively(ctx context.Context, dirClient *directory.Client) error {
+	pager := dirClient.NewListFilesAndDirectoriesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, f := range page.Segment.Files {
+			if f == nil || f.Name == nil {
+				continue
+			}
+			if _, err := dirClient.NewFileClient(*f.Name).Delete(ctx, nil); err != nil && !azerrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete file %s: %w", *f.Name, err)
+			}
+		}
+		for _, dir := range page.Segment.Directories {
+			if dir == nil || dir.Name == nil {
+				continue
+			}
+			if err := deleteDirectoryRecursively(ctx, dirClient.NewSubdirectoryClient(*dir.Name)); err != nil && !azerrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	_, err := dirClient.Delete(ctx, nil)
+	return err
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
+)
+
+func TestGetSubDirectoryInfo(t *testing.T) {
+	tests := []struct {
+		volumeID                      string
+		expectedBaseShare             string
+		expectedSubDir                string
+		expectedOnDelete              string
+		expectedStorageEndpointSuffix string
+		expectedOK                    bool
+	}{
+		{
+			volumeID:          "rg#account#base/pvc-1##pvc-1#ns##delete",
+			expectedBaseShare: "base",
+			expectedSubDir:    "pvc-1",
+			expectedOnDelete:  onDeleteDelete,
+			expectedOK:        true,
+		},
+		{
+			volumeID:          getSubDirectoryVolumeID("rg", "account", "base", "pvc-2", "ns", "subsID", onDeleteArchive, ""),
+			expectedBaseShare: "base",
+			expectedSubDir:    "pvc-2",
+			expectedOnDelete:  onDeleteArchive,
+			expectedOK:        true,
+		},
+		{
+			volumeID:                      getSubDirectoryVolumeID("rg", "account", "base", "pvc-3", "ns", "", onDeleteRetain, "core.chinacloudapi.cn"),
+			expectedBaseShare:             "base",
+			expectedSubDir:                "pvc-3",
+			expectedOnDelete:              onDeleteRetain,
+			expectedStorageEndpointSuffix: "core.chinacloudapi.cn",
+			expectedOK:                    true,
+		},
+		// static volume with subPath
+		{volumeID: "rg#account#base/folder###ns"},
+		{volumeID: "rg#account#base/folder##pvc-1#ns#subsID"},
+		// subdirectory is not named after the volume
+		{volumeID: "rg#account#base/folder##pvc-1#ns##delete"},
+		{volumeID: "rg#account#base/pvc-1##pvc-1#ns##invalid"},
+		{volumeID: "rg#account#pvc-1##pvc-1#ns##delete"},
+		{volumeID: "#account#base/pvc-1##pvc-1#ns##delete"},
+		{volumeID: "rg#account#base/pvc-1##pvc-1#ns##delete#suffix#extra"},
+	}
+	for _, test := range tests {
+		baseShare, subDir, onDelete, ok := getSubDirectoryInfo(test.volumeID)
+		assert.Equal(t, test.expectedOK, ok, test.volumeID)
+		assert.Equal(t, test.expectedBaseShare, baseShare, test.volumeID)
+		assert.Equal(t, test.expectedSubDir, subDir, test.volumeID)
+		assert.Equal(t, test.expectedOnDelete, onDelete, test.volumeID)
+		assert.Equal(t, test.expectedStorageEndpointSuffix, getSubDirectoryStorageEndpointSuffix(test.volumeID), test.volumeID)
+	}
+}
+
+func TestCreateVolumeSubDirectoryInvalidParameters(t *testing.T) {
+	d := NewFakeDriver()
+	capabilities := []*csi.VolumeCapability{{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+	}}
+	tests := []struct {
+		parameters  map[string]string
+		expectedErr error
+	}{
+		{
+			parameters:  map[string]string{provisioningModeField: "invalid"},
+			expectedErr: status.Errorf(codes.InvalidArgument, "provisioningMode(invalid) is not supported, supported provisioningMode list: %v", supportedProvisioningModeList),
+		},
+		{
+			parameters:  map[string]string{onDeleteField: onDeleteArchive},
+			expectedErr: status.Errorf(codes.InvalidArgument, "onDelete is only supported in provisioningMode(%s)", provisioningModeSubDirectory),
+		},
+		{
+			parameters:  map[string]string{provisioningModeField: "SubDirectory", onDeleteField: "invalid", shareNameField: "base", storageAccountField: "account"},
+			expectedErr: status.Errorf(codes.InvalidArgument, "onDelete(invalid) is not supported, supported onDelete list: %v", supportedOnDeleteList),
+		},
+		{
+			parameters:  map[string]string{provisioningModeField: provisioningModeSubDirectory, storageAccountField: "account"},
+			expectedErr: status.Errorf(codes.InvalidArgument, "shareName and storageAccount of the base file share must be provided in provisioningMode(%s)", provisioningModeSubDirectory),
+		},
+		{
+			parameters:  map[string]string{provisioningModeField: provisioningModeSubDirectory, shareNameField: "base", storageAccountField: "account", protocolField: nfs},
+			expectedErr: status.Errorf(codes.InvalidArgument, "provisioningMode(%s) is only supported with smb protocol", provisioningModeSubDirectory),
+		},
+	}
+	for _, test := range tests {
+		req := &csi.CreateVolumeRequest{Name: "pvc-1", VolumeCapabilities: capabilities, Parameters: test.parameters}
+		_, err := d.CreateVolume(context.Background(), req)
+		assert.Equal(t, test.expectedErr, err, test.parameters)
+	}
+}
+
+func TestSubDirectoryVolumeWithFakeBackend(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}
+	secrets := createStorageAccountSecret("testaccount", accountKey)
+	capabilities := []*csi.VolumeCapability{{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+	}}
+	createVolume := func(name, onDelete string) (*csi.CreateVolumeResponse, error) {
+		parameters := map[string]string{
+			provisioningModeField: provisioningModeSubDirectory,
+			shareNameField:        "base",
+			storageAccountField:   "testaccount",
+			resourceGroupField:    "rg",
+		}
+		if onDelete != "" {
+			parameters[onDeleteField] = onDelete
+		}
+		return d.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               name,
+			VolumeCapabilities: capabilities,
+			Parameters:         parameters,
+			Secrets:            secrets,
+		})
+	}
+
+	// base file share must exist
+	_, err := createVolume("pvc-1", "")
+	assert.Equal(t, status.Errorf(codes.InvalidArgument, "base file share(base) does not exist on account(testaccount)"), err)
+	require.NoError(t, d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "base", RequestGiB: 100}, secrets, ""))
+
+	resp, err := createVolume("pvc-1", "")
+	require.NoError(t, err)
+	volumeID := resp.GetVolume().GetVolumeId()
+	assert.Equal(t, "rg#testaccount#base/pvc-1##pvc-1#default##delete", volumeID)
+	assert.Equal(t, "base/pvc-1", resp.GetVolume().GetVolumeContext()[shareNameField])
+	assert.Equal(t, "107374182400", resp.GetVolume().GetVolumeContext()[folderQuotaField])
+	assert.Equal(t, int64(100*1024*1024*1024), resp.GetVolume().GetCapacityBytes())
+	assert.True(t, server.DirectoryExists("testaccount", "base", "pvc-1"))
+	// creating the volume again is idempotent
+	_, err = createVolume("pvc-1", "")
+	require.NoError(t, err)
+
+	// the base file share is neither resized nor snapshotted
+	_, err = d.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{VolumeId: volumeID, CapacityRange: &csi.CapacityRange{RequiredBytes: 200 * 1024 * 1024 * 1024}})
+	assert.Equal(t, status.Errorf(codes.Unimplemented, "subdirectory volume(%s) is not supported on ControllerExpandVolume", volumeID), err)
+	assert.Equal(t, int32(100), server.ShareQuota("testaccount", "base"))
+	_, err = d.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: "snapshot", SourceVolumeId: volumeID})
+	assert.Equal(t, status.Errorf(codes.InvalidArgument, "snapshot of subdirectory volume(%s) is not supported", volumeID), err)
+
+	// files and directories in the subdirectory are deleted recursively
+	fileClient, err := newAzureFileClient("testaccount", accountKey, fakeStorageEndpointSuffix)
+	require.NoError(t, err)
+	dirClient := getDataplaneServiceClient(fileClient).NewShareClient("base").NewDirectoryClient("pvc-1")
+	_, err = dirClient.NewSubdirectoryClient("data").Create(ctx, nil)
+	require.NoError(t, err)
+	_, err = dirClient.NewSubdirectoryClient("data").NewFileClient("file").Create(ctx, 1024, nil)
+	require.NoError(t, err)
+	_, err = dirClient.NewFileClient("file").Create(ctx, 1024, nil)
+	require.NoError(t, err)
+	_, err = d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID, Secrets: secrets})
+	require.NoError(t, err)
+	assert.False(t, server.DirectoryExists("testaccount", "base", "pvc-1"))
+	assert.False(t, server.DirectoryExists("testaccount", "base", "pvc-1/data"))
+	assert.Equal(t, []string{"base"}, server.Shares("testaccount"))
+	// deleting the volume again is idempotent
+	_, err = d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID, Secrets: secrets})
+	require.NoError(t, err)
+
+	resp, err = createVolume("pvc-2", onDeleteArchive)
+	require.NoError(t, err)
+	_, err = d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: resp.GetVolume().GetVolumeId(), Secrets: secrets})
+	require.NoError(t, err)
+	assert.False(t, server.DirectoryExists("testaccount", "base", "pvc-2"))
+	assert.True(t, server.DirectoryExists("testaccount", "base", "archived-pvc-2"))
+
+	resp, err = createVolume("pvc-3", onDeleteRetain)
+	require.NoError(t, err)
+	_, err = d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: resp.GetVolume().GetVolumeId(), Secrets: secrets})
+	require.NoError(t, err)
+	assert.True(t, server.DirectoryExists("testaccount", "base", "pvc-3"))
+}
+
+func TestSubDirectoryVolumeWithStorageEndpointSuffix(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	// the default storage endpoint suffix of the driver does not point to the fake backend
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: "core.windows.net"}
+	secrets := createStorageAccountSecret("testaccount", accountKey)
+	require.NoError(t, d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount", StorageEndpointSuffix: fakeStorageEndpointSuffix},
+		&ShareOptions{Name: "base", RequestGiB: 100}, secrets, ""))
+
+	resp, err := d.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name: "pvc-1",
+		VolumeCapabilities: []*csi.VolumeCapability{{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+		}},
+		Parameters: map[string]string{
+			provisioningModeField:      provisioningModeSubDirectory,
+			shareNameField:             "base",
+			storageAccountField:        "testaccount",
+			resourceGroupField:         "rg",
+			storageEndpointSuffixField: fakeStorageEndpointSuffix,
+		},
+		Secrets: secrets,
+	})
+	require.NoError(t, err)
+	volumeID := resp.GetVolume().GetVolumeId()
+	assert.Equal(t, "rg#testaccount#base/pvc-1##pvc-1#default##delete#"+fakeStorageEndpointSuffix, volumeID)
+	assert.True(t, server.DirectoryExists("testaccount", "base", "pvc-1"))
+
+	// the subdirectory is deleted on the storage endpoint of the StorageClass
+	_, err = d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID, Secrets: secrets})
+	require.NoError(t, err)
+	assert.False(t, server.DirectoryExists("testaccount", "base", "pvc-1"))
+}
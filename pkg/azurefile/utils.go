@@ -29,6 +29,8 @@ import (
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -36,6 +38,9 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/volume"
 	azureconfig "sigs.k8s.io/cloud-provider-azure/pkg/provider/config"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
 )
 
 const (
@@ -132,22 +137,17 @@ func isSupportedFsType(fsType string) bool {
 }
 
 func isRetriableError(err error) bool {
-	if err != nil {
-		for _, v := range retriableErrors {
-			if strings.Contains(strings.ToLower(err.Error()), strings.ToLower(v)) {
-				return true
-			}
-		}
-	}
-	return false
+	return azerrors.IsRetriable(err)
 }
 
 func isThrottlingError(err error) bool {
-	if err != nil {
-		errMsg := strings.ToLower(err.Error())
-		return strings.Contains(errMsg, strings.ToLower(tooManyRequests)) || strings.Contains(errMsg, clientThrottled)
-	}
-	return false
+	return azerrors.IsThrottled(err)
+}
+
+// isAccountLimitExceededError returns true if the total provisioned capacity of file shares exceeds the account limit,
+// data plane API returns "specified share does not exist" in this case
+func isAccountLimitExceededError(err error) bool {
+	return azerrors.IsQuota(err) || (err != nil && strings.Contains(err.Error(), accountLimitExceedDataPlaneAPI))
 }
 
 func sleepIfThrottled(err error, defaultSleepSec int) {
@@ -161,22 +161,67 @@ func sleepIfThrottled(err error, defaultSleepSec int) {
 	}
 }
 
-// getRetryAfterSeconds returns the number of seconds to wait from the error message
+// getRetryAfterSeconds returns the number of seconds to wait from the response headers or the error message
 func getRetryAfterSeconds(err error) int {
-	if err == nil {
-		return 0
-	}
-	re := regexp.MustCompile(`RetryAfter: (\d+)s`)
-	match := re.FindStringSubmatch(err.Error())
-	if len(match) > 1 {
-		if retryAfter, err := strconv.Atoi(match[1]); err == nil {
-			if retryAfter > maxThrottlingSleepSec {
-				return maxThrottlingSleepSec
+	retryAfter := int(math.Ceil(azerrors.RetryAfter(err).Seconds()))
+	if retryAfter > maxThrottlingSleepSec {
+		return maxThrottlingSleepSec
+	}
+	return retryAfter
+}
+
+// azureRetryHandler decides the result of an error before the default retry policy applies,
+// it returns handled=false to fall back to the default policy
+type azureRetryHandler func(err error, category azerrors.Category) (handled bool, rerr error)
+
+// retryAzureOperation is the retry policy of controller operations calling Azure: fn is retried with backoff
+// on retriable errors and waits for Retry-After (or throttlingSleepSec) when throttled,
+// every error is recorded in metrics by operation and category.
+func retryAzureOperation(backoff wait.Backoff, operation string, throttlingSleepSec int, fn func() error, handler azureRetryHandler) error {
+	return wait.ExponentialBackoff(backoff, func() (bool, error) {
+		err := fn()
+		if err == nil {
+			return true, nil
+		}
+		category := azerrors.Classify(err)
+		csiMetrics.RecordAzureError(operation, string(category))
+		if handler != nil {
+			if handled, rerr := handler(err, category); handled {
+				return true, rerr
 			}
-			return retryAfter
 		}
-	}
-	return 0
+		if isRetriableError(err) {
+			klog.Warningf("%s failed with %s error(%v), waiting for retrying", operation, category, err)
+			csiMetrics.RecordAzureRetry(operation, string(category))
+			sleepIfThrottled(err, throttlingSleepSec)
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// azureStatusError is a gRPC status error converted from an Azure error, the Azure error is kept in the error chain
+// so that the error category in metrics is classified by its type rather than by the status message
+type azureStatusError struct {
+	status *status.Status
+	err    error
+}
+
+func (e *azureStatusError) Error() string {
+	return e.status.Err().Error()
+}
+
+func (e *azureStatusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+func (e *azureStatusError) Unwrap() error {
+	return e.err
+}
+
+// azureStatusErrorf returns a gRPC status error with the formatted message, which wraps the Azure error err
+func azureStatusErrorf(c codes.Code, err error, format string, a ...interface{}) error {
+	return &azureStatusError{status: status.Newf(c, format, a...), err: err}
 }
 
 func createStorageAccountSecret(account, key string) map[string]string {
@@ -335,7 +380,7 @@ func isConfidentialRuntimeClass(ctx context.Context, kubeClient clientset.Interf
 	if err != nil {
 		return false, err
 	}
-	klog.V(4).Infof("runtimeClass %s handler: %s", runtimeClassName, runtimeClass.Handler)
+	klog.FromContext(ctx).V(4).Info("got runtimeClass handler", "runtimeClass", runtimeClassName, "handler", runtimeClass.Handler)
 	return runtimeClass.Handler == runtimeClassHandler, nil
 }
 
@@ -353,7 +398,8 @@ func getBackOff(config azureconfig.Config) wait.Backoff {
 	}
 }
 
-func getFileServiceURL(accountName, storageEndpointSuffix string) string {
+// getFileServiceURL is replaced in tests to reach the fake Azure Files backend
+var getFileServiceURL = func(accountName, storageEndpointSuffix string) string {
 	if storageEndpointSuffix == "" {
 		storageEndpointSuffix = defaultStorageEndPointSuffix
 	}
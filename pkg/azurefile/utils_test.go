@@ -20,13 +20,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	nodev1 "k8s.io/api/node/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -34,6 +38,8 @@ import (
 	utiltesting "k8s.io/client-go/util/testing"
 	"k8s.io/kubernetes/pkg/volume"
 	azureconfig "sigs.k8s.io/cloud-provider-azure/pkg/provider/config"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
 )
 
 func TestSimpleLockEntry(t *testing.T) {
@@ -958,6 +964,108 @@ func TestGetBackOff(t *testing.T) {
 	}
 }
 
+func TestRetryAzureOperation(t *testing.T) {
+	backoff := wait.Backoff{Steps: 3}
+	throttledErr := errors.New("Retriable: true, RetryAfter: 0s, HTTPStatusCode: 429, RawError: TooManyRequests")
+	tests := []struct {
+		desc          string
+		errs          []error
+		handler       azureRetryHandler
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			desc:          "success",
+			errs:          []error{nil},
+			expectedCalls: 1,
+		},
+		{
+			desc:          "retriable error then success",
+			errs:          []error{errors.New("The specified share is being deleted. Try operation later."), nil},
+			expectedCalls: 2,
+		},
+		{
+			desc:          "non retriable error",
+			errs:          []error{errors.New("no match")},
+			expectedCalls: 1,
+			expectedErr:   errors.New("no match"),
+		},
+		{
+			desc:          "retriable error until steps are exhausted",
+			errs:          []error{throttledErr, throttledErr, throttledErr},
+			expectedCalls: 3,
+			expectedErr:   wait.ErrWaitTimeout,
+		},
+		{
+			desc: "handler overrides not found error",
+			errs: []error{errors.New("HTTPStatusCode: 404")},
+			handler: func(_ error, category azerrors.Category) (bool, error) {
+				return category == azerrors.NotFound, nil
+			},
+			expectedCalls: 1,
+		},
+		{
+			desc: "handler falls back to default policy",
+			errs: []error{throttledErr, nil},
+			handler: func(_ error, _ azerrors.Category) (bool, error) {
+				return false, nil
+			},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := retryAzureOperation(backoff, "test", 0, func() error {
+			err := test.errs[calls]
+			calls++
+			return err
+		}, test.handler)
+		if !reflect.DeepEqual(err, test.expectedErr) && (err == nil || test.expectedErr == nil || err.Error() != test.expectedErr.Error()) {
+			t.Errorf("desc: (%s), retryAzureOperation returned with error(%v), not equal to expected(%v)", test.desc, err, test.expectedErr)
+		}
+		if calls != test.expectedCalls {
+			t.Errorf("desc: (%s), fn is called %d times, not equal to expected(%d)", test.desc, calls, test.expectedCalls)
+		}
+	}
+}
+
+func TestAzureStatusErrorf(t *testing.T) {
+	azureErr := &azcore.ResponseError{StatusCode: http.StatusTooManyRequests, ErrorCode: azerrors.CodeTooManyRequests}
+	err := azureStatusErrorf(codes.Internal, azureErr, "failed to create file share(%s): %v", "share", azureErr)
+
+	if status.Code(err) != codes.Internal {
+		t.Errorf("status code %v, expected %v", status.Code(err), codes.Internal)
+	}
+	if !strings.Contains(err.Error(), "failed to create file share(share)") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) || respErr != azureErr {
+		t.Errorf("azure error is not kept in the error chain of %v", err)
+	}
+	if category := azerrors.Classify(err); category != azerrors.Throttled {
+		t.Errorf("error category %q, expected %q", category, azerrors.Throttled)
+	}
+}
+
+func TestIsAccountLimitExceededError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil, expected: false},
+		{err: errors.New("Code=\"TotalSharesProvisionedCapacityExceedsAccountLimit\""), expected: true},
+		{err: errors.New("The specified share does not exist."), expected: true},
+		{err: errors.New("no match"), expected: false},
+	}
+	for _, test := range tests {
+		if result := isAccountLimitExceededError(test.err); result != test.expected {
+			t.Errorf("input: err(%v), isAccountLimitExceededError returned with bool(%t), not equal to expected(%t)", test.err, result, test.expected)
+		}
+	}
+}
+
 func TestVolumeMounter(t *testing.T) {
 	path := "/mnt/data"
 	attributes := volume.Attributes{}
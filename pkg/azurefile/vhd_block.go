@@ -0,0 +1,192 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/volume/util/volumepathhandler"
+)
+
+// procMountInfoPath is the mount table of the driver, which shares the mount namespace of the host
+const procMountInfoPath = "/proc/self/mountinfo"
+
+// getBlockProxyMountPath returns the path where the file share of a raw block VHD disk volume is mounted,
+// it's under the staging path since the staging paths of block volumes share the same parent directory
+func getBlockProxyMountPath(stagingTargetPath string) string {
+	return filepath.Join(stagingTargetPath, proxyMount)
+}
+
+// publishBlockVolume bind mounts the loop device of the VHD disk attached in NodeStageVolume to the target file
+func (d *Driver) publishBlockVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	logger := klog.FromContext(ctx)
+	volumeID := req.GetVolumeId()
+	target := req.GetTargetPath()
+	stagingTargetPath := req.GetStagingTargetPath()
+	if len(stagingTargetPath) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Staging target not provided")
+	}
+
+	diskName := getValueInMap(req.GetVolumeContext(), diskNameField)
+	if diskName == "" {
+		if _, _, _, diskName, _, _, _ = GetFileShareInfo(volumeID); diskName == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "block volume(%s) is only supported for VHD disk", volumeID)
+		}
+	}
+	diskPath := filepath.Join(getBlockProxyMountPath(stagingTargetPath), diskName)
+	devicePath, err := d.blockVolumePathHandler.GetLoopDevice(diskPath)
+	if err != nil {
+		if err.Error() == volumepathhandler.ErrDeviceNotFound {
+			return nil, status.Errorf(codes.FailedPrecondition, "disk(%s) of volume(%s) is not staged on %s", diskName, volumeID, stagingTargetPath)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get loop device of %s: %v", diskPath, err)
+	}
+
+	if err := ensureBlockTargetFile(target); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	notMnt, err := d.mounter.IsLikelyNotMountPoint(target)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check mount point %s: %v", target, err)
+	}
+	if !notMnt {
+		logger.V(2).Info("NodePublishVolume: block device is already mounted", "target", target)
+		d.blockVolumeTargets.Store(target, volumeID)
+		return &csi.NodePublishVolumeResponse{}, nil
+	}
+
+	mountOptions := []string{"bind"}
+	if req.GetReadonly() {
+		mountOptions = append(mountOptions, "ro")
+	}
+	logger.V(2).Info("NodePublishVolume: mounting block device", "device", devicePath, "target", target, "mountOptions", mountOptions)
+	if err := d.mounter.Mount(devicePath, target, "", mountOptions); err != nil {
+		if removeErr := os.Remove(target); removeErr != nil {
+			return nil, status.Errorf(codes.Internal, "Could not remove mount target %s: %v", target, removeErr)
+		}
+		return nil, status.Errorf(codes.Internal, "Could not mount block device %s at %s: %v", devicePath, target, err)
+	}
+	logger.V(2).Info("NodePublishVolume: mount block device successfully", "device", devicePath, "target", target)
+	d.blockVolumeTargets.Store(target, volumeID)
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// restoreBlockVolumeTargets rebuilds the targets of published block volumes from the mount table after the driver restarts,
+// so that NodeGetVolumeStats looks up the block device on them instead of the file system the device node is on
+func (d *Driver) restoreBlockVolumeTargets(ctx context.Context, mountInfoPath string) {
+	logger := klog.FromContext(ctx)
+	targets, err := listLoopDeviceTargets(mountInfoPath)
+	if err != nil {
+		logger.Error(err, "failed to list targets of published block volumes", "mountInfo", mountInfoPath)
+		return
+	}
+	for _, target := range targets {
+		// the volume ID is not known from the mount table, it's set when the volume is published again
+		if _, loaded := d.blockVolumeTargets.LoadOrStore(target, ""); !loaded {
+			logger.V(2).Info("restored target of published block volume", "target", target)
+		}
+	}
+}
+
+// ensureBlockTargetFile creates the target file of a block volume, a device node is bind mounted on a file
+func ensureBlockTargetFile(target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return fmt.Errorf("failed to create parent directory of %s: %v", target, err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to create target file %s: %v", target, err)
+	}
+	return f.Close()
+}
+
+// unstageBlockVolume detaches the loop devices of the VHD disks and unmounts the file share of a raw block volume,
+// it's a no-op for other volumes
+func (d *Driver) unstageBlockVolume(ctx context.Context, stagingTargetPath string) error {
+	logger := klog.FromContext(ctx)
+	// the staging path of a block volume is a local directory with the file share mounted under it,
+	// nothing is looked up under a staging path which is a mount point, or could not be checked, e.g. a dead mount,
+	// since it's the file system of another volume
+	notMnt, err := d.mounter.IsLikelyNotMountPoint(stagingTargetPath)
+	if err != nil || !notMnt {
+		return nil
+	}
+	proxyMountPath := getBlockProxyMountPath(stagingTargetPath)
+	if _, err := os.Stat(proxyMountPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat %s: %v", proxyMountPath, err)
+	}
+
+	entries, err := os.ReadDir(proxyMountPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", proxyMountPath, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), vhdSuffix) {
+			continue
+		}
+		diskPath := filepath.Join(proxyMountPath, entry.Name())
+		logger.V(2).Info("NodeUnstageVolume: detach loop device", "disk", diskPath)
+		if err := d.blockVolumePathHandler.DetachFileDevice(diskPath); err != nil {
+			return fmt.Errorf("failed to detach loop device of %s: %v", diskPath, err)
+		}
+	}
+
+	logger.V(2).Info("NodeUnstageVolume: CleanupMountPoint", "target", proxyMountPath)
+	if err := CleanupMountPoint(d.mounter, proxyMountPath, false); err != nil {
+		return fmt.Errorf("failed to unmount %s: %v", proxyMountPath, err)
+	}
+	return nil
+}
+
+// getBlockVolumeStats returns the size of the block device published on volumePath, which is a bind mount of a local loop device,
+// the usage of a raw block volume is not known by the driver so only the total size is returned
+func getBlockVolumeStats(volumePath string) (*csi.NodeGetVolumeStatsResponse, error) {
+	info, err := os.Stat(volumePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, status.Errorf(codes.NotFound, "path %s does not exist", volumePath)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to stat file %s: %v", volumePath, err)
+	}
+	if info.Mode()&os.ModeDevice == 0 || info.Mode()&os.ModeCharDevice != 0 {
+		return nil, status.Errorf(codes.Internal, "%s is not a block device", volumePath)
+	}
+	f, err := os.Open(volumePath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to open block device %s: %v", volumePath, err)
+	}
+	defer f.Close()
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get size of block device %s: %v", volumePath, err)
+	}
+	return &csi.NodeGetVolumeStatsResponse{
+		Usage: []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Total: size}},
+	}, nil
+}
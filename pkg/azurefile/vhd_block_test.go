@@ -0,0 +1,225 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/kubernetes/pkg/volume/util/volumepathhandler"
+)
+
+// fakeBlockVolumePathHandler maps VHD disks to fake loop devices
+type fakeBlockVolumePathHandler struct {
+	volumepathhandler.VolumePathHandler
+	devices  map[string]string
+	onDetach func(path string)
+}
+
+func newFakeBlockVolumePathHandler() *fakeBlockVolumePathHandler {
+	return &fakeBlockVolumePathHandler{devices: map[string]string{}}
+}
+
+func (f *fakeBlockVolumePathHandler) AttachFileDevice(path string) (string, error) {
+	if device, ok := f.devices[path]; ok {
+		return device, nil
+	}
+	device := "/dev/loop" + string(rune('0'+len(f.devices)))
+	f.devices[path] = device
+	return device, nil
+}
+
+func (f *fakeBlockVolumePathHandler) DetachFileDevice(path string) error {
+	delete(f.devices, path)
+	if f.onDetach != nil {
+		f.onDetach(path)
+	}
+	return nil
+}
+
+func (f *fakeBlockVolumePathHandler) GetLoopDevice(path string) (string, error) {
+	if device, ok := f.devices[path]; ok {
+		return device, nil
+	}
+	return "", errors.New(volumepathhandler.ErrDeviceNotFound)
+}
+
+func TestIsValidVolumeCapabilitiesWithBlock(t *testing.T) {
+	blockCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+	mountCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+	assert.EqualError(t, isValidVolumeCapabilities([]*csi.VolumeCapability{blockCap}, false), "driver does not support block volumes")
+	assert.NoError(t, isValidVolumeCapabilities([]*csi.VolumeCapability{blockCap}, true))
+	assert.NoError(t, isValidVolumeCapabilities([]*csi.VolumeCapability{mountCap}, false))
+	assert.NoError(t, isValidVolumeCapabilities([]*csi.VolumeCapability{mountCap, blockCap}, true))
+}
+
+func TestNodeStageBlockVolumeOfFileShare(t *testing.T) {
+	d := NewFakeDriver()
+	_, err := d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          "rg#account#share",
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		},
+		Secrets: map[string]string{"accountname": "account", "accountkey": "key"},
+	})
+	assert.Equal(t, status.Error(codes.InvalidArgument, "block volume(rg#account#share) is only supported for VHD disk"), err)
+}
+
+func TestPublishAndUnstageBlockVolume(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("raw block volume is only supported on Linux")
+	}
+	d := NewFakeDriver()
+	mounter, err := NewFakeMounter()
+	require.NoError(t, err)
+	d.mounter = mounter
+	handler := newFakeBlockVolumePathHandler()
+	d.blockVolumePathHandler = handler
+
+	dir := t.TempDir()
+	stagingPath := filepath.Join(dir, "staging", "pv")
+	target := filepath.Join(dir, "publish", "pv", "pod")
+	volumeID := "rg#account#share#disk.vhd"
+	req := &csi.NodePublishVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: stagingPath,
+		TargetPath:        target,
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		},
+	}
+
+	// the disk is not attached to a loop device
+	_, err = d.NodePublishVolume(context.Background(), req)
+	assert.Equal(t, status.Errorf(codes.FailedPrecondition, "disk(disk.vhd) of volume(%s) is not staged on %s", volumeID, stagingPath), err)
+
+	diskPath := filepath.Join(getBlockProxyMountPath(stagingPath), "disk.vhd")
+	require.NoError(t, os.MkdirAll(filepath.Dir(diskPath), 0750))
+	require.NoError(t, os.WriteFile(diskPath, nil, 0640))
+	device, err := handler.AttachFileDevice(diskPath)
+	require.NoError(t, err)
+
+	_, err = d.NodePublishVolume(context.Background(), req)
+	require.NoError(t, err)
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.False(t, info.IsDir(), "device is bind mounted on a file")
+	assert.Equal(t, "/dev/loop0", device)
+	// only the target on which a block volume is published is looked up as a block device in NodeGetVolumeStats
+	_, ok := d.blockVolumeTargets.Load(target)
+	assert.True(t, ok)
+	_, err = d.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{VolumeId: volumeID, VolumePath: target})
+	assert.Equal(t, status.Errorf(codes.Internal, "%s is not a block device", target), err, "device is not bind mounted by the fake mounter")
+
+	// disk name from volume context
+	req.VolumeId = "rg#account#share"
+	req.VolumeContext = map[string]string{diskNameField: "disk.vhd"}
+	_, err = d.NodePublishVolume(context.Background(), req)
+	require.NoError(t, err)
+
+	_, err = d.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{VolumeId: req.VolumeId, TargetPath: target})
+	require.NoError(t, err)
+	_, ok = d.blockVolumeTargets.Load(target)
+	assert.False(t, ok)
+
+	// the file share is not mounted by the fake mounter, remove the disk as if it's unmounted
+	handler.onDetach = func(path string) {
+		assert.NoError(t, os.Remove(path))
+	}
+	require.NoError(t, d.unstageBlockVolume(context.Background(), stagingPath))
+	assert.Empty(t, handler.devices)
+	_, err = os.Stat(getBlockProxyMountPath(stagingPath))
+	assert.True(t, os.IsNotExist(err))
+
+	// no-op if the volume is not a block volume
+	require.NoError(t, d.unstageBlockVolume(context.Background(), stagingPath))
+
+	// nothing under the staging path of a file system volume is looked up
+	for _, stagingPath := range []string{filepath.Join(dir, "false_is_likely"), filepath.Join(dir, "error_is_likely")} {
+		diskPath := filepath.Join(getBlockProxyMountPath(stagingPath), "disk.vhd")
+		require.NoError(t, os.MkdirAll(filepath.Dir(diskPath), 0750))
+		require.NoError(t, os.WriteFile(diskPath, nil, 0640))
+		_, err = handler.AttachFileDevice(diskPath)
+		require.NoError(t, err)
+		require.NoError(t, d.unstageBlockVolume(context.Background(), stagingPath))
+		assert.Contains(t, handler.devices, diskPath, stagingPath)
+		_, err = os.Stat(diskPath)
+		assert.NoError(t, err, stagingPath)
+	}
+}
+
+func TestGetBlockVolumeStats(t *testing.T) {
+	dir := t.TempDir()
+	_, err := getBlockVolumeStats(dir)
+	assert.Equal(t, status.Errorf(codes.Internal, "%s is not a block device", dir), err)
+	notExist := filepath.Join(dir, "not-exist")
+	_, err = getBlockVolumeStats(notExist)
+	assert.Equal(t, status.Errorf(codes.NotFound, "path %s does not exist", notExist), err)
+	if runtime.GOOS != "linux" {
+		return
+	}
+	_, err = getBlockVolumeStats("/dev/null")
+	assert.Equal(t, status.Error(codes.Internal, "/dev/null is not a block device"), err, "character device")
+}
+
+func TestRestoreBlockVolumeTargets(t *testing.T) {
+	d := NewFakeDriver()
+	// failure to read the mount table is only logged
+	d.restoreBlockVolumeTargets(context.Background(), filepath.Join(t.TempDir(), "not-exist"))
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	target := "/var/lib/kubelet/plugins/kubernetes.io/csi/volumeDevices/publish/pv-block/pod-uid"
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	require.NoError(t, os.WriteFile(mountInfoPath, []byte(
+		"22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw\n"+
+			"25 22 0:5 / /dev rw,nosuid shared:2 - devtmpfs udev rw,size=4017216k\n"+
+			"1106 22 0:5 /loop3 "+target+" rw,nosuid shared:2 - devtmpfs udev rw,size=4017216k\n"+
+			"1107 22 0:5 /sda /var/lib/kubelet/plugins/other rw,nosuid shared:2 - devtmpfs udev rw,size=4017216k\n"+
+			"1108 22 0:52 / /var/lib/kubelet/plugins/kubernetes.io/csi/file.csi.azure.com/vol/globalmount rw - cifs //account/share rw\n"), 0640))
+	d.restoreBlockVolumeTargets(context.Background(), mountInfoPath)
+
+	// only the targets on which a loop device is bind mounted are restored
+	var targets []string
+	d.blockVolumeTargets.Range(func(key, _ interface{}) bool {
+		targets = append(targets, key.(string))
+		return true
+	})
+	assert.Equal(t, []string{target}, targets)
+	// the restored target is looked up as a block device in NodeGetVolumeStats
+	_, err := d.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{VolumeId: "rg#account#share#disk.vhd", VolumePath: target})
+	assert.Equal(t, status.Errorf(codes.NotFound, "path %s does not exist", target), err)
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/file"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/lease"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
+)
+
+const (
+	// the lease is acquired on a lock file next to the VHD disk since a lease on the disk itself
+	// would also block the writes of the holder through SMB
+	vhdLockFileSuffix = ".lock"
+	// metadata of the lock file recording the node which holds the lease
+	vhdLeaseHolderMetadata = "holder"
+
+	leaseAlreadyPresent      = "LeaseAlreadyPresent"
+	leaseIDMismatch          = "LeaseIdMismatchWithLeaseOperation"
+	leaseNotPresent          = "LeaseNotPresentWithLeaseOperation"
+	leaseIDMissing           = "LeaseIdMissing"
+	vhdLeaseOperationTimeout = 30 * time.Second
+	// vhdLeaseTrackRetryInterval is the minimum interval between two attempts to track the lease of a volume
+	// staged before the driver restarted
+	vhdLeaseTrackRetryInterval = 5 * time.Minute
+	vhdLeaseTakeOverCondition  = "deleted or tainted with " + v1.TaintNodeOutOfService
+)
+
+// getVHDLeaseID returns the lease ID of the node, it's derived from the node ID so that
+// the node renews and releases its leases with the same ID after the driver restarts
+func getVHDLeaseID(nodeID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(DefaultDriverName+"/"+nodeID)).String()
+}
+
+// vhdLease is a lease held on the lock file of a VHD disk
+type vhdLease struct {
+	accountName           string
+	accountKey            string
+	shareName             string
+	diskName              string
+	storageEndpointSuffix string
+}
+
+func (l *vhdLease) lockFileClient() (*file.Client, error) {
+	fileClient, err := newAzureFileClient(l.accountName, l.accountKey, l.storageEndpointSuffix)
+	if err != nil || getDataplaneServiceClient(fileClient) == nil {
+		return nil, fmt.Errorf("create Azure File client(%s) failed: %v", l.accountName, err)
+	}
+	return getDataplaneServiceClient(fileClient).NewShareClient(l.shareName).NewRootDirectoryClient().NewFileClient(l.diskName + vhdLockFileSuffix), nil
+}
+
+// vhdLeaseKeeper fences VHD disk volumes to a single node: a lease is acquired on the lock file of the disk
+// in NodeStageVolume, re-asserted every interval while the volume is staged and released in NodeUnstageVolume.
+// File leases never expire, the lease of a node which is deleted or out of service is broken by the next node.
+type vhdLeaseKeeper struct {
+	sync.Mutex
+	nodeID  string
+	leaseID string
+	// leases are indexed by volume ID
+	leases map[string]*vhdLease
+	// trackAttempts are the last attempts to track the leases of volumes staged before the driver restarted
+	trackAttempts map[string]time.Time
+	// isNodeGone returns true if the node holding a lease is deleted or out of service, it's replaced in unit tests
+	isNodeGone func(ctx context.Context, nodeName string) bool
+}
+
+func newVHDLeaseKeeper(nodeID string) *vhdLeaseKeeper {
+	return &vhdLeaseKeeper{
+		nodeID:        nodeID,
+		leaseID:       getVHDLeaseID(nodeID),
+		leases:        map[string]*vhdLease{},
+		trackAttempts: map[string]time.Time{},
+		isNodeGone:    func(context.Context, string) bool { return false },
+	}
+}
+
+// acquire acquires the lease of the disk for this node, codes.FailedPrecondition is returned
+// if the disk is staged on another node
+func (k *vhdLeaseKeeper) acquire(ctx context.Context, volumeID string, l *vhdLease) error {
+	ctx, cancel := context.WithTimeout(ctx, vhdLeaseOperationTimeout)
+	defer cancel()
+	fileClient, err := l.lockFileClient()
+	if err != nil {
+		return err
+	}
+	if _, err := fileClient.GetProperties(ctx, nil); err != nil {
+		if !azerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get lock file of disk(%s): %w", l.diskName, err)
+		}
+		// the lock file may be created and leased by another node at the same time
+		if _, err := fileClient.Create(ctx, 0, nil); err != nil && !azerrors.HasErrorCode(err, leaseIDMissing) {
+			return fmt.Errorf("failed to create lock file of disk(%s): %w", l.diskName, err)
+		}
+	}
+
+	leaseClient, err := lease.NewFileClient(fileClient, &lease.FileClientOptions{LeaseID: ptr.To(k.leaseID)})
+	if err != nil {
+		return err
+	}
+	_, err = leaseClient.Acquire(ctx, nil)
+	if azerrors.HasErrorCode(err, leaseAlreadyPresent) {
+		// the lease may be held by this node, e.g. the volume is staged again after the driver restarts
+		if _, err = leaseClient.Change(ctx, k.leaseID, nil); azerrors.HasErrorCode(err, leaseIDMismatch) {
+			err = k.takeOver(ctx, fileClient, leaseClient, volumeID, l.diskName)
+		}
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return fmt.Errorf("failed to acquire lease of disk(%s): %w", l.diskName, err)
+	}
+
+	if _, err := fileClient.SetMetadata(ctx, &file.SetMetadataOptions{
+		Metadata:              map[string]*string{vhdLeaseHolderMetadata: ptr.To(k.nodeID)},
+		LeaseAccessConditions: &file.LeaseAccessConditions{LeaseID: ptr.To(k.leaseID)},
+	}); err != nil {
+		return fmt.Errorf("failed to record lease holder of disk(%s): %w", l.diskName, err)
+	}
+
+	k.Lock()
+	defer k.Unlock()
+	k.leases[volumeID] = l
+	delete(k.trackAttempts, volumeID)
+	klog.FromContext(ctx).V(2).Info("acquired lease of disk", "disk", l.diskName, "volumeID", volumeID, "node", k.nodeID)
+	return nil
+}
+
+// takeOver breaks the lease of another node and acquires it if the holder is deleted or out of service
+func (k *vhdLeaseKeeper) takeOver(ctx context.Context, fileClient *file.Client, leaseClient *lease.FileClient, volumeID, diskName string) error {
+	props, err := fileClient.GetProperties(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var holder string
+	for key, value := range props.Metadata {
+		if strings.EqualFold(key, vhdLeaseHolderMetadata) {
+			holder = ptr.Deref(value, "")
+		}
+	}
+	if holder == "" || !k.isNodeGone(ctx, holder) {
+		return status.Errorf(codes.FailedPrecondition, "disk(%s) of volume(%s) is staged on node(%s), it can be staged on another node after it's unstaged or the node is %s",
+			diskName, volumeID, holder, vhdLeaseTakeOverCondition)
+	}
+
+	klog.FromContext(ctx).Info("breaking lease of disk held by another node", "disk", diskName, "volumeID", volumeID, "holder", holder, "holderCondition", vhdLeaseTakeOverCondition)
+	if _, err := leaseClient.Break(ctx, nil); err != nil && !azerrors.HasErrorCode(err, leaseNotPresent) {
+		return err
+	}
+	_, err = leaseClient.Acquire(ctx, nil)
+	return err
+}
+
+// release releases the lease of the volume, a lease which is not held by this node is regarded as released
+func (k *vhdLeaseKeeper) release(ctx context.Context, volumeID string, l *vhdLease) error {
+	ctx, cancel := context.WithTimeout(ctx, vhdLeaseOperationTimeout)
+	defer cancel()
+	fileClient, err := l.lockFileClient()
+	if err != nil {
+		return err
+	}
+	leaseClient, err := lease.NewFileClient(fileClient, &lease.FileClientOptions{LeaseID: ptr.To(k.leaseID)})
+	if err != nil {
+		return err
+	}
+	if _, err := leaseClient.Release(ctx, nil); err != nil &&
+		!azerrors.IsNotFound(err) && !azerrors.HasErrorCode(err, leaseNotPresent) && !azerrors.HasErrorCode(err, leaseIDMismatch) {
+		return fmt.Errorf("failed to release lease of disk(%s): %w", l.diskName, err)
+	}
+
+	k.Lock()
+	defer k.Unlock()
+	delete(k.leases, volumeID)
+	delete(k.trackAttempts, volumeID)
+	klog.FromContext(ctx).V(2).Info("released lease of disk", "disk", l.diskName, "volumeID", volumeID, "node", k.nodeID)
+	return nil
+}
+
+// lease returns the lease held for the volume, nil if the volume is not fenced by this driver instance
+func (k *vhdLeaseKeeper) lease(volumeID string) *vhdLease {
+	k.Lock()
+	defer k.Unlock()
+	return k.leases[volumeID]
+}
+
+// track registers the lease of a volume staged before the driver restarted so that it's re-asserted by renewAll,
+// the lease held by this node is not acquired again since the volume is already staged
+func (k *vhdLeaseKeeper) track(ctx context.Context, volumeID string, l *vhdLease) {
+	k.Lock()
+	defer k.Unlock()
+	if _, ok := k.leases[volumeID]; ok {
+		return
+	}
+	k.leases[volumeID] = l
+	delete(k.trackAttempts, volumeID)
+	klog.FromContext(ctx).V(2).Info("tracking lease of disk staged before the driver restarted", "disk", l.diskName, "volumeID", volumeID, "node", k.nodeID)
+}
+
+// shouldTrack returns true if the lease of the volume is not held by this driver instance and it's not tried to be
+// tracked in vhdLeaseTrackRetryInterval, the attempt is recorded if true
+func (k *vhdLeaseKeeper) shouldTrack(volumeID string) bool {
+	k.Lock()
+	defer k.Unlock()
+	if _, ok := k.leases[volumeID]; ok {
+		return false
+	}
+	if lastAttempt, ok := k.trackAttempts[volumeID]; ok && time.Since(lastAttempt) < vhdLeaseTrackRetryInterval {
+		return false
+	}
+	k.trackAttempts[volumeID] = time.Now()
+	return true
+}
+
+// run renews the leases of staged volumes every interval until ctx is done
+func (k *vhdLeaseKeeper) run(ctx context.Context, interval time.Duration) {
+	klog.FromContext(ctx).V(2).Info("VHD lease keeper started", "interval", interval, "leaseID", k.leaseID)
+	wait.UntilWithContext(ctx, k.renewAll, interval)
+}
+
+// renewAll makes sure the leases of staged volumes are still held by this node,
+// a lease which was broken is acquired again unless another node has taken it over
+func (k *vhdLeaseKeeper) renewAll(ctx context.Context) {
+	k.Lock()
+	leases := make(map[string]*vhdLease, len(k.leases))
+	for volumeID, l := range k.leases {
+		leases[volumeID] = l
+	}
+	k.Unlock()
+
+	logger := klog.FromContext(ctx)
+	for volumeID, l := range leases {
+		if err := k.renew(ctx, l); err != nil {
+			logger.Error(err, "failed to renew lease of disk", "disk", l.diskName, "volumeID", volumeID)
+		}
+	}
+}
+
+func (k *vhdLeaseKeeper) renew(ctx context.Context, l *vhdLease) error {
+	ctx, cancel := context.WithTimeout(ctx, vhdLeaseOperationTimeout)
+	defer cancel()
+	fileClient, err := l.lockFileClient()
+	if err != nil {
+		return err
+	}
+	leaseClient, err := lease.NewFileClient(fileClient, &lease.FileClientOptions{LeaseID: ptr.To(k.leaseID)})
+	if err != nil {
+		return err
+	}
+	_, err = leaseClient.Change(ctx, k.leaseID, nil)
+	switch {
+	case err == nil:
+		return nil
+	case azerrors.HasErrorCode(err, leaseNotPresent):
+		klog.FromContext(ctx).Info("lease of disk was broken, acquiring it again", "disk", l.diskName)
+		_, err = leaseClient.Acquire(ctx, nil)
+		return err
+	case azerrors.HasErrorCode(err, leaseIDMismatch):
+		return fmt.Errorf("lease was taken over by another node")
+	}
+	return err
+}
+
+// isNodeGone returns true if the node is deleted or tainted as out of service
+func (d *Driver) isNodeGone(ctx context.Context, nodeName string) bool {
+	if d.kubeClient == nil {
+		return false
+	}
+	node, err := d.kubeClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true
+		}
+		klog.FromContext(ctx).Error(err, "failed to get node", "node", nodeName)
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == v1.TaintNodeOutOfService {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseVHDLease releases the lease of a VHD disk volume in NodeUnstageVolume, the account key is got again
+// if the volume was staged before the driver restarted
+func (d *Driver) releaseVHDLease(ctx context.Context, volumeID string) error {
+	if d.vhdLeaseKeeper == nil {
+		return nil
+	}
+	l := d.vhdLeaseKeeper.lease(volumeID)
+	if l == nil {
+		if l = d.getVHDLease(ctx, volumeID); l == nil {
+			return nil
+		}
+	}
+	return d.vhdLeaseKeeper.release(ctx, volumeID, l)
+}
+
+// trackVHDLease registers the lease of a VHD disk volume staged before the driver restarted, it's called in
+// NodeGetVolumeStats so that a broken lease is noticed by the next renewal
+func (d *Driver) trackVHDLease(ctx context.Context, volumeID, stagingTargetPath string) {
+	if d.vhdLeaseKeeper == nil || stagingTargetPath == "" {
+		return
+	}
+	if _, _, _, diskName, _, _, err := GetFileShareInfo(volumeID); err != nil || !strings.HasSuffix(diskName, vhdSuffix) {
+		return
+	}
+	if !d.vhdLeaseKeeper.shouldTrack(volumeID) {
+		return
+	}
+	if l := d.getVHDLease(ctx, volumeID); l != nil {
+		d.vhdLeaseKeeper.track(ctx, volumeID, l)
+	}
+}
+
+// getVHDLease returns the lease of a VHD disk volume with the account key got again,
+// nil is returned if the volume is not a VHD disk volume or the account key is not available
+func (d *Driver) getVHDLease(ctx context.Context, volumeID string) *vhdLease {
+	_, _, _, diskName, _, _, err := GetFileShareInfo(volumeID)
+	if err != nil || !strings.HasSuffix(diskName, vhdSuffix) {
+		return nil
+	}
+	_, accountName, accountKey, fileShareName, _, _, _, _, err := d.GetAccountInfo(ctx, volumeID, nil, nil)
+	if err != nil || accountKey == "" {
+		klog.FromContext(ctx).Info("lease of disk is not handled since account key is not available", "disk", diskName, "volumeID", volumeID, "err", err)
+		return nil
+	}
+	return &vhdLease{accountName: accountName, accountKey: accountKey, shareName: fileShareName, diskName: diskName, storageEndpointSuffix: d.getStorageEndPointSuffix()}
+}
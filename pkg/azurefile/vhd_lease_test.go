@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/lease"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/provider/storage"
+
+	fakeazure "sigs.k8s.io/azurefile-csi-driver/pkg/azurefile/fake"
+)
+
+func TestGetVHDLeaseID(t *testing.T) {
+	assert.Equal(t, getVHDLeaseID("node1"), getVHDLeaseID("node1"))
+	assert.NotEqual(t, getVHDLeaseID("node1"), getVHDLeaseID("node2"))
+}
+
+func TestVHDLeaseKeeper(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}
+	require.NoError(t, d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "share", RequestGiB: 100}, createStorageAccountSecret("testaccount", accountKey), ""))
+	l := &vhdLease{accountName: "testaccount", accountKey: accountKey, shareName: "share", diskName: "share.vhd", storageEndpointSuffix: fakeStorageEndpointSuffix}
+	lockFile := "share.vhd" + vhdLockFileSuffix
+
+	node1 := newVHDLeaseKeeper("node1")
+	node2 := newVHDLeaseKeeper("node2")
+	require.NoError(t, node1.acquire(ctx, "vol", l))
+	assert.Equal(t, getVHDLeaseID("node1"), server.FileLeaseID("testaccount", "share", lockFile))
+	assert.Equal(t, l, node1.lease("vol"))
+	// the lease is acquired again after the driver restarts
+	require.NoError(t, newVHDLeaseKeeper("node1").acquire(ctx, "vol", l))
+
+	err := node2.acquire(ctx, "vol", l)
+	assert.Equal(t, status.Errorf(codes.FailedPrecondition, "disk(share.vhd) of volume(vol) is staged on node(node1), it can be staged on another node after it's unstaged or the node is %s", vhdLeaseTakeOverCondition), err)
+	assert.Nil(t, node2.lease("vol"))
+
+	require.NoError(t, node2.release(ctx, "vol", l), "lease of another node is regarded as released")
+	assert.Equal(t, getVHDLeaseID("node1"), server.FileLeaseID("testaccount", "share", lockFile))
+
+	// a broken lease is acquired again
+	fileClient, err := l.lockFileClient()
+	require.NoError(t, err)
+	leaseClient, err := lease.NewFileClient(fileClient, nil)
+	require.NoError(t, err)
+	_, err = leaseClient.Break(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, server.FileLeaseID("testaccount", "share", lockFile))
+	node1.renewAll(ctx)
+	assert.Equal(t, getVHDLeaseID("node1"), server.FileLeaseID("testaccount", "share", lockFile))
+
+	require.NoError(t, node1.release(ctx, "vol", l))
+	assert.Empty(t, server.FileLeaseID("testaccount", "share", lockFile))
+	assert.Nil(t, node1.lease("vol"))
+	require.NoError(t, node1.release(ctx, "vol", l), "release is idempotent")
+
+	// lease of a node which is gone is taken over
+	require.NoError(t, node1.acquire(ctx, "vol", l))
+	node2.isNodeGone = func(_ context.Context, nodeName string) bool {
+		assert.Equal(t, "node1", nodeName)
+		return true
+	}
+	require.NoError(t, node2.acquire(ctx, "vol", l))
+	assert.Equal(t, getVHDLeaseID("node2"), server.FileLeaseID("testaccount", "share", lockFile))
+	assert.EqualError(t, node1.renew(ctx, l), "lease was taken over by another node")
+}
+
+func TestTrackVHDLease(t *testing.T) {
+	server := fakeazure.NewServer()
+	defer server.Close()
+	useFakeFileService(t, server)
+	ctx := context.Background()
+	accountKey := server.AddAccount("subsID", "rg", "testaccount", "eastus")
+	d := NewFakeDriver()
+	d.cloud.Environment = &azclient.Environment{StorageEndpointSuffix: fakeStorageEndpointSuffix}
+	require.NoError(t, d.CreateFileShare(ctx, &storage.AccountOptions{Name: "testaccount"}, &ShareOptions{Name: "share", RequestGiB: 100}, createStorageAccountSecret("testaccount", accountKey), ""))
+	volumeID := "rg#testaccount#share#share.vhd"
+	lockFile := "share.vhd" + vhdLockFileSuffix
+
+	// the volume is staged before the driver restarts
+	d.vhdLeaseKeeper = newVHDLeaseKeeper("node1")
+	d.trackVHDLease(ctx, volumeID, "/staging")
+	assert.Nil(t, d.vhdLeaseKeeper.lease(volumeID), "account key is not available")
+	d.accountCacheMap.Set("testaccount", accountKey)
+	d.trackVHDLease(ctx, volumeID, "/staging")
+	assert.Nil(t, d.vhdLeaseKeeper.lease(volumeID), "tracking is retried after the retry interval")
+	d.vhdLeaseKeeper.trackAttempts[volumeID] = time.Now().Add(-vhdLeaseTrackRetryInterval)
+	l := &vhdLease{accountName: "testaccount", accountKey: accountKey, shareName: "share", diskName: "share.vhd", storageEndpointSuffix: fakeStorageEndpointSuffix}
+	require.NoError(t, d.vhdLeaseKeeper.acquire(ctx, volumeID, l))
+
+	// the driver restarts
+	d.vhdLeaseKeeper = newVHDLeaseKeeper("node1")
+	d.trackVHDLease(ctx, "rg#testaccount#share", "/staging")
+	d.trackVHDLease(ctx, volumeID, "")
+	assert.Empty(t, d.vhdLeaseKeeper.leases, "only staged VHD disk volumes are tracked")
+	d.trackVHDLease(ctx, volumeID, "/staging")
+	require.NotNil(t, d.vhdLeaseKeeper.lease(volumeID))
+
+	// a broken lease is noticed and acquired again by the renewal
+	fileClient, err := l.lockFileClient()
+	require.NoError(t, err)
+	leaseClient, err := lease.NewFileClient(fileClient, nil)
+	require.NoError(t, err)
+	_, err = leaseClient.Break(ctx, nil)
+	require.NoError(t, err)
+	d.vhdLeaseKeeper.renewAll(ctx)
+	assert.Equal(t, getVHDLeaseID("node1"), server.FileLeaseID("testaccount", "share", lockFile))
+
+	require.NoError(t, d.releaseVHDLease(ctx, volumeID))
+	assert.Empty(t, server.FileLeaseID("testaccount", "share", lockFile))
+}
+
+func TestIsNodeGone(t *testing.T) {
+	d := NewFakeDriver()
+	ctx := context.Background()
+	assert.False(t, d.isNodeGone(ctx, "node1"), "kubeClient is nil")
+
+	d.kubeClient = fake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Spec:       v1.NodeSpec{Taints: []v1.Taint{{Key: v1.TaintNodeOutOfService, Effect: v1.TaintEffectNoExecute}}},
+		},
+	)
+	assert.False(t, d.isNodeGone(ctx, "node1"))
+	assert.True(t, d.isNodeGone(ctx, "node2"))
+	assert.True(t, d.isNodeGone(ctx, "node3"))
+}
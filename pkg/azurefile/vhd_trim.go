@@ -0,0 +1,163 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+// discard modes of VHD disk volumes, the loop device turns discards into hole punches on the VHD file,
+// which are sent to Azure Files as range clears over SMB so that the share space of deleted blocks is reclaimed
+const (
+	// vhdDiscardModeOnline mounts the filesystem with discard option, blocks are discarded when files are deleted
+	vhdDiscardModeOnline = "online"
+	// vhdDiscardModeFstrim runs fstrim on staged VHD disk volumes periodically
+	vhdDiscardModeFstrim = "fstrim"
+
+	// vhdTrimTimeout is the timeout of a single fstrim run, trimming a large disk over SMB could take a while
+	vhdTrimTimeout = 30 * time.Minute
+)
+
+var (
+	supportedVHDDiscardModeList = []string{"", vhdDiscardModeOnline, vhdDiscardModeFstrim}
+
+	fstrimTrimmedBytesRegexp = regexp.MustCompile(`\((\d+) bytes\)`)
+)
+
+func isSupportedVHDDiscardMode(mode string) bool {
+	for _, v := range supportedVHDDiscardModeList {
+		if mode == v {
+			return true
+		}
+	}
+	return false
+}
+
+// vhdTrimmer runs fstrim on the staged VHD disk volumes periodically, the volumes are trimmed one by one
+// so that the share is not flooded with range clears
+type vhdTrimmer struct {
+	sync.Mutex
+	// paths of the mounted filesystems are indexed by volume ID
+	volumes map[string]string
+	// fstrim is replaced in unit tests
+	fstrim func(ctx context.Context, path string) (int64, error)
+}
+
+func newVHDTrimmer() *vhdTrimmer {
+	return &vhdTrimmer{
+		volumes: map[string]string{},
+		fstrim:  fstrim,
+	}
+}
+
+// add starts trimming the volume mounted on path
+func (t *vhdTrimmer) add(volumeID, path string) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.volumes[volumeID] = path
+}
+
+// remove stops trimming the volume
+func (t *vhdTrimmer) remove(volumeID string) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	delete(t.volumes, volumeID)
+}
+
+// run trims all volumes every interval until ctx is done
+func (t *vhdTrimmer) run(ctx context.Context, interval time.Duration) {
+	klog.FromContext(ctx).V(2).Info("VHD trimmer started", "interval", interval)
+	wait.UntilWithContext(ctx, t.trimAll, interval)
+}
+
+// trimAll trims the volumes in the order of volume ID, a volume removed during the run is skipped
+func (t *vhdTrimmer) trimAll(ctx context.Context) {
+	t.Lock()
+	volumeIDs := make([]string, 0, len(t.volumes))
+	for volumeID := range t.volumes {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	t.Unlock()
+	sort.Strings(volumeIDs)
+
+	for _, volumeID := range volumeIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		t.Lock()
+		path, ok := t.volumes[volumeID]
+		t.Unlock()
+		if !ok {
+			continue
+		}
+		t.trim(ctx, volumeID, path)
+	}
+}
+
+func (t *vhdTrimmer) trim(ctx context.Context, volumeID, path string) {
+	ctx, cancel := context.WithTimeout(ctx, vhdTrimTimeout)
+	defer cancel()
+	start := time.Now()
+	trimmedBytes, err := t.fstrim(ctx, path)
+	csiMetrics.RecordVHDTrim(err == nil, trimmedBytes)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "failed to trim volume", "volumeID", volumeID, "path", path)
+		return
+	}
+	klog.FromContext(ctx).V(2).Info("trimmed volume", "trimmedBytes", trimmedBytes, "volumeID", volumeID, "path", path, "duration", time.Since(start))
+}
+
+// fstrim discards the unused blocks of the filesystem mounted on path and returns the number of bytes discarded
+func fstrim(ctx context.Context, path string) (int64, error) {
+	output, err := exec.CommandContext(ctx, "fstrim", "-v", path).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("fstrim failed with %v, output: %s", err, string(output))
+	}
+	return parseFstrimOutput(string(output)), nil
+}
+
+// parseFstrimOutput parses the number of bytes discarded from the verbose output of fstrim, e.g.
+// "/mnt: 1.5 GiB (1610612736 bytes) trimmed", 0 is returned if the output could not be parsed
+func parseFstrimOutput(output string) int64 {
+	matches := fstrimTrimmedBytesRegexp.FindStringSubmatch(output)
+	if len(matches) < 2 {
+		return 0
+	}
+	trimmedBytes, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return trimmedBytes
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFstrimOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected int64
+	}{
+		{output: "/mnt/disk: 1.5 GiB (1610612736 bytes) trimmed\n", expected: 1610612736},
+		{output: "/mnt/disk: 0 B (0 bytes) trimmed on /dev/loop0\n", expected: 0},
+		{output: "/mnt/disk: 4 KiB (4096 bytes) trimmed on /dev/loop1\n", expected: 4096},
+		{output: "", expected: 0},
+		{output: "fstrim: /mnt/disk: the discard operation is not supported", expected: 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseFstrimOutput(test.output), test.output)
+	}
+}
+
+func TestIsSupportedVHDDiscardMode(t *testing.T) {
+	assert.True(t, isSupportedVHDDiscardMode(""))
+	assert.True(t, isSupportedVHDDiscardMode(vhdDiscardModeOnline))
+	assert.True(t, isSupportedVHDDiscardMode(vhdDiscardModeFstrim))
+	assert.False(t, isSupportedVHDDiscardMode("invalid"))
+}
+
+func TestVHDTrimmer(t *testing.T) {
+	var nilTrimmer *vhdTrimmer
+	nilTrimmer.add("vol", "/mnt")
+	nilTrimmer.remove("vol")
+
+	trimmer := newVHDTrimmer()
+	var trimmed []string
+	trimmer.fstrim = func(_ context.Context, path string) (int64, error) {
+		trimmed = append(trimmed, path)
+		if path == "/mnt/error" {
+			return 0, errors.New("fstrim failed")
+		}
+		return 4096, nil
+	}
+	trimmer.add("vol-b", "/mnt/b")
+	trimmer.add("vol-a", "/mnt/a")
+	trimmer.add("vol-error", "/mnt/error")
+	trimmer.trimAll(context.Background())
+	// a failed volume does not stop trimming the others
+	assert.Equal(t, []string{"/mnt/a", "/mnt/b", "/mnt/error"}, trimmed)
+
+	trimmed = nil
+	trimmer.remove("vol-error")
+	trimmer.trimAll(context.Background())
+	assert.Equal(t, []string{"/mnt/a", "/mnt/b"}, trimmed)
+
+	// no volume is trimmed after ctx is done
+	trimmed = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	trimmer.trimAll(ctx)
+	assert.Empty(t, trimmed)
+}
+
+func TestNewDriverWithVHDDiscardMode(t *testing.T) {
+	tests := []struct {
+		mode            string
+		expectedMode    string
+		expectedTrimmer bool
+	}{
+		{mode: ""},
+		{mode: "invalid"},
+		{mode: "Online", expectedMode: vhdDiscardModeOnline},
+		{mode: vhdDiscardModeFstrim, expectedMode: vhdDiscardModeFstrim, expectedTrimmer: true},
+	}
+	for _, test := range tests {
+		d := NewDriver(&DriverOptions{NodeID: fakeNodeID, DriverName: DefaultDriverName, VHDDiscardMode: test.mode, VHDTrimIntervalSeconds: 60})
+		if runtime.GOOS != "linux" {
+			assert.Empty(t, d.vhdDiscardMode, test.mode)
+			assert.Nil(t, d.vhdTrimmer, test.mode)
+			continue
+		}
+		assert.Equal(t, test.expectedMode, d.vhdDiscardMode, test.mode)
+		assert.Equal(t, test.expectedTrimmer, d.vhdTrimmer != nil, test.mode)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/procfs"
+	"k8s.io/klog/v2"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+const (
+	defaultProcPath      = "/proc"
+	defaultCifsStatsPath = "/proc/fs/cifs/Stats"
+)
+
+var (
+	// e.g. "1) \\account.file.core.windows.net\share"
+	cifsShareRegexp = regexp.MustCompile(`^\d+\) (\\\\\S+)`)
+	// e.g. "Bytes read: 1048576  Bytes written: 2097152"
+	cifsBytesRegexp = regexp.MustCompile(`^Bytes read: (\d+)\s+Bytes written: (\d+)`)
+	// e.g. "Reads: 20 total 1 failed"
+	cifsOpRegexp = regexp.MustCompile(`^(Reads|Writes): (\d+) total (\d+) failed`)
+)
+
+// ioStatsVolume is a staged volume whose I/O counters are collected
+type ioStatsVolume struct {
+	namespace   string
+	pvc         string
+	protocol    string
+	source      string
+	stagingPath string
+}
+
+// volumeIOStatsCollector reads the I/O counters of staged volumes from /proc/self/mountstats for NFS
+// and /proc/fs/cifs/Stats for SMB, volumes are mapped by staging path for NFS and by share UNC for SMB,
+// SMB counters are kept by the kernel per share, so they are exported with the share UNC and are the same
+// on all volumes staged from the share
+type volumeIOStatsCollector struct {
+	sync.Mutex
+	// volumes are indexed by volume ID
+	volumes map[string]ioStatsVolume
+	// maxVolumes limits the label cardinality, volumes over the limit are not exported
+	maxVolumes    int
+	procPath      string
+	cifsStatsPath string
+}
+
+func newVolumeIOStatsCollector(maxVolumes int) *volumeIOStatsCollector {
+	return &volumeIOStatsCollector{
+		volumes:       map[string]ioStatsVolume{},
+		maxVolumes:    maxVolumes,
+		procPath:      defaultProcPath,
+		cifsStatsPath: defaultCifsStatsPath,
+	}
+}
+
+// add starts collecting the I/O counters of the staged volume
+func (c *volumeIOStatsCollector) add(volumeID string, volume ioStatsVolume) {
+	if c == nil {
+		return
+	}
+	if volume.protocol == "" {
+		volume.protocol = smb
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.volumes[volumeID] = volume
+}
+
+// remove stops collecting the I/O counters of the volume
+func (c *volumeIOStatsCollector) remove(volumeID string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	delete(c.volumes, volumeID)
+}
+
+// gather returns the I/O counters of at most maxVolumes volumes ordered by volume ID
+func (c *volumeIOStatsCollector) gather(ctx context.Context) []csiMetrics.VolumeIOStats {
+	logger := klog.FromContext(ctx)
+	c.Lock()
+	volumeIDs := make([]string, 0, len(c.volumes))
+	for volumeID := range c.volumes {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	sort.Strings(volumeIDs)
+	if c.maxVolumes > 0 && len(volumeIDs) > c.maxVolumes {
+		logger.V(4).Info("I/O stats of volumes are not exported since the limit is reached", "volumes", len(volumeIDs)-c.maxVolumes, "limit", c.maxVolumes)
+		volumeIDs = volumeIDs[:c.maxVolumes]
+	}
+	volumes := make([]ioStatsVolume, 0, len(volumeIDs))
+	hasNFS, hasSMB := false, false
+	for _, volumeID := range volumeIDs {
+		volume := c.volumes[volumeID]
+		volumes = append(volumes, volume)
+		if volume.protocol == nfs {
+			hasNFS = true
+		} else {
+			hasSMB = true
+		}
+	}
+	c.Unlock()
+
+	var nfsStats map[string]*procfs.MountStatsNFS
+	if hasNFS {
+		var err error
+		if nfsStats, err = readNFSMountStats(c.procPath); err != nil {
+			logger.Error(err, "failed to read NFS mount stats")
+		}
+	}
+	var smbStats map[string]*cifsShareStats
+	if hasSMB {
+		var err error
+		if smbStats, err = readCifsStats(c.cifsStatsPath); err != nil {
+			logger.Error(err, "failed to read cifs stats")
+		}
+	}
+
+	result := make([]csiMetrics.VolumeIOStats, 0, len(volumes))
+	for i, volume := range volumes {
+		stats := csiMetrics.VolumeIOStats{
+			VolumeID:  volumeIDs[i],
+			Namespace: volume.namespace,
+			PVC:       volume.pvc,
+			Protocol:  volume.protocol,
+		}
+		if volume.protocol == nfs {
+			mountStats, ok := nfsStats[filepath.Clean(volume.stagingPath)]
+			if !ok {
+				continue
+			}
+			stats.ReadBytes, stats.WriteBytes = mountStats.Bytes.ReadTotal, mountStats.Bytes.WriteTotal
+			stats.RPCStatsAvailable = true
+			for _, op := range mountStats.Operations {
+				stats.Retransmissions += op.Transmissions - min(op.Transmissions, op.Requests)
+				if operation := strings.ToLower(op.Operation); operation == "read" || operation == "write" {
+					stats.Operations = append(stats.Operations, csiMetrics.VolumeOperationStats{
+						Operation: operation,
+						Count:     op.Requests,
+						Errors:    op.Errors,
+						Seconds:   float64(op.CumulativeTotalRequestMilliseconds) / 1000,
+					})
+				}
+			}
+		} else {
+			stats.Share = cifsShareKey(volume.source)
+			shareStats, ok := smbStats[stats.Share]
+			if !ok {
+				continue
+			}
+			stats.ReadBytes, stats.WriteBytes = shareStats.readBytes, shareStats.writeBytes
+			stats.Operations = []csiMetrics.VolumeOperationStats{
+				{Operation: "read", Count: shareStats.reads, Errors: shareStats.failedReads},
+				{Operation: "write", Count: shareStats.writes, Errors: shareStats.failedWrites},
+			}
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+// readNFSMountStats returns the NFS mount stats of the driver process indexed by mount point
+func readNFSMountStats(procPath string) (map[string]*procfs.MountStatsNFS, error) {
+	fs, err := procfs.NewFS(procPath)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := fs.Self()
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := proc.MountStats()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]*procfs.MountStatsNFS{}
+	for _, mount := range mounts {
+		if stats, ok := mount.Stats.(*procfs.MountStatsNFS); ok {
+			result[filepath.Clean(mount.Mount)] = stats
+		}
+	}
+	return result, nil
+}
+
+// cifsShareStats are the counters of one share in /proc/fs/cifs/Stats
+type cifsShareStats struct {
+	readBytes    uint64
+	writeBytes   uint64
+	reads        uint64
+	failedReads  uint64
+	writes       uint64
+	failedWrites uint64
+}
+
+// readCifsStats returns the counters of the SMB shares mounted on the node indexed by cifsShareKey
+func readCifsStats(path string) (map[string]*cifsShareStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCifsStats(f)
+}
+
+// parseCifsStats parses /proc/fs/cifs/Stats of SMB2/SMB3 shares, counters of the same share
+// on different connections, e.g. with nosharesock, are summed up
+func parseCifsStats(r io.Reader) (map[string]*cifsShareStats, error) {
+	result := map[string]*cifsShareStats{}
+	var current *cifsShareStats
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := cifsShareRegexp.FindStringSubmatch(line); m != nil {
+			key := strings.ToLower(m[1])
+			if _, ok := result[key]; !ok {
+				result[key] = &cifsShareStats{}
+			}
+			current = result[key]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if m := cifsBytesRegexp.FindStringSubmatch(line); m != nil {
+			current.readBytes += parseUint(m[1])
+			current.writeBytes += parseUint(m[2])
+		} else if m := cifsOpRegexp.FindStringSubmatch(line); m != nil {
+			if m[1] == "Reads" {
+				current.reads += parseUint(m[2])
+				current.failedReads += parseUint(m[3])
+			} else {
+				current.writes += parseUint(m[2])
+				current.failedWrites += parseUint(m[3])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse cifs stats: %v", err)
+	}
+	return result, nil
+}
+
+// cifsShareKey returns the share UNC in lower case of the mount source, e.g. "\\account.file.core.windows.net\share"
+// of "//account.file.core.windows.net/share/folder"
+func cifsShareKey(source string) string {
+	parts := strings.FieldsFunc(source, func(r rune) bool { return r == '/' || r == '\\' })
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.ToLower(`\\` + parts[0] + `\` + parts[1])
+}
+
+func parseUint(s string) uint64 {
+	v, _ := strconv.ParseUint(s, 10, 64)
+	return v
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+const testCifsStats = `Resources in use
+CIFS Session: 2
+Share (unique mount targets): 3
+Total vfs operations: 100 maximum at one time: 2
+
+Max requests in flight: 3
+Total time spent processing by command. Time units are jiffies (250 per second)
+  SMB3 CMD	Number	Total Time	Fastest	Slowest
+  --------	------	----------	-------	-------
+  0		1	0		0	0
+
+1) \\account.file.core.windows.net\IPC$
+SMBs: 10
+2) \\account.file.core.windows.net\share
+SMBs: 150
+Bytes read: 1048576  Bytes written: 2097152
+Open files: 0 total (local), 0 open on server
+TreeConnects: 1 total 0 failed
+Reads: 20 total 1 failed
+Writes: 30 total 2 failed
+3) \\account.file.core.windows.net\Share
+SMBs: 10
+Bytes read: 100  Bytes written: 200
+Reads: 1 total 0 failed
+Writes: 2 total 0 failed
+`
+
+const testMountStats = `device sysfs mounted on /sys with fstype sysfs
+device account.file.core.windows.net:/account/nfsshare mounted on /staging/nfs with fstype nfs4 statvers=1.1
+	opts:	rw,vers=4.1,rsize=1048576,wsize=1048576,namlen=255,hard,proto=tcp,timeo=600,retrans=2,sec=sys
+	age:	100
+	caps:	caps=0x3ffdf,wtmult=512,dtsize=32768,bsize=0,namlen=255
+	sec:	flavor=1,pseudoflavor=1
+	events:	0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+	bytes:	100 200 0 0 1048576 2097152 256 512
+	RPC iostats version: 1.1  p/v: 100003/4 (nfs)
+	xprt:	tcp 0 1 1 0 0 10 10 0 10 0 2 0 0
+	per-op statistics
+	        NULL: 0 0 0 0 0 0 0 0 0
+	        READ: 10 12 0 1000 1049576 5 2000 2100 1
+	       WRITE: 20 21 0 2098152 2000 6 3000 3500 0
+	      GETATTR: 5 6 0 500 600 1 10 12 0
+
+`
+
+func TestParseCifsStats(t *testing.T) {
+	stats, err := parseCifsStats(strings.NewReader(testCifsStats))
+	require.NoError(t, err)
+	assert.Equal(t, &cifsShareStats{}, stats[`\\account.file.core.windows.net\ipc$`])
+	// counters of the same share on different connections are summed up
+	assert.Equal(t, &cifsShareStats{
+		readBytes: 1048676, writeBytes: 2097352, reads: 21, failedReads: 1, writes: 32, failedWrites: 2,
+	}, stats[`\\account.file.core.windows.net\share`])
+}
+
+func TestCifsShareKey(t *testing.T) {
+	assert.Equal(t, `\\account.file.core.windows.net\share`, cifsShareKey("//account.file.core.windows.net/Share/folder"))
+	assert.Equal(t, `\\10.0.0.1\share`, cifsShareKey(`\\10.0.0.1\share`))
+	assert.Equal(t, "", cifsShareKey("invalid"))
+}
+
+func TestVolumeIOStatsCollectorGather(t *testing.T) {
+	var nilCollector *volumeIOStatsCollector
+	nilCollector.add("vol", ioStatsVolume{})
+	nilCollector.remove("vol")
+
+	procPath := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(procPath, "123"), 0755))
+	require.NoError(t, os.Symlink("123", filepath.Join(procPath, "self")))
+	require.NoError(t, os.WriteFile(filepath.Join(procPath, "123", "mountstats"), []byte(testMountStats), 0644))
+	cifsStatsPath := filepath.Join(t.TempDir(), "Stats")
+	require.NoError(t, os.WriteFile(cifsStatsPath, []byte(testCifsStats), 0644))
+
+	c := newVolumeIOStatsCollector(2)
+	c.procPath = procPath
+	c.cifsStatsPath = cifsStatsPath
+	c.add("vol-nfs", ioStatsVolume{namespace: "ns", pvc: "pvc-nfs", protocol: nfs, source: "account.file.core.windows.net:/account/nfsshare", stagingPath: "/staging/nfs/"})
+	c.add("vol-smb", ioStatsVolume{namespace: "ns", pvc: "pvc-smb", source: "//account.file.core.windows.net/share/folder", stagingPath: "/staging/smb"})
+	// volumes over the limit are not exported
+	c.add("vol-tmp", ioStatsVolume{source: "//account.file.core.windows.net/share", stagingPath: "/staging/tmp"})
+
+	stats := c.gather(context.Background())
+	require.Len(t, stats, 2)
+	assert.Equal(t, csiMetrics.VolumeIOStats{
+		VolumeID: "vol-nfs", Namespace: "ns", PVC: "pvc-nfs", Protocol: nfs,
+		ReadBytes: 1048576, WriteBytes: 2097152,
+		Operations: []csiMetrics.VolumeOperationStats{
+			{Operation: "read", Count: 10, Errors: 1, Seconds: 2.1},
+			{Operation: "write", Count: 20, Errors: 0, Seconds: 3.5},
+		},
+		RPCStatsAvailable: true,
+		Retransmissions:   4,
+	}, stats[0])
+	assert.Equal(t, csiMetrics.VolumeIOStats{
+		VolumeID: "vol-smb", Namespace: "ns", PVC: "pvc-smb", Protocol: smb, Share: `\\account.file.core.windows.net\share`,
+		ReadBytes: 1048676, WriteBytes: 2097352,
+		Operations: []csiMetrics.VolumeOperationStats{
+			{Operation: "read", Count: 21, Errors: 1},
+			{Operation: "write", Count: 32, Errors: 2},
+		},
+	}, stats[1])
+
+	// volumes which are not found in the kernel stats are skipped
+	c.remove("vol-smb")
+	c.add("vol-missing", ioStatsVolume{protocol: nfs, stagingPath: "/staging/missing"})
+	stats = c.gather(context.Background())
+	require.Len(t, stats, 1)
+	assert.Equal(t, "vol-nfs", stats[0].VolumeID)
+
+	c.cifsStatsPath = filepath.Join(t.TempDir(), "not-exist")
+	c.remove("vol-missing")
+	stats = c.gather(context.Background())
+	require.Len(t, stats, 1)
+	assert.Equal(t, "vol-nfs", stats[0].VolumeID)
+}
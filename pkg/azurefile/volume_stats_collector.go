@@ -0,0 +1,289 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	azcache "sigs.k8s.io/cloud-provider-azure/pkg/cache"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
+)
+
+// results of background volume stats calls
+const (
+	volumeStatsSuccess = "success"
+	volumeStatsFailure = "failure"
+	volumeStatsTimeout = "timeout"
+)
+
+// volumeStatsSnapshot is the result of the last completed volume stats call
+type volumeStatsSnapshot struct {
+	resp        *csi.NodeGetVolumeStatsResponse
+	err         error
+	collectedAt time.Time
+}
+
+type collectedVolume struct {
+	path     string
+	snapshot *volumeStatsSnapshot
+	// collecting is true while a volume stats call is running, a call hanging on a dead mount is never started again
+	collecting bool
+}
+
+// volumeStatsCollector refreshes the stats of staged volumes in background with a bounded-timeout call,
+// so that NodeGetVolumeStats answers from the last snapshot and never blocks on a hung share
+type volumeStatsCollector struct {
+	sync.Mutex
+	interval time.Duration
+	timeout  time.Duration
+	// volumes are indexed by volume ID
+	volumes map[string]*collectedVolume
+	// checking is the set of volume paths whose existence check is running, a check hanging on a dead mount is never started again
+	checking map[string]bool
+	// getVolumeStats and checkPathExists are replaced in unit tests
+	getVolumeStats  func(path string) (*csi.NodeGetVolumeStatsResponse, error)
+	checkPathExists func(path string) error
+}
+
+func newVolumeStatsCollector(interval, timeout time.Duration, getVolumeStats func(path string) (*csi.NodeGetVolumeStatsResponse, error)) *volumeStatsCollector {
+	return &volumeStatsCollector{
+		interval:        interval,
+		timeout:         timeout,
+		volumes:         map[string]*collectedVolume{},
+		checking:        map[string]bool{},
+		getVolumeStats:  getVolumeStats,
+		checkPathExists: checkVolumePathExists,
+	}
+}
+
+// add starts collecting the stats of the volume, the last snapshot is kept if the path is not changed
+func (c *volumeStatsCollector) add(volumeID, path string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if v, ok := c.volumes[volumeID]; ok && v.path == path {
+		return
+	}
+	c.volumes[volumeID] = &collectedVolume{path: path}
+}
+
+// remove stops collecting the stats of the volume
+func (c *volumeStatsCollector) remove(volumeID string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	delete(c.volumes, volumeID)
+}
+
+// isCollected returns true if the stats of the volume are collected
+func (c *volumeStatsCollector) isCollected(volumeID string) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.volumes[volumeID]
+	return ok
+}
+
+// snapshot returns the last snapshot of the volume, false if the volume is not collected yet
+func (c *volumeStatsCollector) snapshot(volumeID string) (volumeStatsSnapshot, bool) {
+	c.Lock()
+	defer c.Unlock()
+	if v, ok := c.volumes[volumeID]; ok && v.snapshot != nil {
+		return *v.snapshot, true
+	}
+	return volumeStatsSnapshot{}, false
+}
+
+// run refreshes the stats of all volumes every interval until ctx is done
+func (c *volumeStatsCollector) run(ctx context.Context) {
+	klog.FromContext(ctx).V(2).Info("volume stats collector started", "interval", c.interval, "timeout", c.timeout)
+	wait.UntilWithContext(ctx, c.refreshAll, c.interval)
+}
+
+// refreshAll refreshes the stats of all volumes in parallel and waits until all calls are finished or timed out
+func (c *volumeStatsCollector) refreshAll(ctx context.Context) {
+	c.Lock()
+	volumeIDs := make([]string, 0, len(c.volumes))
+	for volumeID := range c.volumes {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	c.Unlock()
+
+	var wg sync.WaitGroup
+	for _, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(volumeID string) {
+			defer wg.Done()
+			c.refresh(ctx, volumeID)
+		}(volumeID)
+	}
+	wg.Wait()
+}
+
+// refresh gets the stats of the volume and saves the snapshot, the last snapshot is kept if the call times out
+func (c *volumeStatsCollector) refresh(ctx context.Context, volumeID string) {
+	logger := klog.FromContext(ctx)
+	c.Lock()
+	v, ok := c.volumes[volumeID]
+	if !ok {
+		c.Unlock()
+		return
+	}
+	if v.collecting {
+		c.Unlock()
+		logger.V(4).Info("skip collecting stats of volume since the previous call is still hanging", "volumeID", volumeID, "path", v.path)
+		csiMetrics.RecordVolumeStatsCollectionSkipped()
+		return
+	}
+	v.collecting = true
+	path := v.path
+	c.Unlock()
+
+	done := make(chan volumeStatsSnapshot, 1)
+	start := time.Now()
+	go func() {
+		resp, err := c.getVolumeStats(path)
+		c.Lock()
+		v.collecting = false
+		c.Unlock()
+		done <- volumeStatsSnapshot{resp: resp, err: err, collectedAt: time.Now()}
+	}()
+
+	select {
+	case snapshot := <-done:
+		result := volumeStatsSuccess
+		if snapshot.err != nil {
+			result = volumeStatsFailure
+			logger.V(4).Info("failed to collect stats of volume", "volumeID", volumeID, "path", path, "err", snapshot.err)
+		}
+		csiMetrics.ObserveVolumeStatsCollection(result, time.Since(start))
+		c.Lock()
+		defer c.Unlock()
+		// the volume may be unstaged or restaged on another path during the call
+		if current, ok := c.volumes[volumeID]; ok && current == v {
+			v.snapshot = &snapshot
+		}
+	case <-time.After(c.timeout):
+		logger.Info("collecting stats of volume timed out, the last snapshot is kept", "volumeID", volumeID, "path", path, "timeout", c.timeout)
+		csiMetrics.ObserveVolumeStatsCollection(volumeStatsTimeout, c.timeout)
+	}
+}
+
+// checkVolumePath returns NotFound if the volume path does not exist, the check is bounded by the collector timeout,
+// it's skipped if it times out or the previous check of the path is still hanging, the mount is probed by the mount health monitor then
+func (c *volumeStatsCollector) checkVolumePath(ctx context.Context, path string) error {
+	logger := klog.FromContext(ctx)
+	c.Lock()
+	if c.checking[path] {
+		c.Unlock()
+		logger.V(4).Info("skip checking volume path since the previous check is still hanging", "path", path)
+		return nil
+	}
+	c.checking[path] = true
+	c.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.checkPathExists(path)
+		c.Lock()
+		delete(c.checking, path)
+		c.Unlock()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(c.timeout):
+		logger.Info("checking volume path timed out", "path", path, "timeout", c.timeout)
+		return nil
+	}
+}
+
+// volumeStatsFromSnapshot answers NodeGetVolumeStats from the last snapshot of the background collector,
+// the volume is collected from now on if it was staged before the driver restarted.
+// Before the first snapshot of the volume, the stats of another volume on the same file share (shareVolumeID)
+// are returned if they are cached, otherwise the stats are collected in the call bounded by the collector timeout
+// and Unavailable is returned if the call times out.
+func (d *Driver) volumeStatsFromSnapshot(ctx context.Context, req *csi.NodeGetVolumeStatsRequest, shareVolumeID string) (*csi.NodeGetVolumeStatsResponse, error) {
+	logger := klog.FromContext(ctx)
+	c := d.volumeStatsCollector
+	if err := c.checkVolumePath(ctx, req.VolumePath); err != nil {
+		return nil, err
+	}
+	if !c.isCollected(req.VolumeId) {
+		path := req.GetStagingTargetPath()
+		if path == "" {
+			path = req.VolumePath
+		}
+		c.add(req.VolumeId, path)
+	}
+	snapshot, ok := c.snapshot(req.VolumeId)
+	if !ok {
+		if shareVolumeID != "" {
+			cache, err := d.volStatsCache.Get(ctx, shareVolumeID, azcache.CacheReadTypeDefault)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "%v", err)
+			}
+			if cache != nil {
+				logger.V(6).Info("NodeGetVolumeStats: volume stats of the file share is cached", "path", req.VolumePath)
+				return d.withVolumeCondition(req.VolumeId, cache.(*csi.NodeGetVolumeStatsResponse)), nil
+			}
+		}
+		c.refresh(ctx, req.VolumeId)
+		if snapshot, ok = c.snapshot(req.VolumeId); !ok {
+			return nil, status.Errorf(codes.Unavailable, "stats of volume(%s) are not collected yet", req.VolumeId)
+		}
+	}
+	if snapshot.err != nil {
+		return nil, snapshot.err
+	}
+	if shareVolumeID != "" && snapshot.resp != nil {
+		d.volStatsCache.Set(shareVolumeID, snapshot.resp)
+	}
+	resp := d.withVolumeCondition(req.VolumeId, snapshot.resp)
+	if age := time.Since(snapshot.collectedAt); age > 2*c.interval {
+		// the stats calls on the volume time out, the stale stats is returned with a message in the volume condition
+		// if the volume condition is advertised in the node capabilities
+		logger.V(2).Info("NodeGetVolumeStats: volume stats is stale", "path", req.VolumePath, "collectedAt", snapshot.collectedAt)
+		if d.ValidateNodeServiceRequest(csi.NodeServiceCapability_RPC_VOLUME_CONDITION) != nil {
+			return resp, nil
+		}
+		message := fmt.Sprintf("volume stats is stale, last collected %v ago", age.Round(time.Second))
+		if resp.GetVolumeCondition().GetMessage() != "" {
+			message = resp.GetVolumeCondition().GetMessage() + ", " + message
+		}
+		resp = &csi.NodeGetVolumeStatsResponse{
+			Usage:           resp.GetUsage(),
+			VolumeCondition: &csi.VolumeCondition{Abnormal: resp.GetVolumeCondition().GetAbnormal(), Message: message},
+		}
+	}
+	logger.V(6).Info("NodeGetVolumeStats: volume stats is answered from snapshot", "path", req.VolumePath, "collectedAt", snapshot.collectedAt)
+	return resp, nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azurefile
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fakeVolumeStats(total int64) *csi.NodeGetVolumeStatsResponse {
+	return &csi.NodeGetVolumeStatsResponse{Usage: []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Total: total}}}
+}
+
+func TestVolumeStatsCollectorRefresh(t *testing.T) {
+	var nilCollector *volumeStatsCollector
+	nilCollector.add("vol", "/mnt")
+	nilCollector.remove("vol")
+
+	var statsErr error
+	c := newVolumeStatsCollector(time.Minute, time.Second, func(path string) (*csi.NodeGetVolumeStatsResponse, error) {
+		assert.Equal(t, "/mnt", path)
+		return fakeVolumeStats(100), statsErr
+	})
+	c.add("vol", "/mnt")
+	assert.True(t, c.isCollected("vol"))
+	_, ok := c.snapshot("vol")
+	assert.False(t, ok, "volume is not collected yet")
+
+	c.refreshAll(context.Background())
+	snapshot, ok := c.snapshot("vol")
+	require.True(t, ok)
+	assert.Equal(t, int64(100), snapshot.resp.GetUsage()[0].GetTotal())
+	assert.NoError(t, snapshot.err)
+
+	// snapshot is kept if the volume is added on the same path again
+	c.add("vol", "/mnt")
+	_, ok = c.snapshot("vol")
+	assert.True(t, ok)
+
+	statsErr = status.Error(codes.NotFound, "not found")
+	c.refresh(context.Background(), "vol")
+	snapshot, _ = c.snapshot("vol")
+	assert.Equal(t, statsErr, snapshot.err)
+
+	c.remove("vol")
+	assert.False(t, c.isCollected("vol"))
+	// refresh of an unknown volume is a no-op
+	c.refresh(context.Background(), "vol")
+}
+
+func TestVolumeStatsCollectorHangingCall(t *testing.T) {
+	release := make(chan struct{})
+	var hang atomic.Bool
+	c := newVolumeStatsCollector(time.Minute, 10*time.Millisecond, func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		if hang.Load() {
+			<-release
+		}
+		return fakeVolumeStats(100), nil
+	})
+	c.add("vol", "/mnt")
+	c.refresh(context.Background(), "vol")
+	before, ok := c.snapshot("vol")
+	require.True(t, ok)
+
+	// the last snapshot is kept if the call times out
+	hang.Store(true)
+	c.refresh(context.Background(), "vol")
+	after, _ := c.snapshot("vol")
+	assert.Equal(t, before.collectedAt, after.collectedAt)
+	// no more call is started while the previous one is hanging
+	c.refresh(context.Background(), "vol")
+	c.Lock()
+	assert.True(t, c.volumes["vol"].collecting)
+	c.Unlock()
+
+	hang.Store(false)
+	close(release)
+	assert.Eventually(t, func() bool {
+		c.refresh(context.Background(), "vol")
+		snapshot, _ := c.snapshot("vol")
+		return snapshot.collectedAt.After(before.collectedAt)
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestNodeGetVolumeStatsFromSnapshot(t *testing.T) {
+	d := NewFakeDriver()
+	calls := 0
+	d.volumeStatsCollector = newVolumeStatsCollector(time.Minute, time.Second, func(path string) (*csi.NodeGetVolumeStatsResponse, error) {
+		calls++
+		assert.Equal(t, "/staging", path)
+		return fakeVolumeStats(int64(100 * calls)), nil
+	})
+	volumeID := "rg#account#share-stats"
+	req := &csi.NodeGetVolumeStatsRequest{VolumeId: volumeID, VolumePath: t.TempDir(), StagingTargetPath: "/staging"}
+
+	// the first call of a volume staged before the driver restarted collects the stats
+	resp, err := d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), resp.GetUsage()[0].GetTotal())
+	assert.Nil(t, resp.GetVolumeCondition())
+
+	// following calls answer from the snapshot
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), resp.GetUsage()[0].GetTotal())
+	assert.Equal(t, 1, calls)
+
+	d.volumeStatsCollector.refreshAll(context.Background())
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), resp.GetUsage()[0].GetTotal())
+
+	// stale snapshot is returned as is if the volume condition is not advertised
+	d.volumeStatsCollector.Lock()
+	d.volumeStatsCollector.volumes[volumeID].snapshot.collectedAt = time.Now().Add(-time.Hour)
+	d.volumeStatsCollector.Unlock()
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), resp.GetUsage()[0].GetTotal())
+	assert.Nil(t, resp.GetVolumeCondition())
+
+	// stale snapshot is returned with a message in the volume condition
+	d.AddNodeServiceCapabilities([]csi.NodeServiceCapability_RPC_Type{csi.NodeServiceCapability_RPC_VOLUME_CONDITION})
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), resp.GetUsage()[0].GetTotal())
+	assert.False(t, resp.GetVolumeCondition().GetAbnormal())
+	assert.Contains(t, resp.GetVolumeCondition().GetMessage(), "volume stats is stale")
+
+	// error of the last call is returned
+	d.volumeStatsCollector.getVolumeStats = func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		return nil, status.Error(codes.Internal, "failed to get metrics")
+	}
+	d.volumeStatsCollector.refreshAll(context.Background())
+	_, err = d.NodeGetVolumeStats(context.Background(), req)
+	assert.Equal(t, status.Error(codes.Internal, "failed to get metrics"), err)
+}
+
+func TestNodeGetVolumeStatsFromSnapshotUnavailable(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	d := NewFakeDriver()
+	d.volumeStatsCollector = newVolumeStatsCollector(time.Minute, 10*time.Millisecond, func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		<-release
+		return nil, errors.New(syscall.EHOSTDOWN.Error())
+	})
+	volumePath := t.TempDir()
+	req := &csi.NodeGetVolumeStatsRequest{VolumeId: "vol", VolumePath: volumePath}
+	_, err := d.NodeGetVolumeStats(context.Background(), req)
+	assert.Equal(t, status.Error(codes.Unavailable, "stats of volume(vol) are not collected yet"), err)
+	// the volume is collected on the volume path if the staging path is unknown
+	assert.Equal(t, volumePath, d.volumeStatsCollector.volumes["vol"].path)
+
+	// volume path which does not exist is checked before the snapshot
+	req = &csi.NodeGetVolumeStatsRequest{VolumeId: "vol-not-exist", VolumePath: filepath.Join(volumePath, "not-exist")}
+	_, err = d.NodeGetVolumeStats(context.Background(), req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.False(t, d.volumeStatsCollector.isCollected("vol-not-exist"))
+}
+
+func TestNodeGetVolumeStatsFromSnapshotHangingPathCheck(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	d := NewFakeDriver()
+	d.volumeStatsCollector = newVolumeStatsCollector(time.Minute, 10*time.Millisecond, func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		return fakeVolumeStats(100), nil
+	})
+	var checks atomic.Int32
+	d.volumeStatsCollector.checkPathExists = func(string) error {
+		checks.Add(1)
+		<-release
+		return nil
+	}
+	req := &csi.NodeGetVolumeStatsRequest{VolumeId: "vol", VolumePath: "/pods/volume", StagingTargetPath: "/staging"}
+
+	// the stats is answered from the snapshot when the check of the volume path hangs on a dead mount
+	for i := 0; i < 3; i++ {
+		resp, err := d.NodeGetVolumeStats(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), resp.GetUsage()[0].GetTotal())
+	}
+	// the hanging check is never started again
+	assert.Equal(t, int32(1), checks.Load())
+}
+
+func TestNodeGetVolumeStatsFromSnapshotSubPath(t *testing.T) {
+	d := NewFakeDriver()
+	calls := 0
+	d.volumeStatsCollector = newVolumeStatsCollector(time.Minute, time.Second, func(string) (*csi.NodeGetVolumeStatsResponse, error) {
+		calls++
+		return fakeVolumeStats(100), nil
+	})
+
+	// the stats of a volume on the file share are shared by the other volumes on subPaths of the same file share
+	req := &csi.NodeGetVolumeStatsRequest{VolumeId: "rg#account#share/dir1", VolumePath: t.TempDir(), StagingTargetPath: "/staging1"}
+	resp, err := d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), resp.GetUsage()[0].GetTotal())
+	req = &csi.NodeGetVolumeStatsRequest{VolumeId: "rg#account#share/dir2", VolumePath: t.TempDir(), StagingTargetPath: "/staging2"}
+	resp, err = d.NodeGetVolumeStats(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), resp.GetUsage()[0].GetTotal())
+	assert.Equal(t, 1, calls)
+	// the second volume is collected in background from now on
+	assert.True(t, d.volumeStatsCollector.isCollected("rg#account#share/dir2"))
+}
+
+func TestGetShareVolumeID(t *testing.T) {
+	assert.Equal(t, "#account#share###", getShareVolumeID("rg#account#share/dir1/dir2"))
+	assert.Equal(t, "#account#share###ns", getShareVolumeID("rg#account#share#uuid##ns"))
+	assert.Equal(t, "", getShareVolumeID("invalid"))
+}
@@ -0,0 +1,37 @@
+//go:build faultinjection
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile"
+)
+
+var faultInjectionScenarioFile = flag.String("fault-injection-scenario-file", "", "path of the scenario file which defines faults injected into Azure calls, only for testing")
+
+// setupFaultInjection enables fault injection if the scenario file is specified,
+// the flag is only available in test builds: go build -tags faultinjection
+func setupFaultInjection(ctx context.Context) error {
+	if *faultInjectionScenarioFile == "" {
+		return nil
+	}
+	return azurefile.EnableFaultInjection(ctx, *faultInjectionScenarioFile)
+}
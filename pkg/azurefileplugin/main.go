@@ -24,9 +24,12 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/azurefile-csi-driver/pkg/azurefile"
+	csicommon "sigs.k8s.io/azurefile-csi-driver/pkg/csi-common"
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
 
 	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
@@ -42,6 +45,9 @@ func init() {
 var (
 	version        = flag.Bool("version", false, "Print the version and exit.")
 	metricsAddress = flag.String("metrics-address", "", "export the metrics")
+	loggingFormat  = flag.String("logging-format", csicommon.LogFormatText, "log output format, \"text\" or \"json\"")
+	metricsLabels  = flag.String("metrics-extra-labels", "", "comma separated extra labels of the operation status metrics, supported labels: protocol, storage_account_type")
+	metricsBuckets = flag.String("metrics-duration-buckets", "", "comma separated buckets in seconds of the operation duration histograms, e.g. 1,10,60,600,3600 for long running clone operations")
 	driverOptions  azurefile.DriverOptions
 )
 
@@ -52,6 +58,9 @@ var exit = func(code int) {
 
 func main() {
 	flag.Parse()
+	if err := csicommon.SetLogFormat(*loggingFormat); err != nil {
+		klog.Fatalln(err)
+	}
 	if *version {
 		info, err := azurefile.GetVersionYAML(driverOptions.DriverName)
 		if err != nil {
@@ -59,6 +68,9 @@ func main() {
 		}
 		fmt.Println(info) // nolint
 	} else {
+		if err := configureMetrics(*metricsLabels, *metricsBuckets); err != nil {
+			klog.Fatalln(err)
+		}
 		exportMetrics()
 		handle()
 	}
@@ -68,6 +80,9 @@ func main() {
 func handle() {
 	runtime.GOMAXPROCS(driverOptions.GoMaxProcs)
 	klog.Infof("Sys info: NumCPU: %v MAXPROC: %v", runtime.NumCPU(), runtime.GOMAXPROCS(0))
+	if err := setupFaultInjection(context.Background()); err != nil {
+		klog.Fatalln(err)
+	}
 
 	driver := azurefile.NewDriver(&driverOptions)
 	if driver == nil {
@@ -78,6 +93,27 @@ func handle() {
 	}
 }
 
+func configureMetrics(labels, buckets string) error {
+	var extraLabels []string
+	for _, label := range strings.Split(labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			extraLabels = append(extraLabels, label)
+		}
+	}
+	var durationBuckets []float64
+	for _, bucket := range strings.Split(buckets, ",") {
+		if bucket = strings.TrimSpace(bucket); bucket == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(bucket, 64)
+		if err != nil {
+			return fmt.Errorf("invalid metrics bucket %q: %w", bucket, err)
+		}
+		durationBuckets = append(durationBuckets, value)
+	}
+	return csiMetrics.ConfigureOperationMetrics(extraLabels, durationBuckets)
+}
+
 func exportMetrics() {
 	if *metricsAddress == "" {
 		return
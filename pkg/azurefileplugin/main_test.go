@@ -22,6 +22,10 @@ import (
 	"os"
 	"reflect"
 	"testing"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	csiMetrics "sigs.k8s.io/azurefile-csi-driver/pkg/metrics"
 )
 
 func TestMain(t *testing.T) {
@@ -80,3 +84,58 @@ func TestTrapClosedConnErr(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigureMetrics(t *testing.T) {
+	tests := []struct {
+		labels      string
+		buckets     string
+		expectedErr bool
+	}{
+		{labels: "volume_id", expectedErr: true},
+		{buckets: "1,ten", expectedErr: true},
+		{labels: "protocol, storage_account_type", buckets: "1, 10,60,600,3600"},
+		// operation metrics could only be configured once
+		{labels: "", buckets: "", expectedErr: true},
+	}
+	for _, test := range tests {
+		err := configureMetrics(test.labels, test.buckets)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("labels: %q, buckets: %q, unexpected error: %v", test.labels, test.buckets, err)
+		}
+	}
+
+	csiMetrics.NewCSIMetricContext("controller_create_volume_from_volume").ObserveWithError(nil,
+		csiMetrics.LabelProtocol, "smb", csiMetrics.LabelStorageAccountType, "Premium_LRS")
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_operation_status_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			found = true
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			expectedLabels := map[string]string{"operation": "controller_create_volume_from_volume", "grpc_code": "OK",
+				"error_category": "none", "protocol": "smb", "storage_account_type": "Premium_LRS"}
+			if !reflect.DeepEqual(labels, expectedLabels) {
+				t.Errorf("expected labels %v, got %v", expectedLabels, labels)
+			}
+			var upperBounds []float64
+			for _, b := range m.GetHistogram().GetBucket() {
+				upperBounds = append(upperBounds, b.GetUpperBound())
+			}
+			if !reflect.DeepEqual(upperBounds, []float64{1, 10, 60, 600, 3600}) {
+				t.Errorf("unexpected buckets %v", upperBounds)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected to find operation status duration histogram")
+	}
+}
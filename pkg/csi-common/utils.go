@@ -19,17 +19,24 @@ package csicommon
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"net"
 	"os"
 	"runtime"
 	"strings"
 
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"k8s.io/klog/v2"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/kubernetes-csi/csi-lib-utils/protosanitizer"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/tracing"
 )
 
 func ParseEndpoint(ep string) (string, string, error) {
@@ -100,20 +107,89 @@ func getLogLevel(method string) int32 {
 	return 2
 }
 
+// LogGRPC logs the gRPC call, and injects a logger carrying the request ID, the volume and snapshot IDs of the request
+// and the trace ID into the context, so that all log lines written through klog.FromContext(ctx) could be correlated.
 func LogGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	level := klog.Level(getLogLevel(info.FullMethod))
-	klog.V(level).Infof("GRPC call: %s", info.FullMethod)
-	klog.V(level).Infof("GRPC request: %s", StripSensitiveValue(protosanitizer.StripSecrets(req), "csi.storage.k8s.io/serviceAccount.tokens"))
+	level := int(getLogLevel(info.FullMethod))
+	logger := klog.LoggerWithValues(klog.FromContext(ctx), requestLogValues(ctx, req)...)
+	ctx = klog.NewContext(ctx, logger)
+	// request and response are logged as strings since structured loggers, e.g. the json format,
+	// do not call String() of the sanitizing wrappers, the strings are only built if the level is enabled
+	verbose := logger.V(level)
+	verbose.Info("GRPC call", "method", info.FullMethod)
+	if verbose.Enabled() {
+		verbose.Info("GRPC request", "request", StripSensitiveValue(protosanitizer.StripSecrets(req), "csi.storage.k8s.io/serviceAccount.tokens").String())
+	}
 
 	resp, err := handler(ctx, req)
 	if err != nil {
-		klog.Errorf("GRPC error: %v", err)
-	} else {
-		klog.V(level).Infof("GRPC response: %s", protosanitizer.StripSecrets(resp))
+		logger.Error(err, "GRPC error", "method", info.FullMethod)
+	} else if verbose.Enabled() {
+		verbose.Info("GRPC response", "response", protosanitizer.StripSecrets(resp).String())
 	}
 	return resp, err
 }
 
+// requestLogValues returns the key value pairs identifying the request in log lines
+func requestLogValues(ctx context.Context, req interface{}) []interface{} {
+	values := []interface{}{"requestID", uuid.NewString()}
+	if r, ok := req.(interface{ GetVolumeId() string }); ok && r.GetVolumeId() != "" {
+		values = append(values, "volumeID", r.GetVolumeId())
+	}
+	if r, ok := req.(interface{ GetSourceVolumeId() string }); ok && r.GetSourceVolumeId() != "" {
+		values = append(values, "volumeID", r.GetSourceVolumeId())
+	}
+	if r, ok := req.(interface{ GetSnapshotId() string }); ok && r.GetSnapshotId() != "" {
+		values = append(values, "snapshotID", r.GetSnapshotId())
+	}
+	if r, ok := req.(interface{ GetName() string }); ok && r.GetName() != "" {
+		values = append(values, "name", r.GetName())
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		values = append(values, "traceID", traceID)
+	}
+	return values
+}
+
+const (
+	// LogFormatText is the default klog text format
+	LogFormatText = "text"
+	// LogFormatJSON writes one JSON object per line
+	LogFormatJSON = "json"
+)
+
+// SetLogFormat sets the output format of klog, it must be called before any goroutine starts logging.
+func SetLogFormat(format string) error {
+	return setLogFormat(format, os.Stderr)
+}
+
+func setLogFormat(format string, w io.Writer) error {
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		return nil
+	case LogFormatJSON:
+		// verbosity is checked by klog, so the handler must not drop any level
+		handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.Level(math.MinInt), ReplaceAttr: replaceLevelAttr})
+		klog.SetLoggerWithOptions(logr.FromSlogHandler(handler))
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format %q, supported formats: %s, %s", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// replaceLevelAttr writes the klog verbosity of info lines as "v", e.g. "v":2 instead of "level":"DEBUG+2",
+// the same as the json format of Kubernetes components, warning and error lines keep their level
+func replaceLevelAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
+		return a
+	}
+	level, ok := a.Value.Any().(slog.Level)
+	if !ok || level > slog.LevelInfo {
+		return a
+	}
+	return slog.Int("v", int(slog.LevelInfo-level))
+}
+
 type stripSensitiveValue struct {
 	// volume_context[key] is the value to be stripped.
 	key string
@@ -19,16 +19,23 @@ package csicommon
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"log/slog"
 	"os"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"k8s.io/klog/v2"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseEndpoint(t *testing.T) {
@@ -119,14 +126,14 @@ func TestLogGRPC(t *testing.T) {
 					"account_key":  "testkey",
 				},
 			},
-			`GRPC request: {"secrets":"***stripped***","volume_id":"vol_1"}`,
+			`request="{\"secrets\":\"***stripped***\",\"volume_id\":\"vol_1\"}"`,
 		},
 		{
 			"without secrets",
 			&csi.ListSnapshotsRequest{
 				StartingToken: "testtoken",
 			},
-			`GRPC request: {"starting_token":"testtoken"}`,
+			`request="{\"starting_token\":\"testtoken\"}"`,
 		},
 		{
 			"NodeStageVolumeRequest with service account token",
@@ -136,7 +143,7 @@ func TestLogGRPC(t *testing.T) {
 					"csi.storage.k8s.io/testfield":             "testvalue",
 				},
 			},
-			`GRPC request: {"volume_context":{"csi.storage.k8s.io/serviceAccount.tokens":"***stripped***","csi.storage.k8s.io/testfield":"testvalue"}}`,
+			`request="{\"volume_context\":{\"csi.storage.k8s.io/serviceAccount.tokens\":\"***stripped***\",\"csi.storage.k8s.io/testfield\":\"testvalue\"}}"`,
 		},
 		{
 			"NodePublishVolumeRequest with service account token",
@@ -146,7 +153,7 @@ func TestLogGRPC(t *testing.T) {
 					"csi.storage.k8s.io/testfield":             "testvalue",
 				},
 			},
-			`GRPC request: {"volume_context":{"csi.storage.k8s.io/serviceAccount.tokens":"***stripped***","csi.storage.k8s.io/testfield":"testvalue"}}`,
+			`request="{\"volume_context\":{\"csi.storage.k8s.io/serviceAccount.tokens\":\"***stripped***\",\"csi.storage.k8s.io/testfield\":\"testvalue\"}}"`,
 		},
 		{
 			"with secrets and service account token",
@@ -161,7 +168,7 @@ func TestLogGRPC(t *testing.T) {
 					"csi.storage.k8s.io/testfield":             "testvalue",
 				},
 			},
-			`GRPC request: {"secrets":"***stripped***","volume_context":{"csi.storage.k8s.io/serviceAccount.tokens":"***stripped***","csi.storage.k8s.io/testfield":"testvalue"},"volume_id":"vol_1"}`,
+			`request="{\"secrets\":\"***stripped***\",\"volume_context\":{\"csi.storage.k8s.io/serviceAccount.tokens\":\"***stripped***\",\"csi.storage.k8s.io/testfield\":\"testvalue\"},\"volume_id\":\"vol_1\"}"`,
 		},
 	}
 
@@ -172,14 +179,41 @@ func TestLogGRPC(t *testing.T) {
 			klog.Flush()
 
 			// ASSERT
-			assert.Contains(t, buf.String(), "GRPC call: fake")
+			assert.Contains(t, buf.String(), `"GRPC call" requestID=`)
+			assert.Contains(t, buf.String(), `method="fake"`)
+			assert.Contains(t, buf.String(), `"GRPC request"`)
 			assert.Contains(t, buf.String(), test.expStr)
-			assert.Contains(t, buf.String(), "GRPC response: null")
+			assert.Contains(t, buf.String(), `"GRPC response"`)
+			assert.Contains(t, buf.String(), `response="null"`)
 
 			// CLEANUP
 			buf.Reset()
 		})
 	}
+
+	t.Run("with trace context and failure", func(t *testing.T) {
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+		failingHandler := func(_ context.Context, _ interface{}) (interface{}, error) { return nil, errors.New("failed") }
+
+		_, _ = LogGRPC(ctx, &csi.NodeUnstageVolumeRequest{VolumeId: "vol_1"}, &info, failingHandler)
+		klog.Flush()
+
+		// error lines are written into the outputs of all severities
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.GreaterOrEqual(t, len(lines), 3)
+		// all lines carry the same request ID, volume ID and trace ID
+		requestID := regexp.MustCompile(`requestID="([^"]+)"`).FindStringSubmatch(lines[0])
+		require.Len(t, requestID, 2)
+		for _, line := range lines {
+			assert.Contains(t, line, `requestID="`+requestID[1]+`"`)
+			assert.Contains(t, line, `volumeID="vol_1"`)
+			assert.Contains(t, line, `traceID="4bf92f3577b34da6a3ce929d0e0e4736"`)
+		}
+		assert.Contains(t, lines[2], `"GRPC error" err="failed"`)
+		buf.Reset()
+	})
 }
 
 func TestNewVolumeCapabilityAccessMode(t *testing.T) {
@@ -270,6 +304,63 @@ func TestNewNodeServiceCapability(t *testing.T) {
 	}
 }
 
+func TestSetLogFormat(t *testing.T) {
+	assert.NoError(t, SetLogFormat(""))
+	assert.NoError(t, SetLogFormat(LogFormatText))
+	assert.Error(t, SetLogFormat("xml"))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, setLogFormat(LogFormatJSON, buf))
+	defer klog.ClearLogger()
+
+	handler := func(_ context.Context, _ interface{}) (interface{}, error) {
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+	req := &csi.NodeStageVolumeRequest{
+		VolumeId: "rg#account#share",
+		Secrets:  map[string]string{"azurestorageaccountkey": "secretKey"},
+		VolumeContext: map[string]string{
+			"csi.storage.k8s.io/serviceAccount.tokens": "saToken",
+			"shareName": "share",
+		},
+	}
+	_, _ = LogGRPC(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodeStageVolume"}, handler)
+	klog.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	entries := make([]map[string]interface{}, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+		assert.Equal(t, "rg#account#share", entries[i]["volumeID"])
+		assert.NotEmpty(t, entries[i]["requestID"])
+		assert.EqualValues(t, 2, entries[i]["v"])
+		assert.NotContains(t, entries[i], "level")
+	}
+	assert.Equal(t, "GRPC call", entries[0]["msg"])
+	assert.Equal(t, "/csi.v1.Node/NodeStageVolume", entries[0]["method"])
+
+	assert.Equal(t, "GRPC request", entries[1]["msg"])
+	request, ok := entries[1]["request"].(string)
+	require.True(t, ok, "request should be logged as a string")
+	assert.Contains(t, request, `"shareName":"share"`)
+	assert.Contains(t, request, `"volume_id":"rg#account#share"`)
+	assert.Contains(t, request, "***stripped***")
+	assert.NotContains(t, request, "secretKey")
+	assert.NotContains(t, request, "saToken")
+
+	assert.Equal(t, "GRPC response", entries[2]["msg"])
+	assert.Equal(t, "{}", entries[2]["response"])
+}
+
+func TestReplaceLevelAttr(t *testing.T) {
+	assert.Equal(t, slog.Int("v", 0), replaceLevelAttr(nil, slog.Any(slog.LevelKey, slog.LevelInfo)))
+	assert.Equal(t, slog.Int("v", 6), replaceLevelAttr(nil, slog.Any(slog.LevelKey, slog.Level(-6))))
+	assert.Equal(t, slog.Any(slog.LevelKey, slog.LevelError), replaceLevelAttr(nil, slog.Any(slog.LevelKey, slog.LevelError)))
+	assert.Equal(t, slog.Any(slog.LevelKey, slog.LevelInfo), replaceLevelAttr([]string{"group"}, slog.Any(slog.LevelKey, slog.LevelInfo)))
+	assert.Equal(t, slog.String("msg", "message"), replaceLevelAttr(nil, slog.String("msg", "message")))
+}
+
 func TestGetLogLevel(t *testing.T) {
 	tests := []struct {
 		method string
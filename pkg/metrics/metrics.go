@@ -17,54 +17,437 @@ limitations under the License.
 package metrics
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
+
+	"sigs.k8s.io/azurefile-csi-driver/pkg/azerrors"
 )
 
 const (
 	subSystem = "azurefile_csi_driver"
+
+	// LabelProtocol is the label of the file share protocol, e.g. smb or nfs
+	LabelProtocol = "protocol"
+	// LabelStorageAccountType is the label of the storage account sku, e.g. Premium_LRS
+	LabelStorageAccountType = "storage_account_type"
 )
 
 var (
-	operationDuration = metrics.NewHistogramVec(
+	// DefaultOperationDurationBuckets are the buckets of CSI operation duration histograms in seconds
+	DefaultOperationDurationBuckets = []float64{0.1, 0.2, 0.5, 1, 5, 10, 15, 20, 30, 40, 50, 60, 100, 200, 300}
+
+	// SupportedStatusLabels are the extra labels which could be added to the operation status metrics
+	SupportedStatusLabels = []string{LabelProtocol, LabelStorageAccountType}
+
+	labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// registerOperationMetricsOnce registers the operation metrics on the first ConfigureOperationMetrics or observation,
+	// since label names of a metric could not be changed once it's registered
+	registerOperationMetricsOnce sync.Once
+	// statusLabels are the extra labels of operationStatusDuration and operationStatusTotal
+	statusLabels []string
+
+	operationDuration           = newOperationDuration(DefaultOperationDurationBuckets)
+	operationDurationWithLabels = newOperationDurationWithLabels(DefaultOperationDurationBuckets)
+	operationStatusDuration     = newOperationStatusDuration(DefaultOperationDurationBuckets, nil)
+	operationStatusTotal        = newOperationStatusTotal(nil)
+
+	operationTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "operations_total",
+			Help:           "Total number of CSI operations",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation", "success"},
+	)
+
+	azureErrorsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "azure_errors_total",
+			Help:           "Total number of errors returned by Azure calls by error category",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation", "category"},
+	)
+
+	azureRetriesTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "azure_retries_total",
+			Help:           "Total number of retried Azure calls by error category",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation", "category"},
+	)
+
+	armRequestWaitDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subSystem,
+			Name:           "arm_request_wait_duration_seconds",
+			Help:           "Histogram of time ARM requests waited for the client side request budget in seconds",
+			Buckets:        []float64{0.001, 0.01, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+
+	armRequestCoalescedTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "arm_request_coalesced_total",
+			Help:           "Total number of ARM read requests served by an identical in-flight request",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+
+	azurefileProxyHealthy = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      subSystem,
+			Name:           "azurefile_proxy_healthy",
+			Help:           "1 if azurefile-proxy was reachable and healthy on the last Probe of the node driver, 0 otherwise",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	accountOperationQueueDepth = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      subSystem,
+			Name:           "account_operation_queue_depth",
+			Help:           "Number of mutating operations waiting for their turn on a storage account",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+
+	accountOperationWaitDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subSystem,
+			Name:           "account_operation_wait_duration_seconds",
+			Help:           "Histogram of time mutating operations waited for their turn on a storage account in seconds",
+			Buckets:        []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+
+	nodeStagePhaseDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subSystem,
+			Name:           "node_stage_phase_duration_seconds",
+			Help:           "Histogram of NodeStageVolume phase duration in seconds by phase and protocol",
+			Buckets:        []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"phase", LabelProtocol},
+	)
+
+	staleMountRecoveryTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "stale_mount_recovery_total",
+			Help:           "Total number of stale staged mount recoveries on the node by protocol and result",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{LabelProtocol, "success"},
+	)
+
+	volumeStatsCollectionDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subSystem,
+			Name:           "volume_stats_collection_duration_seconds",
+			Help:           "Histogram of background volume stats call duration in seconds by result, timed out calls are observed with the timeout",
+			Buckets:        []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	volumeStatsCollectionSkippedTotal = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "volume_stats_collection_skipped_total",
+			Help:           "Total number of background volume stats calls skipped since the previous call on the volume is still hanging",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	folderQuotaExceededTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "folder_quota_exceeded_total",
+			Help:           "Total number of times the usage of a folder-based volume exceeded its soft quota on the node by action",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"action"},
+	)
+
+	vhdTrimTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "vhd_trim_total",
+			Help:           "Total number of background fstrim runs on staged VHD disk volumes on the node by result",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"success"},
+	)
+
+	vhdTrimmedBytesTotal = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "vhd_trimmed_bytes_total",
+			Help:           "Total number of bytes discarded by background fstrim runs on staged VHD disk volumes on the node",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	accountOperationRejectedTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subSystem,
+			Name:           "account_operation_rejected_total",
+			Help:           "Total number of mutating operations rejected since the storage account queue is full",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+)
+
+func newOperationDuration(buckets []float64) *metrics.HistogramVec {
+	return metrics.NewHistogramVec(
 		&metrics.HistogramOpts{
 			Subsystem:      subSystem,
 			Name:           "operation_duration_seconds",
 			Help:           "Histogram of CSI operation duration in seconds",
-			Buckets:        []float64{0.1, 0.2, 0.5, 1, 5, 10, 15, 20, 30, 40, 50, 60, 100, 200, 300},
+			Buckets:        buckets,
 			StabilityLevel: metrics.ALPHA,
 		},
 		[]string{"operation", "success"},
 	)
+}
 
-	operationDurationWithLabels = metrics.NewHistogramVec(
+func newOperationDurationWithLabels(buckets []float64) *metrics.HistogramVec {
+	return metrics.NewHistogramVec(
 		&metrics.HistogramOpts{
 			Subsystem:      subSystem,
 			Name:           "operation_duration_seconds_labeled",
 			Help:           "Histogram of CSI operation duration with additional labels",
-			Buckets:        []float64{0.1, 0.2, 0.5, 1, 5, 10, 15, 20, 30, 40, 50, 60, 100, 200, 300},
+			Buckets:        buckets,
 			StabilityLevel: metrics.ALPHA,
 		},
-		[]string{"operation", "success", "protocol", "storage_account_type"},
+		[]string{"operation", "success", LabelProtocol, LabelStorageAccountType},
 	)
+}
 
-	operationTotal = metrics.NewCounterVec(
+func newOperationStatusDuration(buckets []float64, extraLabels []string) *metrics.HistogramVec {
+	return metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subSystem,
+			Name:           "operation_status_duration_seconds",
+			Help:           "Histogram of CSI operation duration in seconds by gRPC status code and Azure error category",
+			Buckets:        buckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+		append([]string{"operation", "grpc_code", "error_category"}, extraLabels...),
+	)
+}
+
+func newOperationStatusTotal(extraLabels []string) *metrics.CounterVec {
+	return metrics.NewCounterVec(
 		&metrics.CounterOpts{
 			Subsystem:      subSystem,
-			Name:           "operations_total",
-			Help:           "Total number of CSI operations",
+			Name:           "operation_status_total",
+			Help:           "Total number of CSI operations by gRPC status code and Azure error category",
 			StabilityLevel: metrics.ALPHA,
 		},
-		[]string{"operation", "success"},
+		append([]string{"operation", "grpc_code", "error_category"}, extraLabels...),
 	)
-)
+}
 
 func init() {
-	legacyregistry.MustRegister(operationDuration)
-	legacyregistry.MustRegister(operationDurationWithLabels)
 	legacyregistry.MustRegister(operationTotal)
+	legacyregistry.MustRegister(azureErrorsTotal)
+	legacyregistry.MustRegister(azureRetriesTotal)
+	legacyregistry.MustRegister(armRequestWaitDuration)
+	legacyregistry.MustRegister(armRequestCoalescedTotal)
+	legacyregistry.MustRegister(azurefileProxyHealthy)
+	legacyregistry.MustRegister(accountOperationQueueDepth)
+	legacyregistry.MustRegister(accountOperationWaitDuration)
+	legacyregistry.MustRegister(accountOperationRejectedTotal)
+	legacyregistry.MustRegister(nodeStagePhaseDuration)
+	legacyregistry.MustRegister(staleMountRecoveryTotal)
+	legacyregistry.MustRegister(volumeStatsCollectionDuration)
+	legacyregistry.MustRegister(volumeStatsCollectionSkippedTotal)
+	legacyregistry.MustRegister(folderQuotaExceededTotal)
+	legacyregistry.MustRegister(vhdTrimTotal)
+	legacyregistry.MustRegister(vhdTrimmedBytesTotal)
+}
+
+// ConfigureOperationMetrics sets the extra labels of the operation status metrics and the buckets of
+// the operation duration histograms, e.g. larger buckets for long running clone operations.
+// It must be called once before any operation is observed, default buckets are used if buckets is empty.
+func ConfigureOperationMetrics(extraLabels []string, buckets []float64) error {
+	for _, label := range extraLabels {
+		if !labelNameRegexp.MatchString(label) {
+			return fmt.Errorf("invalid metrics label name %q", label)
+		}
+		supported := false
+		for _, l := range SupportedStatusLabels {
+			if l == label {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("unsupported metrics label %q, supported labels: %v", label, SupportedStatusLabels)
+		}
+	}
+	for i, label := range extraLabels {
+		for _, l := range extraLabels[:i] {
+			if l == label {
+				return fmt.Errorf("duplicate metrics label %q", label)
+			}
+		}
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultOperationDurationBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		return fmt.Errorf("metrics buckets %v must be in increasing order", buckets)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] == buckets[i-1] {
+			return fmt.Errorf("duplicate metrics bucket %v", buckets[i])
+		}
+	}
+
+	if !registerOperationMetrics(extraLabels, buckets) {
+		return fmt.Errorf("operation metrics are already registered")
+	}
+	return nil
+}
+
+// registerOperationMetrics registers the operation metrics with extraLabels and buckets,
+// it returns false if the operation metrics have been registered
+func registerOperationMetrics(extraLabels []string, buckets []float64) bool {
+	registered := false
+	registerOperationMetricsOnce.Do(func() {
+		if len(extraLabels) > 0 || len(buckets) > 0 {
+			operationDuration = newOperationDuration(buckets)
+			operationDurationWithLabels = newOperationDurationWithLabels(buckets)
+			operationStatusDuration = newOperationStatusDuration(buckets, extraLabels)
+			operationStatusTotal = newOperationStatusTotal(extraLabels)
+			statusLabels = extraLabels
+		}
+		legacyregistry.MustRegister(operationDuration)
+		legacyregistry.MustRegister(operationDurationWithLabels)
+		legacyregistry.MustRegister(operationStatusDuration)
+		legacyregistry.MustRegister(operationStatusTotal)
+		registered = true
+	})
+	return registered
+}
+
+// RegisterOperationMetrics registers the operation metrics with the default labels and buckets unless they have been
+// configured by ConfigureOperationMetrics, and initializes the series of the operations with gRPC code OK,
+// so that the metrics are exposed before any operation is observed
+func RegisterOperationMetrics(operations ...string) {
+	registerOperationMetrics(nil, nil)
+	for _, operation := range operations {
+		values := make([]string, 3+len(statusLabels))
+		values[0], values[1], values[2] = operation, codes.OK.String(), ErrorCategory(nil)
+		operationStatusDuration.WithLabelValues(values...)
+		operationStatusTotal.WithLabelValues(values...)
+	}
+}
+
+// RecordAzureError records an error returned by an Azure call
+func RecordAzureError(operation, category string) {
+	azureErrorsTotal.WithLabelValues(operation, category).Inc()
+}
+
+// RecordAzureRetry records a retry of an Azure call after a retriable error
+func RecordAzureRetry(operation, category string) {
+	azureRetriesTotal.WithLabelValues(operation, category).Inc()
+}
+
+// ObserveARMRequestWait records the time an ARM request waited for the client side request budget
+func ObserveARMRequestWait(operation string, wait time.Duration) {
+	armRequestWaitDuration.WithLabelValues(operation).Observe(wait.Seconds())
+}
+
+// RecordARMRequestCoalesced records an ARM read request served by an identical in-flight request
+func RecordARMRequestCoalesced(operation string) {
+	armRequestCoalescedTotal.WithLabelValues(operation).Inc()
+}
+
+// SetAzurefileProxyHealthy records the result of the last health check of azurefile-proxy
+func SetAzurefileProxyHealthy(healthy bool) {
+	if healthy {
+		azurefileProxyHealthy.Set(1)
+	} else {
+		azurefileProxyHealthy.Set(0)
+	}
+}
+
+// AddAccountOperationQueueDepth adds delta to the number of operations waiting on storage accounts
+func AddAccountOperationQueueDepth(operation string, delta float64) {
+	accountOperationQueueDepth.WithLabelValues(operation).Add(delta)
+}
+
+// ObserveAccountOperationWait records the time an operation waited for its turn on a storage account
+func ObserveAccountOperationWait(operation string, wait time.Duration) {
+	accountOperationWaitDuration.WithLabelValues(operation).Observe(wait.Seconds())
+}
+
+// RecordAccountOperationRejected records an operation rejected since the storage account queue is full
+func RecordAccountOperationRejected(operation string) {
+	accountOperationRejectedTotal.WithLabelValues(operation).Inc()
+}
+
+// ObserveNodeStagePhase records the duration of a NodeStageVolume phase
+func ObserveNodeStagePhase(phase, protocol string, duration time.Duration) {
+	nodeStagePhaseDuration.WithLabelValues(phase, protocol).Observe(duration.Seconds())
+}
+
+// RecordStaleMountRecovery records a recovery of a stale staged mount
+func RecordStaleMountRecovery(protocol string, success bool) {
+	staleMountRecoveryTotal.WithLabelValues(protocol, strconv.FormatBool(success)).Inc()
+}
+
+// ObserveVolumeStatsCollection records the duration of a background volume stats call,
+// result is one of success, failure and timeout
+func ObserveVolumeStatsCollection(result string, duration time.Duration) {
+	volumeStatsCollectionDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// RecordVolumeStatsCollectionSkipped records a background volume stats call skipped on a hanging volume
+func RecordVolumeStatsCollectionSkipped() {
+	volumeStatsCollectionSkippedTotal.Inc()
+}
+
+// RecordFolderQuotaExceeded records a folder-based volume whose usage exceeded its soft quota,
+// action is the action taken on the volume
+func RecordFolderQuotaExceeded(action string) {
+	folderQuotaExceededTotal.WithLabelValues(action).Inc()
+}
+
+// RecordVHDTrim records a background fstrim run on a VHD disk volume and the bytes it discarded
+func RecordVHDTrim(success bool, trimmedBytes int64) {
+	vhdTrimTotal.WithLabelValues(strconv.FormatBool(success)).Inc()
+	if trimmedBytes > 0 {
+		vhdTrimmedBytesTotal.Add(float64(trimmedBytes))
+	}
 }
 
 // CSIMetricContext represents the context for CSI operation metrics
@@ -100,14 +483,15 @@ func (mc *CSIMetricContext) Observe(success bool) {
 		successStr = "true"
 	}
 
+	registerOperationMetrics(nil, nil)
 	// Always record basic metrics
 	operationDuration.WithLabelValues(mc.operation, successStr).Observe(duration)
 	operationTotal.WithLabelValues(mc.operation, successStr).Inc()
 
 	// Record detailed metrics if labels are present
 	if len(mc.labels) > 0 {
-		protocol := mc.labels["protocol"]
-		storageAccountType := mc.labels["storage_account_type"]
+		protocol := mc.labels[LabelProtocol]
+		storageAccountType := mc.labels[LabelStorageAccountType]
 
 		operationDurationWithLabels.WithLabelValues(
 			mc.operation,
@@ -131,3 +515,47 @@ func (mc *CSIMetricContext) ObserveWithLabels(success bool, labelPairs ...string
 	}
 	mc.Observe(success)
 }
+
+// ObserveWithError records the operation with provided label pairs, besides the metrics recorded by ObserveWithLabels,
+// the operation is recorded by the gRPC status code of err and the Azure error category of err
+func (mc *CSIMetricContext) ObserveWithError(err error, labelPairs ...string) {
+	duration := time.Since(mc.start).Seconds()
+	mc.ObserveWithLabels(err == nil, labelPairs...)
+
+	values := make([]string, 0, 3+len(statusLabels))
+	values = append(values, mc.operation, status.Code(err).String(), ErrorCategory(err))
+	for _, label := range statusLabels {
+		values = append(values, mc.labels[label])
+	}
+	operationStatusDuration.WithLabelValues(values...).Observe(duration)
+	operationStatusTotal.WithLabelValues(values...).Inc()
+}
+
+// ErrorCategory returns the Azure error category label value of err, "none" if err is nil.
+// A gRPC status error is classified by the Azure error it wraps, or by its status code if it does not wrap one,
+// since the type of the Azure error is lost once it's formatted into the status message.
+func ErrorCategory(err error) string {
+	if err == nil {
+		return "none"
+	}
+	if _, ok := status.FromError(err); ok && !azerrors.HasResponseError(err) {
+		return string(categoryFromCode(status.Code(err)))
+	}
+	return string(azerrors.Classify(err))
+}
+
+// categoryFromCode returns the Azure error category of a gRPC status code
+func categoryFromCode(code codes.Code) azerrors.Category {
+	switch code {
+	case codes.ResourceExhausted:
+		return azerrors.Throttled
+	case codes.NotFound:
+		return azerrors.NotFound
+	case codes.AlreadyExists:
+		return azerrors.Conflict
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return azerrors.Auth
+	default:
+		return azerrors.Unknown
+	}
+}
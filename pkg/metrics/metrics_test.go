@@ -17,9 +17,16 @@ limitations under the License.
 package metrics
 
 import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/component-base/metrics/legacyregistry"
 )
 
@@ -310,3 +317,428 @@ func BenchmarkCSIMetricContext_NewAndObserve(b *testing.B) {
 		mc.Observe(true)
 	}
 }
+
+func TestRecordAzureErrorAndRetry(t *testing.T) {
+	azureErrorsTotal.Reset()
+	azureRetriesTotal.Reset()
+
+	RecordAzureError("create_file_share", "throttled")
+	RecordAzureError("create_file_share", "throttled")
+	RecordAzureRetry("create_file_share", "throttled")
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if m.GetCounter() != nil {
+				counts[family.GetName()] += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if counts["azurefile_csi_driver_azure_errors_total"] != 2 {
+		t.Errorf("expected 2 azure errors, got %v", counts["azurefile_csi_driver_azure_errors_total"])
+	}
+	if counts["azurefile_csi_driver_azure_retries_total"] != 1 {
+		t.Errorf("expected 1 azure retry, got %v", counts["azurefile_csi_driver_azure_retries_total"])
+	}
+}
+
+func TestARMRequestMetrics(t *testing.T) {
+	armRequestWaitDuration.Reset()
+	armRequestCoalescedTotal.Reset()
+
+	ObserveARMRequestWait("storage_account_list", 200*time.Millisecond)
+	ObserveARMRequestWait("storage_account_list", 0)
+	RecordARMRequestCoalesced("storage_account_list")
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	var waitCount uint64
+	var coalesced float64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetName() {
+			case "azurefile_csi_driver_arm_request_wait_duration_seconds":
+				waitCount += m.GetHistogram().GetSampleCount()
+			case "azurefile_csi_driver_arm_request_coalesced_total":
+				coalesced += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if waitCount != 2 {
+		t.Errorf("expected 2 wait observations, got %v", waitCount)
+	}
+	if coalesced != 1 {
+		t.Errorf("expected 1 coalesced request, got %v", coalesced)
+	}
+}
+
+func TestAccountOperationMetrics(t *testing.T) {
+	accountOperationQueueDepth.Reset()
+	accountOperationWaitDuration.Reset()
+	accountOperationRejectedTotal.Reset()
+
+	AddAccountOperationQueueDepth("create_volume", 1)
+	AddAccountOperationQueueDepth("create_volume", 1)
+	AddAccountOperationQueueDepth("create_volume", -1)
+	ObserveAccountOperationWait("create_volume", time.Second)
+	RecordAccountOperationRejected("create_volume")
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	var depth, rejected float64
+	var waitCount uint64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetName() {
+			case "azurefile_csi_driver_account_operation_queue_depth":
+				depth += m.GetGauge().GetValue()
+			case "azurefile_csi_driver_account_operation_wait_duration_seconds":
+				waitCount += m.GetHistogram().GetSampleCount()
+			case "azurefile_csi_driver_account_operation_rejected_total":
+				rejected += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if depth != 1 {
+		t.Errorf("expected queue depth 1, got %v", depth)
+	}
+	if waitCount != 1 {
+		t.Errorf("expected 1 wait observation, got %v", waitCount)
+	}
+	if rejected != 1 {
+		t.Errorf("expected 1 rejected operation, got %v", rejected)
+	}
+}
+
+func TestCSIMetricContext_ObserveWithError(t *testing.T) {
+	operationStatusDuration.Reset()
+	operationStatusTotal.Reset()
+
+	NewCSIMetricContext("controller_create_volume").ObserveWithError(nil)
+	NewCSIMetricContext("controller_create_volume").ObserveWithError(
+		&wrappedStatusError{status: status.New(codes.Internal, "failed to create file share"), err: &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}})
+	NewCSIMetricContext("controller_create_volume").ObserveWithError(
+		status.Error(codes.InvalidArgument, "invalid parameter"))
+	// the status message is not inspected
+	NewCSIMetricContext("controller_create_volume").ObserveWithError(
+		status.Error(codes.Internal, "failed to create file share: TooManyRequests"))
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	var durationCount uint64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch family.GetName() {
+			case "azurefile_csi_driver_operation_status_total":
+				counts[labels["grpc_code"]+"/"+labels["error_category"]] += m.GetCounter().GetValue()
+			case "azurefile_csi_driver_operation_status_duration_seconds":
+				durationCount += m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	expected := map[string]float64{"OK/none": 1, "Internal/throttled": 1, "InvalidArgument/unknown": 1, "Internal/unknown": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected %v, got %v", expected, counts)
+	}
+	if durationCount != 4 {
+		t.Errorf("expected 4 duration observations, got %v", durationCount)
+	}
+}
+
+// wrappedStatusError is a gRPC status error which wraps the error it's converted from
+type wrappedStatusError struct {
+	status *status.Status
+	err    error
+}
+
+func (e *wrappedStatusError) Error() string              { return e.status.Err().Error() }
+func (e *wrappedStatusError) GRPCStatus() *status.Status { return e.status }
+func (e *wrappedStatusError) Unwrap() error              { return e.err }
+
+func TestErrorCategory(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected string
+	}{
+		{err: nil, expected: "none"},
+		{err: status.Error(codes.ResourceExhausted, "too many operations"), expected: "throttled"},
+		{err: status.Error(codes.NotFound, "volume not found"), expected: "not_found"},
+		{err: status.Error(codes.AlreadyExists, "snapshot already exists"), expected: "conflict"},
+		{err: status.Error(codes.PermissionDenied, "denied"), expected: "auth"},
+		{err: status.Error(codes.Internal, "ShareNotFound"), expected: "unknown"},
+		{err: &wrappedStatusError{status: status.New(codes.Internal, "failed"), err: &azcore.ResponseError{StatusCode: http.StatusNotFound}}, expected: "not_found"},
+		{err: fmt.Errorf("failed: %w", &azcore.ResponseError{StatusCode: http.StatusForbidden}), expected: "auth"},
+		{err: fmt.Errorf("client throttled"), expected: "throttled"},
+	} {
+		if category := ErrorCategory(test.err); category != test.expected {
+			t.Errorf("ErrorCategory(%v) = %q, expected %q", test.err, category, test.expected)
+		}
+	}
+}
+
+func TestRegisterOperationMetrics(t *testing.T) {
+	RegisterOperationMetrics("node_stage_volume")
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	var found bool
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_operation_status_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == "node_stage_volume" && labels["grpc_code"] == "OK" && labels["error_category"] == "none" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("expected the series of node_stage_volume to be exposed before it's observed")
+	}
+}
+
+func TestConfigureOperationMetrics(t *testing.T) {
+	for _, test := range []struct {
+		labels  []string
+		buckets []float64
+	}{
+		{labels: []string{"invalid-label"}},
+		{labels: []string{"volume_id"}},
+		{labels: []string{LabelProtocol, LabelProtocol}},
+		{buckets: []float64{10, 1}},
+		{buckets: []float64{1, 1}},
+	} {
+		if err := ConfigureOperationMetrics(test.labels, test.buckets); err == nil {
+			t.Errorf("expected error for labels %v and buckets %v", test.labels, test.buckets)
+		}
+	}
+
+	// label names could not be changed after operations are observed
+	NewCSIMetricContext("controller_delete_volume").ObserveWithError(nil)
+	if err := ConfigureOperationMetrics([]string{LabelStorageAccountType}, []float64{60, 600, 3600}); err == nil {
+		t.Error("expected error after operation metrics are registered")
+	}
+}
+
+func TestRecordStaleMountRecovery(t *testing.T) {
+	staleMountRecoveryTotal.Reset()
+
+	RecordStaleMountRecovery("smb", true)
+	RecordStaleMountRecovery("smb", false)
+	RecordStaleMountRecovery("nfs", true)
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_stale_mount_recovery_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["protocol"]+"/"+labels["success"]] += m.GetCounter().GetValue()
+		}
+	}
+	for key, expected := range map[string]float64{"smb/true": 1, "smb/false": 1, "nfs/true": 1} {
+		if counts[key] != expected {
+			t.Errorf("expected %v recoveries of %s, got %v", expected, key, counts[key])
+		}
+	}
+}
+
+func TestVolumeIOStatsCollector(t *testing.T) {
+	RegisterVolumeIOStatsCollector(func() []VolumeIOStats {
+		return []VolumeIOStats{
+			{
+				VolumeID: "vol-nfs", Namespace: "ns", PVC: "pvc-nfs", Protocol: "nfs",
+				ReadBytes: 100, WriteBytes: 200,
+				Operations:        []VolumeOperationStats{{Operation: "read", Count: 3, Errors: 1, Seconds: 0.5}},
+				RPCStatsAvailable: true,
+				Retransmissions:   2,
+			},
+			{
+				VolumeID: "vol-smb", Namespace: "ns", PVC: "pvc-smb", Protocol: "smb", Share: `\\account.file.core.windows.net\share`,
+				ReadBytes:  300,
+				Operations: []VolumeOperationStats{{Operation: "write", Count: 4}},
+			},
+		}
+	})
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "azurefile_csi_driver_volume_") {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["volume_id"] == "" {
+				continue
+			}
+			key := family.GetName() + "/" + labels["volume_id"] + "/" + labels["pvc"] + "/" + labels["protocol"] + "/" + labels["share"] + "/" + labels["operation"]
+			values[key] = m.GetCounter().GetValue()
+		}
+	}
+	expected := map[string]float64{
+		"azurefile_csi_driver_volume_read_bytes_total/vol-nfs/pvc-nfs/nfs//":                                                    100,
+		"azurefile_csi_driver_volume_write_bytes_total/vol-nfs/pvc-nfs/nfs//":                                                   200,
+		"azurefile_csi_driver_volume_operations_total/vol-nfs/pvc-nfs/nfs//read":                                                3,
+		"azurefile_csi_driver_volume_operation_errors_total/vol-nfs/pvc-nfs/nfs//read":                                          1,
+		"azurefile_csi_driver_volume_operation_seconds_total/vol-nfs/pvc-nfs/nfs//read":                                         0.5,
+		"azurefile_csi_driver_volume_retransmissions_total/vol-nfs/pvc-nfs/nfs//":                                               2,
+		"azurefile_csi_driver_volume_read_bytes_total/vol-smb/pvc-smb/smb/\\\\account.file.core.windows.net\\share/":            300,
+		"azurefile_csi_driver_volume_write_bytes_total/vol-smb/pvc-smb/smb/\\\\account.file.core.windows.net\\share/":           0,
+		"azurefile_csi_driver_volume_operations_total/vol-smb/pvc-smb/smb/\\\\account.file.core.windows.net\\share/write":       4,
+		"azurefile_csi_driver_volume_operation_errors_total/vol-smb/pvc-smb/smb/\\\\account.file.core.windows.net\\share/write": 0,
+	}
+	if !reflect.DeepEqual(expected, values) {
+		t.Errorf("expected volume I/O metrics %v, got %v", expected, values)
+	}
+}
+
+func TestVolumeStatsCollectionMetrics(t *testing.T) {
+	volumeStatsCollectionDuration.Reset()
+
+	ObserveVolumeStatsCollection("success", time.Millisecond)
+	ObserveVolumeStatsCollection("timeout", 10*time.Second)
+	RecordVolumeStatsCollectionSkipped()
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]uint64{}
+	var skipped float64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetName() {
+			case "azurefile_csi_driver_volume_stats_collection_duration_seconds":
+				counts[m.GetLabel()[0].GetValue()] += m.GetHistogram().GetSampleCount()
+			case "azurefile_csi_driver_volume_stats_collection_skipped_total":
+				skipped += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if counts["success"] != 1 || counts["timeout"] != 1 {
+		t.Errorf("expected 1 success and 1 timeout observation, got %v", counts)
+	}
+	if skipped < 1 {
+		t.Errorf("expected at least 1 skipped call, got %v", skipped)
+	}
+}
+
+func TestRecordFolderQuotaExceeded(t *testing.T) {
+	folderQuotaExceededTotal.Reset()
+
+	RecordFolderQuotaExceeded("event")
+	RecordFolderQuotaExceeded("event")
+	RecordFolderQuotaExceeded("readonly")
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "azurefile_csi_driver_folder_quota_exceeded_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			counts[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	expected := map[string]float64{"event": 2, "readonly": 1}
+	if !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected folder quota exceeded counts %v, got %v", expected, counts)
+	}
+}
+
+func TestRecordVHDTrim(t *testing.T) {
+	vhdTrimTotal.Reset()
+	vhdTrimmedBytesTotal.Reset()
+
+	RecordVHDTrim(true, 1024)
+	RecordVHDTrim(true, 0)
+	RecordVHDTrim(false, 0)
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	var trimmedBytes float64
+	for _, family := range families {
+		switch family.GetName() {
+		case "azurefile_csi_driver_vhd_trim_total":
+			for _, m := range family.GetMetric() {
+				counts[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+			}
+		case "azurefile_csi_driver_vhd_trimmed_bytes_total":
+			trimmedBytes = family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	expected := map[string]float64{"true": 2, "false": 1}
+	if !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected vhd trim counts %v, got %v", expected, counts)
+	}
+	if trimmedBytes != 1024 {
+		t.Errorf("expected 1024 trimmed bytes, got %v", trimmedBytes)
+	}
+}
+
+func TestSetAzurefileProxyHealthy(t *testing.T) {
+	gaugeValue := func() float64 {
+		families, err := legacyregistry.DefaultGatherer.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+		for _, family := range families {
+			if family.GetName() == "azurefile_csi_driver_azurefile_proxy_healthy" && len(family.GetMetric()) == 1 {
+				return family.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+		t.Fatal("expected to find azurefile proxy health gauge")
+		return 0
+	}
+
+	SetAzurefileProxyHealthy(true)
+	if v := gaugeValue(); v != 1 {
+		t.Errorf("expected 1 for healthy proxy, got %v", v)
+	}
+	SetAzurefileProxyHealthy(false)
+	if v := gaugeValue(); v != 0 {
+		t.Errorf("expected 0 for unhealthy proxy, got %v", v)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// VolumeIOStats are the cumulative I/O counters of a staged volume on the node
+type VolumeIOStats struct {
+	VolumeID  string
+	Namespace string
+	PVC       string
+	Protocol  string
+	// Share is the share UNC of an SMB volume, whose counters are kept by the kernel per share,
+	// so volumes staged from the same share report the same counters, empty for NFS
+	Share string
+
+	ReadBytes  uint64
+	WriteBytes uint64
+	Operations []VolumeOperationStats
+	// RPCStatsAvailable is true if operation durations and retransmissions are available, which is only true for NFS
+	RPCStatsAvailable bool
+	Retransmissions   uint64
+}
+
+// VolumeOperationStats are the cumulative counters of one operation, e.g. read or write
+type VolumeOperationStats struct {
+	Operation string
+	Count     uint64
+	Errors    uint64
+	// Seconds is the cumulative execution time of the operation
+	Seconds float64
+}
+
+var (
+	// SMB counters are of the share in the "share" label and repeated on each volume of the share,
+	// aggregate SMB counters by share instead of summing volumes
+	volumeIOStatsLabels = []string{"volume_id", "namespace", "pvc", LabelProtocol, "share"}
+
+	volumeReadBytesDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_read_bytes_total"),
+		"Total number of bytes read from Azure Files by the staged volume on the node, SMB counters are per share and repeated on each volume of the share",
+		volumeIOStatsLabels, nil, metrics.ALPHA, "")
+	volumeWriteBytesDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_write_bytes_total"),
+		"Total number of bytes written to Azure Files by the staged volume on the node, SMB counters are per share and repeated on each volume of the share",
+		volumeIOStatsLabels, nil, metrics.ALPHA, "")
+	volumeOperationsDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_operations_total"),
+		"Total number of I/O operations sent to Azure Files by the staged volume on the node, SMB counters are per share and repeated on each volume of the share",
+		append(volumeIOStatsLabels, "operation"), nil, metrics.ALPHA, "")
+	volumeOperationErrorsDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_operation_errors_total"),
+		"Total number of failed I/O operations of the staged volume on the node, SMB counters are per share and repeated on each volume of the share",
+		append(volumeIOStatsLabels, "operation"), nil, metrics.ALPHA, "")
+	volumeOperationSecondsDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_operation_seconds_total"),
+		"Total execution time in seconds of I/O operations of the staged volume on the node, only available for NFS",
+		append(volumeIOStatsLabels, "operation"), nil, metrics.ALPHA, "")
+	volumeRetransmissionsDesc = metrics.NewDesc(
+		metrics.BuildFQName("", subSystem, "volume_retransmissions_total"),
+		"Total number of RPC retransmissions of the staged volume on the node, only available for NFS",
+		volumeIOStatsLabels, nil, metrics.ALPHA, "")
+
+	registerVolumeIOStatsCollectorOnce sync.Once
+)
+
+// volumeIOStatsCollector exports the I/O counters of staged volumes read from the kernel on each scrape
+type volumeIOStatsCollector struct {
+	metrics.BaseStableCollector
+
+	gather func() []VolumeIOStats
+}
+
+// RegisterVolumeIOStatsCollector exports the volume I/O counters returned by gather on the metrics endpoint,
+// only the first call takes effect
+func RegisterVolumeIOStatsCollector(gather func() []VolumeIOStats) {
+	registerVolumeIOStatsCollectorOnce.Do(func() {
+		legacyregistry.CustomMustRegister(&volumeIOStatsCollector{gather: gather})
+	})
+}
+
+// DescribeWithStability implements the metrics.StableCollector interface
+func (c *volumeIOStatsCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+	ch <- volumeReadBytesDesc
+	ch <- volumeWriteBytesDesc
+	ch <- volumeOperationsDesc
+	ch <- volumeOperationErrorsDesc
+	ch <- volumeOperationSecondsDesc
+	ch <- volumeRetransmissionsDesc
+}
+
+// CollectWithStability implements the metrics.StableCollector interface
+func (c *volumeIOStatsCollector) CollectWithStability(ch chan<- metrics.Metric) {
+	for _, stats := range c.gather() {
+		labels := []string{stats.VolumeID, stats.Namespace, stats.PVC, stats.Protocol, stats.Share}
+		ch <- metrics.NewLazyConstMetric(volumeReadBytesDesc, metrics.CounterValue, float64(stats.ReadBytes), labels...)
+		ch <- metrics.NewLazyConstMetric(volumeWriteBytesDesc, metrics.CounterValue, float64(stats.WriteBytes), labels...)
+		for _, op := range stats.Operations {
+			opLabels := append(append([]string{}, labels...), op.Operation)
+			ch <- metrics.NewLazyConstMetric(volumeOperationsDesc, metrics.CounterValue, float64(op.Count), opLabels...)
+			ch <- metrics.NewLazyConstMetric(volumeOperationErrorsDesc, metrics.CounterValue, float64(op.Errors), opLabels...)
+			if stats.RPCStatsAvailable {
+				ch <- metrics.NewLazyConstMetric(volumeOperationSecondsDesc, metrics.CounterValue, op.Seconds, opLabels...)
+			}
+		}
+		if stats.RPCStatsAvailable {
+			ch <- metrics.NewLazyConstMetric(volumeRetransmissionsDesc, metrics.CounterValue, float64(stats.Retransmissions), labels...)
+		}
+	}
+}
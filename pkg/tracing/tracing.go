@@ -0,0 +1,211 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports OpenTelemetry traces of CSI calls over OTLP.
+//
+// Tracing is off by default, spans are only recorded after Init is called with an OTLP endpoint.
+// A span is started for each gRPC call by UnaryServerInterceptor, and continued into
+// Azure SDK pipelines by AzurePolicies, kube client calls by WrapTransport and azurefile-proxy calls by UnaryClientInterceptor.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+const tracerName = "sigs.k8s.io/azurefile-csi-driver"
+
+var enabled atomic.Bool
+
+// Init exports traces to the OTLP gRPC endpoint, e.g. http://otel-collector:4317, and returns the function to flush and stop exporting.
+// Tracing is disabled if endpoint is empty. sampleRatio is the ratio of sampled root spans, spans with a sampled parent are always sampled.
+func Init(ctx context.Context, endpoint, serviceName string, sampleRatio float64) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter for %s: %w", endpoint, err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	SetTracerProvider(tp)
+	klog.FromContext(ctx).V(2).Info("tracing is enabled", "endpoint", endpoint, "sampleRatio", sampleRatio)
+	return tp.Shutdown, nil
+}
+
+// SetTracerProvider enables tracing with the tracer provider
+func SetTracerProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	enabled.Store(true)
+}
+
+// Enabled returns true if tracing is enabled
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// StartSpan starts an internal span, the span must be ended by the caller
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error if any and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID of the span in the context, or empty string if there is no valid span
+func TraceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor starts a server span for each gRPC call, the trace context of the caller is continued if any
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !Enabled() {
+		return handler(ctx, req)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(rpcAttributes(info.FullMethod)...))
+	resp, err := handler(ctx, req)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	EndSpan(span, err)
+	return resp, err
+}
+
+// UnaryClientInterceptor starts a client span for each gRPC call and propagates the trace context to the server
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !Enabled() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(rpcAttributes(method)...))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	EndSpan(span, err)
+	return err
+}
+
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// AzurePolicies returns the per retry policies which trace Azure SDK HTTP requests, nil if tracing is disabled
+func AzurePolicies() []policy.Policy {
+	if !Enabled() {
+		return nil
+	}
+	return []policy.Policy{azurePolicy{}}
+}
+
+type azurePolicy struct{}
+
+// Do starts a client span for each try of the request, the query string is not recorded since it may contain a SAS token
+func (azurePolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	ctx, span := otel.Tracer(tracerName).Start(raw.Context(), "HTTP "+raw.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", raw.Method),
+			attribute.String("server.address", raw.URL.Host),
+			attribute.String("url.path", raw.URL.Path),
+		))
+	resp, err := req.Clone(ctx).Next()
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if requestID := resp.Header.Get("x-ms-request-id"); requestID != "" {
+			span.SetAttributes(attribute.String("az.service_request_id", requestID))
+		}
+		if err == nil && resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(otelcodes.Error, resp.Status)
+		}
+	}
+	EndSpan(span, err)
+	return resp, err
+}
+
+// WrapTransport traces kube client requests, it's a no-op if tracing is disabled
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if !Enabled() {
+		return rt
+	}
+	return otelhttp.NewTransport(rt)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// memoryExporter keeps the exported spans in memory
+type memoryExporter struct {
+	sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *memoryExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (e *memoryExporter) reset() []sdktrace.ReadOnlySpan {
+	e.Lock()
+	defer e.Unlock()
+	spans := e.spans
+	e.spans = nil
+	return spans
+}
+
+var (
+	exporterOnce sync.Once
+	exporter     = &memoryExporter{}
+)
+
+func enableTracing(t *testing.T) *memoryExporter {
+	exporterOnce.Do(func() {
+		SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	})
+	exporter.reset()
+	t.Cleanup(func() { exporter.reset() })
+	return exporter
+}
+
+func getAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestInitDisabled(t *testing.T) {
+	shutdown, err := Init(context.Background(), "", "csi-azurefile", 1)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestTraceID(t *testing.T) {
+	assert.Empty(t, TraceID(context.Background()))
+
+	enableTracing(t)
+	ctx, span := StartSpan(context.Background(), "test")
+	defer span.End()
+	assert.Equal(t, span.SpanContext().TraceID().String(), TraceID(ctx))
+}
+
+func TestEndSpan(t *testing.T) {
+	e := enableTracing(t)
+	_, span := StartSpan(context.Background(), "failed", attribute.String("volumeID", "rg#account#share"))
+	EndSpan(span, errors.New("mount failed"))
+
+	spans := e.reset()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "failed", spans[0].Name())
+	assert.Equal(t, otelcodes.Error, spans[0].Status().Code)
+	assert.Equal(t, "mount failed", spans[0].Status().Description)
+	assert.Equal(t, "rg#account#share", getAttribute(spans[0], "volumeID").AsString())
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	e := enableTracing(t)
+
+	var serverTraceID string
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		serverTraceID = TraceID(ctx)
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	// the client interceptor propagates the trace context to the server interceptor through metadata
+	invoker := func(ctx context.Context, method string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		serverCtx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := UnaryServerInterceptor(serverCtx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	ctx, parent := StartSpan(context.Background(), "NodeStageVolume")
+	err := UnaryClientInterceptor(ctx, "/MountService/MountAzureFile", nil, nil, nil, invoker)
+	parent.End()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, parent.SpanContext().TraceID().String(), serverTraceID)
+
+	spans := e.reset()
+	require.Len(t, spans, 3)
+	server, client := spans[0], spans[1]
+	assert.Equal(t, "MountService/MountAzureFile", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, client.SpanContext().SpanID(), server.Parent().SpanID())
+	assert.Equal(t, "NotFound", getAttribute(server, "rpc.grpc.status_code").AsString())
+	assert.Equal(t, "MountAzureFile", getAttribute(server, "rpc.method").AsString())
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent().SpanID())
+}
+
+func TestAzurePolicies(t *testing.T) {
+	e := enableTracing(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("x-ms-request-id", "request-id")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	pipeline := runtime.NewPipeline("test", "v1", runtime.PipelineOptions{}, &policy.ClientOptions{
+		PerRetryPolicies: AzurePolicies(),
+		Retry:            policy.RetryOptions{MaxRetries: -1},
+	})
+	req, err := runtime.NewRequest(context.Background(), http.MethodGet, server.URL+"/share/dir?sig=secret")
+	require.NoError(t, err)
+	resp, err := pipeline.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := e.reset()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "HTTP GET", spans[0].Name())
+	assert.Equal(t, "/share/dir", getAttribute(spans[0], "url.path").AsString())
+	assert.Equal(t, int64(http.StatusNotFound), getAttribute(spans[0], "http.response.status_code").AsInt64())
+	assert.Equal(t, "request-id", getAttribute(spans[0], "az.service_request_id").AsString())
+	assert.Equal(t, otelcodes.Error, spans[0].Status().Code)
+	for _, kv := range spans[0].Attributes() {
+		assert.NotContains(t, kv.Value.Emit(), "secret")
+	}
+}